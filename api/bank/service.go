@@ -26,9 +26,11 @@ func NewBankService(ctx context.Context, address string) *BankService {
 	}
 }
 
-func (bs *BankService) RelayPaymentRequest(m entities.Merchant, p entities.Payment) (entities.Payment, error) {
+// RelayPaymentRequest asks the bank to pay p with card, this is the only place
+// the full card, cvv included, leaves the api
+func (bs *BankService) RelayPaymentRequest(m entities.Merchant, p entities.Payment, card entities.CreditCard) (entities.Payment, error) {
 	type messageRequest struct {
-		Amount           float64             `json:"amount"`
+		AmountMinor      int64               `json:"amount_minor"`
 		Currency         string              `json:"currency"`
 		PurchaseTime     string              `json:"purchase_time"`
 		ValidationMethod string              `json:"validation_method"`
@@ -44,11 +46,11 @@ func (bs *BankService) RelayPaymentRequest(m entities.Merchant, p entities.Payme
 
 	// Create bank request payload
 	payload := messageRequest{
-		Amount:           p.Amount,
+		AmountMinor:      p.Amount,
 		Currency:         p.Currency,
 		PurchaseTime:     p.GetPurchaseTimeStr(),
 		ValidationMethod: p.ValidationMethod,
-		Card:             p.Card,
+		Card:             card,
 		Merchant:         m.Name,
 	}
 
@@ -84,7 +86,7 @@ func (bs *BankService) RelayPaymentRequest(m entities.Merchant, p entities.Payme
 		log.Printf("error relaying payment to bank, status code: %v (%s)", resp.StatusCode, resp.Status)
 		// for bad request, it should read the message
 		if resp.StatusCode != http.StatusBadRequest {
-			return p, err
+			return p, fmt.Errorf("bank responded with %s", resp.Status)
 		}
 	}
 
@@ -117,3 +119,173 @@ func (bs *BankService) RelayPaymentRequest(m entities.Merchant, p entities.Payme
 
 	return p, nil
 }
+
+// RelayRefundRequest asks the bank to return r to the shopper who made p, the
+// bank confirms or rejects it later, like it does with payments
+func (bs *BankService) RelayRefundRequest(m entities.Merchant, p entities.Payment, r entities.Refund) (entities.Refund, error) {
+	type messageRequest struct {
+		PaymentID   string `json:"payment_id"`
+		AmountMinor int64  `json:"amount_minor"`
+		Currency    string `json:"currency"`
+		Merchant    string `json:"merchant"`
+	}
+
+	type messageResponse struct {
+		Id      string `json:"id"`
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+
+	// Create bank request payload
+	payload := messageRequest{
+		PaymentID:   p.BankPaymentID.String(),
+		AmountMinor: r.Amount,
+		Currency:    r.Currency,
+		Merchant:    m.Name,
+	}
+
+	// Marshal payload
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("could not marshal json: %v", err)
+		return r, err
+	}
+
+	// Create a POST request with the JSON payload
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/refund", bs.address), bytes.NewBuffer(jsonData))
+	if err != nil {
+		log.Printf("error creating request: %v", err)
+		return r, err
+	}
+
+	// Set the request headers
+	req.Header.Set("Content-Type", "application/json")
+
+	// Send the request
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("error sending request: %v", err)
+		return r, err
+	}
+	defer resp.Body.Close()
+	r.BankRequestTime = time.Now()
+
+	// Check the response, for bad request the message tells why
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusBadRequest {
+		log.Printf("error relaying refund to bank, status code: %v (%s)", resp.StatusCode, resp.Status)
+		return r, fmt.Errorf("unexpected status from bank: %s", resp.Status)
+	}
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("could not read response: %v", err)
+		return r, err
+	}
+
+	// Unmarchal response
+	var responseData messageResponse
+	err = json.Unmarshal(body, &responseData)
+	if err != nil {
+		log.Printf("could not unmarshal response: %v", err)
+		return r, err
+	}
+	r.BankMessage = responseData.Message
+	if !responseData.Success {
+		return r, fmt.Errorf("request to bank resulted in: %s", responseData.Message)
+	}
+
+	// Assert reference id is uuid
+	id, err := uuid.Parse(responseData.Id)
+	if err != nil {
+		log.Printf("could not parse uuid: %v", err)
+		return r, err
+	}
+	r.BankRefundID = id
+
+	return r, nil
+}
+
+// RelayCaptureRequest tells the bank that amount minor units of the
+// authorization of p were captured, so it gives the rest back to the shopper
+func (bs *BankService) RelayCaptureRequest(m entities.Merchant, p entities.Payment, amount int64) (entities.Payment, error) {
+	return bs.settle("capture", m, p, amount)
+}
+
+// RelayVoidRequest tells the bank that the authorization of p was voided, by
+// the merchant or because it expired, so it gives all of it back to the
+// shopper
+func (bs *BankService) RelayVoidRequest(m entities.Merchant, p entities.Payment) (entities.Payment, error) {
+	return bs.settle("void", m, p, 0)
+}
+
+// settle sends how an authorization ended to the bank, which answers right
+// away instead of confirming it later like payments and refunds
+func (bs *BankService) settle(action string, m entities.Merchant, p entities.Payment, amount int64) (entities.Payment, error) {
+	type messageRequest struct {
+		PaymentID   string `json:"payment_id"`
+		AmountMinor int64  `json:"amount_minor,omitempty"`
+		Currency    string `json:"currency"`
+		Merchant    string `json:"merchant"`
+	}
+
+	type messageResponse struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+
+	payload := messageRequest{
+		PaymentID:   p.BankPaymentID.String(),
+		AmountMinor: amount,
+		Currency:    p.Currency,
+		Merchant:    m.Name,
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("could not marshal json: %v", err)
+		return p, err
+	}
+
+	req, err := http.NewRequestWithContext(bs.ctx, "POST", fmt.Sprintf("%s/%s", bs.address, action), bytes.NewBuffer(jsonData))
+	if err != nil {
+		log.Printf("error creating request: %v", err)
+		return p, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// the sweep of expired authorizations has no request to bound it
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("error sending request: %v", err)
+		return p, err
+	}
+	defer resp.Body.Close()
+
+	// for bad request the message tells why
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		log.Printf("error relaying %s to bank, status code: %v (%s)", action, resp.StatusCode, resp.Status)
+		return p, fmt.Errorf("unexpected status from bank: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("could not read response: %v", err)
+		return p, err
+	}
+
+	var responseData messageResponse
+	err = json.Unmarshal(body, &responseData)
+	if err != nil {
+		log.Printf("could not unmarshal response: %v", err)
+		return p, err
+	}
+	p.BankMessage = responseData.Message
+	if !responseData.Success {
+		return p, fmt.Errorf("request to bank resulted in: %s", responseData.Message)
+	}
+
+	return p, nil
+}
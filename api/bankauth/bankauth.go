@@ -0,0 +1,219 @@
+// Package bankauth tells callbacks sent by a bank apart from anything else
+// reaching PUT /payment, each bank signs the timestamp and the body with a
+// key the gateway knows, and may also be pinned to its networks
+package bankauth
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries keyid=<id>,t=<unix>,sig=<base64> where sig signs
+// "<t>.<body>"
+const SignatureHeader = "Bank-Signature"
+
+// Signature schemes of a Key
+const (
+	HMAC    = "hmac"
+	Ed25519 = "ed25519"
+)
+
+var (
+	// ErrInvalidKey must be used when a configured key is malformed
+	ErrInvalidKey = errors.New("invalid bank key")
+	// ErrMissingSignature must be used when a callback has no signature header
+	ErrMissingSignature = errors.New("missing bank signature")
+	// ErrUnknownKey must be used when a signature names a key that is not configured
+	ErrUnknownKey = errors.New("unknown bank key")
+	// ErrInvalidSignature must be used when a signature is malformed or does not match the body
+	ErrInvalidSignature = errors.New("invalid bank signature")
+	// ErrSignatureExpired must be used when a signature is outside the replay window
+	ErrSignatureExpired = errors.New("bank signature outside of replay window")
+	// ErrAddressNotAllowed must be used when a callback comes from outside the allowed networks
+	ErrAddressNotAllowed = errors.New("bank address not allowed")
+)
+
+// Key is what a bank signs its callbacks with, the shared secret for HMAC or
+// the public key for Ed25519
+type Key struct {
+	ID       string
+	Scheme   string
+	Material []byte
+}
+
+// ParseKeys reads keys in the form id:hmac:<secret> or
+// id:ed25519:<base64 public key>, separated by commas
+func ParseKeys(s string) ([]Key, error) {
+	keys := []Key{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("%w: expected id:scheme:key", ErrInvalidKey)
+		}
+
+		k := Key{ID: parts[0], Scheme: parts[1]}
+		switch k.Scheme {
+		case HMAC:
+			k.Material = []byte(parts[2])
+		case Ed25519:
+			public, err := base64.StdEncoding.DecodeString(parts[2])
+			if err != nil || len(public) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("%w: %s is not a base64 ed25519 public key", ErrInvalidKey, k.ID)
+			}
+			k.Material = public
+		default:
+			return nil, fmt.Errorf("%w: unknown scheme %s", ErrInvalidKey, k.Scheme)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// ParseNetworks reads CIDRs separated by commas, an empty string allows any
+// address
+func ParseNetworks(s string) ([]*net.IPNet, error) {
+	networks := []*net.IPNet{}
+	for _, cidr := range strings.Split(s, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Verifier checks the callbacks of every configured bank
+type Verifier struct {
+	keys      map[string]Key
+	networks  []*net.IPNet
+	tolerance time.Duration
+}
+
+// NewVerifier is a factory for a Verifier that accepts signatures made with
+// keys at most tolerance away from now, from any address when networks is
+// empty
+func NewVerifier(keys []Key, networks []*net.IPNet, tolerance time.Duration) *Verifier {
+	v := &Verifier{
+		keys:      make(map[string]Key, len(keys)),
+		networks:  networks,
+		tolerance: tolerance,
+	}
+	for _, k := range keys {
+		v.keys[k.ID] = k
+	}
+	return v
+}
+
+// Allowed asserts ip is in one of the allowed networks
+func (v *Verifier) Allowed(ip net.IP) error {
+	if len(v.networks) == 0 {
+		return nil
+	}
+	for _, network := range v.networks {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	return ErrAddressNotAllowed
+}
+
+// Verify asserts header is a signature of body by a configured key, made
+// within the replay window around now, and returns the id of the key
+func (v *Verifier) Verify(header string, body []byte, now time.Time) (string, error) {
+	if header == "" {
+		return "", ErrMissingSignature
+	}
+
+	var keyID, timestamp, sig string
+	for _, part := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "keyid":
+			keyID = value
+		case "t":
+			timestamp = value
+		case "sig":
+			sig = value
+		}
+	}
+
+	k, ok := v.keys[keyID]
+	if !ok {
+		return keyID, ErrUnknownKey
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return keyID, ErrInvalidSignature
+	}
+	signature, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil || len(signature) == 0 {
+		return keyID, ErrInvalidSignature
+	}
+
+	message := signedMessage(timestamp, body)
+	switch k.Scheme {
+	case HMAC:
+		if !hmac.Equal(signature, mac(k.Material, message)) {
+			return keyID, ErrInvalidSignature
+		}
+	case Ed25519:
+		if !ed25519.Verify(ed25519.PublicKey(k.Material), message, signature) {
+			return keyID, ErrInvalidSignature
+		}
+	default:
+		return keyID, ErrInvalidSignature
+	}
+
+	// checked once the signature is known to be genuine, so the timestamp
+	// cannot be forged to look fresh
+	age := now.Sub(time.Unix(unix, 0))
+	if age > v.tolerance || age < -v.tolerance {
+		return keyID, ErrSignatureExpired
+	}
+	return keyID, nil
+}
+
+// SignHMAC returns the value of SignatureHeader for body sent at t, as a bank
+// holding secret would
+func SignHMAC(keyID string, secret []byte, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	return header(keyID, timestamp, mac(secret, signedMessage(timestamp, body)))
+}
+
+// SignEd25519 returns the value of SignatureHeader for body sent at t, as a
+// bank holding private would
+func SignEd25519(keyID string, private ed25519.PrivateKey, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	return header(keyID, timestamp, ed25519.Sign(private, signedMessage(timestamp, body)))
+}
+
+func header(keyID, timestamp string, signature []byte) string {
+	return fmt.Sprintf("keyid=%s,t=%s,sig=%s", keyID, timestamp, base64.StdEncoding.EncodeToString(signature))
+}
+
+func signedMessage(timestamp string, body []byte) []byte {
+	return append([]byte(timestamp+"."), body...)
+}
+
+func mac(secret, message []byte) []byte {
+	m := hmac.New(sha256.New, secret)
+	m.Write(message)
+	return m.Sum(nil)
+}
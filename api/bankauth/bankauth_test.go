@@ -0,0 +1,141 @@
+package bankauth_test
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/thiagolcmelo/payment-gateway/api/bankauth"
+)
+
+const secret = "0123456789abcdef"
+
+func TestParseKeys(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName     string
+		keys         string
+		expectedKeys int
+		expectedErr  error
+	}
+
+	testCases := []testCase{
+		{testName: "empty", keys: "", expectedKeys: 0, expectedErr: nil},
+		{testName: "hmac", keys: "bank-1:hmac:" + secret, expectedKeys: 1, expectedErr: nil},
+		{testName: "hmac_secret_with_colon", keys: "bank-1:hmac:a:b", expectedKeys: 1, expectedErr: nil},
+		{
+			testName:     "hmac_and_ed25519",
+			keys:         "bank-1:hmac:" + secret + ", bank-2:ed25519:" + base64.StdEncoding.EncodeToString(public),
+			expectedKeys: 2,
+			expectedErr:  nil,
+		},
+		{testName: "missing_key", keys: "bank-1:hmac:", expectedErr: bankauth.ErrInvalidKey},
+		{testName: "missing_scheme", keys: "bank-1", expectedErr: bankauth.ErrInvalidKey},
+		{testName: "unknown_scheme", keys: "bank-1:rsa:abc", expectedErr: bankauth.ErrInvalidKey},
+		{testName: "short_ed25519", keys: "bank-1:ed25519:YWJj", expectedErr: bankauth.ErrInvalidKey},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			keys, err := bankauth.ParseKeys(tc.keys)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if err == nil && len(keys) != tc.expectedKeys {
+				t.Errorf("expected %d keys, got %d", tc.expectedKeys, len(keys))
+			}
+		})
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPrivate, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := bankauth.ParseKeys("bank-1:hmac:" + secret + ",bank-2:ed25519:" + base64.StdEncoding.EncodeToString(public))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := bankauth.NewVerifier(keys, nil, 5*time.Minute)
+
+	now := time.Now()
+	body := []byte(`{"id":"aa0dd29e-f69b-11ed-8560-8c859093fdeb","success":true,"message":"ok"}`)
+
+	type testCase struct {
+		testName    string
+		header      string
+		body        []byte
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{testName: "hmac", header: bankauth.SignHMAC("bank-1", []byte(secret), now, body), body: body, expectedErr: nil},
+		{testName: "ed25519", header: bankauth.SignEd25519("bank-2", private, now, body), body: body, expectedErr: nil},
+		{testName: "missing", header: "", body: body, expectedErr: bankauth.ErrMissingSignature},
+		{testName: "unknown_key", header: bankauth.SignHMAC("bank-3", []byte(secret), now, body), body: body, expectedErr: bankauth.ErrUnknownKey},
+		{testName: "wrong_secret", header: bankauth.SignHMAC("bank-1", []byte("another secret!!"), now, body), body: body, expectedErr: bankauth.ErrInvalidSignature},
+		{testName: "wrong_private_key", header: bankauth.SignEd25519("bank-2", otherPrivate, now, body), body: body, expectedErr: bankauth.ErrInvalidSignature},
+		{testName: "hmac_signature_for_ed25519_key", header: bankauth.SignHMAC("bank-2", []byte(secret), now, body), body: body, expectedErr: bankauth.ErrInvalidSignature},
+		{testName: "tampered_body", header: bankauth.SignHMAC("bank-1", []byte(secret), now, body), body: []byte(`{"success":false}`), expectedErr: bankauth.ErrInvalidSignature},
+		{testName: "malformed", header: "keyid=bank-1,t=abc,sig=???", body: body, expectedErr: bankauth.ErrInvalidSignature},
+		{testName: "too_old", header: bankauth.SignHMAC("bank-1", []byte(secret), now.Add(-6*time.Minute), body), body: body, expectedErr: bankauth.ErrSignatureExpired},
+		{testName: "too_far_in_future", header: bankauth.SignEd25519("bank-2", private, now.Add(6*time.Minute), body), body: body, expectedErr: bankauth.ErrSignatureExpired},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			_, err := v.Verify(tc.header, tc.body, now)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestVerifier_Allowed(t *testing.T) {
+	networks, err := bankauth.ParseNetworks("10.123.123.0/30, fd00::/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName    string
+		networks    []*net.IPNet
+		ip          string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{testName: "no_networks", networks: nil, ip: "203.0.113.7", expectedErr: nil},
+		{testName: "ipv4_inside", networks: networks, ip: "10.123.123.2", expectedErr: nil},
+		{testName: "ipv4_outside", networks: networks, ip: "10.123.123.4", expectedErr: bankauth.ErrAddressNotAllowed},
+		{testName: "ipv6_inside", networks: networks, ip: "fd12::1", expectedErr: nil},
+		{testName: "ipv6_outside", networks: networks, ip: "2001:db8::1", expectedErr: bankauth.ErrAddressNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			v := bankauth.NewVerifier(nil, tc.networks, time.Minute)
+			if err := v.Allowed(net.ParseIP(tc.ip)); !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+
+	if _, err := bankauth.ParseNetworks("10.0.0.0/33"); err == nil {
+		t.Error("expected an error for an invalid cidr")
+	}
+}
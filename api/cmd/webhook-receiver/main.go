@@ -0,0 +1,47 @@
+// webhook-receiver stands in for a merchant webhook during local testing, it
+// verifies the signature of every delivery and prints the event
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/thiagolcmelo/payment-gateway/api/webhook"
+)
+
+var (
+	portFlag   = flag.Int("port", 9000, "Port to receive deliveries on")
+	secretFlag = flag.String("secret", "", "Secret registered with PUT /webhook")
+	statusFlag = flag.Int("status", http.StatusOK, "Status to answer with, anything but 2xx makes the gateway retry")
+)
+
+func main() {
+	flag.Parse()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Printf("could not read delivery: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err = webhook.Verify(*secretFlag, r.Header.Get(webhook.SignatureHeader), body, 5*time.Minute, time.Now())
+		if err != nil {
+			log.Printf("refusing delivery %s: %v", r.Header.Get(webhook.DeliveryIDHeader), err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		log.Printf("delivery %s: %s", r.Header.Get(webhook.DeliveryIDHeader), body)
+		w.WriteHeader(*statusFlag)
+	})
+
+	address := fmt.Sprintf("0.0.0.0:%d", *portFlag)
+	log.Printf("receiving webhooks at %s", address)
+	log.Fatal(http.ListenAndServe(address, nil))
+}
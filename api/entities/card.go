@@ -1,5 +1,7 @@
 package entities
 
+import "strings"
+
 type CreditCard struct {
 	Number      string `json:"number"`
 	Name        string `json:"name"`
@@ -7,3 +9,27 @@ type CreditCard struct {
 	ExpireYear  int    `json:"expire_year"`
 	CVV         int    `json:"cvv"`
 }
+
+// MaskedCard is all a payment shows of its card, Number keeps only the last
+// four digits visible
+type MaskedCard struct {
+	Number      string `json:"number"`
+	Brand       string `json:"brand"`
+	Last4       string `json:"last4"`
+	Name        string `json:"name"`
+	ExpireMonth int    `json:"expire_month"`
+	ExpireYear  int    `json:"expire_year"`
+}
+
+// NewMaskedCard is a factory for a MaskedCard whose number is made of
+// asterisks followed by last4
+func NewMaskedCard(brand, last4, name string, expireMonth, expireYear int) MaskedCard {
+	return MaskedCard{
+		Number:      strings.Repeat("*", 12) + last4,
+		Brand:       brand,
+		Last4:       last4,
+		Name:        name,
+		ExpireMonth: expireMonth,
+		ExpireYear:  expireYear,
+	}
+}
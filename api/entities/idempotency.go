@@ -0,0 +1,34 @@
+package entities
+
+type IdempotentRequestState int
+
+const (
+	Started IdempotentRequestState = iota
+	InProgress
+	Completed
+	Mismatch
+)
+
+func (s IdempotentRequestState) String() string {
+	switch s {
+	case Started:
+		return "STARTED"
+	case InProgress:
+		return "IN_PROGRESS"
+	case Completed:
+		return "COMPLETED"
+	case Mismatch:
+		return "MISMATCH"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// IdempotentRequest is what the ledger knows about a request sent with an
+// Idempotency-Key, ResponseStatus and ResponseBody are only set once the
+// request is Completed
+type IdempotentRequest struct {
+	State          IdempotentRequestState
+	ResponseStatus int
+	ResponseBody   []byte
+}
@@ -1,6 +1,10 @@
 package entities
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Merchant struct {
 	ID       uuid.UUID
@@ -10,3 +14,29 @@ type Merchant struct {
 	Active   bool
 	MaxQPS   int
 }
+
+// Webhook is where events about the payments of a merchant are sent, URL is
+// empty when the merchant did not register one
+type Webhook struct {
+	URL    string
+	Secret string
+}
+
+// APIKey describes an api key of a merchant, the key itself is only known
+// when it is created
+type APIKey struct {
+	ID        uuid.UUID  `json:"id"`
+	Prefix    string     `json:"prefix"`
+	Label     string     `json:"label"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// User is one more login of a merchant, limited to what its Roles allow
+type User struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Roles     []string  `json:"roles"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	ledgerEntity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
+)
+
+var (
+	// ErrUnknownCurrency is returned for currencies that are not ISO 4217 codes known by the gateway
+	ErrUnknownCurrency = ledgerEntity.ErrUnknownCurrency
+	// ErrInvalidAmount is returned for amounts that are not decimal numbers
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrTooManyDecimals is returned for amounts more precise than the minor unit of their currency
+	ErrTooManyDecimals = errors.New("amount has more decimals than its currency allows")
+)
+
+// CurrencyExponent returns the number of minor unit digits of an ISO 4217
+// currency, the ledger owns the list of currencies so both services agree on it
+func CurrencyExponent(currency string) (int, error) {
+	exponent, err := ledgerEntity.CurrencyExponent(currency)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return exponent, nil
+}
+
+// ParseAmount converts a decimal string such as "1234567.89" into minor units
+// of currency without going through floating point, so no precision is lost
+func ParseAmount(value, currency string) (int64, error) {
+	exponent, err := CurrencyExponent(currency)
+	if err != nil {
+		return 0, err
+	}
+
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	integer, fraction, _ := strings.Cut(value, ".")
+	if integer == "" || strings.ContainsAny(integer+fraction, "+-eE") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	// trailing zeros do not add precision, 10.500 USD is still 1050 cents
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exponent {
+		return 0, fmt.Errorf("%w: %q has %d, %s allows %d", ErrTooManyDecimals, value, len(fraction), currency, exponent)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	minor, err := strconv.ParseInt(integer+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return minor, nil
+}
+
+// FormatAmount converts minor units of currency into a decimal string, it is
+// the inverse of ParseAmount
+func FormatAmount(minor int64, currency string) (string, error) {
+	exponent, err := CurrencyExponent(currency)
+	if err != nil {
+		return "", err
+	}
+
+	sign, magnitude := "", uint64(minor)
+	if minor < 0 {
+		// negating as unsigned also covers math.MinInt64
+		sign, magnitude = "-", -magnitude
+	}
+
+	digits := strconv.FormatUint(magnitude, 10)
+	if exponent == 0 {
+		return sign + digits, nil
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:], nil
+}
@@ -0,0 +1,92 @@
+package entities_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/thiagolcmelo/payment-gateway/api/entities"
+)
+
+func TestMoney_ParseAmount(t *testing.T) {
+	type testCase struct {
+		testName      string
+		value         string
+		currency      string
+		expectedMinor int64
+		expectedErr   error
+	}
+
+	testCases := []testCase{
+		{testName: "two_decimals", value: "10.50", currency: "USD", expectedMinor: 1050, expectedErr: nil},
+		{testName: "no_decimals", value: "10", currency: "USD", expectedMinor: 1000, expectedErr: nil},
+		{testName: "one_decimal", value: "10.5", currency: "USD", expectedMinor: 1050, expectedErr: nil},
+		{testName: "trailing_zeros", value: "10.500", currency: "USD", expectedMinor: 1050, expectedErr: nil},
+		{testName: "large_amount_keeps_cents", value: "1234567.89", currency: "USD", expectedMinor: 123456789, expectedErr: nil},
+		{testName: "zero_exponent", value: "500", currency: "JPY", expectedMinor: 500, expectedErr: nil},
+		{testName: "three_decimals", value: "1.234", currency: "KWD", expectedMinor: 1234, expectedErr: nil},
+		{testName: "negative", value: "-1.50", currency: "USD", expectedMinor: -150, expectedErr: nil},
+		{testName: "too_many_decimals", value: "10.505", currency: "USD", expectedErr: entities.ErrTooManyDecimals},
+		{testName: "decimals_on_zero_exponent", value: "1.5", currency: "JPY", expectedErr: entities.ErrTooManyDecimals},
+		{testName: "exponent_notation", value: "1e3", currency: "USD", expectedErr: entities.ErrInvalidAmount},
+		{testName: "missing_integer_part", value: ".50", currency: "USD", expectedErr: entities.ErrInvalidAmount},
+		{testName: "not_a_number", value: "ten", currency: "USD", expectedErr: entities.ErrInvalidAmount},
+		{testName: "overflow", value: "92233720368547758.08", currency: "USD", expectedErr: entities.ErrInvalidAmount},
+		{testName: "unknown_currency", value: "10.50", currency: "XYZ", expectedErr: entities.ErrUnknownCurrency},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			minor, err := entities.ParseAmount(tc.value, tc.currency)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if tc.expectedMinor != minor {
+				t.Errorf("expected %d, got %d", tc.expectedMinor, minor)
+			}
+		})
+	}
+}
+
+func TestMoney_FormatAmount(t *testing.T) {
+	type testCase struct {
+		testName    string
+		minor       int64
+		currency    string
+		expected    string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{testName: "two_decimals", minor: 1050, currency: "USD", expected: "10.50", expectedErr: nil},
+		{testName: "less_than_one_unit", minor: 5, currency: "USD", expected: "0.05", expectedErr: nil},
+		{testName: "zero", minor: 0, currency: "USD", expected: "0.00", expectedErr: nil},
+		{testName: "large_amount_keeps_cents", minor: 123456789, currency: "USD", expected: "1234567.89", expectedErr: nil},
+		{testName: "zero_exponent", minor: 500, currency: "JPY", expected: "500", expectedErr: nil},
+		{testName: "three_decimals", minor: 1234, currency: "KWD", expected: "1.234", expectedErr: nil},
+		{testName: "negative", minor: -150, currency: "USD", expected: "-1.50", expectedErr: nil},
+		{testName: "min_int64", minor: -9223372036854775808, currency: "USD", expected: "-92233720368547758.08", expectedErr: nil},
+		{testName: "unknown_currency", minor: 1050, currency: "XYZ", expectedErr: entities.ErrUnknownCurrency},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			value, err := entities.FormatAmount(tc.minor, tc.currency)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if tc.expected != value {
+				t.Errorf("expected %s, got %s", tc.expected, value)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -14,6 +15,9 @@ const (
 	Pending
 	Success
 	Fail
+	Authorized
+	Captured
+	Voided
 )
 
 func (ps PaymentStatus) String() string {
@@ -26,31 +30,73 @@ func (ps PaymentStatus) String() string {
 		return "SUCCESS"
 	case 3:
 		return "FAIL"
+	case 4:
+		return "AUTHORIZED"
+	case 5:
+		return "CAPTURED"
+	case 6:
+		return "VOIDED"
 	default:
 		return fmt.Sprintf("%d", ps)
 	}
 }
 
+// ErrUnknownStatus is returned for status names that are not a PaymentStatus
+var ErrUnknownStatus = errors.New("unknown payment status")
+
+// ParsePaymentStatus is the inverse of PaymentStatus.String
+func ParsePaymentStatus(name string) (PaymentStatus, error) {
+	for _, ps := range []PaymentStatus{Created, Pending, Success, Fail, Authorized, Captured, Voided} {
+		if ps.String() == name {
+			return ps, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownStatus, name)
+}
+
+// Capture modes of a payment, with ManualCapture the bank approval only
+// authorizes the payment until the merchant captures or voids it
+const (
+	AutomaticCapture = "automatic"
+	ManualCapture    = "manual"
+)
+
+// Payment keeps Amount in minor units of Currency, e.g. 1050 USD is 10.50 USD
 type Payment struct {
 	ID               uuid.UUID  `json:"id"`
 	MerchantID       uuid.UUID  `json:"merchant_id"`
-	Amount           float64    `json:"amount"`
+	Amount           int64      `json:"amount_minor"`
 	Currency         string     `json:"currency"`
 	PurchaseTime     time.Time  `json:"purchase_time"`
 	ValidationMethod string     `json:"validation_method"`
-	Card             CreditCard `json:"card"`
+	Card             MaskedCard `json:"card"`
 	Metadata         string     `json:"metadata"`
 	Status           string     `json:"status"`
 	BankPaymentID    uuid.UUID  `json:"bank_payment_id"`
 	BankRequestTime  time.Time  `json:"bank_request_time"`
 	BankResponseTime time.Time  `json:"bank_response_time"`
 	BankMessage      string     `json:"bank_message"`
+	CaptureMode      string     `json:"capture"`
+	// AuthorizationExpiresAt is zero unless the payment was authorized
+	AuthorizationExpiresAt time.Time `json:"authorization_expires_at"`
+	CapturedAmount         int64     `json:"captured_amount_minor"`
+	// QuotaConsumedAt is when the payment was counted in the quotas of its
+	// merchant, zero if it was not
+	QuotaConsumedAt time.Time `json:"-"`
 }
 
 func (p Payment) GetPurchaseTimeStr() string {
 	return p.PurchaseTime.Format("2006-01-02T15:04:05.000")
 }
 
+// GetQuotaConsumedAtStr is empty for a payment that was not counted
+func (p Payment) GetQuotaConsumedAtStr() string {
+	if p.QuotaConsumedAt.IsZero() {
+		return ""
+	}
+	return p.QuotaConsumedAt.UTC().Format("2006-01-02T15:04:05.000")
+}
+
 func (p Payment) GetBankRequestTimeStr() string {
 	return p.BankRequestTime.Format("2006-01-02T15:04:05.000")
 }
@@ -58,3 +104,18 @@ func (p Payment) GetBankRequestTimeStr() string {
 func (p Payment) GetBankResponseTimeStr() string {
 	return p.BankResponseTime.Format("2006-01-02T15:04:05.000")
 }
+
+// PaymentFilter selects payments of a single merchant, zero values of
+// optional fields mean they do not filter anything
+type PaymentFilter struct {
+	MerchantID    uuid.UUID
+	Status        *PaymentStatus
+	Currency      string
+	MinAmount     *int64
+	MaxAmount     *int64
+	PurchasedFrom time.Time
+	PurchasedTo   time.Time
+	Metadata      string
+	Cursor        string
+	Limit         int
+}
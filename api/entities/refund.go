@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Refund keeps Amount in minor units of Currency, like Payment, and goes
+// through the same statuses
+type Refund struct {
+	ID               uuid.UUID `json:"id"`
+	PaymentID        uuid.UUID `json:"payment_id"`
+	MerchantID       uuid.UUID `json:"merchant_id"`
+	Amount           int64     `json:"amount_minor"`
+	Currency         string    `json:"currency"`
+	Status           string    `json:"status"`
+	BankRefundID     uuid.UUID `json:"bank_refund_id"`
+	BankRequestTime  time.Time `json:"bank_request_time"`
+	BankResponseTime time.Time `json:"bank_response_time"`
+	BankMessage      string    `json:"bank_message"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (r Refund) GetBankRequestTimeStr() string {
+	return r.BankRequestTime.Format("2006-01-02T15:04:05.000")
+}
+
+func (r Refund) GetBankResponseTimeStr() string {
+	return r.BankResponseTime.Format("2006-01-02T15:04:05.000")
+}
@@ -40,3 +40,9 @@ require (
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace (
+	github.com/thiagolcmelo/payment-gateway/ledger => ../ledger
+	github.com/thiagolcmelo/payment-gateway/merchant => ../merchant
+	github.com/thiagolcmelo/payment-gateway/ratelimiter => ../ratelimiter
+)
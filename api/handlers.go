@@ -1,9 +1,13 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -13,6 +17,8 @@ import (
 	"github.com/thiagolcmelo/payment-gateway/api/entities"
 	"github.com/thiagolcmelo/payment-gateway/api/ledger"
 	"github.com/thiagolcmelo/payment-gateway/api/merchant"
+	"github.com/thiagolcmelo/payment-gateway/api/ratelimiter"
+	"github.com/thiagolcmelo/payment-gateway/api/webhook"
 )
 
 func loginHandler(c *gin.Context) {
@@ -25,29 +31,194 @@ func loginHandler(c *gin.Context) {
 
 	ms := merchant.NewMerchantService(c, merchantAddress)
 
-	merchantID, ok := ms.Validate(username, password)
-	if !ok {
+	var (
+		locked       *merchant.LoginLockedError
+		secondFactor *merchant.SecondFactorRequiredError
+	)
+	login, err := ms.Validate(username, password, c.ClientIP())
+	switch {
+	case errors.As(err, &secondFactor):
+		c.JSON(http.StatusOK, loginChallengeResponse{
+			Challenge:     secondFactor.Challenge,
+			ChallengeType: "totp",
+			ExpiresIn:     int64(secondFactor.ExpiresIn / time.Second),
+		})
+		return
+	case errors.As(err, &locked):
+		c.Header("Retry-After", strconv.FormatInt(int64(locked.RetryAfter/time.Second), 10))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed logins"})
+		return
+	case errors.Is(err, merchant.ErrInvalidCredentials):
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
+	case errors.Is(err, merchant.ErrMerchantInactive):
+		c.JSON(http.StatusForbidden, gin.H{"error": "merchant is not active"})
+		return
+	case errors.Is(err, merchant.ErrUserInactive):
+		c.JSON(http.StatusForbidden, gin.H{"error": "user is not active"})
+		return
+	case err != nil:
+		log.Printf("could not validate credentials: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
 	}
 
-	// Create a new JWT token
-	claims := MerchantClaims{
-		Username: username,
-		ID:       merchantID,
-		Exp:      time.Now().Add(time.Hour * 24),
+	refreshToken, err := ms.CreateRefreshToken(login, refreshTokenTTL)
+	switch {
+	case errors.Is(err, merchant.ErrMerchantInactive):
+		c.JSON(http.StatusForbidden, gin.H{"error": "merchant is not active"})
+		return
+	case errors.Is(err, merchant.ErrUserInactive):
+		c.JSON(http.StatusForbidden, gin.H{"error": "user is not active"})
+		return
+	case err != nil:
+		log.Printf("could not create refresh token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	respondWithTokens(c, login, refreshToken)
+}
+
+// verifyLoginHandler completes the login of a merchant with TOTP enabled,
+// with the challenge from loginHandler and a TOTP or recovery code
+func verifyLoginHandler(c *gin.Context) {
+	var body verifyLoginRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil || body.Challenge == "" || body.Code == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "missing challenge or code"})
+		return
+	}
+
+	ms := merchant.NewMerchantService(c, merchantAddress)
+
+	var locked *merchant.LoginLockedError
+	login, err := ms.VerifyLogin(body.Challenge, body.Code, c.ClientIP())
+	switch {
+	case errors.As(err, &locked):
+		c.Header("Retry-After", strconv.FormatInt(int64(locked.RetryAfter/time.Second), 10))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed logins"})
+		return
+	case errors.Is(err, merchant.ErrInvalidChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired challenge"})
+		return
+	case errors.Is(err, merchant.ErrInvalidCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return
+	case err != nil:
+		log.Printf("could not verify login: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	refreshToken, err := ms.CreateRefreshToken(login, refreshTokenTTL)
+	switch {
+	case errors.Is(err, merchant.ErrMerchantInactive):
+		c.JSON(http.StatusForbidden, gin.H{"error": "merchant is not active"})
+		return
+	case errors.Is(err, merchant.ErrUserInactive):
+		c.JSON(http.StatusForbidden, gin.H{"error": "user is not active"})
+		return
+	case err != nil:
+		log.Printf("could not create refresh token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	// Generate encoded token
-	tokenString, err := token.SignedString([]byte(secretKey))
+	respondWithTokens(c, login, refreshToken)
+}
+
+// respondWithTokens sends a new access token along with the refresh token
+// that gets the next one
+func respondWithTokens(c *gin.Context, login merchant.Login, refreshToken string) {
+	now := time.Now()
+	claims := MerchantClaims{
+		Username: login.Username,
+		ID:       login.MerchantID,
+		Exp:      now.Add(accessTokenTTL),
+		UserID:   login.UserID,
+		Roles:    login.Roles,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.NewString(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(accessTokenTTL).Unix(),
+		},
+	}
+	tokenString, err := tokenKeys.Sign(claims)
 	if err != nil {
+		log.Printf("could not sign token: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
 
-	// Return the token to the client
-	c.JSON(http.StatusOK, gin.H{"token": tokenString})
+	c.JSON(http.StatusOK, tokenResponse{
+		Token:        tokenString,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTokenTTL / time.Second),
+		RefreshToken: refreshToken,
+	})
+}
+
+// refreshTokenHandler exchanges a refresh token for a new access token and a
+// new refresh token, the one presented can not be used again
+func refreshTokenHandler(c *gin.Context) {
+	var body refreshTokenRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil || body.RefreshToken == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "missing refresh_token"})
+		return
+	}
+
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	login, refreshToken, err := ms.RotateRefreshToken(body.RefreshToken, refreshTokenTTL)
+	if errors.Is(err, merchant.ErrInvalidRefreshToken) {
+		log.Printf("refusing refresh token: %v", err)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+	if err != nil {
+		log.Printf("could not rotate refresh token: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	respondWithTokens(c, login, refreshToken)
+}
+
+// logoutHandler revokes the access token of the request, and the refresh
+// token in the body, if any, with every token rotated from the same login
+func logoutHandler(c *gin.Context) {
+	var body refreshTokenRequestBody
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	if err := ms.RevokeAccessToken(claims.Id, claims.Exp); err != nil {
+		log.Printf("could not revoke access token: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	merchantStatus.Revoke(claims.Id, claims.Exp)
+
+	if body.RefreshToken != "" {
+		err := ms.RevokeRefreshToken(body.RefreshToken)
+		if err != nil && !errors.Is(err, merchant.ErrInvalidRefreshToken) {
+			log.Printf("could not revoke refresh token: %v", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// jwksHandler publishes the public keys tokens can be verified with
+func jwksHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, tokenKeys.JWKS())
 }
 
 func createPaymentHandler(c *gin.Context) {
@@ -72,30 +243,61 @@ func createPaymentHandler(c *gin.Context) {
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
+	// the cached status checked by authMiddleware can be a little behind
+	if !m.Active {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "merchant is not active"})
+		return
+	}
+
+	amount, err := body.getAmount()
+	if err != nil {
+		log.Printf("could not parse amount: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	p := entities.Payment{
 		MerchantID:       m.ID,
-		Amount:           body.Amount,
+		Amount:           amount,
 		Currency:         body.Currency,
 		PurchaseTime:     body.getPurchaseTime(),
 		ValidationMethod: body.ValidationMethod,
-		Card:             body.Card,
 		Metadata:         body.Metadata,
 		Status:           fmt.Sprint(entities.Created),
+		CaptureMode:      body.getCaptureMode(),
+	}
+
+	// payments are counted before they exist, so concurrent ones can not
+	// slip past a quota together
+	rls := ratelimiter.NewRateLimiterService(c, rateLimiterAddress, true)
+	consumed, _ := rls.Consume(m.ID, p.Currency, p.Amount)
+	if !consumed.Allow {
+		c.Header("Retry-After", strconv.FormatInt(ceilSeconds(time.Until(consumed.Exceeded.PeriodEnd)), 10))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error": quotaExceededMessage(consumed.Exceeded),
+			"quota": newQuotaUsageResponse(consumed.Exceeded),
+		})
+		return
 	}
+	// kept with the payment, to give it back to the same period
+	p.QuotaConsumedAt = consumed.ConsumedAt
 
 	ls := ledger.NewLedgerService(c, ledgerAddress)
-	p, err = ls.CreatePayment(p)
+	p, err = ls.CreatePayment(p, body.Card)
 	if err != nil {
 		log.Printf("could not create payment: %v", err)
+		// the request may be retried, which counts it again
+		releaseQuota(rls, p)
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
+	c.Set(ledgerRecordKey, true)
 
 	bs := bank.NewBankService(c, bankAddress)
-	p, err = bs.RelayPaymentRequest(m, p)
+	p, err = bs.RelayPaymentRequest(m, p, body.Card)
 	if err != nil {
 		log.Printf("could not relay payment to bank: %v", err)
+		releaseQuota(rls, p)
 		p, err = ls.SetPaymentFail(p)
 		if err != nil {
 			log.Printf("could not set payment status to fail in the ledger: %v", err)
@@ -113,6 +315,18 @@ func createPaymentHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"id": p.ID.String(), "status": p.Status, "bank_message": p.BankMessage})
 }
 
+// releaseQuota gives back to the quotas of its merchant a payment that did
+// not happen, a payment that was not counted, because the rate limiter
+// failed open, is left alone
+func releaseQuota(rls *ratelimiter.RateLimiterService, p entities.Payment) {
+	if p.QuotaConsumedAt.IsZero() {
+		return
+	}
+	if err := rls.Release(p.MerchantID, p.Currency, p.Amount, p.QuotaConsumedAt); err != nil {
+		log.Printf("could not release payment %s from the quotas: %v", p.ID.String(), err)
+	}
+}
+
 func updatePaymentHandler(c *gin.Context) {
 	var body bankMessage
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -137,6 +351,12 @@ func updatePaymentHandler(c *gin.Context) {
 	ls := ledger.NewLedgerService(c, ledgerAddress)
 	p, err := ls.ReadPaymentUsingBankReference(bankPaymentID)
 	if err != nil {
+		// refunds are confirmed through the same message, using the reference
+		// the bank gave to the refund
+		if r, refundErr := ls.ReadRefundUsingBankReference(bankPaymentID); refundErr == nil {
+			updateRefund(c, ls, r, body)
+			return
+		}
 		log.Printf("could not find payment: %v", err)
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid bank payment id", "acknowledge": false})
 		return
@@ -144,27 +364,171 @@ func updatePaymentHandler(c *gin.Context) {
 	p.BankResponseTime = time.Now()
 	p.BankMessage = body.Message
 
+	switch {
+	case body.Success && p.CaptureMode == entities.ManualCapture:
+		// the bank approval only authorizes it, the merchant captures later
+		p, err = ls.SetPaymentAuthorized(p)
+	case body.Success:
+		p, err = ls.SetPaymentSuccess(p)
+	default:
+		p, err = ls.SetPaymentFail(p)
+	}
+	if errors.Is(err, ledger.ErrInvalidTransition) {
+		log.Printf("refusing bank message for payment %s in status %s: %v", p.ID.String(), p.Status, err)
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "payment status cannot be changed", "acknowledge": false})
+		return
+	}
+	if err != nil {
+		log.Printf("could not set payment to success=%v: %v", body.Success, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error", "acknowledge": false})
+		return
+	}
+	if !body.Success {
+		releaseQuota(ratelimiter.NewRateLimiterService(c, rateLimiterAddress, true), p)
+	}
+	notifyMerchant(p)
+
+	c.JSON(http.StatusOK, gin.H{"acknowledge": true})
+}
+
+// notifyMerchant sends the event of the new status of a payment to the
+// webhook of its merchant, if there is one, delivery happens in background
+func notifyMerchant(p entities.Payment) {
+	e, ok := webhook.NewPaymentEvent(p)
+	if !ok {
+		return
+	}
+	dl, err := webhooks.Send(p.MerchantID, e)
+	if errors.Is(err, webhook.ErrNoWebhook) {
+		return
+	}
+	if err != nil {
+		log.Printf("could not send %s of payment %s: %v", e.Type, p.ID.String(), err)
+		return
+	}
+	log.Printf("sending %s of payment %s in delivery %s", e.Type, p.ID.String(), dl.ID.String())
+}
+
+// updateRefund applies the bank message to a refund, it answers the bank the
+// same way updatePaymentHandler does
+func updateRefund(c *gin.Context, ls *ledger.LedgerService, r entities.Refund, body bankMessage) {
+	r.BankResponseTime = time.Now()
+	r.BankMessage = body.Message
+
+	var err error
 	if body.Success {
-		_, err = ls.SetPaymentSuccess(p)
+		_, err = ls.SetRefundSuccess(r)
+	} else {
+		_, err = ls.SetRefundFail(r)
+	}
+	if errors.Is(err, ledger.ErrInvalidTransition) {
+		log.Printf("refusing bank message for refund %s in status %s: %v", r.ID.String(), r.Status, err)
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "refund status cannot be changed", "acknowledge": false})
+		return
+	}
+	if err != nil {
+		log.Printf("could not set refund to success=%v: %v", body.Success, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error", "acknowledge": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"acknowledge": true})
+}
+
+func createRefundHandler(c *gin.Context) {
+	pID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.Printf("could not parse payment id: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid payment id"})
+		return
+	}
+
+	// an empty body refunds whatever is left of the payment
+	var body createRefundRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		log.Printf("could not parse request: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ls := ledger.NewLedgerService(c, ledgerAddress)
+	p, err := ls.ReadPayment(pID)
+	if err != nil {
+		log.Printf("could not read payment: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid payment id"})
+		return
+	}
+
+	// Assert mechant is owner of payment
+	claims := c.MustGet("claims").(MerchantClaims)
+	if p.MerchantID != claims.ID {
+		log.Printf("merchant %s trying to refund unauthorized payment id: %s", claims.ID.String(), pID.String())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid payment id"})
+		return
+	}
+
+	amount, err := body.getAmount(p.Currency)
+	if err != nil {
+		log.Printf("could not parse amount: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	m, err := ms.Get(claims.ID)
+	if err != nil {
+		log.Printf("could not retrieve merchant: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	r, err := ls.CreateRefund(pID, amount)
+	if errors.Is(err, ledger.ErrRefundNotAllowed) || errors.Is(err, ledger.ErrInvalidArgument) {
+		log.Printf("ledger refused refund of payment %s: %v", pID.String(), err)
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("could not create refund: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Set(ledgerRecordKey, true)
+
+	bs := bank.NewBankService(c, bankAddress)
+	r, err = bs.RelayRefundRequest(m, p, r)
+	if err != nil {
+		log.Printf("could not relay refund to bank: %v", err)
+		r, err = ls.SetRefundFail(r)
 		if err != nil {
-			log.Printf("could not set payment to success: %v", err)
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error", "acknowledge": false})
+			log.Printf("could not set refund status to fail in the ledger: %v", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
 			return
 		}
 	} else {
-		_, err = ls.SetPaymentFail(p)
+		r, err = ls.SetRefundPending(r)
 		if err != nil {
-			log.Printf("could not set payment to fail: %v", err)
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error", "acknowledge": false})
+			log.Printf("could not set refund status to pending in the ledger: %v", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
 			return
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"acknowledge": true})
+	amountStr, err := entities.FormatAmount(r.Amount, r.Currency)
+	if err != nil {
+		log.Printf("could not format amount of refund %s: %v", r.ID.String(), err)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"id":           r.ID.String(),
+		"payment_id":   pID.String(),
+		"status":       r.Status,
+		"amount_minor": r.Amount,
+		"amount":       amountStr,
+		"bank_message": r.BankMessage,
+	})
 }
 
-func readPaymentHandler(c *gin.Context) {
-	// Parse and check payment ID
+func capturePaymentHandler(c *gin.Context) {
 	pID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		log.Printf("could not parse payment id: %v", err)
@@ -172,7 +536,14 @@ func readPaymentHandler(c *gin.Context) {
 		return
 	}
 
-	// Read payment from Ledger
+	// an empty body captures the whole authorization
+	var body capturePaymentRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		log.Printf("could not parse request: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	ls := ledger.NewLedgerService(c, ledgerAddress)
 	p, err := ls.ReadPayment(pID)
 	if err != nil {
@@ -181,55 +552,848 @@ func readPaymentHandler(c *gin.Context) {
 		return
 	}
 
-	// Get Info about merchant requesting payment
-	claims := c.MustGet("claims").(MerchantClaims)
-	merchantId := claims.ID
-
 	// Assert mechant is owner of payment
-	if p.MerchantID != merchantId {
-		log.Printf("merchant %s trying to read unauthorized payment id: %s", merchantId.String(), pID.String())
+	claims := c.MustGet("claims").(MerchantClaims)
+	if p.MerchantID != claims.ID {
+		log.Printf("merchant %s trying to capture unauthorized payment id: %s", claims.ID.String(), pID.String())
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid payment id"})
 		return
 	}
 
-	c.JSON(http.StatusOK, p)
-}
+	amount, err := body.getAmount(p.Currency)
+	if err != nil {
+		log.Printf("could not parse amount: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-type createPaymentRequestBody struct {
-	Amount           float64             `json:"amount"`
-	Currency         string              `json:"currency"`
-	PurchaseTime     string              `json:"purchate_time"`
-	ValidationMethod string              `json:"validation_method"`
-	Card             entities.CreditCard `json:"card"`
-	Metadata         string              `json:"metadata"`
-}
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	m, err := ms.Get(claims.ID)
+	if err != nil {
+		log.Printf("could not retrieve merchant: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
 
-func (p *createPaymentRequestBody) getPurchaseTime() time.Time {
-	purchaseTime, err := time.Parse("2006-01-02T15:04:05.000", p.PurchaseTime)
+	captured, err := ls.CapturePayment(pID, amount)
+	if errors.Is(err, ledger.ErrInvalidArgument) {
+		log.Printf("ledger refused capture of payment %s: %v", pID.String(), err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ledger.ErrInvalidTransition) {
+		log.Printf("ledger refused capture of payment %s in status %s: %v", pID.String(), p.Status, err)
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		return time.Now()
+		log.Printf("could not capture payment: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
 	}
-	return purchaseTime
-}
 
-func (p *createPaymentRequestBody) validate() error {
-	_, err := time.Parse("2006-01-02T15:04:05.000", p.PurchaseTime)
+	// the ledger is the record of the capture, a bank that cannot be told
+	// still holds the rest of the authorization, which bank_message shows
+	bs := bank.NewBankService(c, bankAddress)
+	p, err = bs.RelayCaptureRequest(m, p, captured)
 	if err != nil {
-		return err
+		log.Printf("could not relay capture of payment %s to bank: %v", pID.String(), err)
 	}
-	if p.Amount < 0 {
-		return fmt.Errorf("invalid amount: %f", p.Amount)
+
+	amountStr, err := entities.FormatAmount(captured, p.Currency)
+	if err != nil {
+		log.Printf("could not format captured amount of payment %s: %v", pID.String(), err)
 	}
-	if p.Currency == "" {
-		return fmt.Errorf("invalid currency")
+	c.JSON(http.StatusOK, gin.H{
+		"id":                    pID.String(),
+		"status":                fmt.Sprint(entities.Captured),
+		"captured_amount_minor": captured,
+		"captured_amount":       amountStr,
+		"bank_message":          p.BankMessage,
+	})
+}
+
+func voidPaymentHandler(c *gin.Context) {
+	pID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.Printf("could not parse payment id: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid payment id"})
+		return
 	}
-	if p.ValidationMethod == "" {
-		return fmt.Errorf("invalid validation method")
+
+	ls := ledger.NewLedgerService(c, ledgerAddress)
+	p, err := ls.ReadPayment(pID)
+	if err != nil {
+		log.Printf("could not read payment: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid payment id"})
+		return
 	}
-	if p.Metadata == "" {
-		return fmt.Errorf("invalid metadata")
+
+	// Assert mechant is owner of payment
+	claims := c.MustGet("claims").(MerchantClaims)
+	if p.MerchantID != claims.ID {
+		log.Printf("merchant %s trying to void unauthorized payment id: %s", claims.ID.String(), pID.String())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid payment id"})
+		return
 	}
-	return nil
+
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	m, err := ms.Get(claims.ID)
+	if err != nil {
+		log.Printf("could not retrieve merchant: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	err = ls.VoidPayment(pID)
+	if errors.Is(err, ledger.ErrInvalidTransition) {
+		log.Printf("ledger refused void of payment %s in status %s: %v", pID.String(), p.Status, err)
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("could not void payment: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	// nothing was charged, the authorization does not count anymore
+	releaseQuota(ratelimiter.NewRateLimiterService(c, rateLimiterAddress, true), p)
+
+	bs := bank.NewBankService(c, bankAddress)
+	p, err = bs.RelayVoidRequest(m, p)
+	if err != nil {
+		log.Printf("could not relay void of payment %s to bank: %v", pID.String(), err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": pID.String(), "status": fmt.Sprint(entities.Voided), "bank_message": p.BankMessage})
+}
+
+func setWebhookHandler(c *gin.Context) {
+	var body setWebhookRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		log.Printf("could not parse request: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	err := ms.SetWebhook(claims.ID, body.URL, body.Secret)
+	if errors.Is(err, merchant.ErrInvalidWebhook) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("could not set webhook: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": body.URL})
+}
+
+// createAPIKeyHandler creates an api key for the merchant, the key is only
+// shown in this response
+func createAPIKeyHandler(c *gin.Context) {
+	var body apiKeyRequestBody
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	k, key, err := ms.CreateAPIKey(claims.ID, body.Label)
+	if errors.Is(err, merchant.ErrInvalidAPIKeyLabel) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("could not create api key: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, createAPIKeyResponse{APIKey: k, Key: key})
+}
+
+// enrollTOTPHandler gives the merchant a TOTP secret to add to an
+// authenticator app, logins only ask for codes once one is confirmed
+func enrollTOTPHandler(c *gin.Context) {
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	secret, url, err := ms.EnrollTOTP(claims.ID)
+	if errors.Is(err, merchant.ErrTOTPAlreadyEnabled) {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "totp already enabled"})
+		return
+	}
+	if err != nil {
+		log.Printf("could not enroll totp: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"secret": secret, "url": url})
+}
+
+func confirmTOTPHandler(c *gin.Context) {
+	var body totpCodeRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil || body.Code == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "missing code"})
+		return
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	recoveryCodes, err := ms.ConfirmTOTP(claims.ID, body.Code)
+	if err != nil {
+		totpErrorResponse(c, "confirm", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": recoveryCodes})
+}
+
+func disableTOTPHandler(c *gin.Context) {
+	var body totpCodeRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil || body.Code == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "missing code"})
+		return
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	if err := ms.DisableTOTP(claims.ID, body.Code); err != nil {
+		totpErrorResponse(c, "disable", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func totpErrorResponse(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, merchant.ErrInvalidCode):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid code"})
+	case errors.Is(err, merchant.ErrTOTPNotEnrolled):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "totp not enrolled"})
+	case errors.Is(err, merchant.ErrTOTPAlreadyEnabled):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "totp already enabled"})
+	default:
+		log.Printf("could not %s totp: %v", action, err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+func listAPIKeysHandler(c *gin.Context) {
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	keys, err := ms.ListAPIKeys(claims.ID)
+	if err != nil {
+		log.Printf("could not list api keys: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+func updateAPIKeyHandler(c *gin.Context) {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "unknown api key"})
+		return
+	}
+
+	var body apiKeyRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	err = ms.UpdateAPIKeyLabel(claims.ID, keyID, body.Label)
+	switch {
+	case errors.Is(err, merchant.ErrUnknownAPIKey):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "unknown api key"})
+		return
+	case errors.Is(err, merchant.ErrInvalidAPIKeyLabel):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("could not update api key %s: %v", keyID.String(), err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": keyID.String(), "label": body.Label})
+}
+
+func revokeAPIKeyHandler(c *gin.Context) {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "unknown api key"})
+		return
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	err = ms.RevokeAPIKey(claims.ID, keyID)
+	if errors.Is(err, merchant.ErrUnknownAPIKey) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "unknown api key"})
+		return
+	}
+	if err != nil {
+		log.Printf("could not revoke api key %s: %v", keyID.String(), err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// createUserHandler adds a user to the merchant, with its own username,
+// password and roles
+func createUserHandler(c *gin.Context) {
+	var body createUserRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	u, err := ms.CreateUser(claims.ID, body.Username, body.Password, body.Roles)
+	if err != nil {
+		userErrorResponse(c, "create", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, u)
+}
+
+func listUsersHandler(c *gin.Context) {
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	users, err := ms.ListUsers(claims.ID)
+	if err != nil {
+		log.Printf("could not list users: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// updateUserHandler changes the roles, active flag or password of a user of
+// the merchant, turning a user off or changing its password ends its sessions
+func updateUserHandler(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "unknown user"})
+		return
+	}
+
+	var body updateUserRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Roles == nil && body.Active == nil && body.Password == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "nothing to update"})
+		return
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	u, err := ms.UpdateUser(claims.ID, userID, body.Roles, body.Active, body.Password)
+	if err != nil {
+		userErrorResponse(c, "update", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, u)
+}
+
+func deleteUserHandler(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "unknown user"})
+		return
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	if err := ms.DeleteUser(claims.ID, userID); err != nil {
+		userErrorResponse(c, "delete", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func userErrorResponse(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, merchant.ErrUnknownUser):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "unknown user"})
+	case errors.Is(err, merchant.ErrUsernameTaken):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "username already taken"})
+	case errors.Is(err, merchant.ErrInvalidUser):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("could not %s user: %v", action, err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+func listWebhookDeliveriesHandler(c *gin.Context) {
+	status := webhook.DeliveryStatus(c.Query("status"))
+	switch status {
+	case "", webhook.DeliveryPending, webhook.DeliverySucceeded, webhook.DeliveryFailed:
+	default:
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown delivery status: %q", status)})
+		return
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	c.JSON(http.StatusOK, gin.H{"deliveries": webhooks.Deliveries(claims.ID, status)})
+}
+
+func replayWebhookDeliveryHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.Printf("could not parse delivery id: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+		return
+	}
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	dl, err := webhooks.Replay(claims.ID, id)
+	if errors.Is(err, webhook.ErrUnknownDelivery) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, webhook.ErrNotReplayable) {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("could not replay delivery %s: %v", id.String(), err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dl)
+}
+
+func readPaymentHandler(c *gin.Context) {
+	// Parse and check payment ID
+	pID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.Printf("could not parse payment id: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid payment id"})
+		return
+	}
+
+	// Read payment from Ledger
+	ls := ledger.NewLedgerService(c, ledgerAddress)
+	p, err := ls.ReadPayment(pID)
+	if err != nil {
+		log.Printf("could not read payment: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid payment id"})
+		return
+	}
+
+	// Get Info about merchant requesting payment
+	claims := c.MustGet("claims").(MerchantClaims)
+	merchantId := claims.ID
+
+	// Assert mechant is owner of payment
+	if p.MerchantID != merchantId {
+		log.Printf("merchant %s trying to read unauthorized payment id: %s", merchantId.String(), pID.String())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid payment id"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newPaymentResponse(p))
+}
+
+func listPaymentsHandler(c *gin.Context) {
+	var query listPaymentsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		log.Printf("could not parse query: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Payments are always scoped to the merchant requesting them
+	claims := c.MustGet("claims").(MerchantClaims)
+	filter, err := query.filter(claims.ID)
+	if err != nil {
+		log.Printf("could not validate query: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ls := ledger.NewLedgerService(c, ledgerAddress)
+	payments, nextCursor, err := ls.ListPayments(filter)
+	if errors.Is(err, ledger.ErrInvalidArgument) {
+		log.Printf("ledger refused payments filter: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("could not list payments: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	resp := listPaymentsResponse{
+		Payments:   make([]paymentResponse, 0, len(payments)),
+		NextCursor: nextCursor,
+	}
+	for _, p := range payments {
+		resp.Payments = append(resp.Payments, newPaymentResponse(p))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// paymentResponse adds the decimal amount to a payment, so merchants do not
+// need to know the exponent of each currency to display it
+type paymentResponse struct {
+	entities.Payment
+	Amount string `json:"amount"`
+}
+
+func newPaymentResponse(p entities.Payment) paymentResponse {
+	amount, err := entities.FormatAmount(p.Amount, p.Currency)
+	if err != nil {
+		log.Printf("could not format amount of payment %s: %v", p.ID.String(), err)
+	}
+	return paymentResponse{Payment: p, Amount: amount}
+}
+
+// usageHandler reports how much of each quota of the plan of the merchant
+// its current day and month used
+func usageHandler(c *gin.Context) {
+	claims := c.MustGet("claims").(MerchantClaims)
+	rls := ratelimiter.NewRateLimiterService(c, rateLimiterAddress, true)
+	usage, err := rls.Usage(claims.ID)
+	if err != nil {
+		log.Printf("could not read usage: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	res := make([]quotaUsageResponse, 0, len(usage))
+	for _, u := range usage {
+		res = append(res, newQuotaUsageResponse(u))
+	}
+	c.JSON(http.StatusOK, gin.H{"quotas": res})
+}
+
+// quotaUsageResponse shows amounts both in minor units and as decimals, like
+// paymentResponse, caps of 0 are left out
+type quotaUsageResponse struct {
+	Period         string    `json:"period"`
+	Currency       string    `json:"currency,omitempty"`
+	MaxCount       int64     `json:"max_count,omitempty"`
+	MaxAmountMinor int64     `json:"max_amount_minor,omitempty"`
+	MaxAmount      string    `json:"max_amount,omitempty"`
+	Count          int64     `json:"count"`
+	AmountMinor    int64     `json:"amount_minor,omitempty"`
+	Amount         string    `json:"amount,omitempty"`
+	PeriodStart    time.Time `json:"period_start"`
+	PeriodEnd      time.Time `json:"period_end"`
+}
+
+func newQuotaUsageResponse(u ratelimiter.QuotaUsage) quotaUsageResponse {
+	res := quotaUsageResponse{
+		Period:      u.Period,
+		Currency:    u.Currency,
+		MaxCount:    u.MaxCount,
+		Count:       u.Count,
+		PeriodStart: u.PeriodStart,
+		PeriodEnd:   u.PeriodEnd,
+	}
+	// amounts of payments in different currencies do not add up
+	if u.Currency == "" {
+		return res
+	}
+	res.AmountMinor = u.AmountMinor
+	res.Amount, _ = entities.FormatAmount(u.AmountMinor, u.Currency)
+	if u.MaxAmountMinor > 0 {
+		res.MaxAmountMinor = u.MaxAmountMinor
+		res.MaxAmount, _ = entities.FormatAmount(u.MaxAmountMinor, u.Currency)
+	}
+	return res
+}
+
+// quotaExceededMessage tells which cap of a quota a payment did not fit in,
+// e.g. "daily quota of 100 payments exceeded"
+func quotaExceededMessage(u ratelimiter.QuotaUsage) string {
+	period := map[string]string{"day": "daily", "month": "monthly"}[u.Period]
+	if period == "" {
+		period = u.Period
+	}
+	if u.MaxCount > 0 && u.Count >= u.MaxCount {
+		in := ""
+		if u.Currency != "" {
+			in = " in " + u.Currency
+		}
+		return fmt.Sprintf("%s quota of %d payments%s exceeded", period, u.MaxCount, in)
+	}
+	amount, err := entities.FormatAmount(u.MaxAmountMinor, u.Currency)
+	if err != nil {
+		amount = strconv.FormatInt(u.MaxAmountMinor, 10)
+	}
+	return fmt.Sprintf("%s quota of %s %s exceeded", period, amount, u.Currency)
+}
+
+// listPaymentsQuery takes times as RFC 3339 and amounts in minor units, the
+// purchase time range includes from and excludes to
+type listPaymentsQuery struct {
+	Status         string `form:"status"`
+	Currency       string `form:"currency"`
+	MinAmountMinor *int64 `form:"min_amount_minor"`
+	MaxAmountMinor *int64 `form:"max_amount_minor"`
+	From           string `form:"from"`
+	To             string `form:"to"`
+	Metadata       string `form:"metadata"`
+	Cursor         string `form:"cursor"`
+	Limit          int    `form:"limit"`
+}
+
+func (q *listPaymentsQuery) filter(merchantID uuid.UUID) (entities.PaymentFilter, error) {
+	f := entities.PaymentFilter{
+		MerchantID: merchantID,
+		Currency:   q.Currency,
+		MinAmount:  q.MinAmountMinor,
+		MaxAmount:  q.MaxAmountMinor,
+		Metadata:   q.Metadata,
+		Cursor:     q.Cursor,
+		Limit:      q.Limit,
+	}
+
+	if q.Status != "" {
+		ps, err := entities.ParsePaymentStatus(q.Status)
+		if err != nil {
+			return f, err
+		}
+		f.Status = &ps
+	}
+	if q.Currency != "" {
+		if _, err := entities.CurrencyExponent(q.Currency); err != nil {
+			return f, err
+		}
+	}
+	if q.From != "" {
+		from, err := time.Parse(time.RFC3339, q.From)
+		if err != nil {
+			return f, fmt.Errorf("invalid from: %w", err)
+		}
+		f.PurchasedFrom = from
+	}
+	if q.To != "" {
+		to, err := time.Parse(time.RFC3339, q.To)
+		if err != nil {
+			return f, fmt.Errorf("invalid to: %w", err)
+		}
+		f.PurchasedTo = to
+	}
+
+	return f, nil
+}
+
+type listPaymentsResponse struct {
+	Payments   []paymentResponse `json:"payments"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+type tokenResponse struct {
+	Token        string `json:"token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// loginChallengeResponse asks for a second factor, the login continues at
+// POST /login/verify
+type loginChallengeResponse struct {
+	Challenge     string `json:"challenge"`
+	ChallengeType string `json:"challenge_type"`
+	ExpiresIn     int64  `json:"expires_in"`
+}
+
+type verifyLoginRequestBody struct {
+	Challenge string `json:"challenge"`
+	// Code is the current TOTP code, or a recovery code
+	Code string `json:"code"`
+}
+
+type totpCodeRequestBody struct {
+	Code string `json:"code"`
+}
+
+type refreshTokenRequestBody struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// apiKeyRequestBody labels an api key, e.g. after the system that uses it
+type apiKeyRequestBody struct {
+	Label string `json:"label"`
+}
+
+// createAPIKeyResponse is the only place the key itself is ever shown
+type createAPIKeyResponse struct {
+	entities.APIKey
+	Key string `json:"key"`
+}
+
+type createUserRequestBody struct {
+	Username string   `json:"username" binding:"required"`
+	Password string   `json:"password" binding:"required"`
+	Roles    []string `json:"roles" binding:"required"`
+}
+
+// updateUserRequestBody leaves out what is not to be changed
+type updateUserRequestBody struct {
+	Roles    *[]string `json:"roles"`
+	Active   *bool     `json:"active"`
+	Password string    `json:"password"`
+}
+
+// createPaymentRequestBody takes the amount in minor units of the currency,
+// the decimal amount is still accepted for clients written before that
+type createPaymentRequestBody struct {
+	AmountMinor      *int64              `json:"amount_minor"`
+	Amount           json.Number         `json:"amount"`
+	Currency         string              `json:"currency"`
+	PurchaseTime     string              `json:"purchate_time"`
+	ValidationMethod string              `json:"validation_method"`
+	Card             entities.CreditCard `json:"card"`
+	Metadata         string              `json:"metadata"`
+	// Capture is automatic when empty
+	Capture string `json:"capture"`
+}
+
+func (p *createPaymentRequestBody) getPurchaseTime() time.Time {
+	purchaseTime, err := time.Parse("2006-01-02T15:04:05.000", p.PurchaseTime)
+	if err != nil {
+		return time.Now()
+	}
+	return purchaseTime
+}
+
+// getAmount returns the amount in minor units, parsing the decimal amount
+// exactly when amount_minor is missing
+func (p *createPaymentRequestBody) getAmount() (int64, error) {
+	if p.AmountMinor == nil && p.Amount == "" {
+		return 0, fmt.Errorf("missing amount")
+	}
+
+	var legacyAmount *int64
+	if p.Amount != "" {
+		amount, err := entities.ParseAmount(p.Amount.String(), p.Currency)
+		if err != nil {
+			return 0, err
+		}
+		legacyAmount = &amount
+	}
+
+	switch {
+	case p.AmountMinor == nil:
+		return *legacyAmount, nil
+	case legacyAmount != nil && *legacyAmount != *p.AmountMinor:
+		return 0, fmt.Errorf("amount %s does not match amount_minor %d", p.Amount, *p.AmountMinor)
+	default:
+		return *p.AmountMinor, nil
+	}
+}
+
+func (p *createPaymentRequestBody) getCaptureMode() string {
+	if p.Capture == "" {
+		return entities.AutomaticCapture
+	}
+	return p.Capture
+}
+
+func (p *createPaymentRequestBody) validate() error {
+	_, err := time.Parse("2006-01-02T15:04:05.000", p.PurchaseTime)
+	if err != nil {
+		return err
+	}
+	if p.Currency == "" {
+		return fmt.Errorf("invalid currency")
+	}
+	if _, err := entities.CurrencyExponent(p.Currency); err != nil {
+		return err
+	}
+	amount, err := p.getAmount()
+	if err != nil {
+		return err
+	}
+	if amount < 0 {
+		return fmt.Errorf("invalid amount: %d", amount)
+	}
+	if p.ValidationMethod == "" {
+		return fmt.Errorf("invalid validation method")
+	}
+	if p.Metadata == "" {
+		return fmt.Errorf("invalid metadata")
+	}
+	if mode := p.getCaptureMode(); mode != entities.AutomaticCapture && mode != entities.ManualCapture {
+		return fmt.Errorf("invalid capture: %s", p.Capture)
+	}
+	return nil
+}
+
+// createRefundRequestBody takes the amount like createPaymentRequestBody,
+// without any amount the refund is for whatever is left of the payment
+type createRefundRequestBody struct {
+	AmountMinor *int64      `json:"amount_minor"`
+	Amount      json.Number `json:"amount"`
+}
+
+// getAmount returns the amount in minor units of currency, zero meaning a
+// full refund
+func (r *createRefundRequestBody) getAmount(currency string) (int64, error) {
+	if r.AmountMinor == nil && r.Amount == "" {
+		return 0, nil
+	}
+
+	amount := int64(0)
+	if r.Amount != "" {
+		var err error
+		amount, err = entities.ParseAmount(r.Amount.String(), currency)
+		if err != nil {
+			return 0, err
+		}
+		if r.AmountMinor != nil && *r.AmountMinor != amount {
+			return 0, fmt.Errorf("amount %s does not match amount_minor %d", r.Amount, *r.AmountMinor)
+		}
+	} else {
+		amount = *r.AmountMinor
+	}
+
+	if amount <= 0 {
+		return 0, fmt.Errorf("invalid amount: %d", amount)
+	}
+	return amount, nil
+}
+
+// capturePaymentRequestBody takes the amount like createRefundRequestBody,
+// without any amount the whole authorization is captured
+type capturePaymentRequestBody = createRefundRequestBody
+
+// setWebhookRequestBody with an empty url and secret removes the webhook
+type setWebhookRequestBody struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
 }
 
 type bankMessage struct {
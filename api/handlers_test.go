@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/api/merchant"
+	"github.com/thiagolcmelo/payment-gateway/api/webhook"
+	rpcLedger "github.com/thiagolcmelo/payment-gateway/ledger/pb"
+	rpcMerchant "github.com/thiagolcmelo/payment-gateway/merchant/pb"
+	rpcRateLimiter "github.com/thiagolcmelo/payment-gateway/ratelimiter/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// quotaLimiter counts every payment while it is not full, and remembers
+// the ones given back
+type quotaLimiter struct {
+	rpcRateLimiter.UnimplementedRateLimiterServiceServer
+	mu       sync.Mutex
+	full     bool
+	releases []*rpcRateLimiter.ReleaseRequest
+}
+
+func (ql *quotaLimiter) setFull(full bool) {
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+	ql.full = full
+}
+
+func (ql *quotaLimiter) Consume(ctx context.Context, req *rpcRateLimiter.ConsumeRequest) (*rpcRateLimiter.ConsumeResponse, error) {
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+	if ql.full {
+		return &rpcRateLimiter.ConsumeResponse{Exceeded: &rpcRateLimiter.QuotaUsage{
+			Period:      "day",
+			MaxCount:    1,
+			Count:       1,
+			PeriodStart: 1792281600,
+			PeriodEnd:   1792368000,
+		}}, nil
+	}
+	return &rpcRateLimiter.ConsumeResponse{Allow: true, ConsumedAt: 1792324800}, nil
+}
+
+func (ql *quotaLimiter) Release(ctx context.Context, req *rpcRateLimiter.ReleaseRequest) (*rpcRateLimiter.ReleaseResponse, error) {
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+	ql.releases = append(ql.releases, req)
+	return &rpcRateLimiter.ReleaseResponse{}, nil
+}
+
+// paymentLedger records payments, unless it is down, and keeps idempotency
+// keys like fakeLedger. Reads find payment, whatever the id
+type paymentLedger struct {
+	fakeLedger
+	down    bool
+	payment *rpcLedger.Payment
+	created []*rpcLedger.CreatePaymentRequest
+}
+
+func (pl *paymentLedger) ReadPayment(ctx context.Context, req *rpcLedger.ReadPaymentRequest) (*rpcLedger.ReadPaymentResponse, error) {
+	return &rpcLedger.ReadPaymentResponse{Payment: pl.payment}, nil
+}
+
+func (pl *paymentLedger) ReadPaymentUsingBankReference(ctx context.Context, req *rpcLedger.ReadPaymentUsingBankReferenceRequest) (*rpcLedger.ReadPaymentUsingBankReferenceResponse, error) {
+	return &rpcLedger.ReadPaymentUsingBankReferenceResponse{Payment: pl.payment}, nil
+}
+
+func (pl *paymentLedger) VoidPayment(ctx context.Context, req *rpcLedger.VoidPaymentRequest) (*rpcLedger.VoidPaymentResponse, error) {
+	return &rpcLedger.VoidPaymentResponse{}, nil
+}
+
+func (pl *paymentLedger) CreatePayment(ctx context.Context, req *rpcLedger.CreatePaymentRequest) (*rpcLedger.CreatePaymentResponse, error) {
+	if pl.down {
+		return nil, status.Error(codes.Unavailable, "ledger is down")
+	}
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.created = append(pl.created, req)
+	return &rpcLedger.CreatePaymentResponse{Id: uuid.New().String()}, nil
+}
+
+func (pl *paymentLedger) UpdatePaymentToPending(ctx context.Context, req *rpcLedger.UpdatePaymentToPendingRequest) (*rpcLedger.UpdatePaymentToPendingResponse, error) {
+	return &rpcLedger.UpdatePaymentToPendingResponse{}, nil
+}
+
+func (pl *paymentLedger) UpdatePaymentToFail(ctx context.Context, req *rpcLedger.UpdatePaymentToFailRequest) (*rpcLedger.UpdatePaymentToFailResponse, error) {
+	return &rpcLedger.UpdatePaymentToFailResponse{}, nil
+}
+
+func TestCreatePaymentHandler_ReleasesQuota(t *testing.T) {
+	type testCase struct {
+		testName         string
+		ledgerDown       bool
+		bankStatus       int
+		expectedCode     int
+		expectedReleases int
+	}
+
+	testCases := []testCase{
+		{testName: "ledger error", ledgerDown: true, bankStatus: http.StatusCreated, expectedCode: http.StatusInternalServerError, expectedReleases: 1},
+		{testName: "bank error", bankStatus: http.StatusInternalServerError, expectedCode: http.StatusOK, expectedReleases: 1},
+		{testName: "relayed", bankStatus: http.StatusCreated, expectedCode: http.StatusOK, expectedReleases: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			ql := &quotaLimiter{}
+			rateLimiterAddress = serveGRPC(t, func(s *grpc.Server) {
+				rpcRateLimiter.RegisterRateLimiterServiceServer(s, ql)
+			})
+			pl := &paymentLedger{down: tc.ledgerDown}
+			ledgerAddress = serveGRPC(t, func(s *grpc.Server) {
+				rpcLedger.RegisterLedgerServiceServer(s, pl)
+			})
+			merchantAddress = serveGRPC(t, func(s *grpc.Server) {
+				rpcMerchant.RegisterMerchantServiceServer(s, &webhookMerchants{})
+			})
+			bank := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.bankStatus)
+				w.Write([]byte(`{"id": "` + uuid.New().String() + `", "success": true, "message": "payment request created"}`))
+			}))
+			defer bank.Close()
+			bankAddress = bank.URL
+
+			router := gin.New()
+			router.POST("/payment", withClaims(MerchantClaims{ID: uuid.New()}), createPaymentHandler)
+
+			body := `{"amount_minor": 1000, "currency": "USD", "purchate_time": "2023-05-18T10:00:00.000", "validation_method": "push", "metadata": "shopper 0",
+				"card": {"number": "1111-2222-3333-4444", "name": "shopper 0", "expire_month": 10, "expire_year": 2050, "cvv": 123}}`
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/payment", strings.NewReader(body)))
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedCode, w.Code, w.Body.String())
+			}
+			if len(ql.releases) != tc.expectedReleases {
+				t.Fatalf("expected %d releases, got %d", tc.expectedReleases, len(ql.releases))
+			}
+			// the ledger keeps when the payment was counted
+			for _, req := range pl.created {
+				if req.QuotaConsumedAtUtc != "2026-10-18T12:00:00.000" {
+					t.Errorf("expected the payment to be counted at 2026-10-18T12:00:00.000, got %q", req.QuotaConsumedAtUtc)
+				}
+			}
+			for _, r := range ql.releases {
+				if r.AmountMinor != 1000 || r.Currency != "USD" || r.ConsumedAt != 1792324800 {
+					t.Errorf("expected the payment counted at 1792324800 to be released, got %+v", r)
+				}
+			}
+		})
+	}
+}
+
+func TestCreatePaymentHandler_RetryAfterQuota(t *testing.T) {
+	ql := &quotaLimiter{full: true}
+	rateLimiterAddress = serveGRPC(t, func(s *grpc.Server) {
+		rpcRateLimiter.RegisterRateLimiterServiceServer(s, ql)
+	})
+	ledgerAddress = serveGRPC(t, func(s *grpc.Server) {
+		rpcLedger.RegisterLedgerServiceServer(s, &paymentLedger{fakeLedger: fakeLedger{requests: make(map[string]idempotentRequest)}})
+	})
+	merchantAddress = serveGRPC(t, func(s *grpc.Server) {
+		rpcMerchant.RegisterMerchantServiceServer(s, &webhookMerchants{})
+	})
+	bank := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "` + uuid.New().String() + `", "success": true, "message": "payment request created"}`))
+	}))
+	defer bank.Close()
+	bankAddress = bank.URL
+
+	router := gin.New()
+	router.POST("/payment", withClaims(MerchantClaims{ID: uuid.New()}), idempotencyMiddleware, createPaymentHandler)
+	send := func() *httptest.ResponseRecorder {
+		body := `{"amount_minor": 1000, "currency": "USD", "purchate_time": "2023-05-18T10:00:00.000", "validation_method": "push", "metadata": "shopper 0",
+			"card": {"number": "1111-2222-3333-4444", "name": "shopper 0", "expire_month": 10, "expire_year": 2050, "cvv": 123}}`
+		req := httptest.NewRequest(http.MethodPost, "/payment", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", "order-123")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusTooManyRequests, w.Code, w.Body.String())
+	}
+
+	// the day starts over, the same payment fits now
+	ql.setFull(false)
+	w := send()
+	if w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") == "true" {
+		t.Fatalf("expected the payment to be created, got %d (replayed %q): %s", w.Code, w.Header().Get("Idempotent-Replayed"), w.Body.String())
+	}
+	if w := send(); w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected the created payment to be replayed, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPaymentHandlers_ReleaseQuota(t *testing.T) {
+	type testCase struct {
+		testName         string
+		status           rpcLedger.PaymentStatus
+		quotaConsumedAt  string
+		request          func(id uuid.UUID) *http.Request
+		expectedReleases int
+	}
+
+	decline := func(uuid.UUID) *http.Request {
+		return httptest.NewRequest(http.MethodPut, "/payment", strings.NewReader(`{"id": "`+uuid.New().String()+`", "success": false, "message": "insufficient funds"}`))
+	}
+	void := func(id uuid.UUID) *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/payment/"+id.String()+"/void", nil)
+	}
+
+	testCases := []testCase{
+		{testName: "declined", status: rpcLedger.PaymentStatus_PENDING, quotaConsumedAt: "2026-10-18T12:00:00.000", request: decline, expectedReleases: 1},
+		{testName: "declined without being counted", status: rpcLedger.PaymentStatus_PENDING, request: decline, expectedReleases: 0},
+		{testName: "voided", status: rpcLedger.PaymentStatus_AUTHORIZED, quotaConsumedAt: "2026-10-18T12:00:00.000", request: void, expectedReleases: 1},
+		{testName: "voided without being counted", status: rpcLedger.PaymentStatus_AUTHORIZED, request: void, expectedReleases: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			merchantID := uuid.New()
+			payment := &rpcLedger.Payment{
+				Id:                 uuid.New().String(),
+				MerchantId:         merchantID.String(),
+				AmountMinor:        1000,
+				Currency:           "USD",
+				Status:             tc.status,
+				BankPaymentId:      uuid.New().String(),
+				CaptureMode:        rpcLedger.CaptureMode_MANUAL,
+				QuotaConsumedAtUtc: tc.quotaConsumedAt,
+			}
+			ql := &quotaLimiter{}
+			rateLimiterAddress = serveGRPC(t, func(s *grpc.Server) {
+				rpcRateLimiter.RegisterRateLimiterServiceServer(s, ql)
+			})
+			ledgerAddress = serveGRPC(t, func(s *grpc.Server) {
+				rpcLedger.RegisterLedgerServiceServer(s, &paymentLedger{payment: payment})
+			})
+			merchantAddress = serveGRPC(t, func(s *grpc.Server) {
+				rpcMerchant.RegisterMerchantServiceServer(s, &webhookMerchants{})
+			})
+			webhooks = webhook.NewDispatcher(merchant.NewMerchantService(context.Background(), merchantAddress), webhook.NewClient(time.Second, []*net.IPNet{}), 1, time.Second, 10)
+			bank := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"id": "` + payment.BankPaymentId + `", "success": true, "message": "authorization released"}`))
+			}))
+			defer bank.Close()
+			bankAddress = bank.URL
+
+			router := gin.New()
+			router.PUT("/payment", updatePaymentHandler)
+			router.POST("/payment/:id/void", withClaims(MerchantClaims{ID: merchantID}), voidPaymentHandler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tc.request(uuid.MustParse(payment.Id)))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			if len(ql.releases) != tc.expectedReleases {
+				t.Fatalf("expected %d releases, got %d", tc.expectedReleases, len(ql.releases))
+			}
+			// the payment goes back to the period it was counted in
+			consumedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC).Unix()
+			for _, r := range ql.releases {
+				if r.Id != merchantID.String() || r.AmountMinor != 1000 || r.ConsumedAt != consumedAt {
+					t.Errorf("expected the payment counted at %d to be released, got %+v", consumedAt, r)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Package jwtkeys keeps the keys merchant tokens are signed and verified
+// with, the first key signs new tokens and every key verifies them, so a new
+// key can take over while tokens signed by the previous one are still valid
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// MinRSABits is the smallest RSA modulus accepted
+const MinRSABits = 2048
+
+var (
+	// ErrInvalidKey must be used when a key cannot be read or is not RSA or Ed25519
+	ErrInvalidKey = errors.New("invalid jwt key")
+	// ErrNoSigningKey must be used when the first key of a set has no private part
+	ErrNoSigningKey = errors.New("first jwt key must be a private key")
+	// ErrDuplicateKey must be used when two keys of a set share an id
+	ErrDuplicateKey = errors.New("duplicate jwt key id")
+	// ErrUnknownKey must be used when a token names a key that is not in the set
+	ErrUnknownKey = errors.New("unknown jwt key id")
+	// ErrUnexpectedAlgorithm must be used when a token is not signed with the algorithm of its key
+	ErrUnexpectedAlgorithm = errors.New("unexpected jwt signing algorithm")
+)
+
+// Key is one RSA (RS256) or Ed25519 (EdDSA) key, private is nil for keys
+// that only verify
+type Key struct {
+	ID      string
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+	method  jwt.SigningMethod
+}
+
+// Algorithm returns the alg of the tokens the key signs
+func (k Key) Algorithm() string {
+	return k.method.Alg()
+}
+
+// NewKey is a factory for a Key from a PEM block, a private key in PKCS #8 or
+// PKCS #1, or a public key in PKIX or PKCS #1
+func NewKey(id string, data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("%w: %s is not PEM", ErrInvalidKey, id)
+	}
+
+	var (
+		parsed interface{}
+		err    error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("%w: %s has unexpected PEM type %s", ErrInvalidKey, id, block.Type)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %s: %v", ErrInvalidKey, id, err)
+	}
+
+	k := Key{ID: id}
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		k.private, k.public, k.method = key, &key.PublicKey, jwt.SigningMethodRS256
+	case *rsa.PublicKey:
+		k.public, k.method = key, jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		k.private, k.public, k.method = key, key.Public(), SigningMethodEdDSA
+	case ed25519.PublicKey:
+		k.public, k.method = key, SigningMethodEdDSA
+	default:
+		return Key{}, fmt.Errorf("%w: %s is neither RSA nor Ed25519", ErrInvalidKey, id)
+	}
+	if public, ok := k.public.(*rsa.PublicKey); ok && public.N.BitLen() < MinRSABits {
+		return Key{}, fmt.Errorf("%w: %s has less than %d bits", ErrInvalidKey, id, MinRSABits)
+	}
+	return k, nil
+}
+
+// GenerateKey is a factory for a new Ed25519 Key, tokens it signs are only
+// valid until the process that made it stops
+func GenerateKey(id string) (Key, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{ID: id, private: private, public: public, method: SigningMethodEdDSA}, nil
+}
+
+// ReadKeys reads keys in the form <id>:<path to PEM file>, separated by
+// commas
+func ReadKeys(s string) ([]Key, error) {
+	keys := []Key{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, path, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || path == "" {
+			return nil, fmt.Errorf("%w: expected id:path", ErrInvalidKey)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidKey, id, err)
+		}
+		k, err := NewKey(id, data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// KeySet signs tokens with its first key and verifies them with any of its
+// keys, picked by the kid header
+type KeySet struct {
+	signing Key
+	keys    map[string]Key
+	order   []string
+}
+
+// NewKeySet is a factory for a KeySet, the first key must be private
+func NewKeySet(keys []Key) (*KeySet, error) {
+	if len(keys) == 0 || keys[0].private == nil {
+		return nil, ErrNoSigningKey
+	}
+	ks := &KeySet{signing: keys[0], keys: make(map[string]Key, len(keys))}
+	for _, k := range keys {
+		if _, ok := ks.keys[k.ID]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateKey, k.ID)
+		}
+		ks.keys[k.ID] = k
+		ks.order = append(ks.order, k.ID)
+	}
+	return ks, nil
+}
+
+// Sign returns claims as a token signed by the first key, naming it in kid
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.private)
+}
+
+// Keyfunc is a jwt.Keyfunc that returns the public key named in kid, once
+// it is sure the token uses the algorithm of that key, so a token cannot
+// pass off a public key as an HMAC secret
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	id, _ := token.Header["kid"].(string)
+	k, ok := ks.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedAlgorithm, token.Method.Alg())
+	}
+	return k.public, nil
+}
+
+// JWK is the public part of a key as described in RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	ID        string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519, RFC 8037
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS is the body of /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public part of every key, the signing key first
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.order))}
+	for _, id := range ks.order {
+		k := ks.keys[id]
+		jwk := JWK{ID: k.ID, Use: "sig", Algorithm: k.method.Alg()}
+		switch public := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
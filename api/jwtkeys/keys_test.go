@@ -0,0 +1,265 @@
+package jwtkeys_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/thiagolcmelo/payment-gateway/api/jwtkeys"
+)
+
+func pemFile(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// keyFiles writes an RSA private key, an Ed25519 private key and the public
+// part of another Ed25519 key
+func keyFiles(t *testing.T) (rsaPath, edPath, edPublicPath string, rsaKey *rsa.PrivateKey) {
+	t.Helper()
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublicDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaPath = pemFile(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	edPath = pemFile(t, dir, "ed.pem", "PRIVATE KEY", edDER)
+	edPublicPath = pemFile(t, dir, "ed.pub.pem", "PUBLIC KEY", edPublicDER)
+	return rsaPath, edPath, edPublicPath, rsaKey
+}
+
+func claims() jwt.StandardClaims {
+	return jwt.StandardClaims{Subject: "merchant0", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+}
+
+func TestReadKeys(t *testing.T) {
+	rsaPath, edPath, edPublicPath, _ := keyFiles(t)
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakPath := pemFile(t, t.TempDir(), "weak.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(weak))
+
+	type testCase struct {
+		testName           string
+		keys               string
+		expectedAlgorithms []string
+		expectedErr        error
+	}
+
+	testCases := []testCase{
+		{testName: "empty", keys: "", expectedAlgorithms: []string{}, expectedErr: nil},
+		{testName: "rsa", keys: "a:" + rsaPath, expectedAlgorithms: []string{"RS256"}, expectedErr: nil},
+		{
+			testName:           "ed25519_and_public_key",
+			keys:               "a:" + edPath + ", b:" + edPublicPath,
+			expectedAlgorithms: []string{"EdDSA", "EdDSA"},
+			expectedErr:        nil,
+		},
+		{testName: "missing_path", keys: "a", expectedErr: jwtkeys.ErrInvalidKey},
+		{testName: "missing_file", keys: "a:" + rsaPath + ".missing", expectedErr: jwtkeys.ErrInvalidKey},
+		{testName: "weak_rsa", keys: "a:" + weakPath, expectedErr: jwtkeys.ErrInvalidKey},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			keys, err := jwtkeys.ReadKeys(tc.keys)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if len(keys) != len(tc.expectedAlgorithms) {
+				t.Fatalf("expected %d keys, got %d", len(tc.expectedAlgorithms), len(keys))
+			}
+			for i, k := range keys {
+				if k.Algorithm() != tc.expectedAlgorithms[i] {
+					t.Errorf("expected %s, got %s", tc.expectedAlgorithms[i], k.Algorithm())
+				}
+			}
+		})
+	}
+
+	if _, err := jwtkeys.NewKey("a", []byte("not pem")); !errors.Is(err, jwtkeys.ErrInvalidKey) {
+		t.Errorf("expected %v, got %v", jwtkeys.ErrInvalidKey, err)
+	}
+}
+
+func TestNewKeySet(t *testing.T) {
+	_, edPath, edPublicPath, _ := keyFiles(t)
+	keys, err := jwtkeys.ReadKeys("a:" + edPath + ",b:" + edPublicPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName    string
+		keys        []jwtkeys.Key
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{testName: "private_first", keys: keys, expectedErr: nil},
+		{testName: "no_keys", keys: nil, expectedErr: jwtkeys.ErrNoSigningKey},
+		{testName: "public_first", keys: []jwtkeys.Key{keys[1], keys[0]}, expectedErr: jwtkeys.ErrNoSigningKey},
+		{testName: "duplicate_id", keys: []jwtkeys.Key{keys[0], keys[0]}, expectedErr: jwtkeys.ErrDuplicateKey},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if _, err := jwtkeys.NewKeySet(tc.keys); !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestKeySet_Verify(t *testing.T) {
+	rsaPath, edPath, _, rsaKey := keyFiles(t)
+	keys, err := jwtkeys.ReadKeys("new:" + edPath + ",old:" + rsaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the previous set, before new was added
+	previous, err := jwtkeys.NewKeySet(keys[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := jwtkeys.NewKeySet(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := jwtkeys.GenerateKey("new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	impostor, err := jwtkeys.NewKeySet([]jwtkeys.Key{other})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(ks *jwtkeys.KeySet) string {
+		token, err := ks.Sign(claims())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	// a token that passes the public key of old off as an HMAC secret
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	confused.Header["kid"] = "old"
+	confusedToken, err := confused.SignedString(x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withoutKid := jwt.NewWithClaims(jwt.SigningMethodRS256, claims())
+	withoutKidToken, err := withoutKid.SignedString(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName    string
+		token       string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{testName: "signed_by_current_key", token: sign(current), expectedErr: nil},
+		{testName: "signed_by_previous_key", token: sign(previous), expectedErr: nil},
+		{testName: "signed_by_unknown_key_with_known_kid", token: sign(impostor), expectedErr: jwt.ErrSignatureInvalid},
+		{testName: "without_kid", token: withoutKidToken, expectedErr: jwtkeys.ErrUnknownKey},
+		{testName: "algorithm_confusion", token: confusedToken, expectedErr: jwtkeys.ErrUnexpectedAlgorithm},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			var c jwt.StandardClaims
+			_, err := jwt.ParseWithClaims(tc.token, &c, current.Keyfunc)
+			var validationErr *jwt.ValidationError
+			if errors.As(err, &validationErr) {
+				err = validationErr.Inner
+			}
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if err == nil && c.Subject != "merchant0" {
+				t.Errorf("expected subject merchant0, got %s", c.Subject)
+			}
+		})
+	}
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	rsaPath, edPath, _, rsaKey := keyFiles(t)
+	keys, err := jwtkeys.ReadKeys("new:" + edPath + ",old:" + rsaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := jwtkeys.NewKeySet(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := ks.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(jwks.Keys))
+	}
+
+	ed := jwks.Keys[0]
+	if ed.ID != "new" || ed.KeyType != "OKP" || ed.Curve != "Ed25519" || ed.Algorithm != "EdDSA" || ed.Use != "sig" {
+		t.Errorf("unexpected Ed25519 JWK: %+v", ed)
+	}
+	if x, err := base64.RawURLEncoding.DecodeString(ed.X); err != nil || len(x) != ed25519.PublicKeySize {
+		t.Errorf("unexpected x: %s", ed.X)
+	}
+
+	r := jwks.Keys[1]
+	if r.ID != "old" || r.KeyType != "RSA" || r.Algorithm != "RS256" {
+		t.Errorf("unexpected RSA JWK: %+v", r)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(r.N)
+	if err != nil || new(big.Int).SetBytes(n).Cmp(rsaKey.N) != 0 {
+		t.Errorf("unexpected n: %s", r.N)
+	}
+	if r.E != "AQAB" {
+		t.Errorf("expected e AQAB, got %s", r.E)
+	}
+}
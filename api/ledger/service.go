@@ -2,6 +2,7 @@ package ledger
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -10,9 +11,23 @@ import (
 	"github.com/thiagolcmelo/payment-gateway/api/entities"
 	rpcLedger "github.com/thiagolcmelo/payment-gateway/ledger/pb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
+// ErrInvalidTransition is returned when the ledger refuses to move a payment
+// to the requested status, e.g. a payment that already succeeded cannot fail
+var ErrInvalidTransition = errors.New("invalid payment status transition")
+
+// ErrRefundNotAllowed is returned when the ledger refuses a refund, e.g. the
+// payment did not succeed or the amount is more than what is left to refund
+var ErrRefundNotAllowed = errors.New("refund not allowed")
+
+// ErrInvalidArgument is returned when the ledger refuses a malformed request,
+// e.g. a listing cursor that it did not issue
+var ErrInvalidArgument = errors.New("invalid argument")
+
 type LedgerService struct {
 	ctx     context.Context
 	address string
@@ -25,7 +40,22 @@ func NewLedgerService(ctx context.Context, address string) *LedgerService {
 	}
 }
 
-func (ls *LedgerService) CreatePayment(p entities.Payment) (entities.Payment, error) {
+// ledgerError converts gRPC status errors the caller can act on into the
+// errors of this package, anything else is returned as is
+func ledgerError(err error) error {
+	switch status.Code(err) {
+	case codes.FailedPrecondition:
+		return fmt.Errorf("%w: %s", ErrInvalidTransition, status.Convert(err).Message())
+	case codes.InvalidArgument:
+		return fmt.Errorf("%w: %s", ErrInvalidArgument, status.Convert(err).Message())
+	default:
+		return err
+	}
+}
+
+// CreatePayment records p, card goes to the ledger once to be kept in its
+// vault, and only its masked version is ever returned
+func (ls *LedgerService) CreatePayment(p entities.Payment, card entities.CreditCard) (entities.Payment, error) {
 	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
@@ -37,18 +67,23 @@ func (ls *LedgerService) CreatePayment(p entities.Payment) (entities.Payment, er
 
 	req := &rpcLedger.CreatePaymentRequest{
 		MerchantId:       p.MerchantID.String(),
-		Amount:           float32(p.Amount),
+		AmountMinor:      p.Amount,
 		Currency:         p.Currency,
 		PurchaseTimeUtc:  p.GetPurchaseTimeStr(),
 		ValidationMethod: p.ValidationMethod,
 		Card: &rpcLedger.CreditCard{
-			Number:      p.Card.Number,
-			Name:        p.Card.Name,
-			ExpireMonth: int32(p.Card.ExpireMonth),
-			ExpireYear:  int32(p.Card.ExpireYear),
-			Cvv:         int32(p.Card.CVV),
+			Number:      card.Number,
+			Name:        card.Name,
+			ExpireMonth: int32(card.ExpireMonth),
+			ExpireYear:  int32(card.ExpireYear),
+			Cvv:         int32(card.CVV),
 		},
-		Metadata: p.Metadata,
+		Metadata:           p.Metadata,
+		CaptureMode:        rpcLedger.CaptureMode_AUTOMATIC,
+		QuotaConsumedAtUtc: p.GetQuotaConsumedAtStr(),
+	}
+	if p.CaptureMode == entities.ManualCapture {
+		req.CaptureMode = rpcLedger.CaptureMode_MANUAL
 	}
 
 	resp, err := ledgerClient.CreatePayment(ls.ctx, req)
@@ -86,7 +121,7 @@ func (ls *LedgerService) SetPaymentPending(p entities.Payment) (entities.Payment
 	_, err = ledgerClient.UpdatePaymentToPending(ls.ctx, req)
 	if err != nil {
 		log.Printf("error updating payment to pending: %v", err)
-		return p, err
+		return p, ledgerError(err)
 	}
 	p.Status = fmt.Sprint(entities.Pending)
 	return p, nil
@@ -112,7 +147,7 @@ func (ls *LedgerService) SetPaymentSuccess(p entities.Payment) (entities.Payment
 	_, err = ledgerClient.UpdatePaymentToSuccess(ls.ctx, req)
 	if err != nil {
 		log.Printf("error updating payment to success: %v", err)
-		return p, err
+		return p, ledgerError(err)
 	}
 	p.Status = fmt.Sprint(entities.Success)
 	return p, nil
@@ -140,12 +175,118 @@ func (ls *LedgerService) SetPaymentFail(p entities.Payment) (entities.Payment, e
 	_, err = ledgerClient.UpdatePaymentToFail(ls.ctx, req)
 	if err != nil {
 		log.Printf("error updating payment to fail: %v", err)
-		return p, err
+		return p, ledgerError(err)
 	}
 	p.Status = fmt.Sprint(entities.Fail)
 	return p, nil
 }
 
+// SetPaymentAuthorized records the bank approved a payment with manual
+// capture, the ledger decides until when it can be captured
+func (ls *LedgerService) SetPaymentAuthorized(p entities.Payment) (entities.Payment, error) {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return p, err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.UpdatePaymentToAuthorizedRequest{
+		Id:                  p.ID.String(),
+		BankPaymentId:       p.BankPaymentID.String(),
+		BankResponseTimeUtc: p.GetBankResponseTimeStr(),
+		BankMessage:         p.BankMessage,
+	}
+
+	resp, err := ledgerClient.UpdatePaymentToAuthorized(ls.ctx, req)
+	if err != nil {
+		log.Printf("error updating payment to authorized: %v", err)
+		return p, ledgerError(err)
+	}
+	expiresAt, err := time.Parse("2006-01-02T15:04:05.000", resp.AuthorizationExpiresAtUtc)
+	if err != nil {
+		log.Printf("error parsing authorization expiry: %v", err)
+	}
+	p.Status = fmt.Sprint(entities.Authorized)
+	p.AuthorizationExpiresAt = expiresAt
+	return p, nil
+}
+
+// CapturePayment settles amount minor units of an authorized payment, zero
+// meaning all of it, and returns the amount captured
+func (ls *LedgerService) CapturePayment(id uuid.UUID, amount int64) (int64, error) {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return 0, err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.CapturePaymentRequest{
+		Id:          id.String(),
+		AmountMinor: amount,
+	}
+
+	resp, err := ledgerClient.CapturePayment(ls.ctx, req)
+	if err != nil {
+		log.Printf("error capturing payment: %v", err)
+		return 0, ledgerError(err)
+	}
+	return resp.CapturedAmountMinor, nil
+}
+
+// VoidPayment releases an authorized payment without capturing it
+func (ls *LedgerService) VoidPayment(id uuid.UUID) error {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.VoidPaymentRequest{
+		Id: id.String(),
+	}
+
+	_, err = ledgerClient.VoidPayment(ls.ctx, req)
+	if err != nil {
+		log.Printf("error voiding payment: %v", err)
+		return ledgerError(err)
+	}
+	return nil
+}
+
+// ExpireAuthorizations voids the authorizations that were not captured in
+// time, and returns them
+func (ls *LedgerService) ExpireAuthorizations() ([]entities.Payment, error) {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return nil, err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	resp, err := ledgerClient.ExpireAuthorizations(ls.ctx, &rpcLedger.ExpireAuthorizationsRequest{})
+	if err != nil {
+		log.Printf("error expiring authorizations: %v", err)
+		return nil, ledgerError(err)
+	}
+
+	payments := make([]entities.Payment, 0, len(resp.Payments))
+	for _, p := range resp.Payments {
+		payments = append(payments, newPayment(p))
+	}
+	return payments, nil
+}
+
 func (ls *LedgerService) ReadPayment(id uuid.UUID) (entities.Payment, error) {
 	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -166,113 +307,433 @@ func (ls *LedgerService) ReadPayment(id uuid.UUID) (entities.Payment, error) {
 		return entities.Payment{}, err
 	}
 
-	merchantID, err := uuid.Parse(resp.Payment.MerchantId)
+	return newPayment(resp.Payment), nil
+}
+
+func (ls *LedgerService) ReadPaymentUsingBankReference(bankPaymentID uuid.UUID) (entities.Payment, error) {
+	// return entities.Payment{}, nil
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return entities.Payment{}, err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.ReadPaymentUsingBankReferenceRequest{
+		Id: bankPaymentID.String(),
+	}
+
+	resp, err := ledgerClient.ReadPaymentUsingBankReference(ls.ctx, req)
+	if err != nil {
+		log.Printf("error reading payment: %v", err)
+		return entities.Payment{}, err
+	}
+
+	return newPayment(resp.Payment), nil
+}
+
+// ListPayments returns one page of the payments selected by f, and the cursor
+// of the next page, which is empty for the last one
+func (ls *LedgerService) ListPayments(f entities.PaymentFilter) ([]entities.Payment, string, error) {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return nil, "", err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.ListPaymentsRequest{
+		MerchantId:     f.MerchantID.String(),
+		Currency:       f.Currency,
+		MinAmountMinor: f.MinAmount,
+		MaxAmountMinor: f.MaxAmount,
+		Metadata:       f.Metadata,
+		Cursor:         f.Cursor,
+		Limit:          int32(f.Limit),
+	}
+	if f.Status != nil {
+		paymentStatus := rpcLedger.PaymentStatus(*f.Status)
+		req.Status = &paymentStatus
+	}
+	if !f.PurchasedFrom.IsZero() {
+		req.PurchaseTimeFromUtc = f.PurchasedFrom.UTC().Format("2006-01-02T15:04:05.000")
+	}
+	if !f.PurchasedTo.IsZero() {
+		req.PurchaseTimeToUtc = f.PurchasedTo.UTC().Format("2006-01-02T15:04:05.000")
+	}
+
+	resp, err := ledgerClient.ListPayments(ls.ctx, req)
+	if err != nil {
+		log.Printf("error listing payments: %v", err)
+		return nil, "", ledgerError(err)
+	}
+
+	payments := make([]entities.Payment, 0, len(resp.Payments))
+	for _, p := range resp.Payments {
+		payments = append(payments, newPayment(p))
+	}
+
+	return payments, resp.NextCursor, nil
+}
+
+// newPayment converts a payment sent by the ledger, fields that cannot be
+// parsed are logged and left empty
+func newPayment(p *rpcLedger.Payment) entities.Payment {
+	id, err := uuid.Parse(p.Id)
+	if err != nil {
+		log.Printf("error parsing payment uuid: %v", err)
+	}
+	merchantID, err := uuid.Parse(p.MerchantId)
 	if err != nil {
 		log.Printf("error parsing merchant uuid: %v", err)
 	}
-	bankPaymentID, err := uuid.Parse(resp.Payment.BankPaymentId)
+	bankPaymentID, err := uuid.Parse(p.BankPaymentId)
 	if err != nil {
 		log.Printf("error parsing bank payment uuid: %v", err)
 	}
-	purchaseTimeUTC, err := time.Parse("2006-01-02T15:04:05.000", resp.Payment.PurchaseTimeUtc)
+	purchaseTimeUTC, err := time.Parse("2006-01-02T15:04:05.000", p.PurchaseTimeUtc)
 	if err != nil {
 		log.Printf("error parsing purchate time: %v", err)
 	}
-	bankRequestTimeUTC, err := time.Parse("2006-01-02T15:04:05.000", resp.Payment.BankRequestTimeUtc)
+	bankRequestTimeUTC, err := time.Parse("2006-01-02T15:04:05.000", p.BankRequestTimeUtc)
 	if err != nil {
 		log.Printf("error parsing bank request time: %v", err)
 	}
-	bankResponseTimeUTC, err := time.Parse("2006-01-02T15:04:05.000", resp.Payment.BankResponseTimeUtc)
+	bankResponseTimeUTC, err := time.Parse("2006-01-02T15:04:05.000", p.BankResponseTimeUtc)
 	if err != nil {
 		log.Printf("error parsing bank response time: %v", err)
 	}
-	card := entities.CreditCard{
-		Number:      resp.Payment.Card.Number,
-		Name:        resp.Payment.Card.Name,
-		ExpireMonth: int(resp.Payment.Card.ExpireMonth),
-		ExpireYear:  int(resp.Payment.Card.ExpireYear),
-		CVV:         int(resp.Payment.Card.Cvv),
+	var authorizationExpiresAt time.Time
+	if p.AuthorizationExpiresAtUtc != "" {
+		authorizationExpiresAt, err = time.Parse("2006-01-02T15:04:05.000", p.AuthorizationExpiresAtUtc)
+		if err != nil {
+			log.Printf("error parsing authorization expiry: %v", err)
+		}
+	}
+	var quotaConsumedAt time.Time
+	if p.QuotaConsumedAtUtc != "" {
+		quotaConsumedAt, err = time.Parse("2006-01-02T15:04:05.000", p.QuotaConsumedAtUtc)
+		if err != nil {
+			log.Printf("error parsing quota consumption time: %v", err)
+		}
 	}
 
 	return entities.Payment{
 		ID:               id,
 		MerchantID:       merchantID,
-		Amount:           float64(resp.Payment.Amount),
-		Currency:         resp.Payment.Currency,
+		Amount:           p.AmountMinor,
+		Currency:         p.Currency,
 		PurchaseTime:     purchaseTimeUTC,
-		ValidationMethod: resp.Payment.ValidationMethod,
-		Card:             card,
-		Metadata:         resp.Payment.Metadata,
-		Status:           fmt.Sprint(entities.PaymentStatus(resp.Payment.Status)),
-		BankPaymentID:    bankPaymentID,
-		BankRequestTime:  bankRequestTimeUTC,
-		BankResponseTime: bankResponseTimeUTC,
-		BankMessage:      resp.Payment.BankMessage,
-	}, nil
+		ValidationMethod: p.ValidationMethod,
+		Card: entities.NewMaskedCard(
+			p.MaskedCard.GetBrand(),
+			p.MaskedCard.GetLast4(),
+			p.MaskedCard.GetName(),
+			int(p.MaskedCard.GetExpireMonth()),
+			int(p.MaskedCard.GetExpireYear()),
+		),
+		Metadata:               p.Metadata,
+		Status:                 fmt.Sprint(entities.PaymentStatus(p.Status)),
+		BankPaymentID:          bankPaymentID,
+		BankRequestTime:        bankRequestTimeUTC,
+		BankResponseTime:       bankResponseTimeUTC,
+		BankMessage:            p.BankMessage,
+		CaptureMode:            captureMode(p.CaptureMode),
+		AuthorizationExpiresAt: authorizationExpiresAt,
+		CapturedAmount:         p.CapturedAmountMinor,
+		QuotaConsumedAt:        quotaConsumedAt,
+	}
 }
 
-func (ls *LedgerService) ReadPaymentUsingBankReference(bankPaymentID uuid.UUID) (entities.Payment, error) {
-	// return entities.Payment{}, nil
+func captureMode(mode rpcLedger.CaptureMode) string {
+	if mode == rpcLedger.CaptureMode_MANUAL {
+		return entities.ManualCapture
+	}
+	return entities.AutomaticCapture
+}
+
+// CreateRefund records a refund of amount minor units of a payment, zero
+// meaning whatever is left to refund
+func (ls *LedgerService) CreateRefund(paymentID uuid.UUID, amount int64) (entities.Refund, error) {
 	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
-		return entities.Payment{}, err
+		return entities.Refund{}, err
 	}
 	defer conn.Close()
 
 	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
 
-	req := &rpcLedger.ReadPaymentUsingBankReferenceRequest{
-		Id: bankPaymentID.String(),
+	req := &rpcLedger.CreateRefundRequest{
+		PaymentId:   paymentID.String(),
+		AmountMinor: amount,
 	}
 
-	resp, err := ledgerClient.ReadPaymentUsingBankReference(ls.ctx, req)
+	resp, err := ledgerClient.CreateRefund(ls.ctx, req)
 	if err != nil {
-		log.Printf("error reading payment: %v", err)
-		return entities.Payment{}, err
+		log.Printf("error creating refund: %v", err)
+		if status.Code(err) == codes.FailedPrecondition {
+			return entities.Refund{}, fmt.Errorf("%w: %s", ErrRefundNotAllowed, status.Convert(err).Message())
+		}
+		return entities.Refund{}, ledgerError(err)
 	}
 
-	merchantID, err := uuid.Parse(resp.Payment.MerchantId)
+	return newRefund(resp.Refund), nil
+}
+
+func (ls *LedgerService) ReadRefund(id uuid.UUID) (entities.Refund, error) {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		log.Printf("error parsing merchant uuid: %v", err)
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return entities.Refund{}, err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.ReadRefundRequest{
+		Id: id.String(),
+	}
+
+	resp, err := ledgerClient.ReadRefund(ls.ctx, req)
+	if err != nil {
+		log.Printf("error reading refund: %v", err)
+		return entities.Refund{}, err
+	}
+
+	return newRefund(resp.Refund), nil
+}
+
+func (ls *LedgerService) ReadRefundUsingBankReference(bankRefundID uuid.UUID) (entities.Refund, error) {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return entities.Refund{}, err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.ReadRefundUsingBankReferenceRequest{
+		Id: bankRefundID.String(),
+	}
+
+	resp, err := ledgerClient.ReadRefundUsingBankReference(ls.ctx, req)
+	if err != nil {
+		log.Printf("error reading refund: %v", err)
+		return entities.Refund{}, err
+	}
+
+	return newRefund(resp.Refund), nil
+}
+
+func (ls *LedgerService) SetRefundPending(r entities.Refund) (entities.Refund, error) {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return r, err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.UpdateRefundToPendingRequest{
+		Id:                 r.ID.String(),
+		BankRefundId:       r.BankRefundID.String(),
+		BankRequestTimeUtc: r.GetBankRequestTimeStr(),
+	}
+
+	_, err = ledgerClient.UpdateRefundToPending(ls.ctx, req)
+	if err != nil {
+		log.Printf("error updating refund to pending: %v", err)
+		return r, ledgerError(err)
+	}
+	r.Status = fmt.Sprint(entities.Pending)
+	return r, nil
+}
+
+func (ls *LedgerService) SetRefundSuccess(r entities.Refund) (entities.Refund, error) {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return r, err
 	}
-	paymentID, err := uuid.Parse(resp.Payment.Id)
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.UpdateRefundToSuccessRequest{
+		Id:                  r.ID.String(),
+		BankResponseTimeUtc: r.GetBankResponseTimeStr(),
+		BankMessage:         r.BankMessage,
+	}
+
+	_, err = ledgerClient.UpdateRefundToSuccess(ls.ctx, req)
+	if err != nil {
+		log.Printf("error updating refund to success: %v", err)
+		return r, ledgerError(err)
+	}
+	r.Status = fmt.Sprint(entities.Success)
+	return r, nil
+}
+
+func (ls *LedgerService) SetRefundFail(r entities.Refund) (entities.Refund, error) {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return r, err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.UpdateRefundToFailRequest{
+		Id:          r.ID.String(),
+		BankMessage: &r.BankMessage,
+	}
+	if !r.BankResponseTime.IsZero() {
+		respTimeStr := r.GetBankResponseTimeStr()
+		req.BankResponseTimeUtc = &respTimeStr
+	}
+
+	_, err = ledgerClient.UpdateRefundToFail(ls.ctx, req)
+	if err != nil {
+		log.Printf("error updating refund to fail: %v", err)
+		return r, ledgerError(err)
+	}
+	r.Status = fmt.Sprint(entities.Fail)
+	return r, nil
+}
+
+// newRefund converts a refund sent by the ledger, fields that cannot be
+// parsed are logged and left empty
+func newRefund(r *rpcLedger.Refund) entities.Refund {
+	id, err := uuid.Parse(r.Id)
+	if err != nil {
+		log.Printf("error parsing refund uuid: %v", err)
+	}
+	paymentID, err := uuid.Parse(r.PaymentId)
 	if err != nil {
 		log.Printf("error parsing payment uuid: %v", err)
 	}
-	purchaseTimeUTC, err := time.Parse("2006-01-02T15:04:05.000", resp.Payment.PurchaseTimeUtc)
+	merchantID, err := uuid.Parse(r.MerchantId)
 	if err != nil {
-		log.Printf("error parsing purchate time: %v", err)
+		log.Printf("error parsing merchant uuid: %v", err)
 	}
-	bankRequestTimeUTC, err := time.Parse("2006-01-02T15:04:05.000", resp.Payment.BankRequestTimeUtc)
+	bankRefundID, err := uuid.Parse(r.BankRefundId)
+	if err != nil {
+		log.Printf("error parsing bank refund uuid: %v", err)
+	}
+	bankRequestTimeUTC, err := time.Parse("2006-01-02T15:04:05.000", r.BankRequestTimeUtc)
 	if err != nil {
 		log.Printf("error parsing bank request time: %v", err)
 	}
-	bankResponseTimeUTC, err := time.Parse("2006-01-02T15:04:05.000", resp.Payment.BankResponseTimeUtc)
+	bankResponseTimeUTC, err := time.Parse("2006-01-02T15:04:05.000", r.BankResponseTimeUtc)
 	if err != nil {
 		log.Printf("error parsing bank response time: %v", err)
 	}
-	card := entities.CreditCard{
-		Number:      resp.Payment.Card.Number,
-		Name:        resp.Payment.Card.Name,
-		ExpireMonth: int(resp.Payment.Card.ExpireMonth),
-		ExpireYear:  int(resp.Payment.Card.ExpireYear),
-		CVV:         int(resp.Payment.Card.Cvv),
+	createdAtUTC, err := time.Parse("2006-01-02T15:04:05.000", r.CreatedAtUtc)
+	if err != nil {
+		log.Printf("error parsing refund creation time: %v", err)
 	}
 
-	return entities.Payment{
-		ID:               paymentID,
+	return entities.Refund{
+		ID:               id,
+		PaymentID:        paymentID,
 		MerchantID:       merchantID,
-		Amount:           float64(resp.Payment.Amount),
-		Currency:         resp.Payment.Currency,
-		PurchaseTime:     purchaseTimeUTC,
-		ValidationMethod: resp.Payment.ValidationMethod,
-		Card:             card,
-		Metadata:         resp.Payment.Metadata,
-		Status:           fmt.Sprint(entities.PaymentStatus(resp.Payment.Status)),
-		BankPaymentID:    bankPaymentID,
+		Amount:           r.AmountMinor,
+		Currency:         r.Currency,
+		Status:           fmt.Sprint(entities.PaymentStatus(r.Status)),
+		BankRefundID:     bankRefundID,
 		BankRequestTime:  bankRequestTimeUTC,
 		BankResponseTime: bankResponseTimeUTC,
-		BankMessage:      resp.Payment.BankMessage,
+		BankMessage:      r.BankMessage,
+		CreatedAt:        createdAtUTC,
+	}
+}
+
+// StartIdempotentRequest claims key for merchantID, the returned state tells
+// whether the request must be processed (Started) or not
+func (ls *LedgerService) StartIdempotentRequest(merchantID uuid.UUID, key, requestHash string) (entities.IdempotentRequest, error) {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return entities.IdempotentRequest{}, err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.StartIdempotentRequestRequest{
+		MerchantId:  merchantID.String(),
+		Key:         key,
+		RequestHash: requestHash,
+	}
+
+	resp, err := ledgerClient.StartIdempotentRequest(ls.ctx, req)
+	if err != nil {
+		log.Printf("error starting idempotent request: %v", err)
+		return entities.IdempotentRequest{}, err
+	}
+
+	return entities.IdempotentRequest{
+		State:          entities.IdempotentRequestState(resp.State),
+		ResponseStatus: int(resp.ResponseStatus),
+		ResponseBody:   resp.ResponseBody,
 	}, nil
 }
+
+// CompleteIdempotentRequest stores the response sent for key, so retries can
+// replay it
+func (ls *LedgerService) CompleteIdempotentRequest(merchantID uuid.UUID, key string, responseStatus int, responseBody []byte) error {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.CompleteIdempotentRequestRequest{
+		MerchantId:     merchantID.String(),
+		Key:            key,
+		ResponseStatus: int32(responseStatus),
+		ResponseBody:   responseBody,
+	}
+
+	_, err = ledgerClient.CompleteIdempotentRequest(ls.ctx, req)
+	if err != nil {
+		log.Printf("error completing idempotent request: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ReleaseIdempotentRequest frees key, so a retry is processed as a new request
+func (ls *LedgerService) ReleaseIdempotentRequest(merchantID uuid.UUID, key string) error {
+	conn, err := grpc.Dial(ls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("ledger service is unreachable at address: %s, %v", ls.address, err)
+		return err
+	}
+	defer conn.Close()
+
+	ledgerClient := rpcLedger.NewLedgerServiceClient(conn)
+
+	req := &rpcLedger.ReleaseIdempotentRequestRequest{
+		MerchantId: merchantID.String(),
+		Key:        key,
+	}
+
+	_, err = ledgerClient.ReleaseIdempotentRequest(ls.ctx, req)
+	if err != nil {
+		log.Printf("error releasing idempotent request: %v", err)
+		return err
+	}
+	return nil
+}
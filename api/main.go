@@ -1,21 +1,24 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
-	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-)
-
-const (
-	secretKey = "your-secret-key"
+	"github.com/thiagolcmelo/payment-gateway/api/bank"
+	"github.com/thiagolcmelo/payment-gateway/api/bankauth"
+	"github.com/thiagolcmelo/payment-gateway/api/jwtkeys"
+	"github.com/thiagolcmelo/payment-gateway/api/ledger"
+	"github.com/thiagolcmelo/payment-gateway/api/merchant"
+	"github.com/thiagolcmelo/payment-gateway/api/ratelimiter"
+	"github.com/thiagolcmelo/payment-gateway/api/webhook"
 )
 
 var (
@@ -30,10 +33,28 @@ var (
 	ledgerPortFlag      = flag.Int("ledger-port", 50053, "Ledger Service Port")
 	bankHostFlag        = flag.String("bank-host", "0.0.0.0", "Bank host address")
 	bankPortFlag        = flag.Int("bank-port", 8000, "Bank Port")
+	jwtKeysFlag         = flag.String("jwt-keys", "", "Keys merchant tokens are signed with, as id:<path to PEM file> separated by commas, the first one signs and all of them verify")
+	accessTTLFlag       = flag.String("access-token-ttl", "15m", "How long an access token is valid")
+	refreshTTLFlag      = flag.String("refresh-token-ttl", "720h", "How long a refresh token can be exchanged for new tokens")
+	statusTTLFlag       = flag.String("merchant-status-ttl", "30s", "How long whether a merchant is active, or a token revoked, is cached")
+	bankKeysFlag        = flag.String("bank-keys", "", "Keys banks sign callbacks with, as id:hmac:<secret> or id:ed25519:<base64 public key>, separated by commas")
+	bankNetworksFlag    = flag.String("bank-networks", "", "CIDRs bank callbacks may come from, separated by commas, any when empty")
+	bankToleranceFlag   = flag.String("bank-signature-tolerance", "5m", "How far from now the timestamp of a bank callback signature may be")
+	webhookAttemptsFlag = flag.Int("webhook-max-attempts", 8, "Attempts of each webhook delivery before it is marked as failed")
+	webhookBackoffFlag  = flag.String("webhook-backoff", "30s", "Wait after the first failed webhook attempt, doubled after each of the following ones")
+	webhookLogSizeFlag  = flag.Int("webhook-log-size", 1000, "Finished webhook deliveries kept per merchant, the oldest ones are forgotten first")
+	webhookNetworksFlag = flag.String("webhook-allowed-networks", "", "CIDRs webhooks may reach even though they are not public, separated by commas, none when empty")
+	authSweepFlag       = flag.String("authorization-sweep-interval", "1m", "How often authorizations that were not captured in time are voided and released at the bank")
+	trustedProxiesFlag  = flag.String("trusted-proxies", "", "CIDRs of proxies whose X-Forwarded-For is trusted to tell the client ip, separated by commas, none when empty")
 	merchantAddress     string
 	rateLimiterAddress  string
 	ledgerAddress       string
 	bankAddress         string
+	tokenKeys           *jwtkeys.KeySet
+	accessTokenTTL      time.Duration
+	refreshTokenTTL     time.Duration
+	merchantStatus      *merchant.StatusCache
+	webhooks            *webhook.Dispatcher
 )
 
 func getEnvOrFlag[T int | string](env string, flagVal *T, conv func(string) (T, error)) T {
@@ -64,6 +85,19 @@ func main() {
 		ledgerPort      int    = getEnvOrFlag("LEDGER_SERVICE_PORT", ledgerPortFlag, strconv.Atoi)
 		bankHost        string = getEnvOrFlag("BANK_SIMULATOR_HOST", bankHostFlag, dummyFunc)
 		bankPort        int    = getEnvOrFlag("BANK_SIMULATOR_PORT", bankPortFlag, strconv.Atoi)
+		jwtKeys         string = getEnvOrFlag("JWT_KEYS", jwtKeysFlag, dummyFunc)
+		accessTTL       string = getEnvOrFlag("ACCESS_TOKEN_TTL", accessTTLFlag, dummyFunc)
+		refreshTTL      string = getEnvOrFlag("REFRESH_TOKEN_TTL", refreshTTLFlag, dummyFunc)
+		statusTTL       string = getEnvOrFlag("MERCHANT_STATUS_TTL", statusTTLFlag, dummyFunc)
+		bankKeys        string = getEnvOrFlag("BANK_KEYS", bankKeysFlag, dummyFunc)
+		bankNetworks    string = getEnvOrFlag("BANK_NETWORKS", bankNetworksFlag, dummyFunc)
+		bankTolerance   string = getEnvOrFlag("BANK_SIGNATURE_TOLERANCE", bankToleranceFlag, dummyFunc)
+		webhookAttempts int    = getEnvOrFlag("WEBHOOK_MAX_ATTEMPTS", webhookAttemptsFlag, strconv.Atoi)
+		webhookBackoff  string = getEnvOrFlag("WEBHOOK_BACKOFF", webhookBackoffFlag, dummyFunc)
+		webhookLogSize  int    = getEnvOrFlag("WEBHOOK_LOG_SIZE", webhookLogSizeFlag, strconv.Atoi)
+		webhookNetworks string = getEnvOrFlag("WEBHOOK_ALLOWED_NETWORKS", webhookNetworksFlag, dummyFunc)
+		authSweep       string = getEnvOrFlag("AUTHORIZATION_SWEEP_INTERVAL", authSweepFlag, dummyFunc)
+		trustedProxies  string = getEnvOrFlag("TRUSTED_PROXIES", trustedProxiesFlag, dummyFunc)
 	)
 
 	if ipVersion == 6 {
@@ -80,50 +114,180 @@ func main() {
 	ledgerAddress = fmt.Sprintf("%s:%d", ledgerHost, ledgerPort)
 	bankAddress = fmt.Sprintf("http://%s:%d", bankHost, bankPort)
 
-	bankIP, err := getBankIP()
+	backoff, err := time.ParseDuration(webhookBackoff)
+	if err != nil {
+		log.Fatalf("invalid webhook backoff: %v", err)
+	}
+	allowedNetworks, err := bankauth.ParseNetworks(webhookNetworks)
+	if err != nil {
+		log.Fatalf("invalid webhook allowed networks: %v", err)
+	}
+	// deliveries and cached statuses outlive the requests that started them
+	merchants := merchant.NewMerchantService(context.Background(), merchantAddress)
+	webhooks = webhook.NewDispatcher(merchants, webhook.NewClient(10*time.Second, allowedNetworks), webhookAttempts, backoff, webhookLogSize)
+
+	authSweepInterval, err := time.ParseDuration(authSweep)
+	if err != nil || authSweepInterval <= 0 {
+		log.Fatalf("invalid authorization sweep interval: %s", authSweep)
+	}
+	go expireAuthorizations(authSweepInterval)
+
+	tokenKeys, err = newTokenKeys(jwtKeys)
+	if err != nil {
+		log.Fatalf("invalid jwt keys: %v", err)
+	}
+
+	accessTokenTTL, err = time.ParseDuration(accessTTL)
 	if err != nil {
-		log.Fatalf("could not determine bank ip: %v", err)
-	} else {
-		log.Printf("bank ip: %s", bankIP)
+		log.Fatalf("invalid access token ttl: %v", err)
 	}
+	refreshTokenTTL, err = time.ParseDuration(refreshTTL)
+	if err != nil {
+		log.Fatalf("invalid refresh token ttl: %v", err)
+	}
+
+	merchantStatusTTL, err := time.ParseDuration(statusTTL)
+	if err != nil {
+		log.Fatalf("invalid merchant status ttl: %v", err)
+	}
+	merchantStatus = merchant.NewStatusCache(merchants, merchantStatusTTL)
+
+	keys, err := bankauth.ParseKeys(bankKeys)
+	if err != nil {
+		log.Fatalf("invalid bank keys: %v", err)
+	}
+	if len(keys) == 0 {
+		log.Fatal("no bank keys, bank callbacks could not be verified")
+	}
+	networks, err := bankauth.ParseNetworks(bankNetworks)
+	if err != nil {
+		log.Fatalf("invalid bank networks: %v", err)
+	}
+	tolerance, err := time.ParseDuration(bankTolerance)
+	if err != nil {
+		log.Fatalf("invalid bank signature tolerance: %v", err)
+	}
+	bankCallbacks := bankauth.NewVerifier(keys, networks, tolerance)
+	log.Printf("verifying bank callbacks with %d keys", len(keys))
 
 	router := gin.Default()
+	// the client ip counts failed logins, it must not be taken from a header
+	// anyone can set
+	var proxies []string
+	if trustedProxies != "" {
+		proxies = strings.Split(trustedProxies, ",")
+	}
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		log.Fatalf("invalid trusted proxies: %v", err)
+	}
 
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Authorization", "Content-Type", "Accept", "Access-Control-Allow-Origin"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Authorization", "Content-Type", "Accept", "Access-Control-Allow-Origin", "Idempotency-Key"}
+	config.ExposeHeaders = []string{"Idempotent-Replayed"}
 
 	router.Use(cors.New(config))
 
-	router.GET("/login", loginHandler)
-
-	router.POST("/payment", authMiddleware, rateLimitMiddleware, createPaymentHandler)
-	router.PUT("/payment", restrictMiddleware(bankIP), updatePaymentHandler)
-	router.GET("/payment/:id", authMiddleware, rateLimitMiddleware, readPaymentHandler)
+	registerRoutes(router, bankCallbacks)
 
 	router.Run(address)
 }
 
-func getBankIP() (string, error) {
-	cmd := exec.Command("ping", "-c", "1", "bank-simulator")
+// expireAuthorizations voids, every interval, the authorizations that were
+// not captured in time, see releaseExpiredAuthorizations
+func expireAuthorizations(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		releaseExpiredAuthorizations(context.Background())
+	}
+}
 
-	output, err := cmd.CombinedOutput()
+// releaseExpiredAuthorizations has the ledger void the authorizations that
+// expired, gives them back to the shoppers at the bank and tells the
+// merchants. The ledger returns each one only once, so a release the bank
+// refuses or never gets is logged and not retried.
+func releaseExpiredAuthorizations(ctx context.Context) {
+	ls := ledger.NewLedgerService(ctx, ledgerAddress)
+	expired, err := ls.ExpireAuthorizations()
 	if err != nil {
-		fmt.Printf("error executing command: %v", err)
-		return "", err
+		log.Printf("could not expire authorizations: %v", err)
+		return
 	}
 
-	// Create a regular expression pattern to capture the value within parentheses
-	re := regexp.MustCompile(`PING bank-simulator \(([0-9\.]*)\): [0-9]* data bytes`)
+	ms := merchant.NewMerchantService(ctx, merchantAddress)
+	bs := bank.NewBankService(ctx, bankAddress)
+	rls := ratelimiter.NewRateLimiterService(ctx, rateLimiterAddress, true)
+	for _, p := range expired {
+		log.Printf("authorization of payment %s expired", p.ID.String())
+		releaseQuota(rls, p)
+		m, err := ms.Get(p.MerchantID)
+		if err == nil {
+			p, err = bs.RelayVoidRequest(m, p)
+		}
+		if err != nil {
+			log.Printf("could not release expired authorization of payment %s at the bank: %v", p.ID.String(), err)
+		}
+		notifyMerchant(p)
+	}
+}
+
+// registerRoutes adds every endpoint to router, along with the middleware
+// that decides who can call it
+func registerRoutes(router gin.IRouter, bankCallbacks *bankauth.Verifier) {
+	router.GET("/login", loginHandler)
+	router.POST("/login/verify", verifyLoginHandler)
+	router.POST("/token/refresh", refreshTokenHandler)
+	router.POST("/logout", authMiddleware, tokenOnlyMiddleware, logoutHandler)
+	router.GET("/.well-known/jwks.json", jwksHandler)
 
-	// Find the first match of the pattern in the output
-	match := re.FindStringSubmatch(string(output))
+	admin := requireRole(roleAdmin)
+	router.POST("/api-keys", authMiddleware, tokenOnlyMiddleware, admin, createAPIKeyHandler)
+	router.GET("/api-keys", authMiddleware, tokenOnlyMiddleware, admin, listAPIKeysHandler)
+	router.PATCH("/api-keys/:id", authMiddleware, tokenOnlyMiddleware, admin, updateAPIKeyHandler)
+	router.DELETE("/api-keys/:id", authMiddleware, tokenOnlyMiddleware, admin, revokeAPIKeyHandler)
 
-	if len(match) > 1 {
-		// The captured value is at index 1
-		return strings.TrimSpace(match[1]), nil
-	} else {
-		return "", fmt.Errorf("unable to find bank ip address")
+	router.POST("/users", authMiddleware, tokenOnlyMiddleware, admin, createUserHandler)
+	router.GET("/users", authMiddleware, tokenOnlyMiddleware, admin, listUsersHandler)
+	router.PATCH("/users/:id", authMiddleware, tokenOnlyMiddleware, admin, updateUserHandler)
+	router.DELETE("/users/:id", authMiddleware, tokenOnlyMiddleware, admin, deleteUserHandler)
+
+	router.POST("/totp", authMiddleware, tokenOnlyMiddleware, ownerOnlyMiddleware, enrollTOTPHandler)
+	router.POST("/totp/confirm", authMiddleware, tokenOnlyMiddleware, ownerOnlyMiddleware, confirmTOTPHandler)
+	router.DELETE("/totp", authMiddleware, tokenOnlyMiddleware, ownerOnlyMiddleware, disableTOTPHandler)
+
+	paymentsWrite := requireRole(rolePaymentsWrite)
+	paymentsRead := requireRole(rolePaymentsRead)
+	refunds := requireRole(roleRefunds)
+	router.POST("/payment", authMiddleware, paymentsWrite, rateLimitMiddleware, idempotencyMiddleware, createPaymentHandler)
+	router.PUT("/payment", bankCallbackMiddleware(bankCallbacks), updatePaymentHandler)
+	router.GET("/payment/:id", authMiddleware, paymentsRead, rateLimitMiddleware, readPaymentHandler)
+	router.POST("/payment/:id/capture", authMiddleware, paymentsWrite, rateLimitMiddleware, idempotencyMiddleware, capturePaymentHandler)
+	router.POST("/payment/:id/void", authMiddleware, paymentsWrite, rateLimitMiddleware, idempotencyMiddleware, voidPaymentHandler)
+	router.POST("/payment/:id/refunds", authMiddleware, refunds, rateLimitMiddleware, idempotencyMiddleware, createRefundHandler)
+	router.GET("/payments", authMiddleware, paymentsRead, rateLimitMiddleware, listPaymentsHandler)
+	router.GET("/usage", authMiddleware, paymentsRead, rateLimitMiddleware, usageHandler)
+	router.PUT("/webhook", authMiddleware, tokenOnlyMiddleware, admin, rateLimitMiddleware, setWebhookHandler)
+	router.GET("/webhook/deliveries", authMiddleware, tokenOnlyMiddleware, admin, rateLimitMiddleware, listWebhookDeliveriesHandler)
+	router.POST("/webhook/deliveries/:id/replay", authMiddleware, tokenOnlyMiddleware, admin, rateLimitMiddleware, replayWebhookDeliveryHandler)
+}
+
+// newTokenKeys reads the jwt keys, without any a random key is used, so the
+// tokens it signs do not survive a restart
+func newTokenKeys(keys string) (*jwtkeys.KeySet, error) {
+	ks, err := jwtkeys.ReadKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(ks) == 0 {
+		log.Print("no jwt keys given, using a random one")
+		k, err := jwtkeys.GenerateKey("ephemeral")
+		if err != nil {
+			return nil, err
+		}
+		ks = append(ks, k)
 	}
+	return jwtkeys.NewKeySet(ks)
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/api/merchant"
+	"github.com/thiagolcmelo/payment-gateway/api/webhook"
+	rpcLedger "github.com/thiagolcmelo/payment-gateway/ledger/pb"
+	rpcMerchant "github.com/thiagolcmelo/payment-gateway/merchant/pb"
+	rpcRateLimiter "github.com/thiagolcmelo/payment-gateway/ratelimiter/pb"
+	"google.golang.org/grpc"
+)
+
+// expiringLedger voids the authorizations in expired on the first call, the
+// way the ledger returns each one only once
+type expiringLedger struct {
+	rpcLedger.UnimplementedLedgerServiceServer
+	mu      sync.Mutex
+	expired []*rpcLedger.Payment
+}
+
+func (el *expiringLedger) ExpireAuthorizations(ctx context.Context, req *rpcLedger.ExpireAuthorizationsRequest) (*rpcLedger.ExpireAuthorizationsResponse, error) {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+
+	resp := &rpcLedger.ExpireAuthorizationsResponse{Payments: el.expired}
+	el.expired = nil
+	return resp, nil
+}
+
+// webhookMerchants knows the name and the webhook of every merchant
+type webhookMerchants struct {
+	rpcMerchant.UnimplementedMerchantServiceServer
+	url string
+}
+
+func (wm *webhookMerchants) GetMerchant(ctx context.Context, req *rpcMerchant.GetMerchantRequest) (*rpcMerchant.GetMerchantResponse, error) {
+	return &rpcMerchant.GetMerchantResponse{Id: req.Id, Name: "Merchant 0 Ltd.", Active: true}, nil
+}
+
+func (wm *webhookMerchants) GetWebhook(ctx context.Context, req *rpcMerchant.GetWebhookRequest) (*rpcMerchant.GetWebhookResponse, error) {
+	return &rpcMerchant.GetWebhookResponse{Url: wm.url, Secret: "0123456789abcdef"}, nil
+}
+
+func TestReleaseExpiredAuthorizations(t *testing.T) {
+	bankPaymentID := uuid.New()
+	payment := &rpcLedger.Payment{
+		Id:            uuid.New().String(),
+		MerchantId:    uuid.New().String(),
+		AmountMinor:   1000,
+		Currency:      "USD",
+		Status:        rpcLedger.PaymentStatus_VOIDED,
+		BankPaymentId: bankPaymentID.String(),
+		// 2026-10-18T12:00:00Z, 1792324800
+		QuotaConsumedAtUtc: "2026-10-18T12:00:00.000",
+	}
+	ledgerAddress = serveGRPC(t, func(s *grpc.Server) {
+		rpcLedger.RegisterLedgerServiceServer(s, &expiringLedger{expired: []*rpcLedger.Payment{payment}})
+	})
+	ql := &quotaLimiter{}
+	rateLimiterAddress = serveGRPC(t, func(s *grpc.Server) {
+		rpcRateLimiter.RegisterRateLimiterServiceServer(s, ql)
+	})
+
+	voids := make(chan map[string]any, 1)
+	bank := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/void" {
+			t.Errorf("expected a void, got %s", r.URL.Path)
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		voids <- body
+		w.Write([]byte(`{"id": "` + bankPaymentID.String() + `", "success": true, "message": "authorization released"}`))
+	}))
+	defer bank.Close()
+	bankAddress = bank.URL
+
+	events := make(chan webhook.Event, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e webhook.Event
+		json.NewDecoder(r.Body).Decode(&e)
+		events <- e
+	}))
+	defer receiver.Close()
+
+	fm := &webhookMerchants{url: receiver.URL}
+	merchantAddress = serveGRPC(t, func(s *grpc.Server) {
+		rpcMerchant.RegisterMerchantServiceServer(s, fm)
+	})
+	_, loopback, _ := net.ParseCIDR("127.0.0.1/32")
+	endpoints := merchant.NewMerchantService(context.Background(), merchantAddress)
+	webhooks = webhook.NewDispatcher(endpoints, webhook.NewClient(time.Second, []*net.IPNet{loopback}), 1, time.Second, 10)
+
+	releaseExpiredAuthorizations(context.Background())
+	// the ledger returns nothing the second time, the bank must not be asked again
+	releaseExpiredAuthorizations(context.Background())
+
+	select {
+	case body := <-voids:
+		if body["payment_id"] != bankPaymentID.String() || body["merchant"] != "Merchant 0 Ltd." {
+			t.Errorf("expected void of %s by Merchant 0 Ltd., got %v", bankPaymentID, body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the bank to release the authorization")
+	}
+	select {
+	case body := <-voids:
+		t.Errorf("expected a single void, got another one %v", body)
+	default:
+	}
+
+	select {
+	case e := <-events:
+		if e.Type != webhook.PaymentVoided || e.Data.ID.String() != payment.Id {
+			t.Errorf("expected %s of %s, got %s of %s", webhook.PaymentVoided, payment.Id, e.Type, e.Data.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the merchant to be told")
+	}
+
+	// nothing was charged, the payment is given back to its quotas once
+	if len(ql.releases) != 1 || ql.releases[0].Id != payment.MerchantId || ql.releases[0].ConsumedAt != 1792324800 {
+		t.Errorf("expected the payment counted at 1792324800 to be released once, got %v", ql.releases)
+	}
+}
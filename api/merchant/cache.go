@@ -0,0 +1,121 @@
+package merchant
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatusSource answers what StatusCache keeps, MerchantService in production
+type StatusSource interface {
+	Active(id uuid.UUID) (bool, error)
+	AccessTokenRevoked(jti string, id, userID uuid.UUID, issuedAt time.Time) (bool, error)
+}
+
+// StatusCache keeps whether merchants are active and whether access tokens
+// are revoked for ttl, so checking them on every request does not cost a
+// call to the merchant service. A merchant deactivated, or a token revoked,
+// through another instance of the api is only noticed once the entry expires
+type StatusCache struct {
+	source StatusSource
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	active    map[uuid.UUID]cached
+	revoked   map[string]cached
+	lastSweep time.Time
+}
+
+type cached struct {
+	value     bool
+	expiresAt time.Time
+}
+
+// NewStatusCache is a factory for a StatusCache that asks source about
+// anything older than ttl, errors are never cached
+func NewStatusCache(source StatusSource, ttl time.Duration) *StatusCache {
+	return newStatusCache(source, ttl, time.Now)
+}
+
+func newStatusCache(source StatusSource, ttl time.Duration, now func() time.Time) *StatusCache {
+	return &StatusCache{
+		source:  source,
+		ttl:     ttl,
+		now:     now,
+		active:  make(map[uuid.UUID]cached),
+		revoked: make(map[string]cached),
+	}
+}
+
+// Active tells if a merchant is active
+func (sc *StatusCache) Active(id uuid.UUID) (bool, error) {
+	sc.mu.Lock()
+	c, ok := sc.active[id]
+	sc.mu.Unlock()
+	if ok && sc.now().Before(c.expiresAt) {
+		return c.value, nil
+	}
+
+	active, err := sc.source.Active(id)
+	if err != nil {
+		return false, err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.active[id] = cached{value: active, expiresAt: sc.now().Add(sc.ttl)}
+	sc.sweep()
+	return active, nil
+}
+
+// AccessTokenRevoked tells if the access token jti of a merchant, or of
+// userID of the merchant unless nil, was revoked
+func (sc *StatusCache) AccessTokenRevoked(jti string, id, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	sc.mu.Lock()
+	c, ok := sc.revoked[jti]
+	sc.mu.Unlock()
+	if ok && sc.now().Before(c.expiresAt) {
+		return c.value, nil
+	}
+
+	revoked, err := sc.source.AccessTokenRevoked(jti, id, userID, issuedAt)
+	if err != nil {
+		return true, err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.revoked[jti] = cached{value: revoked, expiresAt: sc.now().Add(sc.ttl)}
+	sc.sweep()
+	return revoked, nil
+}
+
+// Revoke marks jti as revoked right away, for tokens revoked through this
+// instance
+func (sc *StatusCache) Revoke(jti string, expiresAt time.Time) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.revoked[jti] = cached{value: true, expiresAt: expiresAt}
+}
+
+// sweep drops the expired entries, at most once per ttl, it must be called
+// with mu held
+func (sc *StatusCache) sweep() {
+	now := sc.now()
+	if now.Sub(sc.lastSweep) < sc.ttl {
+		return
+	}
+	sc.lastSweep = now
+	for id, c := range sc.active {
+		if !now.Before(c.expiresAt) {
+			delete(sc.active, id)
+		}
+	}
+	for jti, c := range sc.revoked {
+		if !now.Before(c.expiresAt) {
+			delete(sc.revoked, jti)
+		}
+	}
+}
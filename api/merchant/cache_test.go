@@ -0,0 +1,105 @@
+package merchant
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// source answers with its fields and counts the calls it gets
+type source struct {
+	active  bool
+	revoked bool
+	err     error
+	calls   int
+}
+
+func (s *source) Active(id uuid.UUID) (bool, error) {
+	s.calls++
+	return s.active, s.err
+}
+
+func (s *source) AccessTokenRevoked(jti string, id, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	s.calls++
+	return s.revoked, s.err
+}
+
+func TestStatusCache_Active(t *testing.T) {
+	now := time.Now()
+	src := &source{active: true}
+	sc := newStatusCache(src, time.Minute, func() time.Time { return now })
+	id := uuid.New()
+
+	type testCase struct {
+		testName       string
+		advance        time.Duration
+		sourceActive   bool
+		sourceErr      error
+		expectedActive bool
+		expectedCalls  int
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{testName: "first_lookup", sourceActive: true, expectedActive: true, expectedCalls: 1},
+		{testName: "cached", sourceActive: false, expectedActive: true, expectedCalls: 1},
+		{testName: "expired", advance: time.Minute, sourceActive: false, expectedActive: false, expectedCalls: 2},
+		{testName: "error_not_cached", advance: time.Minute, sourceErr: errors.New("unreachable"), expectedCalls: 3, expectedErr: errors.New("unreachable")},
+		{testName: "after_error", sourceActive: true, expectedActive: true, expectedCalls: 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			now = now.Add(tc.advance)
+			src.active, src.err = tc.sourceActive, tc.sourceErr
+
+			active, err := sc.Active(id)
+			if (err == nil) != (tc.expectedErr == nil) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if err == nil && active != tc.expectedActive {
+				t.Errorf("expected active=%v, got %v", tc.expectedActive, active)
+			}
+			if src.calls != tc.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tc.expectedCalls, src.calls)
+			}
+		})
+	}
+}
+
+func TestStatusCache_AccessTokenRevoked(t *testing.T) {
+	now := time.Now()
+	src := &source{}
+	sc := newStatusCache(src, time.Minute, func() time.Time { return now })
+	id := uuid.New()
+
+	if revoked, err := sc.AccessTokenRevoked("jti-0", id, uuid.Nil, now); err != nil || revoked {
+		t.Fatalf("expected token to be valid, got %v, %v", revoked, err)
+	}
+
+	// revoked through this instance, noticed before the entry expires
+	sc.Revoke("jti-0", now.Add(time.Hour))
+	if revoked, _ := sc.AccessTokenRevoked("jti-0", id, uuid.Nil, now); !revoked {
+		t.Error("expected token revoked locally to be revoked")
+	}
+
+	// revoked through another instance, noticed once the entry expires
+	if revoked, _ := sc.AccessTokenRevoked("jti-1", id, uuid.Nil, now); revoked {
+		t.Error("expected token to be valid")
+	}
+	src.revoked = true
+	if revoked, _ := sc.AccessTokenRevoked("jti-1", id, uuid.Nil, now); revoked {
+		t.Error("expected cached answer before ttl")
+	}
+	now = now.Add(time.Minute)
+	if revoked, _ := sc.AccessTokenRevoked("jti-1", id, uuid.Nil, now); !revoked {
+		t.Error("expected fresh answer after ttl")
+	}
+
+	src.err = errors.New("unreachable")
+	if revoked, err := sc.AccessTokenRevoked("jti-2", id, uuid.Nil, now); err == nil || !revoked {
+		t.Errorf("expected errors to count as revoked, got %v, %v", revoked, err)
+	}
+}
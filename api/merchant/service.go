@@ -2,15 +2,98 @@ package merchant
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/thiagolcmelo/payment-gateway/api/entities"
 	rpcMerchant "github.com/thiagolcmelo/payment-gateway/merchant/pb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
+var (
+	// ErrInvalidWebhook is returned when the merchant service refuses a webhook,
+	// e.g. its url is not absolute or its secret is too short
+	ErrInvalidWebhook = errors.New("invalid webhook")
+	// ErrInvalidCredentials is returned when a username and password do not
+	// match any merchant
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrMerchantInactive is returned when the merchant exists but is not
+	// active
+	ErrMerchantInactive = errors.New("merchant is not active")
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown,
+	// expired, revoked or reused, or its merchant is no longer active
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrInvalidAPIKey is returned when an api key is malformed, unknown or
+	// revoked
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrUnknownAPIKey is returned when a merchant has no api key with a
+	// given id
+	ErrUnknownAPIKey = errors.New("unknown api key")
+	// ErrInvalidAPIKeyLabel is returned when the merchant service refuses the
+	// label of an api key, e.g. it is too long
+	ErrInvalidAPIKeyLabel = errors.New("invalid api key label")
+	// ErrInvalidChallenge is returned when a login challenge is unknown,
+	// expired, or took too many wrong codes
+	ErrInvalidChallenge = errors.New("invalid login challenge")
+	// ErrInvalidCode is returned when a TOTP or recovery code is wrong
+	ErrInvalidCode = errors.New("invalid code")
+	// ErrTOTPNotEnrolled is returned when a merchant has no TOTP to confirm
+	// or disable
+	ErrTOTPNotEnrolled = errors.New("totp not enrolled")
+	// ErrTOTPAlreadyEnabled is returned when a merchant enrols TOTP again
+	// without disabling it first
+	ErrTOTPAlreadyEnabled = errors.New("totp already enabled")
+	// ErrUserInactive is returned when a user of a merchant exists but is not
+	// active
+	ErrUserInactive = errors.New("user is not active")
+	// ErrUnknownUser is returned when a merchant has no user with a given id
+	ErrUnknownUser = errors.New("unknown user")
+	// ErrInvalidUser is returned when the merchant service refuses the fields
+	// of a user, e.g. an unknown role or an empty password
+	ErrInvalidUser = errors.New("invalid user")
+	// ErrUsernameTaken is returned when a username is already used by a
+	// merchant or one of their users
+	ErrUsernameTaken = errors.New("username already taken")
+)
+
+// Login is who a login, refresh token or api key stands for
+type Login struct {
+	MerchantID uuid.UUID
+	// UserID is the user of the merchant, nil for the merchant itself
+	UserID   uuid.UUID
+	Username string
+	Roles    []string
+}
+
+// LoginLockedError is returned when a login is refused, without checking
+// the password, because of too many failed attempts for the username or
+// from the source ip
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed logins, retry after %v", e.RetryAfter)
+}
+
+// SecondFactorRequiredError is returned when the password of a merchant
+// with TOTP enabled is right, the login is completed by VerifyLogin with
+// Challenge and a code
+type SecondFactorRequiredError struct {
+	Challenge string
+	ExpiresIn time.Duration
+}
+
+func (e *SecondFactorRequiredError) Error() string {
+	return "second factor required"
+}
+
 type MerchantService struct {
 	ctx     context.Context
 	address string
@@ -23,11 +106,16 @@ func NewMerchantService(ctx context.Context, address string) *MerchantService {
 	}
 }
 
-func (ms *MerchantService) Validate(username, password string) (uuid.UUID, bool) {
+// Validate returns the login of the merchant, or user of a merchant, with
+// username and password, tried from sourceIP. It returns ErrMerchantInactive
+// or ErrUserInactive when they are not active, a *LoginLockedError after too
+// many failed attempts, and a *SecondFactorRequiredError when the merchant
+// has TOTP enabled
+func (ms *MerchantService) Validate(username, password, sourceIP string) (Login, error) {
 	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
-		return uuid.Nil, false
+		return Login{}, err
 	}
 	defer conn.Close()
 
@@ -36,28 +124,210 @@ func (ms *MerchantService) Validate(username, password string) (uuid.UUID, bool)
 	req := &rpcMerchant.FindMerchantRequest{
 		Username: username,
 		Password: password,
+		SourceIp: sourceIP,
 	}
 
 	resp, err := merchantClient.FindMerchant(ms.ctx, req)
 	if err != nil {
 		log.Printf("error finding merchant: %v", err)
-		return uuid.Nil, false
+		return Login{}, err
+	}
+
+	if resp.RetryAfterSeconds > 0 {
+		return Login{}, &LoginLockedError{RetryAfter: time.Duration(resp.RetryAfterSeconds) * time.Second}
 	}
 
 	if !resp.Exists {
-		return uuid.Nil, false
+		return Login{}, ErrInvalidCredentials
 	} else if resp.Id == nil {
 		log.Print("missing merchant id for existing merchant")
-		return uuid.Nil, false
+		return Login{}, ErrInvalidCredentials
+	}
+
+	login, err := parseLogin(*resp.Id, resp.UserId, username, resp.Roles)
+	if err != nil {
+		return Login{}, err
 	}
 
-	id, err := uuid.Parse(*resp.Id)
+	if !resp.Active {
+		if login.UserID != uuid.Nil {
+			return login, ErrUserInactive
+		}
+		return login, ErrMerchantInactive
+	}
+
+	if resp.Challenge != "" {
+		return login, &SecondFactorRequiredError{
+			Challenge: resp.Challenge,
+			ExpiresIn: time.Duration(resp.ChallengeExpiresIn) * time.Second,
+		}
+	}
+
+	return login, nil
+}
+
+// parseLogin builds a Login out of the fields of the merchant service, an
+// empty userID is the merchant itself
+func parseLogin(merchantID, userID, username string, roles []string) (Login, error) {
+	id, err := uuid.Parse(merchantID)
 	if err != nil {
 		log.Printf("error parsing merchant id: %v", err)
-		return uuid.Nil, false
+		return Login{}, err
+	}
+	login := Login{MerchantID: id, Username: username, Roles: roles}
+	if userID != "" {
+		login.UserID, err = uuid.Parse(userID)
+		if err != nil {
+			log.Printf("error parsing user id: %v", err)
+			return Login{}, err
+		}
+	}
+	return login, nil
+}
+
+// VerifyLogin completes the login of challenge with a TOTP or recovery
+// code, tried from sourceIP. It returns a *LoginLockedError after too many
+// failed attempts
+func (ms *MerchantService) VerifyLogin(challenge, code, sourceIP string) (Login, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return Login{}, err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.VerifyLoginRequest{
+		Challenge: challenge,
+		Code:      code,
+		SourceIp:  sourceIP,
+	}
+	resp, err := merchantClient.VerifyLogin(ms.ctx, req)
+	if status.Code(err) == codes.Unauthenticated {
+		return Login{}, ErrInvalidChallenge
+	}
+	if err != nil {
+		log.Printf("error verifying login: %v", err)
+		return Login{}, err
+	}
+
+	if resp.RetryAfterSeconds > 0 {
+		return Login{}, &LoginLockedError{RetryAfter: time.Duration(resp.RetryAfterSeconds) * time.Second}
+	}
+	if !resp.Verified {
+		return Login{}, ErrInvalidCode
+	}
+
+	return parseLogin(resp.MerchantId, "", resp.Username, resp.Roles)
+}
+
+// EnrollTOTP gives a merchant a new TOTP secret, and returns it along with
+// the otpauth:// url of it. It is only enabled once ConfirmTOTP gets a code
+func (ms *MerchantService) EnrollTOTP(id uuid.UUID) (string, string, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return "", "", err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.EnrollTOTPRequest{
+		MerchantId: id.String(),
+	}
+	resp, err := merchantClient.EnrollTOTP(ms.ctx, req)
+	if err != nil {
+		return "", "", totpError("enrolling totp", err)
+	}
+
+	return resp.Secret, resp.Url, nil
+}
+
+// ConfirmTOTP enables the TOTP of a merchant with its first code, and
+// returns the recovery codes, which can not be retrieved again
+func (ms *MerchantService) ConfirmTOTP(id uuid.UUID, code string) ([]string, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return nil, err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.ConfirmTOTPRequest{
+		MerchantId: id.String(),
+		Code:       code,
+	}
+	resp, err := merchantClient.ConfirmTOTP(ms.ctx, req)
+	if err != nil {
+		return nil, totpError("confirming totp", err)
+	}
+
+	return resp.RecoveryCodes, nil
+}
+
+// DisableTOTP turns the TOTP of a merchant off, given a TOTP or recovery
+// code
+func (ms *MerchantService) DisableTOTP(id uuid.UUID, code string) error {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.DisableTOTPRequest{
+		MerchantId: id.String(),
+		Code:       code,
+	}
+	_, err = merchantClient.DisableTOTP(ms.ctx, req)
+	if err != nil {
+		return totpError("disabling totp", err)
+	}
+
+	return nil
+}
+
+// totpError maps the codes of the TOTP rpcs to errors
+func totpError(action string, err error) error {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return ErrInvalidCode
+	case codes.FailedPrecondition:
+		return ErrTOTPNotEnrolled
+	case codes.AlreadyExists:
+		return ErrTOTPAlreadyEnabled
+	}
+	log.Printf("error %s: %v", action, err)
+	return err
+}
+
+// Active tells if a merchant is active
+func (ms *MerchantService) Active(id uuid.UUID) (bool, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return false, err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.MerchantActiveRequest{
+		Id: id.String(),
+	}
+	resp, err := merchantClient.MerchantActive(ms.ctx, req)
+	if err != nil {
+		log.Printf("error checking merchant active: %v", err)
+		return false, err
 	}
 
-	return id, true
+	return resp.Active, nil
 }
 
 func (ms *MerchantService) Get(id uuid.UUID) (entities.Merchant, error) {
@@ -88,3 +358,522 @@ func (ms *MerchantService) Get(id uuid.UUID) (entities.Merchant, error) {
 		MaxQPS:   int(resp.MaxQps),
 	}, nil
 }
+
+// SetWebhook registers the webhook of a merchant, empty url and secret remove
+// it
+func (ms *MerchantService) SetWebhook(id uuid.UUID, url, secret string) error {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.SetWebhookRequest{
+		Id:     id.String(),
+		Url:    url,
+		Secret: secret,
+	}
+	_, err = merchantClient.SetWebhook(ms.ctx, req)
+	if status.Code(err) == codes.InvalidArgument {
+		return fmt.Errorf("%w: %s", ErrInvalidWebhook, status.Convert(err).Message())
+	}
+	if err != nil {
+		log.Printf("error setting webhook: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (ms *MerchantService) GetWebhook(id uuid.UUID) (entities.Webhook, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return entities.Webhook{}, err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.GetWebhookRequest{
+		Id: id.String(),
+	}
+	resp, err := merchantClient.GetWebhook(ms.ctx, req)
+	if err != nil {
+		log.Printf("error getting webhook: %v", err)
+		return entities.Webhook{}, err
+	}
+
+	return entities.Webhook{
+		URL:    resp.Url,
+		Secret: resp.Secret,
+	}, nil
+}
+
+// CreateRefreshToken starts a new family of refresh tokens for a login
+func (ms *MerchantService) CreateRefreshToken(login Login, ttl time.Duration) (string, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return "", err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.CreateRefreshTokenRequest{
+		MerchantId: login.MerchantID.String(),
+		TtlSeconds: int64(ttl / time.Second),
+	}
+	if login.UserID != uuid.Nil {
+		req.UserId = login.UserID.String()
+	}
+	resp, err := merchantClient.CreateRefreshToken(ms.ctx, req)
+	if status.Code(err) == codes.PermissionDenied {
+		if login.UserID != uuid.Nil {
+			return "", ErrUserInactive
+		}
+		return "", ErrMerchantInactive
+	}
+	if err != nil {
+		log.Printf("error creating refresh token: %v", err)
+		return "", err
+	}
+
+	return resp.RefreshToken, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one, and returns the
+// login it belongs to, with its roles as of now
+func (ms *MerchantService) RotateRefreshToken(token string, ttl time.Duration) (Login, string, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return Login{}, "", err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.RotateRefreshTokenRequest{
+		RefreshToken: token,
+		TtlSeconds:   int64(ttl / time.Second),
+	}
+	resp, err := merchantClient.RotateRefreshToken(ms.ctx, req)
+	if code := status.Code(err); code == codes.Unauthenticated || code == codes.PermissionDenied {
+		return Login{}, "", fmt.Errorf("%w: %s", ErrInvalidRefreshToken, status.Convert(err).Message())
+	}
+	if err != nil {
+		log.Printf("error rotating refresh token: %v", err)
+		return Login{}, "", err
+	}
+
+	login, err := parseLogin(resp.MerchantId, resp.UserId, resp.Username, resp.Roles)
+	if err != nil {
+		return Login{}, "", err
+	}
+
+	return login, resp.RefreshToken, nil
+}
+
+// RevokeRefreshToken revokes a refresh token and every token rotated from the
+// same login
+func (ms *MerchantService) RevokeRefreshToken(token string) error {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.RevokeRefreshTokenRequest{
+		RefreshToken: token,
+	}
+	_, err = merchantClient.RevokeRefreshToken(ms.ctx, req)
+	if status.Code(err) == codes.NotFound {
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		log.Printf("error revoking refresh token: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAccessToken revokes the access token jti until it expires
+func (ms *MerchantService) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.RevokeAccessTokenRequest{
+		Jti:       jti,
+		ExpiresAt: expiresAt.Unix(),
+	}
+	_, err = merchantClient.RevokeAccessToken(ms.ctx, req)
+	if err != nil {
+		log.Printf("error revoking access token: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// AccessTokenRevoked tells if the access token jti of a merchant, or of
+// userID of the merchant unless nil, was revoked, on its own or with every
+// token of the merchant or user
+func (ms *MerchantService) AccessTokenRevoked(jti string, id, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return true, err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.AccessTokenRevokedRequest{
+		Jti:        jti,
+		MerchantId: id.String(),
+		IssuedAt:   issuedAt.Unix(),
+	}
+	if userID != uuid.Nil {
+		req.UserId = userID.String()
+	}
+	resp, err := merchantClient.AccessTokenRevoked(ms.ctx, req)
+	if err != nil {
+		log.Printf("error checking access token: %v", err)
+		return true, err
+	}
+
+	return resp.Revoked, nil
+}
+
+// CreateAPIKey creates a new api key for a merchant, and returns it along
+// with the key itself, which can not be retrieved again
+func (ms *MerchantService) CreateAPIKey(id uuid.UUID, label string) (entities.APIKey, string, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return entities.APIKey{}, "", err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.CreateAPIKeyRequest{
+		MerchantId: id.String(),
+		Label:      label,
+	}
+	resp, err := merchantClient.CreateAPIKey(ms.ctx, req)
+	if status.Code(err) == codes.InvalidArgument {
+		return entities.APIKey{}, "", fmt.Errorf("%w: %s", ErrInvalidAPIKeyLabel, status.Convert(err).Message())
+	}
+	if err != nil {
+		log.Printf("error creating api key: %v", err)
+		return entities.APIKey{}, "", err
+	}
+
+	k, err := apiKeyFromPb(resp.Key)
+	if err != nil {
+		return entities.APIKey{}, "", err
+	}
+	return k, resp.ApiKey, nil
+}
+
+// ListAPIKeys returns the api keys of a merchant, revoked ones included
+func (ms *MerchantService) ListAPIKeys(id uuid.UUID) ([]entities.APIKey, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return nil, err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.ListAPIKeysRequest{
+		MerchantId: id.String(),
+	}
+	resp, err := merchantClient.ListAPIKeys(ms.ctx, req)
+	if err != nil {
+		log.Printf("error listing api keys: %v", err)
+		return nil, err
+	}
+
+	keys := make([]entities.APIKey, 0, len(resp.Keys))
+	for _, pk := range resp.Keys {
+		k, err := apiKeyFromPb(pk)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// UpdateAPIKeyLabel changes the label of an api key of a merchant
+func (ms *MerchantService) UpdateAPIKeyLabel(id, keyID uuid.UUID, label string) error {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.UpdateAPIKeyLabelRequest{
+		MerchantId: id.String(),
+		Id:         keyID.String(),
+		Label:      label,
+	}
+	_, err = merchantClient.UpdateAPIKeyLabel(ms.ctx, req)
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.NotFound:
+		return ErrUnknownAPIKey
+	case codes.InvalidArgument:
+		return fmt.Errorf("%w: %s", ErrInvalidAPIKeyLabel, status.Convert(err).Message())
+	}
+	log.Printf("error updating api key: %v", err)
+	return err
+}
+
+// RevokeAPIKey revokes an api key of a merchant
+func (ms *MerchantService) RevokeAPIKey(id, keyID uuid.UUID) error {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.RevokeAPIKeyRequest{
+		MerchantId: id.String(),
+		Id:         keyID.String(),
+	}
+	_, err = merchantClient.RevokeAPIKey(ms.ctx, req)
+	if status.Code(err) == codes.NotFound {
+		return ErrUnknownAPIKey
+	}
+	if err != nil {
+		log.Printf("error revoking api key: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ValidateAPIKey returns the login an api key stands for, with the roles of
+// api keys, and the id of the key. It returns ErrMerchantInactive when the
+// merchant is not active
+func (ms *MerchantService) ValidateAPIKey(key string) (Login, uuid.UUID, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return Login{}, uuid.Nil, err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.ValidateAPIKeyRequest{
+		ApiKey: key,
+	}
+	resp, err := merchantClient.ValidateAPIKey(ms.ctx, req)
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.Unauthenticated:
+		return Login{}, uuid.Nil, fmt.Errorf("%w: %s", ErrInvalidAPIKey, status.Convert(err).Message())
+	case codes.PermissionDenied:
+		return Login{}, uuid.Nil, ErrMerchantInactive
+	default:
+		log.Printf("error validating api key: %v", err)
+		return Login{}, uuid.Nil, err
+	}
+
+	login, err := parseLogin(resp.MerchantId, "", resp.Username, resp.Roles)
+	if err != nil {
+		return Login{}, uuid.Nil, err
+	}
+	keyID, err := uuid.Parse(resp.KeyId)
+	if err != nil {
+		log.Printf("error parsing api key id: %v", err)
+		return Login{}, uuid.Nil, err
+	}
+
+	return login, keyID, nil
+}
+
+// CreateUser adds a user to a merchant
+func (ms *MerchantService) CreateUser(id uuid.UUID, username, password string, roles []string) (entities.User, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return entities.User{}, err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.CreateUserRequest{
+		MerchantId: id.String(),
+		Username:   username,
+		Password:   password,
+		Roles:      roles,
+	}
+	resp, err := merchantClient.CreateUser(ms.ctx, req)
+	if err != nil {
+		return entities.User{}, userError("creating user", err)
+	}
+
+	return userFromPb(resp.User)
+}
+
+// ListUsers returns the users of a merchant
+func (ms *MerchantService) ListUsers(id uuid.UUID) ([]entities.User, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return nil, err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.ListUsersRequest{
+		MerchantId: id.String(),
+	}
+	resp, err := merchantClient.ListUsers(ms.ctx, req)
+	if err != nil {
+		log.Printf("error listing users: %v", err)
+		return nil, err
+	}
+
+	users := make([]entities.User, 0, len(resp.Users))
+	for _, pu := range resp.Users {
+		u, err := userFromPb(pu)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+// UpdateUser changes a user of a merchant, nil roles and active, and an
+// empty password, are left untouched
+func (ms *MerchantService) UpdateUser(id, userID uuid.UUID, roles *[]string, active *bool, password string) (entities.User, error) {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return entities.User{}, err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.UpdateUserRequest{
+		MerchantId: id.String(),
+		Id:         userID.String(),
+		Active:     active,
+		Password:   password,
+	}
+	if roles != nil {
+		req.SetRoles = true
+		req.Roles = *roles
+	}
+	resp, err := merchantClient.UpdateUser(ms.ctx, req)
+	if err != nil {
+		return entities.User{}, userError("updating user", err)
+	}
+
+	return userFromPb(resp.User)
+}
+
+// DeleteUser removes a user of a merchant
+func (ms *MerchantService) DeleteUser(id, userID uuid.UUID) error {
+	conn, err := grpc.Dial(ms.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("merchant service is unreachable at address: %s, %v", ms.address, err)
+		return err
+	}
+	defer conn.Close()
+
+	merchantClient := rpcMerchant.NewMerchantServiceClient(conn)
+
+	req := &rpcMerchant.DeleteUserRequest{
+		MerchantId: id.String(),
+		Id:         userID.String(),
+	}
+	_, err = merchantClient.DeleteUser(ms.ctx, req)
+	if err != nil {
+		return userError("deleting user", err)
+	}
+
+	return nil
+}
+
+// userError maps the codes of the user rpcs to errors
+func userError(action string, err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return ErrUnknownUser
+	case codes.AlreadyExists:
+		return ErrUsernameTaken
+	case codes.InvalidArgument:
+		return fmt.Errorf("%w: %s", ErrInvalidUser, status.Convert(err).Message())
+	}
+	log.Printf("error %s: %v", action, err)
+	return err
+}
+
+func userFromPb(pu *rpcMerchant.User) (entities.User, error) {
+	id, err := uuid.Parse(pu.Id)
+	if err != nil {
+		log.Printf("error parsing user id: %v", err)
+		return entities.User{}, err
+	}
+	return entities.User{
+		ID:        id,
+		Username:  pu.Username,
+		Roles:     pu.Roles,
+		Active:    pu.Active,
+		CreatedAt: time.Unix(pu.CreatedAt, 0).UTC(),
+	}, nil
+}
+
+func apiKeyFromPb(pk *rpcMerchant.APIKey) (entities.APIKey, error) {
+	id, err := uuid.Parse(pk.Id)
+	if err != nil {
+		log.Printf("error parsing api key id: %v", err)
+		return entities.APIKey{}, err
+	}
+
+	k := entities.APIKey{
+		ID:        id,
+		Prefix:    pk.Prefix,
+		Label:     pk.Label,
+		CreatedAt: time.Unix(pk.CreatedAt, 0).UTC(),
+	}
+	if pk.RevokedAt != 0 {
+		revokedAt := time.Unix(pk.RevokedAt, 0).UTC()
+		k.RevokedAt = &revokedAt
+	}
+	return k, nil
+}
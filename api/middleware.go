@@ -1,15 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
 	"log"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/api/bankauth"
+	"github.com/thiagolcmelo/payment-gateway/api/entities"
+	"github.com/thiagolcmelo/payment-gateway/api/ledger"
+	"github.com/thiagolcmelo/payment-gateway/api/merchant"
 	"github.com/thiagolcmelo/payment-gateway/api/ratelimiter"
 )
 
@@ -17,11 +27,30 @@ type MerchantClaims struct {
 	Username string    `json:"username"`
 	ID       uuid.UUID `json:"id"`
 	Exp      time.Time `json:"exp"`
+	// UserID is the user of the merchant the token was issued to, nil for
+	// the merchant itself
+	UserID uuid.UUID `json:"user_id"`
+	// Roles limit the routes the token is let into, see requireRole
+	Roles []string `json:"roles"`
+	// APIKeyID is set when the request was made with an api key instead of
+	// an access token, it is never part of a token
+	APIKeyID uuid.UUID `json:"-"`
 	jwt.StandardClaims
 }
 
+// apiKeyPrefix starts every api key, anything else is taken as a jwt
+const apiKeyPrefix = "pk_live_"
+
+// roles of merchant users, as defined by the merchant service
+const (
+	roleAdmin         = "admin"
+	rolePaymentsWrite = "payments:write"
+	rolePaymentsRead  = "payments:read"
+	roleRefunds       = "refunds"
+)
+
 func (mc MerchantClaims) valid() bool {
-	if mc.ID == uuid.Nil {
+	if mc.ID == uuid.Nil || mc.Id == "" {
 		return false
 	}
 
@@ -47,10 +76,13 @@ func authMiddleware(c *gin.Context) {
 		return
 	}
 
+	if strings.HasPrefix(tokenString, apiKeyPrefix) {
+		apiKeyAuth(c, tokenString)
+		return
+	}
+
 	var claims MerchantClaims
-	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &claims, tokenKeys.Keyfunc)
 
 	if err != nil {
 		log.Printf("err: %v", err)
@@ -58,62 +90,261 @@ func authMiddleware(c *gin.Context) {
 		return
 	}
 
-	if claims.valid() && token.Valid {
-		c.Set("claims", claims)
-		c.Next()
-	} else {
+	if !claims.valid() || !token.Valid {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	active, err := merchantStatus.Active(claims.ID)
+	if err != nil {
+		log.Printf("could not check merchant status: %v", err)
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+		return
+	}
+	if !active {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "merchant is not active"})
+		return
+	}
+
+	revoked, err := merchantStatus.AccessTokenRevoked(claims.Id, claims.ID, claims.UserID, time.Unix(claims.IssuedAt, 0))
+	if err != nil {
+		log.Printf("could not check token revocation: %v", err)
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+		return
+	}
+	if revoked {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+		return
+	}
+
+	c.Set("claims", claims)
+	c.Next()
+}
+
+// apiKeyAuth lets a request made with an api key in, with the same claims an
+// access token of its merchant would carry, the key is checked on every
+// request so a revoked key stops working right away
+func apiKeyAuth(c *gin.Context, key string) {
+	ms := merchant.NewMerchantService(c, merchantAddress)
+	login, keyID, err := ms.ValidateAPIKey(key)
+	switch {
+	case errors.Is(err, merchant.ErrInvalidAPIKey):
+		log.Printf("refusing api key: %v", err)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+		return
+	case errors.Is(err, merchant.ErrMerchantInactive):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "merchant is not active"})
+		return
+	case err != nil:
+		log.Printf("could not validate api key: %v", err)
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+		return
+	}
+
+	now := time.Now()
+	c.Set("claims", MerchantClaims{
+		Username: login.Username,
+		ID:       login.MerchantID,
+		Exp:      now,
+		Roles:    login.Roles,
+		APIKeyID: keyID,
+		StandardClaims: jwt.StandardClaims{
+			Id:       keyID.String(),
+			IssuedAt: now.Unix(),
+		},
+	})
+	c.Next()
+}
+
+// tokenOnlyMiddleware refuses requests made with an api key, for endpoints
+// that manage the login itself, so a leaked key can not mint more keys
+func tokenOnlyMiddleware(c *gin.Context) {
+	claims := c.MustGet("claims").(MerchantClaims)
+	if claims.APIKeyID != uuid.Nil {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this endpoint requires an access token, not an api key"})
+		return
+	}
+	c.Next()
+}
+
+// ownerOnlyMiddleware refuses requests of users of a merchant, for endpoints
+// that manage the login of the merchant itself
+func ownerOnlyMiddleware(c *gin.Context) {
+	claims := c.MustGet("claims").(MerchantClaims)
+	if claims.UserID != uuid.Nil {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this endpoint requires the login of the merchant"})
+		return
+	}
+	c.Next()
+}
+
+// requireRole refuses requests whose claims have none of roles, admin is let
+// in everywhere
+func requireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("claims").(MerchantClaims)
+		for _, have := range claims.Roles {
+			if have == roleAdmin {
+				c.Next()
+				return
+			}
+			for _, want := range roles {
+				if have == want {
+					c.Next()
+					return
+				}
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing role", "required_roles": roles})
 	}
 }
 
 func rateLimitMiddleware(c *gin.Context) {
 	claims := c.MustGet("claims").(MerchantClaims)
 	rls := ratelimiter.NewRateLimiterService(c, rateLimiterAddress, true)
-	if !rls.Allow(claims.ID) {
+	d, err := rls.Allow(claims.ID)
+	// there is nothing to tell when the rate limiter did not answer
+	if err == nil {
+		c.Header("RateLimit-Limit", strconv.FormatInt(d.Limit, 10))
+		c.Header("RateLimit-Remaining", strconv.FormatInt(d.Remaining, 10))
+		c.Header("RateLimit-Reset", strconv.FormatInt(ceilSeconds(d.Reset), 10))
+	}
+	if !d.Allow {
+		// a merchant without any limit is not told to never come back, its
+		// limit may be raised
+		retryAfter := ceilSeconds(d.RetryAfter)
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
 		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
 	} else {
 		c.Next()
 	}
 }
 
-func restrictMiddleware(ipToEnable string) func(c *gin.Context) {
+// ceilSeconds rounds d up to whole seconds, the unit of the rate limit
+// headers, so clients waiting for them are never early
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+// ledgerRecordKey is set by handlers once a payment or refund exists in the
+// ledger, from that point a failed request must not be retried as a new one
+const ledgerRecordKey = "ledger_record"
+
+// responseRecorder keeps a copy of the response body, so it can be stored
+// for idempotent replays
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// idempotencyMiddleware makes retries of a request sent with the same
+// Idempotency-Key header get the original response instead of being
+// processed again, keys are scoped per merchant
+func idempotencyMiddleware(c *gin.Context) {
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		c.Next()
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		log.Printf("could not read request body: %v", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "could not read request body"})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	hash.Write(body)
+	requestHash := hex.EncodeToString(hash.Sum(nil))
+
+	claims := c.MustGet("claims").(MerchantClaims)
+	ls := ledger.NewLedgerService(c, ledgerAddress)
+	req, err := ls.StartIdempotentRequest(claims.ID, key, requestHash)
+	if err != nil {
+		log.Printf("could not start idempotent request: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "could not check idempotency key"})
+		return
+	}
+
+	switch req.State {
+	case entities.Completed:
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(req.ResponseStatus, "application/json; charset=utf-8", req.ResponseBody)
+		c.Abort()
+		return
+	case entities.InProgress:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this idempotency key is in progress"})
+		return
+	case entities.Mismatch:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "idempotency key was already used with a different request"})
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+	c.Next()
+
+	// requests refused or failed before anything was recorded in the ledger,
+	// like a payment over its quota, are safe to retry, so the key is freed
+	// instead of replaying the error once it may not apply anymore
+	if recorder.Status() >= http.StatusBadRequest && !c.GetBool(ledgerRecordKey) {
+		if err := ls.ReleaseIdempotentRequest(claims.ID, key); err != nil {
+			log.Printf("could not release idempotency key %s: %v", key, err)
+		}
+		return
+	}
+
+	if err := ls.CompleteIdempotentRequest(claims.ID, key, recorder.Status(), recorder.body.Bytes()); err != nil {
+		log.Printf("could not complete idempotency key %s: %v", key, err)
+	}
+}
+
+// bankCallbackMiddleware only lets through requests signed by a configured
+// bank, and from its networks when they are configured
+func bankCallbackMiddleware(v *bankauth.Verifier) func(c *gin.Context) {
 	return func(c *gin.Context) {
-		ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+		host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
 		if err != nil {
 			log.Printf("error getting client ip: %v", err)
 			c.AbortWithStatus(http.StatusInternalServerError)
 			return
 		}
-		log.Printf("ip=%s, expected=%s", ip, ipToEnable)
-		if ip != ipToEnable {
-			log.Printf("unauthorized ip: %s", ip)
-			c.AbortWithStatus(http.StatusUnauthorized)
+		if err := v.Allowed(net.ParseIP(host)); err != nil {
+			log.Printf("refusing bank callback from %s: %v", host, err)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error(), "acknowledge": false})
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			log.Printf("could not read request body: %v", err)
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "could not read request body", "acknowledge": false})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		keyID, err := v.Verify(c.GetHeader(bankauth.SignatureHeader), body, time.Now())
+		if err != nil {
+			log.Printf("refusing bank callback from %s with key %q: %v", host, keyID, err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "acknowledge": false})
 			return
 		}
 		c.Next()
 	}
-
-	// allowedNetwork := "10.123.123.0/30" // Specify the network address and mask
-	// // Get the client's IP address
-	// ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
-	// if err != nil {
-	// 	log.Printf("error getting client ip: %v", err)
-	// 	c.AbortWithStatus(http.StatusInternalServerError)
-	// 	return
-	// }
-	// // Check if the client's IP is within the allowed network
-	// _, allowedCIDR, err := net.ParseCIDR(allowedNetwork)
-	// if err != nil {
-	// 	log.Printf("error verifying ip: %v", err)
-	// 	c.AbortWithStatus(http.StatusInternalServerError)
-	// 	return
-	// }
-	// clientIP := net.ParseIP(ip)
-	// if allowedCIDR.Contains(clientIP) {
-	// 	// IP is allowed, proceed to the next handler
-	// 	c.Next()
-	// } else {
-	// 	// IP is not allowed, return a Forbidden response
-	// 	c.AbortWithStatus(http.StatusForbidden)
-	// }
 }
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/api/jwtkeys"
+	"github.com/thiagolcmelo/payment-gateway/api/merchant"
+	rpcLedger "github.com/thiagolcmelo/payment-gateway/ledger/pb"
+	rpcMerchant "github.com/thiagolcmelo/payment-gateway/merchant/pb"
+	rpcRateLimiter "github.com/thiagolcmelo/payment-gateway/ratelimiter/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serveGRPC starts a grpc server on a random local port with whatever
+// register adds to it, and returns its address
+func serveGRPC(t *testing.T, register func(*grpc.Server)) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	register(s)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	return listener.Addr().String()
+}
+
+// idempotentRequest is a key as kept by fakeLedger
+type idempotentRequest struct {
+	hash      string
+	completed bool
+	status    int32
+	body      []byte
+}
+
+// fakeLedger keeps idempotency keys the way the ledger does
+type fakeLedger struct {
+	rpcLedger.UnimplementedLedgerServiceServer
+	mu       sync.Mutex
+	requests map[string]idempotentRequest
+}
+
+func newFakeLedger(t *testing.T) *fakeLedger {
+	t.Helper()
+
+	fl := &fakeLedger{requests: make(map[string]idempotentRequest)}
+	ledgerAddress = serveGRPC(t, func(s *grpc.Server) {
+		rpcLedger.RegisterLedgerServiceServer(s, fl)
+	})
+	return fl
+}
+
+func (fl *fakeLedger) StartIdempotentRequest(ctx context.Context, req *rpcLedger.StartIdempotentRequestRequest) (*rpcLedger.StartIdempotentRequestResponse, error) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	id := req.MerchantId + "/" + req.Key
+	existing, ok := fl.requests[id]
+	switch {
+	case !ok:
+		fl.requests[id] = idempotentRequest{hash: req.RequestHash}
+		return &rpcLedger.StartIdempotentRequestResponse{State: rpcLedger.IdempotentRequestState_STARTED}, nil
+	case existing.hash != req.RequestHash:
+		return &rpcLedger.StartIdempotentRequestResponse{State: rpcLedger.IdempotentRequestState_MISMATCH}, nil
+	case !existing.completed:
+		return &rpcLedger.StartIdempotentRequestResponse{State: rpcLedger.IdempotentRequestState_IN_PROGRESS}, nil
+	default:
+		return &rpcLedger.StartIdempotentRequestResponse{
+			State:          rpcLedger.IdempotentRequestState_COMPLETED,
+			ResponseStatus: existing.status,
+			ResponseBody:   existing.body,
+		}, nil
+	}
+}
+
+func (fl *fakeLedger) CompleteIdempotentRequest(ctx context.Context, req *rpcLedger.CompleteIdempotentRequestRequest) (*rpcLedger.CompleteIdempotentRequestResponse, error) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	id := req.MerchantId + "/" + req.Key
+	existing, ok := fl.requests[id]
+	if !ok || existing.completed {
+		return nil, status.Error(codes.FailedPrecondition, "idempotent request is not in progress")
+	}
+	fl.requests[id] = idempotentRequest{hash: existing.hash, completed: true, status: req.ResponseStatus, body: req.ResponseBody}
+	return &rpcLedger.CompleteIdempotentRequestResponse{}, nil
+}
+
+func (fl *fakeLedger) ReleaseIdempotentRequest(ctx context.Context, req *rpcLedger.ReleaseIdempotentRequestRequest) (*rpcLedger.ReleaseIdempotentRequestResponse, error) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	id := req.MerchantId + "/" + req.Key
+	existing, ok := fl.requests[id]
+	if !ok || existing.completed {
+		return nil, status.Error(codes.FailedPrecondition, "idempotent request is not in progress")
+	}
+	delete(fl.requests, id)
+	return &rpcLedger.ReleaseIdempotentRequestResponse{}, nil
+}
+
+// withClaims stands in for authMiddleware
+func withClaims(claims MerchantClaims) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	newFakeLedger(t)
+	claims := MerchantClaims{ID: uuid.New()}
+
+	// the handler answers with the next of responses on every call, and can
+	// send another request with the same key while it is running
+	type response struct {
+		status       int
+		body         string
+		ledgerRecord bool
+	}
+	var (
+		router    = gin.New()
+		responses []response
+		calls     int
+		during    func()
+	)
+	router.POST("/payment", withClaims(claims), idempotencyMiddleware, func(c *gin.Context) {
+		r := responses[calls]
+		calls++
+		if during != nil {
+			during()
+		}
+		if r.ledgerRecord {
+			c.Set(ledgerRecordKey, true)
+		}
+		c.Data(r.status, "application/json; charset=utf-8", []byte(r.body))
+	})
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/payment", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	type attempt struct {
+		key              string
+		body             string
+		expectedStatus   int
+		expectedBody     string
+		expectedReplayed bool
+	}
+
+	type testCase struct {
+		testName      string
+		responses     []response
+		attempts      []attempt
+		expectedCalls int
+	}
+
+	testCases := []testCase{
+		{
+			testName:  "without_key_every_request_is_processed",
+			responses: []response{{status: 200, body: `{"id":"1"}`}, {status: 200, body: `{"id":"2"}`}},
+			attempts: []attempt{
+				{body: `{"amount":1}`, expectedStatus: 200, expectedBody: `{"id":"1"}`},
+				{body: `{"amount":1}`, expectedStatus: 200, expectedBody: `{"id":"2"}`},
+			},
+			expectedCalls: 2,
+		},
+		{
+			testName:  "retry_is_replayed",
+			responses: []response{{status: 200, body: `{"id":"1"}`}},
+			attempts: []attempt{
+				{key: "replay", body: `{"amount":1}`, expectedStatus: 200, expectedBody: `{"id":"1"}`},
+				{key: "replay", body: `{"amount":1}`, expectedStatus: 200, expectedBody: `{"id":"1"}`, expectedReplayed: true},
+			},
+			expectedCalls: 1,
+		},
+		{
+			testName:  "client_error_before_ledger_releases_key",
+			responses: []response{{status: 429, body: `{"error":"quota exceeded"}`}, {status: 200, body: `{"id":"1"}`}},
+			attempts: []attempt{
+				{key: "quota", body: `{"amount":1}`, expectedStatus: 429, expectedBody: `{"error":"quota exceeded"}`},
+				{key: "quota", body: `{"amount":1}`, expectedStatus: 200, expectedBody: `{"id":"1"}`},
+				{key: "quota", body: `{"amount":1}`, expectedStatus: 200, expectedBody: `{"id":"1"}`, expectedReplayed: true},
+			},
+			expectedCalls: 2,
+		},
+		{
+			testName:  "client_error_after_ledger_is_replayed",
+			responses: []response{{status: 409, body: `{"error":"conflict"}`, ledgerRecord: true}},
+			attempts: []attempt{
+				{key: "recorded-conflict", body: `{"amount":1}`, expectedStatus: 409, expectedBody: `{"error":"conflict"}`},
+				{key: "recorded-conflict", body: `{"amount":1}`, expectedStatus: 409, expectedBody: `{"error":"conflict"}`, expectedReplayed: true},
+			},
+			expectedCalls: 1,
+		},
+		{
+			testName:  "other_request_with_same_key_is_refused",
+			responses: []response{{status: 200, body: `{"id":"1"}`}},
+			attempts: []attempt{
+				{key: "mismatch", body: `{"amount":1}`, expectedStatus: 200, expectedBody: `{"id":"1"}`},
+				{key: "mismatch", body: `{"amount":2}`, expectedStatus: http.StatusConflict},
+			},
+			expectedCalls: 1,
+		},
+		{
+			testName:  "server_error_before_ledger_releases_key",
+			responses: []response{{status: 500, body: `{}`}, {status: 200, body: `{"id":"1"}`}},
+			attempts: []attempt{
+				{key: "release", body: `{"amount":1}`, expectedStatus: 500, expectedBody: `{}`},
+				{key: "release", body: `{"amount":1}`, expectedStatus: 200, expectedBody: `{"id":"1"}`},
+				{key: "release", body: `{"amount":1}`, expectedStatus: 200, expectedBody: `{"id":"1"}`, expectedReplayed: true},
+			},
+			expectedCalls: 2,
+		},
+		{
+			testName:  "server_error_after_ledger_is_replayed",
+			responses: []response{{status: 500, body: `{}`, ledgerRecord: true}},
+			attempts: []attempt{
+				{key: "recorded", body: `{"amount":1}`, expectedStatus: 500, expectedBody: `{}`},
+				{key: "recorded", body: `{"amount":1}`, expectedStatus: 500, expectedBody: `{}`, expectedReplayed: true},
+			},
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			responses, calls, during = tc.responses, 0, nil
+
+			for i, a := range tc.attempts {
+				w := send(a.key, a.body)
+				if w.Code != a.expectedStatus {
+					t.Errorf("attempt %d: expected status %d, got %d", i, a.expectedStatus, w.Code)
+				}
+				if a.expectedBody != "" && w.Body.String() != a.expectedBody {
+					t.Errorf("attempt %d: expected body %s, got %s", i, a.expectedBody, w.Body.String())
+				}
+				if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != a.expectedReplayed {
+					t.Errorf("attempt %d: expected replayed=%v, got %v", i, a.expectedReplayed, replayed)
+				}
+			}
+			if calls != tc.expectedCalls {
+				t.Errorf("expected %d calls to the handler, got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+
+	t.Run("retry_while_in_progress_is_refused", func(t *testing.T) {
+		responses, calls = []response{{status: 200, body: `{"id":"1"}`}}, 0
+
+		var concurrent *httptest.ResponseRecorder
+		during = func() {
+			concurrent = send("in-progress", `{"amount":1}`)
+		}
+		if w := send("in-progress", `{"amount":1}`); w.Code != 200 {
+			t.Errorf("expected status 200, got %d", w.Code)
+		}
+		if concurrent.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d", http.StatusConflict, concurrent.Code)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call to the handler, got %d", calls)
+		}
+	})
+}
+
+// fakeRateLimiter answers every Allow with resp, or err
+type fakeRateLimiter struct {
+	rpcRateLimiter.UnimplementedRateLimiterServiceServer
+	resp *rpcRateLimiter.AllowResponse
+	err  error
+}
+
+func newFakeRateLimiter(t *testing.T) *fakeRateLimiter {
+	t.Helper()
+
+	frl := &fakeRateLimiter{resp: &rpcRateLimiter.AllowResponse{Allow: true, Limit: 10, Remaining: 9}}
+	rateLimiterAddress = serveGRPC(t, func(s *grpc.Server) {
+		rpcRateLimiter.RegisterRateLimiterServiceServer(s, frl)
+	})
+	return frl
+}
+
+func (frl *fakeRateLimiter) Allow(ctx context.Context, req *rpcRateLimiter.AllowRequest) (*rpcRateLimiter.AllowResponse, error) {
+	return frl.resp, frl.err
+}
+
+// fakeMerchants knows the api keys in keys, by the key itself
+type fakeMerchants struct {
+	rpcMerchant.UnimplementedMerchantServiceServer
+	keys map[string]*rpcMerchant.ValidateAPIKeyResponse
+}
+
+func (fm *fakeMerchants) ValidateAPIKey(ctx context.Context, req *rpcMerchant.ValidateAPIKeyRequest) (*rpcMerchant.ValidateAPIKeyResponse, error) {
+	resp, ok := fm.keys[req.ApiKey]
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unknown api key")
+	}
+	return resp, nil
+}
+
+// activeMerchants says every merchant is active and no token is revoked
+type activeMerchants struct{}
+
+func (activeMerchants) Active(id uuid.UUID) (bool, error) {
+	return true, nil
+}
+
+func (activeMerchants) AccessTokenRevoked(jti string, id, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	return false, nil
+}
+
+func TestRequireRole(t *testing.T) {
+	key, err := jwtkeys.GenerateKey("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenKeys, err = jwtkeys.NewKeySet([]jwtkeys.Key{key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	merchantStatus = merchant.NewStatusCache(activeMerchants{}, time.Minute)
+	newFakeRateLimiter(t)
+
+	merchantID := uuid.New()
+	fm := &fakeMerchants{keys: make(map[string]*rpcMerchant.ValidateAPIKeyResponse)}
+	merchantAddress = serveGRPC(t, func(s *grpc.Server) {
+		rpcMerchant.RegisterMerchantServiceServer(s, fm)
+	})
+
+	token := func(userID uuid.UUID, roles ...string) string {
+		now := time.Now()
+		signed, err := tokenKeys.Sign(MerchantClaims{
+			Username: "merchant0",
+			ID:       merchantID,
+			Exp:      now.Add(time.Minute),
+			UserID:   userID,
+			Roles:    roles,
+			StandardClaims: jwt.StandardClaims{
+				Id:        uuid.NewString(),
+				IssuedAt:  now.Unix(),
+				ExpiresAt: now.Add(time.Minute).Unix(),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	apiKey := func(roles ...string) string {
+		k := apiKeyPrefix + uuid.NewString()
+		fm.keys[k] = &rpcMerchant.ValidateAPIKeyResponse{
+			MerchantId: merchantID.String(),
+			KeyId:      uuid.NewString(),
+			Username:   "merchant0",
+			Roles:      roles,
+		}
+		return k
+	}
+
+	router := gin.New()
+	registerRoutes(router, nil)
+
+	// every handler below refuses its request right away, so reaching it
+	// shows as its own status instead of 403
+	type route struct {
+		method        string
+		path          string
+		body          string
+		reachedStatus int
+	}
+	var (
+		createPayment = route{http.MethodPost, "/payment", `{}`, http.StatusBadRequest}
+		readPayment   = route{http.MethodGet, "/payment/unknown", "", http.StatusBadRequest}
+		createRefund  = route{http.MethodPost, "/payment/unknown/refunds", "", http.StatusBadRequest}
+		setWebhook    = route{http.MethodPut, "/webhook", "", http.StatusBadRequest}
+		updateAPIKey  = route{http.MethodPatch, "/api-keys/unknown", "", http.StatusNotFound}
+	)
+
+	type testCase struct {
+		testName  string
+		auth      string
+		reached   []route
+		forbidden []route
+	}
+
+	testCases := []testCase{
+		{
+			testName: "owner",
+			auth:     token(uuid.Nil, roleAdmin),
+			reached:  []route{createPayment, readPayment, createRefund, setWebhook, updateAPIKey},
+		},
+		{
+			testName: "admin_user",
+			auth:     token(uuid.New(), roleAdmin),
+			reached:  []route{createPayment, readPayment, createRefund, setWebhook, updateAPIKey},
+		},
+		{
+			testName:  "payments_read_user",
+			auth:      token(uuid.New(), rolePaymentsRead),
+			reached:   []route{readPayment},
+			forbidden: []route{createPayment, createRefund, setWebhook, updateAPIKey},
+		},
+		{
+			testName:  "payments_write_user",
+			auth:      token(uuid.New(), rolePaymentsWrite),
+			reached:   []route{createPayment},
+			forbidden: []route{readPayment, createRefund, setWebhook, updateAPIKey},
+		},
+		{
+			testName:  "refunds_user",
+			auth:      token(uuid.New(), roleRefunds),
+			reached:   []route{createRefund},
+			forbidden: []route{createPayment, readPayment, setWebhook, updateAPIKey},
+		},
+		{
+			testName:  "user_without_roles",
+			auth:      token(uuid.New()),
+			forbidden: []route{createPayment, readPayment, createRefund, setWebhook, updateAPIKey},
+		},
+		{
+			testName:  "payments_read_api_key",
+			auth:      apiKey(rolePaymentsRead),
+			reached:   []route{readPayment},
+			forbidden: []route{createPayment, createRefund, setWebhook, updateAPIKey},
+		},
+		{
+			testName:  "payments_api_key",
+			auth:      apiKey(rolePaymentsRead, rolePaymentsWrite),
+			reached:   []route{createPayment, readPayment},
+			forbidden: []route{createRefund, setWebhook, updateAPIKey},
+		},
+		{
+			// api keys never manage the login nor the webhook, whatever
+			// their roles
+			testName:  "admin_api_key",
+			auth:      apiKey(roleAdmin),
+			reached:   []route{createPayment, readPayment, createRefund},
+			forbidden: []route{setWebhook, updateAPIKey},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			send := func(r route) int {
+				req := httptest.NewRequest(r.method, r.path, strings.NewReader(r.body))
+				req.Header.Set("Authorization", "Bearer "+tc.auth)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				return w.Code
+			}
+
+			for _, r := range tc.reached {
+				if got := send(r); got != r.reachedStatus {
+					t.Errorf("%s %s: expected %d, got %d", r.method, r.path, r.reachedStatus, got)
+				}
+			}
+			for _, r := range tc.forbidden {
+				if got := send(r); got != http.StatusForbidden {
+					t.Errorf("%s %s: expected %d, got %d", r.method, r.path, http.StatusForbidden, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	frl := newFakeRateLimiter(t)
+
+	router := gin.New()
+	router.GET("/payments", withClaims(MerchantClaims{ID: uuid.New()}), rateLimitMiddleware, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	type testCase struct {
+		testName        string
+		resp            *rpcRateLimiter.AllowResponse
+		err             error
+		expectedStatus  int
+		expectedHeaders map[string]string
+	}
+
+	testCases := []testCase{
+		{
+			testName:       "allowed",
+			resp:           &rpcRateLimiter.AllowResponse{Allow: true, Limit: 10, Remaining: 9, ResetMs: 1500},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "9",
+				"RateLimit-Reset":     "2",
+				"Retry-After":         "",
+			},
+		},
+		{
+			testName:       "denied",
+			resp:           &rpcRateLimiter.AllowResponse{Allow: false, Limit: 10, Remaining: 0, ResetMs: 10000, RetryAfterMs: 2500},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "10",
+				"Retry-After":         "3",
+			},
+		},
+		{
+			testName:       "denied_for_less_than_a_second",
+			resp:           &rpcRateLimiter.AllowResponse{Allow: false, Limit: 10, Remaining: 0, ResetMs: 300, RetryAfterMs: 300},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedHeaders: map[string]string{
+				"RateLimit-Reset": "1",
+				"Retry-After":     "1",
+			},
+		},
+		{
+			testName:       "denied_without_limit",
+			resp:           &rpcRateLimiter.AllowResponse{Allow: false},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "0",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "0",
+				"Retry-After":         "1",
+			},
+		},
+		{
+			// the middleware fails open, and has no numbers to tell
+			testName:       "rate_limiter_error",
+			err:            status.Error(codes.Unavailable, "unavailable"),
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "",
+				"RateLimit-Remaining": "",
+				"RateLimit-Reset":     "",
+				"Retry-After":         "",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			frl.resp, frl.err = tc.resp, tc.err
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/payments", nil))
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			for header, expected := range tc.expectedHeaders {
+				if got := w.Header().Get(header); got != expected {
+					t.Errorf("expected %s=%q, got %q", header, expected, got)
+				}
+			}
+		})
+	}
+}
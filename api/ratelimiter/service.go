@@ -3,6 +3,7 @@ package ratelimiter
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	rpcRateLimiter "github.com/thiagolcmelo/payment-gateway/ratelimiter/pb"
@@ -16,6 +17,20 @@ type RateLimiterService struct {
 	failOpen bool
 }
 
+// Decision is the answer of the rate limiter to one request
+type Decision struct {
+	Allow bool
+	// Limit is how many requests fit at once
+	Limit int64
+	// Remaining is how many more requests would be allowed right now
+	Remaining int64
+	// Reset is how long until the whole Limit is available again
+	Reset time.Duration
+	// RetryAfter is how long until a request is allowed, only set when this
+	// one was not
+	RetryAfter time.Duration
+}
+
 func NewRateLimiterService(ctx context.Context, address string, failOpen bool) *RateLimiterService {
 	return &RateLimiterService{
 		ctx:      ctx,
@@ -24,14 +39,14 @@ func NewRateLimiterService(ctx context.Context, address string, failOpen bool) *
 	}
 }
 
-func (rls *RateLimiterService) Allow(id uuid.UUID) bool {
-	// fail open
-	failOpen := true
-
+// Allow asks the rate limiter whether the merchant id may make one more
+// request. When the rate limiter can not answer, the error is returned
+// along with a decision that only tells whether to fail open
+func (rls *RateLimiterService) Allow(id uuid.UUID) (Decision, error) {
 	conn, err := grpc.Dial(rls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		log.Printf("rate limiter service is unreachable at address: %s, %v", rls.address, err)
-		return failOpen
+		return Decision{Allow: rls.failOpen}, err
 	}
 	defer conn.Close()
 
@@ -43,7 +58,135 @@ func (rls *RateLimiterService) Allow(id uuid.UUID) bool {
 	resp, err := rateLimiterClient.Allow(rls.ctx, req)
 	if err != nil {
 		log.Printf("error rate limiting merchant: %v", err)
-		return failOpen
+		return Decision{Allow: rls.failOpen}, err
+	}
+	return Decision{
+		Allow:      resp.Allow,
+		Limit:      resp.Limit,
+		Remaining:  resp.Remaining,
+		Reset:      time.Duration(resp.ResetMs) * time.Millisecond,
+		RetryAfter: time.Duration(resp.RetryAfterMs) * time.Millisecond,
+	}, nil
+}
+
+// QuotaUsage is how much of a quota of a merchant its current period used
+type QuotaUsage struct {
+	// Period is "day" or "month"
+	Period string
+	// Currency is empty for quotas counting payments in any currency
+	Currency       string
+	MaxCount       int64
+	MaxAmountMinor int64
+	Count          int64
+	AmountMinor    int64
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+}
+
+// ConsumeDecision is the answer of the rate limiter to one payment
+type ConsumeDecision struct {
+	Allow bool
+	// Exceeded is the quota the payment did not fit in, only set when it is
+	// not allowed
+	Exceeded QuotaUsage
+	// ConsumedAt is when the payment was counted, zero when it was not
+	ConsumedAt time.Time
+}
+
+// Consume asks the rate limiter to count a payment of amountMinor in
+// currency against the quotas of the merchant id. When the rate limiter can
+// not answer, the error is returned along with a decision that only tells
+// whether to fail open
+func (rls *RateLimiterService) Consume(id uuid.UUID, currency string, amountMinor int64) (ConsumeDecision, error) {
+	conn, err := grpc.Dial(rls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("rate limiter service is unreachable at address: %s, %v", rls.address, err)
+		return ConsumeDecision{Allow: rls.failOpen}, err
+	}
+	defer conn.Close()
+
+	rateLimiterClient := rpcRateLimiter.NewRateLimiterServiceClient(conn)
+
+	req := &rpcRateLimiter.ConsumeRequest{
+		Id:          id.String(),
+		Currency:    currency,
+		AmountMinor: amountMinor,
+	}
+	resp, err := rateLimiterClient.Consume(rls.ctx, req)
+	if err != nil {
+		log.Printf("error counting payment of merchant: %v", err)
+		return ConsumeDecision{Allow: rls.failOpen}, err
+	}
+
+	d := ConsumeDecision{Allow: resp.Allow}
+	if resp.Exceeded != nil {
+		d.Exceeded = quotaUsageFromPb(resp.Exceeded)
+	}
+	if resp.ConsumedAt != 0 {
+		d.ConsumedAt = time.Unix(resp.ConsumedAt, 0)
+	}
+	return d, nil
+}
+
+// Release gives back to the quotas of the merchant id a payment of
+// amountMinor in currency, counted at consumedAt, that did not happen
+func (rls *RateLimiterService) Release(id uuid.UUID, currency string, amountMinor int64, consumedAt time.Time) error {
+	conn, err := grpc.Dial(rls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("rate limiter service is unreachable at address: %s, %v", rls.address, err)
+		return err
+	}
+	defer conn.Close()
+
+	rateLimiterClient := rpcRateLimiter.NewRateLimiterServiceClient(conn)
+
+	req := &rpcRateLimiter.ReleaseRequest{
+		Id:          id.String(),
+		Currency:    currency,
+		AmountMinor: amountMinor,
+		ConsumedAt:  consumedAt.Unix(),
+	}
+	_, err = rateLimiterClient.Release(rls.ctx, req)
+	if err != nil {
+		log.Printf("error releasing payment of merchant: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Usage returns how much of each quota of the merchant id was used
+func (rls *RateLimiterService) Usage(id uuid.UUID) ([]QuotaUsage, error) {
+	conn, err := grpc.Dial(rls.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("rate limiter service is unreachable at address: %s, %v", rls.address, err)
+		return nil, err
+	}
+	defer conn.Close()
+
+	rateLimiterClient := rpcRateLimiter.NewRateLimiterServiceClient(conn)
+
+	resp, err := rateLimiterClient.Usage(rls.ctx, &rpcRateLimiter.UsageRequest{Id: id.String()})
+	if err != nil {
+		log.Printf("error reading usage of merchant: %v", err)
+		return nil, err
+	}
+
+	usage := make([]QuotaUsage, 0, len(resp.Usage))
+	for _, u := range resp.Usage {
+		usage = append(usage, quotaUsageFromPb(u))
+	}
+	return usage, nil
+}
+
+func quotaUsageFromPb(u *rpcRateLimiter.QuotaUsage) QuotaUsage {
+	return QuotaUsage{
+		Period:         u.Period,
+		Currency:       u.Currency,
+		MaxCount:       u.MaxCount,
+		MaxAmountMinor: u.MaxAmountMinor,
+		Count:          u.Count,
+		AmountMinor:    u.AmountMinor,
+		PeriodStart:    time.Unix(u.PeriodStart, 0).UTC(),
+		PeriodEnd:      time.Unix(u.PeriodEnd, 0).UTC(),
 	}
-	return resp.Allow
 }
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress must be used when a webhook leads to an address that is
+// not public, such as the services behind the api
+var ErrForbiddenAddress = errors.New("webhook address is not public")
+
+// reserved lists the networks that are not public but that net.IP has no
+// method for
+var reserved = mustParseNetworks(
+	"0.0.0.0/8",     // this network
+	"100.64.0.0/10", // carrier grade nat
+	"192.0.0.0/24",  // ietf protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, including broadcast
+	"64:ff9b::/96",  // nat64, which reaches ipv4 addresses
+)
+
+// NewClient is an http client for webhooks. It refuses to connect to
+// loopback, private, link local and reserved addresses, unless they are in
+// allowed, so a merchant can not point its webhook at the services behind
+// the api. The address is checked after it is resolved, on every
+// connection, and redirects are not followed, so neither dns nor a redirect
+// gets around it.
+func NewClient(timeout time.Duration, allowed []*net.IPNet) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !Public(ip, allowed) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// a proxy would connect on our behalf, out of reach of the check
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// the redirect is the answer of the attempt, and not a 2xx
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Public tells if a webhook may reach ip, addresses in allowed always may
+func Public(ip net.IP, allowed []*net.IPNet) bool {
+	for _, network := range allowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range reserved {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
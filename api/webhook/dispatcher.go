@@ -0,0 +1,314 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/api/entities"
+)
+
+// MaxBackoff caps the wait between two attempts of the same delivery
+const MaxBackoff = time.Hour
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING"
+	DeliverySucceeded DeliveryStatus = "SUCCEEDED"
+	DeliveryFailed    DeliveryStatus = "FAILED"
+)
+
+var (
+	// ErrNoWebhook must be used when sending an event to a merchant without a webhook
+	ErrNoWebhook = errors.New("merchant has no webhook")
+	// ErrUnknownDelivery must be used when a delivery does not exist or belongs to another merchant
+	ErrUnknownDelivery = errors.New("unknown webhook delivery")
+	// ErrNotReplayable must be used when replaying a delivery that did not fail
+	ErrNotReplayable = errors.New("only failed deliveries can be replayed")
+)
+
+// Attempt records one request of a delivery, StatusCode is zero when the
+// merchant could not be reached at all
+type Attempt struct {
+	Time       time.Time `json:"time"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Delivery is the log of sending one event to one merchant, Attempts keeps
+// the attempts of every replay too
+type Delivery struct {
+	ID            uuid.UUID      `json:"id"`
+	MerchantID    uuid.UUID      `json:"merchant_id"`
+	Event         Event          `json:"event"`
+	Status        DeliveryStatus `json:"status"`
+	Attempts      []Attempt      `json:"attempts"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty"`
+}
+
+// Endpoints tells where the events of a merchant go, it is asked before
+// every attempt so a new url or secret applies to retries too
+type Endpoints interface {
+	GetWebhook(merchantID uuid.UUID) (entities.Webhook, error)
+}
+
+// Dispatcher delivers events in the background, retrying each delivery with
+// exponential backoff, and keeps the log of the latest deliveries of each
+// merchant in memory. Nothing is durable: the log, and the retries that were
+// still pending, are lost on restart.
+type Dispatcher struct {
+	endpoints     Endpoints
+	client        *http.Client
+	maxAttempts   int
+	backoff       time.Duration
+	maxDeliveries int
+
+	mu         sync.Mutex
+	deliveries map[uuid.UUID]*Delivery
+	byMerchant map[uuid.UUID][]uuid.UUID
+	inFlight   sync.WaitGroup
+}
+
+// NewDispatcher is a factory for a Dispatcher that tries each delivery up to
+// maxAttempts times, waiting backoff after the first failure and twice as
+// long after each of the following ones. It keeps up to maxDeliveries
+// finished deliveries per merchant, forgetting the oldest ones first.
+func NewDispatcher(endpoints Endpoints, client *http.Client, maxAttempts int, backoff time.Duration, maxDeliveries int) *Dispatcher {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if maxDeliveries < 1 {
+		maxDeliveries = 1
+	}
+	return &Dispatcher{
+		endpoints:     endpoints,
+		client:        client,
+		maxAttempts:   maxAttempts,
+		backoff:       backoff,
+		maxDeliveries: maxDeliveries,
+		deliveries:    make(map[uuid.UUID]*Delivery),
+		byMerchant:    make(map[uuid.UUID][]uuid.UUID),
+	}
+}
+
+// Send starts delivering e to the webhook of a merchant, it returns
+// ErrNoWebhook when the merchant did not register one
+func (d *Dispatcher) Send(merchantID uuid.UUID, e Event) (Delivery, error) {
+	w, err := d.endpoints.GetWebhook(merchantID)
+	if err != nil {
+		return Delivery{}, err
+	}
+	if w.URL == "" {
+		return Delivery{}, ErrNoWebhook
+	}
+
+	dl := &Delivery{
+		ID:         uuid.New(),
+		MerchantID: merchantID,
+		Event:      e,
+		Status:     DeliveryPending,
+		Attempts:   []Attempt{},
+	}
+
+	d.mu.Lock()
+	d.deliveries[dl.ID] = dl
+	d.byMerchant[merchantID] = append(d.byMerchant[merchantID], dl.ID)
+	d.prune(merchantID)
+	sent := dl.copy()
+	d.mu.Unlock()
+
+	d.start(dl.ID)
+	return sent, nil
+}
+
+// Replay delivers again an event whose delivery failed, with a new round of
+// attempts
+func (d *Dispatcher) Replay(merchantID, id uuid.UUID) (Delivery, error) {
+	d.mu.Lock()
+	dl, ok := d.deliveries[id]
+	if !ok || dl.MerchantID != merchantID {
+		d.mu.Unlock()
+		return Delivery{}, ErrUnknownDelivery
+	}
+	if dl.Status != DeliveryFailed {
+		d.mu.Unlock()
+		return Delivery{}, fmt.Errorf("%w: delivery is %s", ErrNotReplayable, dl.Status)
+	}
+	dl.Status = DeliveryPending
+	replayed := dl.copy()
+	d.mu.Unlock()
+
+	d.start(id)
+	return replayed, nil
+}
+
+// Delivery returns a delivery of a merchant
+func (d *Dispatcher) Delivery(merchantID, id uuid.UUID) (Delivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dl, ok := d.deliveries[id]
+	if !ok || dl.MerchantID != merchantID {
+		return Delivery{}, ErrUnknownDelivery
+	}
+	return dl.copy(), nil
+}
+
+// Deliveries returns the deliveries of a merchant in the given status, all of
+// them when status is empty, the most recent first
+func (d *Dispatcher) Deliveries(merchantID uuid.UUID, status DeliveryStatus) []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ids := d.byMerchant[merchantID]
+	deliveries := []Delivery{}
+	for i := len(ids) - 1; i >= 0; i-- {
+		dl := d.deliveries[ids[i]]
+		if status == "" || dl.Status == status {
+			deliveries = append(deliveries, dl.copy())
+		}
+	}
+	return deliveries
+}
+
+// Wait blocks until no delivery is being attempted or waiting for a retry
+func (d *Dispatcher) Wait() {
+	d.inFlight.Wait()
+}
+
+func (d *Dispatcher) start(id uuid.UUID) {
+	d.inFlight.Add(1)
+	go func() {
+		defer d.inFlight.Done()
+		d.deliver(id)
+	}()
+}
+
+// deliver runs one round of attempts of a delivery, until the merchant
+// acknowledges it or maxAttempts is reached
+func (d *Dispatcher) deliver(id uuid.UUID) {
+	for n := 1; ; n++ {
+		d.mu.Lock()
+		dl := d.deliveries[id]
+		merchantID, e := dl.MerchantID, dl.Event
+		d.mu.Unlock()
+
+		a, retry := d.attempt(merchantID, id, e)
+
+		d.mu.Lock()
+		dl.Attempts = append(dl.Attempts, a)
+		dl.NextAttemptAt = nil
+		switch {
+		case a.Error == "":
+			dl.Status = DeliverySucceeded
+		case !retry || n >= d.maxAttempts:
+			dl.Status = DeliveryFailed
+		default:
+			next := time.Now().Add(d.delay(n))
+			dl.NextAttemptAt = &next
+		}
+		status, next := dl.Status, dl.NextAttemptAt
+		d.mu.Unlock()
+
+		if status != DeliveryPending {
+			return
+		}
+		time.Sleep(time.Until(*next))
+	}
+}
+
+// attempt sends an event once, it returns false when retrying is pointless
+func (d *Dispatcher) attempt(merchantID, id uuid.UUID, e Event) (Attempt, bool) {
+	a := Attempt{Time: time.Now().UTC()}
+
+	w, err := d.endpoints.GetWebhook(merchantID)
+	if err != nil {
+		a.Error = fmt.Sprintf("could not read webhook: %v", err)
+		return a, true
+	}
+	if w.URL == "" {
+		a.Error = ErrNoWebhook.Error()
+		return a, false
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		a.Error = fmt.Sprintf("could not encode event: %v", err)
+		return a, false
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		a.Error = fmt.Sprintf("could not create request: %v", err)
+		return a, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(w.Secret, a.Time, body))
+	req.Header.Set(EventIDHeader, e.ID.String())
+	req.Header.Set(DeliveryIDHeader, id.String())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		a.Error = err.Error()
+		return a, true
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	a.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		a.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return a, true
+}
+
+// prune forgets the oldest finished deliveries of a merchant once it has more
+// than maxDeliveries, pending ones are kept until they finish. It must be
+// called with the lock held.
+func (d *Dispatcher) prune(merchantID uuid.UUID) {
+	ids := d.byMerchant[merchantID]
+	excess := len(ids) - d.maxDeliveries
+	if excess <= 0 {
+		return
+	}
+
+	kept := make([]uuid.UUID, 0, len(ids)-excess)
+	for _, id := range ids {
+		if excess > 0 && d.deliveries[id].Status != DeliveryPending {
+			delete(d.deliveries, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	d.byMerchant[merchantID] = kept
+}
+
+// delay is how long to wait after the n-th failed attempt
+func (d *Dispatcher) delay(n int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < n && delay < MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > MaxBackoff {
+		return MaxBackoff
+	}
+	return delay
+}
+
+func (dl *Delivery) copy() Delivery {
+	c := *dl
+	c.Attempts = append([]Attempt{}, dl.Attempts...)
+	if dl.NextAttemptAt != nil {
+		next := *dl.NextAttemptAt
+		c.NextAttemptAt = &next
+	}
+	return c
+}
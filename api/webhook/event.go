@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/api/entities"
+)
+
+// Types of the events sent to merchants
+const (
+	PaymentSucceeded  = "payment.succeeded"
+	PaymentFailed     = "payment.failed"
+	PaymentAuthorized = "payment.authorized"
+	PaymentVoided     = "payment.voided"
+)
+
+// Headers of every delivery, SignatureHeader has the form t=<unix>,v1=<hex>
+const (
+	SignatureHeader  = "Webhook-Signature"
+	EventIDHeader    = "Webhook-Event-Id"
+	DeliveryIDHeader = "Webhook-Delivery-Id"
+)
+
+var (
+	// ErrInvalidSignature must be used when a signature header is malformed or does not match the body
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrSignatureExpired must be used when a signature is older than the tolerance of the receiver
+	ErrSignatureExpired = errors.New("webhook signature expired")
+)
+
+// Event is the body of a webhook delivery
+type Event struct {
+	ID        uuid.UUID   `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      PaymentData `json:"data"`
+}
+
+// PaymentData is the part of a payment merchants need to act on an event
+type PaymentData struct {
+	ID          uuid.UUID `json:"id"`
+	Status      string    `json:"status"`
+	AmountMinor int64     `json:"amount_minor"`
+	Currency    string    `json:"currency"`
+	Metadata    string    `json:"metadata"`
+	BankMessage string    `json:"bank_message"`
+}
+
+// NewPaymentEvent is a factory for the event of a payment that reached its
+// current status, it returns false for statuses merchants are not told about
+func NewPaymentEvent(p entities.Payment) (Event, bool) {
+	var eventType string
+	switch p.Status {
+	case fmt.Sprint(entities.Success):
+		eventType = PaymentSucceeded
+	case fmt.Sprint(entities.Fail):
+		eventType = PaymentFailed
+	case fmt.Sprint(entities.Authorized):
+		eventType = PaymentAuthorized
+	case fmt.Sprint(entities.Voided):
+		eventType = PaymentVoided
+	default:
+		return Event{}, false
+	}
+
+	return Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data: PaymentData{
+			ID:          p.ID,
+			Status:      p.Status,
+			AmountMinor: p.Amount,
+			Currency:    p.Currency,
+			Metadata:    p.Metadata,
+			BankMessage: p.BankMessage,
+		},
+	}, true
+}
+
+// Sign returns the value of SignatureHeader for body sent at t, the HMAC
+// covers the timestamp too so a captured delivery cannot be replayed later
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, signature(secret, timestamp, body))
+}
+
+// Verify asserts header is a signature of body by secret made at most
+// tolerance before now, it is what merchants are expected to do
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var timestamp, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			sig = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || sig == "" {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	if now.Sub(time.Unix(unix, 0)) > tolerance {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
+func signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
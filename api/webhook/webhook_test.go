@@ -0,0 +1,348 @@
+package webhook_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/api/entities"
+	"github.com/thiagolcmelo/payment-gateway/api/webhook"
+)
+
+const secret = "0123456789abcdef"
+
+type endpoints map[uuid.UUID]entities.Webhook
+
+func (e endpoints) GetWebhook(merchantID uuid.UUID) (entities.Webhook, error) {
+	return e[merchantID], nil
+}
+
+// receiver stands in for a merchant, it answers with the given statuses in
+// order, then with the last one
+type receiver struct {
+	sync.Mutex
+	statuses []int
+	requests int
+	errs     []error
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.Lock()
+	defer rc.Unlock()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rc.errs = append(rc.errs, err)
+	}
+	if err := webhook.Verify(secret, r.Header.Get(webhook.SignatureHeader), body, time.Minute, time.Now()); err != nil {
+		rc.errs = append(rc.errs, err)
+	}
+	if r.Header.Get(webhook.DeliveryIDHeader) == "" || r.Header.Get(webhook.EventIDHeader) == "" {
+		rc.errs = append(rc.errs, errors.New("missing delivery headers"))
+	}
+
+	status := rc.statuses[len(rc.statuses)-1]
+	if rc.requests < len(rc.statuses) {
+		status = rc.statuses[rc.requests]
+	}
+	rc.requests++
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) answer(statuses ...int) {
+	rc.Lock()
+	defer rc.Unlock()
+	rc.statuses = statuses
+	rc.requests = 0
+}
+
+func newPaymentEvent(t *testing.T) webhook.Event {
+	t.Helper()
+
+	e, ok := webhook.NewPaymentEvent(entities.Payment{
+		ID:       uuid.New(),
+		Amount:   1050,
+		Currency: "USD",
+		Status:   "SUCCESS",
+	})
+	if !ok {
+		t.Fatal("expected an event for a successful payment")
+	}
+	return e
+}
+
+func TestWebhook_Verify(t *testing.T) {
+	now := time.Unix(1684540800, 0)
+	body := []byte(`{"id":"1"}`)
+	header := webhook.Sign(secret, now, body)
+
+	type testCase struct {
+		testName    string
+		secret      string
+		header      string
+		body        []byte
+		now         time.Time
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{testName: "valid", secret: secret, header: header, body: body, now: now, expectedErr: nil},
+		{testName: "within_tolerance", secret: secret, header: header, body: body, now: now.Add(time.Minute), expectedErr: nil},
+		{testName: "too_old", secret: secret, header: header, body: body, now: now.Add(time.Minute + time.Second), expectedErr: webhook.ErrSignatureExpired},
+		{testName: "other_body", secret: secret, header: header, body: []byte(`{"id":"2"}`), now: now, expectedErr: webhook.ErrInvalidSignature},
+		{testName: "other_secret", secret: "fedcba9876543210", header: header, body: body, now: now, expectedErr: webhook.ErrInvalidSignature},
+		{testName: "missing_timestamp", secret: secret, header: header[len("t=1684540800,"):], body: body, now: now, expectedErr: webhook.ErrInvalidSignature},
+		{testName: "empty", secret: secret, header: "", body: body, now: now, expectedErr: webhook.ErrInvalidSignature},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := webhook.Verify(tc.secret, tc.header, tc.body, time.Minute, tc.now)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestWebhook_NewPaymentEvent(t *testing.T) {
+	type testCase struct {
+		testName     string
+		status       entities.PaymentStatus
+		expectedType string
+		expectedOk   bool
+	}
+
+	testCases := []testCase{
+		{testName: "success", status: entities.Success, expectedType: webhook.PaymentSucceeded, expectedOk: true},
+		{testName: "fail", status: entities.Fail, expectedType: webhook.PaymentFailed, expectedOk: true},
+		{testName: "authorized", status: entities.Authorized, expectedType: webhook.PaymentAuthorized, expectedOk: true},
+		{testName: "voided", status: entities.Voided, expectedType: webhook.PaymentVoided, expectedOk: true},
+		{testName: "pending", status: entities.Pending, expectedOk: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e, ok := webhook.NewPaymentEvent(entities.Payment{ID: uuid.New(), Status: tc.status.String()})
+			if ok != tc.expectedOk {
+				t.Fatalf("expected ok=%v, got %v", tc.expectedOk, ok)
+			}
+			if e.Type != tc.expectedType {
+				t.Errorf("expected Type=%s, got %s", tc.expectedType, e.Type)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Send(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	merchantID := uuid.New()
+	d := webhook.NewDispatcher(endpoints{merchantID: {URL: srv.URL, Secret: secret}}, srv.Client(), 5, time.Millisecond, 10)
+
+	if _, err := d.Send(uuid.New(), newPaymentEvent(t)); !errors.Is(err, webhook.ErrNoWebhook) {
+		t.Errorf("expected %v, got %v", webhook.ErrNoWebhook, err)
+	}
+
+	sent, err := d.Send(merchantID, newPaymentEvent(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Wait()
+
+	dl, err := d.Delivery(merchantID, sent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl.Status != webhook.DeliverySucceeded {
+		t.Errorf("expected %s, got %s", webhook.DeliverySucceeded, dl.Status)
+	}
+	if len(dl.Attempts) != 3 || dl.Attempts[0].StatusCode != http.StatusInternalServerError || dl.Attempts[2].Error != "" {
+		t.Errorf("unexpected attempts: %+v", dl.Attempts)
+	}
+	if dl.NextAttemptAt != nil {
+		t.Errorf("expected no next attempt, got %v", dl.NextAttemptAt)
+	}
+	if len(rc.errs) != 0 {
+		t.Errorf("receiver refused deliveries: %v", rc.errs)
+	}
+}
+
+func TestDispatcher_Replay(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	merchantID := uuid.New()
+	d := webhook.NewDispatcher(endpoints{merchantID: {URL: srv.URL, Secret: secret}}, srv.Client(), 2, time.Millisecond, 10)
+
+	sent, err := d.Send(merchantID, newPaymentEvent(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Wait()
+
+	failed := d.Deliveries(merchantID, webhook.DeliveryFailed)
+	if len(failed) != 1 || failed[0].ID != sent.ID || len(failed[0].Attempts) != 2 {
+		t.Fatalf("expected one failed delivery after 2 attempts, got %+v", failed)
+	}
+
+	if _, err := d.Replay(uuid.New(), sent.ID); !errors.Is(err, webhook.ErrUnknownDelivery) {
+		t.Errorf("expected %v, got %v", webhook.ErrUnknownDelivery, err)
+	}
+
+	rc.answer(http.StatusNoContent)
+	if _, err := d.Replay(merchantID, sent.ID); err != nil {
+		t.Fatal(err)
+	}
+	d.Wait()
+
+	dl, err := d.Delivery(merchantID, sent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl.Status != webhook.DeliverySucceeded || len(dl.Attempts) != 3 {
+		t.Errorf("expected success on the third attempt, got %s after %d", dl.Status, len(dl.Attempts))
+	}
+
+	if _, err := d.Replay(merchantID, sent.ID); !errors.Is(err, webhook.ErrNotReplayable) {
+		t.Errorf("expected %v, got %v", webhook.ErrNotReplayable, err)
+	}
+	if all := d.Deliveries(merchantID, ""); len(all) != 1 {
+		t.Errorf("expected 1 delivery, got %d", len(all))
+	}
+}
+
+func TestDispatcher_Prune(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusOK}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	merchantID := uuid.New()
+	d := webhook.NewDispatcher(endpoints{merchantID: {URL: srv.URL, Secret: secret}}, srv.Client(), 1, time.Hour, 2)
+
+	var sent []webhook.Delivery
+	for i := 0; i < 3; i++ {
+		dl, err := d.Send(merchantID, newPaymentEvent(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Wait()
+		sent = append(sent, dl)
+	}
+
+	if _, err := d.Delivery(merchantID, sent[0].ID); !errors.Is(err, webhook.ErrUnknownDelivery) {
+		t.Errorf("expected the oldest delivery to be forgotten, got %v", err)
+	}
+	all := d.Deliveries(merchantID, "")
+	if len(all) != 2 || all[0].ID != sent[2].ID || all[1].ID != sent[1].ID {
+		t.Errorf("expected the 2 latest deliveries, got %+v", all)
+	}
+
+	// pending deliveries are kept even beyond the limit
+	rc.answer(http.StatusServiceUnavailable)
+	d = webhook.NewDispatcher(endpoints{merchantID: {URL: srv.URL, Secret: secret}}, srv.Client(), 2, time.Hour, 1)
+	for i := 0; i < 2; i++ {
+		if _, err := d.Send(merchantID, newPaymentEvent(t)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pending := d.Deliveries(merchantID, webhook.DeliveryPending); len(pending) != 2 {
+		t.Errorf("expected 2 pending deliveries, got %d", len(pending))
+	}
+}
+
+func TestWebhook_Public(t *testing.T) {
+	_, allowed, err := net.ParseCIDR("10.1.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName string
+		ip       string
+		expected bool
+	}
+
+	testCases := []testCase{
+		{testName: "public_ipv4", ip: "93.184.216.34", expected: true},
+		{testName: "public_ipv6", ip: "2606:2800:220:1:248:1893:25c8:1946", expected: true},
+		{testName: "loopback", ip: "127.0.0.1", expected: false},
+		{testName: "loopback_ipv6", ip: "::1", expected: false},
+		{testName: "loopback_mapped_to_ipv6", ip: "::ffff:127.0.0.1", expected: false},
+		{testName: "private_10", ip: "10.0.0.5", expected: false},
+		{testName: "private_172", ip: "172.16.3.4", expected: false},
+		{testName: "private_192", ip: "192.168.1.1", expected: false},
+		{testName: "private_ipv6", ip: "fd00::1", expected: false},
+		{testName: "cloud_metadata", ip: "169.254.169.254", expected: false},
+		{testName: "link_local_ipv6", ip: "fe80::1", expected: false},
+		{testName: "unspecified", ip: "0.0.0.0", expected: false},
+		{testName: "this_network", ip: "0.1.2.3", expected: false},
+		{testName: "carrier_grade_nat", ip: "100.64.0.1", expected: false},
+		{testName: "broadcast", ip: "255.255.255.255", expected: false},
+		{testName: "nat64", ip: "64:ff9b::7f00:1", expected: false},
+		{testName: "allowed_private", ip: "10.1.2.3", expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if got := webhook.Public(net.ParseIP(tc.ip), []*net.IPNet{allowed}); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestWebhook_NewClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	_, loopback, err := net.ParseCIDR("127.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName       string
+		allowed        []*net.IPNet
+		path           string
+		expectedStatus int
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{testName: "loopback_is_refused", path: "/", expectedErr: webhook.ErrForbiddenAddress},
+		{testName: "allowed_loopback", allowed: []*net.IPNet{loopback}, path: "/", expectedStatus: http.StatusOK},
+		{testName: "redirect_is_not_followed", allowed: []*net.IPNet{loopback}, path: "/redirect", expectedStatus: http.StatusFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			resp, err := webhook.NewClient(time.Second, tc.allowed).Post(srv.URL+tc.path, "application/json", nil)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected %d, got %d", tc.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -1,5 +1,6 @@
 import asyncio
 from datetime import datetime
+from decimal import Decimal
 from enum import Enum
 import json
 from logging import Logger
@@ -11,6 +12,27 @@ import httpx
 from pydantic import BaseModel
 
 
+# ISO 4217 exponents, amounts and balances are kept in minor units (e.g. cents)
+CURRENCY_EXPONENTS = {
+    "BHD": 3,
+    "BRL": 2,
+    "CAD": 2,
+    "CHF": 2,
+    "CLP": 0,
+    "EUR": 2,
+    "GBP": 2,
+    "JPY": 0,
+    "KRW": 0,
+    "KWD": 3,
+    "USD": 2,
+}
+
+
+def to_minor_units(value: float, currency: str) -> int:
+    exponent = CURRENCY_EXPONENTS[currency]
+    return int(Decimal(str(value)).scaleb(exponent))
+
+
 class PaymentStatus(Enum):
     CREATED = 0
     PENDING = 1
@@ -23,7 +45,7 @@ class Shopper(BaseModel):
     name: str
     description: str
     currency: str
-    balance: float
+    balance: int
 
 
 class Card(BaseModel):
@@ -38,7 +60,7 @@ class Card(BaseModel):
 class Payment(BaseModel):
     id: int
     uuid_id: str
-    amount: float
+    amount: int
     currency: str
     purchase_time: datetime
     validation_method: str
@@ -47,6 +69,19 @@ class Payment(BaseModel):
     shopper_id: int
     created_at: datetime
     status: PaymentStatus
+    # what was kept once the authorization ended, None while it holds it all
+    captured: Optional[int]
+
+
+class Refund(BaseModel):
+    id: int
+    uuid_id: str
+    payment_id: int
+    amount: int
+    currency: str
+    merchant: str
+    created_at: datetime
+    status: PaymentStatus
 
 
 class MemoryDB:
@@ -78,13 +113,25 @@ class MemoryDB:
             self.database_lock.release()
         return card
 
-    async def decrement_shopper_balance(self, shopper: Shopper, amount: float) -> None:
+    async def decrement_shopper_balance(self, shopper: Shopper, amount: int) -> None:
+        await self.database_lock.acquire()
+        try:
+            cursor = self.conn.cursor()
+            # relative to the stored balance, a release may have landed since
+            # the shopper was read
+            cursor.execute(
+                "UPDATE shoppers SET balance=balance-? WHERE id=?", (amount, shopper.id)
+            )
+            self.conn.commit()
+        finally:
+            self.database_lock.release()
+
+    async def increment_shopper_balance(self, shopper: Shopper, amount: int) -> None:
         await self.database_lock.acquire()
         try:
-            new_balance = shopper.balance - amount
             cursor = self.conn.cursor()
             cursor.execute(
-                "UPDATE shoppers SET balance=? WHERE id=?", (new_balance, shopper.id)
+                "UPDATE shoppers SET balance=balance+? WHERE id=?", (amount, shopper.id)
             )
             self.conn.commit()
         finally:
@@ -110,7 +157,7 @@ class MemoryDB:
                         name=_shopper[1],
                         description=_shopper[2],
                         currency=_shopper[3],
-                        balance=float(_shopper[4]),
+                        balance=int(_shopper[4]),
                     )
         finally:
             self.database_lock.release()
@@ -136,7 +183,7 @@ class MemoryDB:
                         name=_shopper[1],
                         description=_shopper[2],
                         currency=_shopper[3],
-                        balance=float(_shopper[4]),
+                        balance=int(_shopper[4]),
                     )
         finally:
             self.database_lock.release()
@@ -148,7 +195,7 @@ class MemoryDB:
         try:
             cursor = self.conn.cursor()
             cursor.execute(
-                "SELECT id, uuid_id, amount, currency, purchase_time, validation_method, card_id, merchant, shopper_id, created_at, status FROM payments WHERE id=?",
+                "SELECT id, uuid_id, amount, currency, purchase_time, validation_method, card_id, merchant, shopper_id, created_at, status, captured FROM payments WHERE id=?",
                 (payment_id,),
             )
             row = cursor.fetchone()
@@ -156,7 +203,7 @@ class MemoryDB:
                 payment = Payment(
                     id=int(row[0]),
                     uuid_id=row[1],
-                    amount=float(row[2]),
+                    amount=int(row[2]),
                     currency=row[3],
                     purchase_time=datetime.strptime(row[4], "%Y%m%dT%H%M%S.%f"),
                     validation_method=row[5],
@@ -165,11 +212,131 @@ class MemoryDB:
                     shopper_id=int(row[8]),
                     created_at=datetime.strptime(row[9], "%Y%m%dT%H%M%S.%f"),
                     status=PaymentStatus(int(row[10])),
+                    captured=None if row[11] is None else int(row[11]),
                 )
         finally:
             self.database_lock.release()
         return payment
 
+    async def find_payment_by_uuid(self, payment_uuid: str) -> Optional[int]:
+        await self.database_lock.acquire()
+        payment_id = None
+        try:
+            cursor = self.conn.cursor()
+            cursor.execute("SELECT id FROM payments WHERE uuid_id=?", (payment_uuid,))
+            row = cursor.fetchone()
+            if row is not None:
+                payment_id = int(row[0])
+        finally:
+            self.database_lock.release()
+        return payment_id
+
+    async def settle_payment(self, payment_id: int, captured: int) -> bool:
+        """Ends the authorization of a payment keeping captured of it, it is
+        False if it had already ended"""
+        await self.database_lock.acquire()
+        settled = False
+        try:
+            cursor = self.conn.cursor()
+            cursor.execute(
+                "UPDATE payments SET captured=? WHERE id=? AND captured IS NULL",
+                (captured, payment_id),
+            )
+            settled = cursor.rowcount == 1
+            self.conn.commit()
+        finally:
+            self.database_lock.release()
+        return settled
+
+    async def find_refund_by_id(self, refund_id: int) -> Refund:
+        await self.database_lock.acquire()
+        refund = None
+        try:
+            cursor = self.conn.cursor()
+            cursor.execute(
+                "SELECT id, uuid_id, payment_id, amount, currency, merchant, created_at, status FROM refunds WHERE id=?",
+                (refund_id,),
+            )
+            row = cursor.fetchone()
+            if row is not None and len(row) > 0:
+                refund = Refund(
+                    id=int(row[0]),
+                    uuid_id=row[1],
+                    payment_id=int(row[2]),
+                    amount=int(row[3]),
+                    currency=row[4],
+                    merchant=row[5],
+                    created_at=datetime.strptime(row[6], "%Y%m%dT%H%M%S.%f"),
+                    status=PaymentStatus(int(row[7])),
+                )
+        finally:
+            self.database_lock.release()
+        return refund
+
+    async def sum_refunds_of_payment(self, payment_id: int, refund_id: int) -> int:
+        """Total refunded from a payment, failed refunds and refund_id itself are left out"""
+        await self.database_lock.acquire()
+        total = 0
+        try:
+            cursor = self.conn.cursor()
+            cursor.execute(
+                "SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id=? AND id!=? AND status!=?",
+                (payment_id, refund_id, int(PaymentStatus.FAIL.value)),
+            )
+            total = int(cursor.fetchone()[0])
+        finally:
+            self.database_lock.release()
+        return total
+
+    async def mark_refund_status(self, refund_id: int, status: PaymentStatus) -> None:
+        await self.database_lock.acquire()
+        try:
+            cursor = self.conn.cursor()
+            cursor.execute(
+                "UPDATE refunds SET status=? WHERE id=?",
+                (int(status.value), refund_id),
+            )
+            self.conn.commit()
+        finally:
+            self.database_lock.release()
+
+    async def create_refund_for_payment(
+        self,
+        payment_id: int,
+        amount: int,
+        currency: str,
+        merchant: str,
+    ) -> Tuple[str]:
+        await self.database_lock.acquire()
+        refund_id, refund_uuid = None, None
+
+        try:
+            id = uuid.uuid1()
+            cursor = self.conn.cursor()
+            cursor.execute(
+                """
+                INSERT INTO refunds
+                    (uuid_id, payment_id, amount, currency, merchant, created_at, status)
+                    VALUES
+                    (?, ?, ?, ?, ?, ?, ?)""",
+                (
+                    str(id),
+                    payment_id,
+                    amount,
+                    currency,
+                    merchant,
+                    datetime.now().strftime("%Y%m%dT%H%M%S.%f"),
+                    int(PaymentStatus.CREATED.value),
+                ),
+            )
+            refund_id = cursor.lastrowid
+            refund_uuid = str(id)
+            self.conn.commit()
+        finally:
+            self.database_lock.release()
+
+        return refund_id, refund_uuid
+
     async def find_shopper_merchants(self, shopper: Shopper) -> List[str]:
         await self.database_lock.acquire()
         merchants = []
@@ -202,7 +369,7 @@ class MemoryDB:
         self,
         shopper: Shopper,
         card: Card,
-        amount: float,
+        amount: int,
         currency: str,
         purchase_time: datetime,
         validation_method: str,
@@ -266,7 +433,7 @@ def create_memory_db(json_data: str) -> sqlite3.Connection:
         name TEXT,
         description TEXT,
         currency TEXT,
-        balance REAL
+        balance INTEGER
     )"""
     )
     cursor.execute(
@@ -287,7 +454,7 @@ def create_memory_db(json_data: str) -> sqlite3.Connection:
     CREATE TABLE IF NOT EXISTS payments (
         id INTEGER PRIMARY KEY,
         uuid_id TEXT,
-        amount REAL,
+        amount INTEGER,
         currency TEXT,
         purchase_time TEXT,
         validation_method TEXt,
@@ -296,10 +463,25 @@ def create_memory_db(json_data: str) -> sqlite3.Connection:
         shopper_id INTEGER,
         created_at TEXT,
         status INTEGER,
+        captured INTEGER,
         FOREIGN KEY (card_id) REFERENCES cards(id),
         FOREIGN KEY (shopper_id) REFERENCES shoppers(id)
     )"""
     )
+    cursor.execute(
+        """
+    CREATE TABLE IF NOT EXISTS refunds (
+        id INTEGER PRIMARY KEY,
+        uuid_id TEXT,
+        payment_id INTEGER,
+        amount INTEGER,
+        currency TEXT,
+        merchant TEXT,
+        created_at TEXT,
+        status INTEGER,
+        FOREIGN KEY (payment_id) REFERENCES payments(id)
+    )"""
+    )
     cursor.execute(
         """
     CREATE TABLE IF NOT EXISTS auto_approve_merchants (
@@ -317,7 +499,7 @@ def create_memory_db(json_data: str) -> sqlite3.Connection:
                 shopper["name"],
                 shopper["description"],
                 shopper["currency"],
-                shopper["balance"],
+                to_minor_units(shopper["balance"], shopper["currency"]),
             ),
         )
         shopper_id = cursor.lastrowid
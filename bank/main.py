@@ -1,5 +1,10 @@
 from datetime import datetime
+import base64
+import hashlib
+import hmac
+import json
 import os
+import time
 
 
 from fastapi import BackgroundTasks, FastAPI, Request, Response, status
@@ -7,6 +12,7 @@ import httpx
 from pydantic import BaseModel
 
 from db.memory import (
+    CURRENCY_EXPONENTS,
     MemoryDB,
     Card,
     PaymentStatus,
@@ -24,7 +30,7 @@ logger = logging.getLogger("bank-simulator")
 
 
 class PaymentRequest(BaseModel):
-    amount: float
+    amount_minor: int
     currency: str
     purchase_time: datetime
     validation_method: str
@@ -38,6 +44,21 @@ class PaymentResponse(BaseModel):
     message: str
 
 
+class RefundRequest(BaseModel):
+    payment_id: str
+    amount_minor: int
+    currency: str
+    merchant: str
+
+
+class SettleRequest(BaseModel):
+    payment_id: str
+    # what is kept of the authorization, 0 to release all of it
+    amount_minor: int = 0
+    currency: str
+    merchant: str
+
+
 class UpdatePaymentRequest(BaseModel):
     id: str
     success: bool
@@ -48,6 +69,36 @@ class UpdatePaymentResponse(BaseModel):
     acknowledge: bool
 
 
+def sign_callback(body: bytes) -> dict:
+    """Headers that let the Payment Gateway know the callback comes from this
+    bank, the HMAC covers the timestamp so it cannot be replayed later"""
+    key_id = os.getenv("BANK_KEY_ID", "bank-simulator")
+    secret = os.getenv("BANK_SIGNING_SECRET", "")
+    if not secret:
+        logger.warning("BANK_SIGNING_SECRET is not set, callbacks will be refused")
+    timestamp = str(int(time.time()))
+    signature = hmac.new(
+        secret.encode(), timestamp.encode() + b"." + body, hashlib.sha256
+    ).digest()
+    return {
+        "Content-Type": "application/json",
+        "Bank-Signature": f"keyid={key_id},t={timestamp},sig={base64.b64encode(signature).decode()}",
+    }
+
+
+async def send_callback(client: httpx.AsyncClient, host: str, json_data: dict) -> httpx.Response:
+    host = os.getenv("PAYMENT_GATEWAY_HOST", host)
+    port = os.getenv("PAYMENT_GATEWAY_PORT", 8080)
+    # the exact bytes that are signed must be sent
+    body = json.dumps(json_data).encode()
+    return await client.put(
+        f"http://{host}:{port}/payment",
+        content=body,
+        headers=sign_callback(body),
+        timeout=10.0,
+    )
+
+
 async def process_payment(payment_id: int, host: str, db_manager: MemoryDB) -> None:
     shopper = await db_manager.find_shopper_by_payment_id(payment_id)
     merchants = await db_manager.find_shopper_merchants(shopper)
@@ -58,6 +109,9 @@ async def process_payment(payment_id: int, host: str, db_manager: MemoryDB) -> N
         # merchant first, to not disclose info to unauthorized merchant
         message = "merchant unauthorized"
         success = False
+    elif payment.currency not in CURRENCY_EXPONENTS:
+        message = "unknown currency"
+        success = False
     elif shopper.currency != payment.currency:
         message = "shopper currency is not correct"
         success = False
@@ -66,16 +120,12 @@ async def process_payment(payment_id: int, host: str, db_manager: MemoryDB) -> N
         success = False
 
     async with httpx.AsyncClient() as client:
-        host = os.getenv("PAYMENT_GATEWAY_HOST", host)
-        port = os.getenv("PAYMENT_GATEWAY_PORT", 8080)
         json_data = {
             "id": payment.uuid_id,
             "success": success,
             "message": message,
         }
-        r = await client.put(
-            f"http://{host}:{port}/payment", json=json_data, timeout=10.0
-        )
+        r = await send_callback(client, host, json_data)
         r_data = r.json()
 
         if r.status_code == httpx.codes.OK and r_data.get("acknowledge", False):
@@ -87,6 +137,47 @@ async def process_payment(payment_id: int, host: str, db_manager: MemoryDB) -> N
             db_manager.logger.info(f"{payment.uuid_id} - FAIL")
 
 
+async def process_refund(refund_id: int, host: str, db_manager: MemoryDB) -> None:
+    refund = await db_manager.find_refund_by_id(refund_id)
+    payment = await db_manager.find_payment_by_id(refund.payment_id)
+    shopper = await db_manager.find_shopper_by_payment_id(refund.payment_id)
+    refunded = await db_manager.sum_refunds_of_payment(refund.payment_id, refund_id)
+
+    success, message = True, "refund processed successfully"
+    if refund.merchant != payment.merchant:
+        message = "merchant unauthorized"
+        success = False
+    elif payment.status != PaymentStatus.SUCCESS:
+        message = "payment was not successful"
+        success = False
+    elif refund.currency != payment.currency:
+        message = "refund currency is not correct"
+        success = False
+    elif refund.amount <= 0 or refunded + refund.amount > (
+        payment.amount if payment.captured is None else payment.captured
+    ):
+        message = "refund exceeds payment"
+        success = False
+
+    async with httpx.AsyncClient() as client:
+        # refunds are confirmed through the same endpoint as payments
+        json_data = {
+            "id": refund.uuid_id,
+            "success": success,
+            "message": message,
+        }
+        r = await send_callback(client, host, json_data)
+        r_data = r.json()
+
+        if success and r.status_code == httpx.codes.OK and r_data.get("acknowledge", False):
+            await db_manager.increment_shopper_balance(shopper, refund.amount)
+            await db_manager.mark_refund_status(refund_id, PaymentStatus.SUCCESS)
+            db_manager.logger.info(f"{refund.uuid_id} - SUCCESS")
+        else:
+            await db_manager.mark_refund_status(refund_id, PaymentStatus.FAIL)
+            db_manager.logger.info(f"{refund.uuid_id} - FAIL")
+
+
 app = FastAPI()
 
 
@@ -126,7 +217,7 @@ async def create_payment(
         payment_id, payment_uuid = await app.state.db_helper.create_payment_for_shopper(
             shopper,
             card,
-            payment_request.amount,
+            payment_request.amount_minor,
             payment_request.currency,
             payment_request.purchase_time,
             payment_request.validation_method,
@@ -154,6 +245,112 @@ async def create_payment(
     return response
 
 
+@app.post("/refund")
+async def create_refund(
+    refund_request: RefundRequest,
+    background_tasks: BackgroundTasks,
+    req: Request,
+    resp: Response,
+) -> PaymentResponse:
+    response = PaymentResponse(id="", success=False, message="error")
+
+    try:
+        payment_id = await app.state.db_helper.find_payment_by_uuid(
+            refund_request.payment_id
+        )
+        if payment_id is None:
+            response.message = "payment not found"
+            raise Exception(response.message)
+
+        refund_id, refund_uuid = await app.state.db_helper.create_refund_for_payment(
+            payment_id,
+            refund_request.amount_minor,
+            refund_request.currency,
+            refund_request.merchant,
+        )
+        if refund_id is None:
+            response.message = "could not create refund"
+            raise Exception(response.message)
+        logger.info(f"{refund_uuid} - CREATED")
+
+        background_tasks.add_task(
+            process_refund, refund_id, req.client.host, app.state.db_helper
+        )
+        await app.state.db_helper.mark_refund_status(refund_id, PaymentStatus.PENDING)
+
+        response.id = refund_uuid
+        response.message = "refund request created"
+        response.success = True
+        resp.status_code = status.HTTP_201_CREATED
+        logger.info(f"{refund_uuid} - PENDING")
+    except Exception as err:
+        resp.status_code = status.HTTP_400_BAD_REQUEST
+        logger.error(err)
+
+    return response
+
+
+async def settle_authorization(
+    settle_request: SettleRequest, resp: Response, success_message: str
+) -> PaymentResponse:
+    """Ends the authorization of a payment, giving back to the Shopper what is
+    not kept. It is answered right away, there is no callback."""
+    response = PaymentResponse(id="", success=False, message="error")
+
+    try:
+        payment_id = await app.state.db_helper.find_payment_by_uuid(
+            settle_request.payment_id
+        )
+        if payment_id is None:
+            response.message = "payment not found"
+            raise Exception(response.message)
+        payment = await app.state.db_helper.find_payment_by_id(payment_id)
+        response.id = payment.uuid_id
+
+        if payment.merchant != settle_request.merchant:
+            response.message = "merchant unauthorized"
+        # the gateway may settle as soon as it acknowledged the payment, before
+        # it is marked successful here
+        elif payment.status not in (PaymentStatus.PENDING, PaymentStatus.SUCCESS):
+            response.message = "payment was not successful"
+        elif settle_request.currency != payment.currency:
+            response.message = "currency is not correct"
+        elif settle_request.amount_minor < 0 or settle_request.amount_minor > payment.amount:
+            response.message = "capture exceeds payment"
+        elif not await app.state.db_helper.settle_payment(
+            payment_id, settle_request.amount_minor
+        ):
+            response.message = "authorization already ended"
+        else:
+            shopper = await app.state.db_helper.find_shopper_by_payment_id(payment_id)
+            await app.state.db_helper.increment_shopper_balance(
+                shopper, payment.amount - settle_request.amount_minor
+            )
+            response.success = True
+            response.message = success_message
+        if not response.success:
+            raise Exception(response.message)
+
+        resp.status_code = status.HTTP_200_OK
+        logger.info(f"{payment.uuid_id} - SETTLED {settle_request.amount_minor}")
+    except Exception as err:
+        resp.status_code = status.HTTP_400_BAD_REQUEST
+        logger.error(err)
+
+    return response
+
+
+@app.post("/capture")
+async def capture_payment(capture_request: SettleRequest, resp: Response) -> PaymentResponse:
+    return await settle_authorization(capture_request, resp, "payment captured")
+
+
+@app.post("/void")
+async def void_payment(void_request: SettleRequest, resp: Response) -> PaymentResponse:
+    void_request.amount_minor = 0
+    return await settle_authorization(void_request, resp, "authorization released")
+
+
 @app.put("/payment")
 async def update_payment(
     update: UpdatePaymentRequest,
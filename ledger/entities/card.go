@@ -3,7 +3,10 @@ package entity
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 )
 
 var (
@@ -17,6 +20,9 @@ var (
 	ErrInvalidCVV = errors.New("invalid cvv")
 )
 
+// CreditCard is a card as the shopper gives it, it is only kept long enough
+// to put Number in the vault and relay the card to the bank, payments keep a
+// MaskedCard instead
 type CreditCard struct {
 	Number      string
 	Name        string
@@ -44,15 +50,101 @@ func (c CreditCard) Validate() error {
 	if c.Number == "" {
 		return ErrInvalidNumber
 	}
-	date, err := time.Parse("2006-01", fmt.Sprintf("%d-%02d", c.ExpireYear, c.ExpireMonth))
+	if err := validateExpiration(c.ExpireMonth, c.ExpireYear); err != nil {
+		return err
+	}
+	if c.CVV < 0 || c.CVV > 999 {
+		return ErrInvalidCVV
+	}
+	return nil
+}
+
+func validateExpiration(month, year int) error {
+	date, err := time.Parse("2006-01", fmt.Sprintf("%d-%02d", year, month))
 	if err != nil {
 		return err
 	}
 	if date.Before(time.Now()) {
 		return ErrCardExpired
 	}
-	if c.CVV < 0 || c.CVV > 999 {
-		return ErrInvalidCVV
-	}
 	return nil
 }
+
+// Card brands, told apart by the first digits of the number
+const (
+	Visa       = "VISA"
+	Mastercard = "MASTERCARD"
+	Amex       = "AMEX"
+	Discover   = "DISCOVER"
+	Unknown    = "UNKNOWN"
+)
+
+// MaskedCard is what a payment keeps of its card, Token references the
+// number in the vault and the CVV is not kept at all
+type MaskedCard struct {
+	Token       string
+	Brand       string
+	Last4       string
+	Name        string
+	ExpireMonth int
+	ExpireYear  int
+}
+
+// Mask returns the card without its number and CVV, the Token is set once
+// the number is in the vault
+func (c CreditCard) Mask() MaskedCard {
+	digits := c.digits()
+	last4 := digits
+	if len(digits) > 4 {
+		last4 = digits[len(digits)-4:]
+	}
+	return MaskedCard{
+		Brand:       brand(digits),
+		Last4:       last4,
+		Name:        c.Name,
+		ExpireMonth: c.ExpireMonth,
+		ExpireYear:  c.ExpireYear,
+	}
+}
+
+func (c CreditCard) digits() string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, c.Number)
+}
+
+func brand(digits string) string {
+	prefix := func(n int) int {
+		if len(digits) < n {
+			return -1
+		}
+		v, _ := strconv.Atoi(digits[:n])
+		return v
+	}
+	switch {
+	case prefix(1) == 4:
+		return Visa
+	case prefix(2) >= 51 && prefix(2) <= 55, prefix(4) >= 2221 && prefix(4) <= 2720:
+		return Mastercard
+	case prefix(2) == 34, prefix(2) == 37:
+		return Amex
+	case prefix(4) == 6011, prefix(2) == 65:
+		return Discover
+	default:
+		return Unknown
+	}
+}
+
+// Validate asserts the card has what is needed to show it to the merchant
+func (m MaskedCard) Validate() error {
+	if m.Name == "" {
+		return ErrInvalidName
+	}
+	if m.Last4 == "" {
+		return ErrInvalidNumber
+	}
+	return validateExpiration(m.ExpireMonth, m.ExpireYear)
+}
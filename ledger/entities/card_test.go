@@ -84,3 +84,42 @@ func TestCreditCard_NewCreditCard(t *testing.T) {
 		})
 	}
 }
+
+func TestCreditCard_Mask(t *testing.T) {
+	type testCase struct {
+		testName      string
+		number        string
+		expectedBrand string
+		expectedLast4 string
+	}
+
+	testCases := []testCase{
+		{testName: "visa", number: "4111 1111 1111 1111", expectedBrand: entity.Visa, expectedLast4: "1111"},
+		{testName: "mastercard", number: "5500-0000-0000-0004", expectedBrand: entity.Mastercard, expectedLast4: "0004"},
+		{testName: "mastercard_2_series", number: "2221000000000009", expectedBrand: entity.Mastercard, expectedLast4: "0009"},
+		{testName: "amex", number: "3782-822463-10005", expectedBrand: entity.Amex, expectedLast4: "0005"},
+		{testName: "discover", number: "6011111111111117", expectedBrand: entity.Discover, expectedLast4: "1117"},
+		{testName: "unknown", number: "1111-2222-3333-4444", expectedBrand: entity.Unknown, expectedLast4: "4444"},
+		{testName: "short", number: "12", expectedBrand: entity.Unknown, expectedLast4: "12"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			card, err := entity.NewCreditCard(tc.number, "name surname", 10, 2099, 123)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			masked := card.Mask()
+			if masked.Brand != tc.expectedBrand {
+				t.Errorf("expected Brand=%s, got %s", tc.expectedBrand, masked.Brand)
+			}
+			if masked.Last4 != tc.expectedLast4 {
+				t.Errorf("expected Last4=%s, got %s", tc.expectedLast4, masked.Last4)
+			}
+			if masked.Name != card.Name || masked.ExpireMonth != 10 || masked.ExpireYear != 2099 {
+				t.Errorf("expected name and expiry to be kept, got %+v", masked)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"errors"
+)
+
+var (
+	// ErrUnknownCurrency must be used when a currency is not an ISO 4217 code known by the ledger
+	ErrUnknownCurrency = errors.New("unknown currency")
+)
+
+// currencies maps ISO 4217 codes to their exponent, which is the number of
+// digits after the decimal separator, e.g. 1 USD is 100 minor units (cents)
+var currencies = map[string]int{
+	"AED": 2,
+	"ARS": 2,
+	"AUD": 2,
+	"BHD": 3,
+	"BRL": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CLP": 0,
+	"CNY": 2,
+	"COP": 2,
+	"CZK": 2,
+	"DKK": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"HKD": 2,
+	"HUF": 2,
+	"IDR": 2,
+	"ILS": 2,
+	"INR": 2,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"MXN": 2,
+	"MYR": 2,
+	"NOK": 2,
+	"NZD": 2,
+	"OMR": 3,
+	"PEN": 2,
+	"PHP": 2,
+	"PLN": 2,
+	"RON": 2,
+	"SAR": 2,
+	"SEK": 2,
+	"SGD": 2,
+	"THB": 2,
+	"TND": 3,
+	"TRY": 2,
+	"TWD": 2,
+	"UAH": 2,
+	"USD": 2,
+	"VND": 0,
+	"ZAR": 2,
+}
+
+// CurrencyExponent returns the number of minor unit digits of an ISO 4217 currency
+func CurrencyExponent(currency string) (int, error) {
+	exponent, ok := currencies[currency]
+	if !ok {
+		return 0, ErrUnknownCurrency
+	}
+	return exponent, nil
+}
@@ -0,0 +1,150 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultListLimit is the page size used when the filter has no limit
+	DefaultListLimit = 50
+	// MaxListLimit is the largest page size a filter can ask for
+	MaxListLimit = 200
+)
+
+var (
+	// ErrMissingMerchantID must be used when listing payments without a merchant
+	ErrMissingMerchantID = errors.New("missing merchant id")
+	// ErrInvalidLimit must be used when the page size is negative or above MaxListLimit
+	ErrInvalidLimit = errors.New("invalid limit")
+	// ErrInvalidAmountRange must be used when the minimum amount is above the maximum
+	ErrInvalidAmountRange = errors.New("invalid amount range")
+	// ErrInvalidTimeRange must be used when the purchase time range ends before it starts
+	ErrInvalidTimeRange = errors.New("invalid purchase time range")
+	// ErrInvalidCursor must be used when a cursor was not issued by the ledger
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// PaymentCursor is the position of a payment in a listing, payments are
+// listed from the most recent purchase to the oldest, ties broken by ID
+type PaymentCursor struct {
+	PurchaseTime time.Time
+	ID           uuid.UUID
+}
+
+// NewPaymentCursor is a factory for the cursor right after p
+func NewPaymentCursor(p Payment) PaymentCursor {
+	return PaymentCursor{PurchaseTime: p.PurchaseTime, ID: p.ID}
+}
+
+// Encode turns the cursor into an opaque string that can be handed to clients
+func (c PaymentCursor) Encode() string {
+	raw := c.PurchaseTime.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodePaymentCursor is the inverse of PaymentCursor.Encode
+func DecodePaymentCursor(value string) (PaymentCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return PaymentCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	purchaseTime, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return PaymentCursor{}, ErrInvalidCursor
+	}
+
+	c := PaymentCursor{}
+	if c.PurchaseTime, err = time.Parse(time.RFC3339Nano, purchaseTime); err != nil {
+		return PaymentCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if c.ID, err = uuid.Parse(id); err != nil {
+		return PaymentCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	return c, nil
+}
+
+// Before tells if a payment at this position is listed before other
+func (c PaymentCursor) Before(other PaymentCursor) bool {
+	if !c.PurchaseTime.Equal(other.PurchaseTime) {
+		return c.PurchaseTime.After(other.PurchaseTime)
+	}
+	return bytes.Compare(c.ID[:], other.ID[:]) > 0
+}
+
+// PaymentFilter selects payments of a single merchant, zero values of
+// optional fields mean they do not filter anything
+type PaymentFilter struct {
+	MerchantID uuid.UUID
+	Status     *PaymentStatus
+	Currency   string
+	MinAmount  *int64
+	MaxAmount  *int64
+	// PurchasedFrom is inclusive and PurchasedTo is exclusive
+	PurchasedFrom time.Time
+	PurchasedTo   time.Time
+	// Metadata matches payments whose metadata contains it
+	Metadata string
+	// Cursor is where the previous page stopped, nil for the first page
+	Cursor *PaymentCursor
+	Limit  int
+}
+
+// Validate runs some checks to assert a filter is valid
+func (f PaymentFilter) Validate() error {
+	if f.MerchantID == uuid.Nil {
+		return ErrMissingMerchantID
+	}
+	if f.Limit < 0 || f.Limit > MaxListLimit {
+		return fmt.Errorf("%w: %d, must be between 0 and %d", ErrInvalidLimit, f.Limit, MaxListLimit)
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount {
+		return ErrInvalidAmountRange
+	}
+	if !f.PurchasedFrom.IsZero() && !f.PurchasedTo.IsZero() && f.PurchasedTo.Before(f.PurchasedFrom) {
+		return ErrInvalidTimeRange
+	}
+	return nil
+}
+
+// PageSize is the number of payments a page must have at most
+func (f PaymentFilter) PageSize() int {
+	if f.Limit == 0 {
+		return DefaultListLimit
+	}
+	return f.Limit
+}
+
+// Match tells if p is selected by the filter, the cursor is not considered
+func (f PaymentFilter) Match(p Payment) bool {
+	if p.MerchantID != f.MerchantID {
+		return false
+	}
+	if f.Status != nil && p.Status != *f.Status {
+		return false
+	}
+	if f.Currency != "" && p.Currency != f.Currency {
+		return false
+	}
+	if f.MinAmount != nil && p.Amount < *f.MinAmount {
+		return false
+	}
+	if f.MaxAmount != nil && p.Amount > *f.MaxAmount {
+		return false
+	}
+	if !f.PurchasedFrom.IsZero() && p.PurchaseTime.Before(f.PurchasedFrom) {
+		return false
+	}
+	if !f.PurchasedTo.IsZero() && !p.PurchaseTime.Before(f.PurchasedTo) {
+		return false
+	}
+	return strings.Contains(p.Metadata, f.Metadata)
+}
@@ -0,0 +1,127 @@
+package entity_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
+)
+
+func TestPaymentCursor_Encode(t *testing.T) {
+	c := entity.PaymentCursor{
+		PurchaseTime: time.Date(2023, 5, 18, 1, 0, 0, 123000000, time.UTC),
+		ID:           uuid.New(),
+	}
+
+	decoded, err := entity.DecodePaymentCursor(c.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.PurchaseTime.Equal(c.PurchaseTime) || decoded.ID != c.ID {
+		t.Errorf("expected %v, got %v", c, decoded)
+	}
+
+	for _, value := range []string{"not base64!", "bm8tc2VwYXJhdG9y", "MjAyMy0wNS0xOHxub3QtYS11dWlk"} {
+		if _, err := entity.DecodePaymentCursor(value); !errors.Is(err, entity.ErrInvalidCursor) {
+			t.Errorf("expected %v for %q, got %v", entity.ErrInvalidCursor, value, err)
+		}
+	}
+}
+
+func TestPaymentFilter_Validate(t *testing.T) {
+	low, high := int64(100), int64(200)
+	start := time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		testName    string
+		filter      entity.PaymentFilter
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "valid_filter",
+			filter:      entity.PaymentFilter{MerchantID: uuid.New(), MinAmount: &low, MaxAmount: &high, PurchasedFrom: start, PurchasedTo: start.Add(time.Hour)},
+			expectedErr: nil,
+		},
+		{
+			testName:    "missing_merchant",
+			filter:      entity.PaymentFilter{},
+			expectedErr: entity.ErrMissingMerchantID,
+		},
+		{
+			testName:    "negative_limit",
+			filter:      entity.PaymentFilter{MerchantID: uuid.New(), Limit: -1},
+			expectedErr: entity.ErrInvalidLimit,
+		},
+		{
+			testName:    "limit_too_big",
+			filter:      entity.PaymentFilter{MerchantID: uuid.New(), Limit: entity.MaxListLimit + 1},
+			expectedErr: entity.ErrInvalidLimit,
+		},
+		{
+			testName:    "inverted_amount_range",
+			filter:      entity.PaymentFilter{MerchantID: uuid.New(), MinAmount: &high, MaxAmount: &low},
+			expectedErr: entity.ErrInvalidAmountRange,
+		},
+		{
+			testName:    "inverted_time_range",
+			filter:      entity.PaymentFilter{MerchantID: uuid.New(), PurchasedFrom: start, PurchasedTo: start.Add(-time.Hour)},
+			expectedErr: entity.ErrInvalidTimeRange,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := tc.filter.Validate()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestPaymentFilter_Match(t *testing.T) {
+	merchantID := uuid.New()
+	purchaseTime := time.Date(2023, 5, 18, 1, 0, 0, 0, time.UTC)
+	p := entity.Payment{
+		MerchantID:   merchantID,
+		Amount:       15000,
+		Currency:     "USD",
+		PurchaseTime: purchaseTime,
+		Metadata:     "shopper-123",
+		Status:       entity.Pending,
+	}
+	pending, success := entity.Pending, entity.Success
+	low, high := int64(15000), int64(14999)
+
+	type testCase struct {
+		testName string
+		filter   entity.PaymentFilter
+		expected bool
+	}
+
+	testCases := []testCase{
+		{testName: "merchant_only", filter: entity.PaymentFilter{MerchantID: merchantID}, expected: true},
+		{testName: "other_merchant", filter: entity.PaymentFilter{MerchantID: uuid.New()}, expected: false},
+		{testName: "same_status", filter: entity.PaymentFilter{MerchantID: merchantID, Status: &pending}, expected: true},
+		{testName: "other_status", filter: entity.PaymentFilter{MerchantID: merchantID, Status: &success}, expected: false},
+		{testName: "other_currency", filter: entity.PaymentFilter{MerchantID: merchantID, Currency: "EUR"}, expected: false},
+		{testName: "min_amount_is_inclusive", filter: entity.PaymentFilter{MerchantID: merchantID, MinAmount: &low}, expected: true},
+		{testName: "below_max_amount", filter: entity.PaymentFilter{MerchantID: merchantID, MaxAmount: &high}, expected: false},
+		{testName: "from_is_inclusive", filter: entity.PaymentFilter{MerchantID: merchantID, PurchasedFrom: purchaseTime}, expected: true},
+		{testName: "to_is_exclusive", filter: entity.PaymentFilter{MerchantID: merchantID, PurchasedTo: purchaseTime}, expected: false},
+		{testName: "metadata_substring", filter: entity.PaymentFilter{MerchantID: merchantID, Metadata: "per-1"}, expected: true},
+		{testName: "metadata_mismatch", filter: entity.PaymentFilter{MerchantID: merchantID, Metadata: "shopper-456"}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.filter.Match(p) != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, !tc.expected)
+			}
+		})
+	}
+}
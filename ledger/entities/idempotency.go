@@ -0,0 +1,106 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IdempotencyKeyStatus int
+
+const (
+	InProgress IdempotencyKeyStatus = iota
+	Completed
+)
+
+const (
+	// MaxIdempotencyKeyLength is the longest key a merchant can send
+	MaxIdempotencyKeyLength = 255
+	// InProgressTTL is how long a request holds a key by default before it is
+	// considered abandoned, e.g. because the api crashed while processing it.
+	// It is long because the request may have created a payment before the
+	// crash, and claiming the key again would create another one.
+	InProgressTTL = 24 * time.Hour
+	// CompletedTTL is how long a response is kept for replay
+	CompletedTTL = 24 * time.Hour
+)
+
+var (
+	// ErrInvalidIdempotencyKey must be used when the key is empty or too long
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+	// ErrMissingRequestHash must be used when the hash of the request is missing
+	ErrMissingRequestHash = errors.New("missing request hash")
+)
+
+// IdempotencyKey records a request sent by a merchant with a given key, so a
+// retry with the same key gets the original response instead of being
+// processed again
+type IdempotencyKey struct {
+	MerchantID     uuid.UUID
+	Key            string
+	RequestHash    string
+	Status         IdempotencyKeyStatus
+	ResponseStatus int
+	ResponseBody   []byte
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	// ExpiresAt is when the key can be reused as if it was never seen
+	ExpiresAt time.Time
+}
+
+// NewIdempotencyKey is a factory for a key whose request just started
+func NewIdempotencyKey(merchantID string, key string, requestHash string) (IdempotencyKey, error) {
+	merchantUUID, err := uuid.Parse(merchantID)
+	if err != nil {
+		return IdempotencyKey{}, err
+	}
+
+	now := time.Now().UTC()
+	k := IdempotencyKey{
+		MerchantID:  merchantUUID,
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      InProgress,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(InProgressTTL),
+	}
+
+	return k, k.Validate()
+}
+
+// Validate runs some checks to assert a key is valid
+func (k IdempotencyKey) Validate() error {
+	if k.Key == "" || len(k.Key) > MaxIdempotencyKeyLength {
+		return ErrInvalidIdempotencyKey
+	}
+	if k.RequestHash == "" {
+		return ErrMissingRequestHash
+	}
+	return nil
+}
+
+// Complete stores the response of the request, which is kept for CompletedTTL
+func (k *IdempotencyKey) Complete(responseStatus int, responseBody []byte, now time.Time) {
+	k.Status = Completed
+	k.ResponseStatus = responseStatus
+	k.ResponseBody = responseBody
+	k.UpdatedAt = now
+	k.ExpiresAt = now.Add(CompletedTTL)
+}
+
+// Expired tells if the key can be reused as if it was never seen
+func (k IdempotencyKey) Expired(now time.Time) bool {
+	if !k.ExpiresAt.IsZero() {
+		return now.After(k.ExpiresAt)
+	}
+
+	// keys stored before they had an expiry
+	switch k.Status {
+	case InProgress:
+		return now.Sub(k.UpdatedAt) > InProgressTTL
+	default:
+		return now.Sub(k.UpdatedAt) > CompletedTTL
+	}
+}
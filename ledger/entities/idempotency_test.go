@@ -0,0 +1,121 @@
+package entity_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
+)
+
+func TestIdempotencyKey_NewIdempotencyKey(t *testing.T) {
+	type testCase struct {
+		testName    string
+		merchantID  string
+		key         string
+		requestHash string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "valid_key",
+			merchantID:  uuid.New().String(),
+			key:         "order-123",
+			requestHash: "abc",
+			expectedErr: nil,
+		},
+		{
+			testName:    "empty_key",
+			merchantID:  uuid.New().String(),
+			key:         "",
+			requestHash: "abc",
+			expectedErr: entity.ErrInvalidIdempotencyKey,
+		},
+		{
+			testName:    "key_too_long",
+			merchantID:  uuid.New().String(),
+			key:         strings.Repeat("k", entity.MaxIdempotencyKeyLength+1),
+			requestHash: "abc",
+			expectedErr: entity.ErrInvalidIdempotencyKey,
+		},
+		{
+			testName:    "missing_request_hash",
+			merchantID:  uuid.New().String(),
+			key:         "order-123",
+			requestHash: "",
+			expectedErr: entity.ErrMissingRequestHash,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			k, err := entity.NewIdempotencyKey(tc.merchantID, tc.key, tc.requestHash)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if k.Status != entity.InProgress {
+				t.Errorf("expected Status=%v, got %v", entity.InProgress, k.Status)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKey_Expired(t *testing.T) {
+	now := time.Now()
+
+	type testCase struct {
+		testName  string
+		status    entity.IdempotencyKeyStatus
+		updatedAt time.Time
+		expiresAt time.Time
+		expected  bool
+	}
+
+	testCases := []testCase{
+		{testName: "fresh_in_progress", status: entity.InProgress, updatedAt: now, expiresAt: now.Add(entity.InProgressTTL), expected: false},
+		{testName: "in_progress_for_an_hour", status: entity.InProgress, updatedAt: now.Add(-time.Hour), expiresAt: now.Add(entity.InProgressTTL - time.Hour), expected: false},
+		{testName: "abandoned_in_progress", status: entity.InProgress, updatedAt: now.Add(-time.Hour), expiresAt: now.Add(-time.Minute), expected: true},
+		{testName: "fresh_completed", status: entity.Completed, updatedAt: now.Add(-time.Hour), expiresAt: now.Add(entity.CompletedTTL - time.Hour), expected: false},
+		{testName: "old_completed", status: entity.Completed, updatedAt: now.Add(-2 * entity.CompletedTTL), expiresAt: now.Add(-entity.CompletedTTL), expected: true},
+		{testName: "without_expiry_in_progress", status: entity.InProgress, updatedAt: now, expected: false},
+		{testName: "without_expiry_abandoned", status: entity.InProgress, updatedAt: now.Add(-2 * entity.InProgressTTL), expected: true},
+		{testName: "without_expiry_old_completed", status: entity.Completed, updatedAt: now.Add(-2 * entity.CompletedTTL), expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			k := entity.IdempotencyKey{Status: tc.status, UpdatedAt: tc.updatedAt, ExpiresAt: tc.expiresAt}
+			if k.Expired(now) != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, k.Expired(now))
+			}
+		})
+	}
+}
+
+func TestIdempotencyKey_Complete(t *testing.T) {
+	k, err := entity.NewIdempotencyKey(uuid.New().String(), "order-123", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	k.Complete(201, []byte(`{"id":"1"}`), now)
+
+	if k.Status != entity.Completed || k.ResponseStatus != 201 || string(k.ResponseBody) != `{"id":"1"}` {
+		t.Errorf("response was not stored: %v", k)
+	}
+	if k.Expired(now.Add(entity.CompletedTTL - time.Second)) {
+		t.Errorf("expected completed key to be kept for %s", entity.CompletedTTL)
+	}
+	if !k.Expired(now.Add(entity.CompletedTTL + time.Second)) {
+		t.Errorf("expected completed key to expire after %s", entity.CompletedTTL)
+	}
+}
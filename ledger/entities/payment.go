@@ -2,6 +2,7 @@ package entity
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,8 +15,69 @@ const (
 	Pending
 	Success
 	Fail
+	Authorized
+	Captured
+	Voided
 )
 
+// transitions lists, for every status, the statuses a payment can move to.
+// Success, Fail, Captured and Voided are terminal, so they are absent from
+// the table. Created and Pending may stay as they are, so repeated updates
+// are harmless, but Authorized may not, as authorizing again would push its
+// expiry back.
+var transitions = map[PaymentStatus][]PaymentStatus{
+	Created:    {Created, Pending, Fail},
+	Pending:    {Pending, Success, Authorized, Fail},
+	Authorized: {Captured, Voided},
+}
+
+// CaptureMode tells whether a payment approved by the bank is settled right
+// away, or only authorized until the merchant captures it
+type CaptureMode int
+
+const (
+	AutomaticCapture CaptureMode = iota
+	ManualCapture
+)
+
+func (ps PaymentStatus) String() string {
+	switch ps {
+	case Created:
+		return "CREATED"
+	case Pending:
+		return "PENDING"
+	case Success:
+		return "SUCCESS"
+	case Fail:
+		return "FAIL"
+	case Authorized:
+		return "AUTHORIZED"
+	case Captured:
+		return "CAPTURED"
+	case Voided:
+		return "VOIDED"
+	default:
+		return fmt.Sprintf("%d", ps)
+	}
+}
+
+// Terminal tells if a payment in this status can no longer be changed
+func (ps PaymentStatus) Terminal() bool {
+	_, ok := transitions[ps]
+	return !ok
+}
+
+// TransitionTo asserts a payment in this status can move to next, staying in
+// the same status is only allowed for Created and Pending
+func (ps PaymentStatus) TransitionTo(next PaymentStatus) error {
+	for _, allowed := range transitions[ps] {
+		if allowed == next {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, ps, next)
+}
+
 var (
 	// ErrNegativeAmount must be use when validating payment and amount is negative
 	ErrNegativeAmount = errors.New("negative amount")
@@ -23,28 +85,44 @@ var (
 	ErrMissingCurrency = errors.New("missing currency")
 	// ErrMissingValidationMethod must be use when validating payment and validation method is missing
 	ErrMissingValidationMethod = errors.New("missing validation method")
+	// ErrInvalidTransition must be used when a payment cannot move from its current status to the requested one
+	ErrInvalidTransition = errors.New("invalid payment status transition")
+	// ErrInvalidCaptureAmount must be used when capturing nothing or more than was authorized
+	ErrInvalidCaptureAmount = errors.New("invalid capture amount")
+	// ErrAuthorizationExpired must be used when capturing an authorization after it expired
+	ErrAuthorizationExpired = errors.New("authorization expired")
 )
 
+// Payment keeps Amount in minor units of Currency, e.g. 1050 USD is 10.50 USD
 type Payment struct {
 	ID               uuid.UUID
 	MerchantID       uuid.UUID
-	Amount           float64
+	Amount           int64
 	Currency         string
 	PurchaseTime     time.Time
 	ValidationMethod string
-	Card             CreditCard
+	Card             MaskedCard
 	Metadata         string
 	Status           PaymentStatus
 	BankPaymentID    uuid.UUID
 	BankRequestTime  time.Time
 	BankResponseTime time.Time
 	BankMessage      string
+	CaptureMode      CaptureMode
+	// AuthorizationExpiresAt is set when a payment with ManualCapture is
+	// authorized, after it the authorization is voided
+	AuthorizationExpiresAt time.Time
+	// CapturedAmount is the part of Amount captured, the rest is released
+	CapturedAmount int64
+	// QuotaConsumedAt is when the payment was counted in the quotas of its
+	// merchant, zero if it was not, so it can be given back to that period
+	QuotaConsumedAt time.Time
 }
 
-// NewPayment is a factory for a payment just created
+// NewPayment is a factory for a payment just created, it keeps card masked
 func NewPayment(
 	merchantID string,
-	amount float64,
+	amount int64,
 	currency string,
 	purchaseTimeUTC string,
 	validationMethod string,
@@ -67,25 +145,34 @@ func NewPayment(
 		Currency:         currency,
 		PurchaseTime:     purchaseTime,
 		ValidationMethod: validationMethod,
-		Card:             card,
+		Card:             card.Mask(),
 		Metadata:         metadata,
 		Status:           Created,
 	}
 
-	return p, p.Validate()
+	if err := p.Validate(); err != nil {
+		return p, err
+	}
+	return p, card.Validate()
 }
 
 // Validate runs some checks to asser a payment is valid
 func (p Payment) Validate() error {
-	if p.Amount < 0.0 {
+	if p.Amount < 0 {
 		return ErrNegativeAmount
 	}
 	if p.Currency == "" {
 		return ErrMissingCurrency
 	}
+	if _, err := CurrencyExponent(p.Currency); err != nil {
+		return err
+	}
 	if p.ValidationMethod == "" {
 		return ErrMissingValidationMethod
 	}
+	if p.CapturedAmount < 0 || p.CapturedAmount > p.Amount {
+		return ErrInvalidCaptureAmount
+	}
 	return p.Card.Validate()
 }
 
@@ -102,7 +189,62 @@ func (p Payment) Equal(other Payment) bool {
 		p.BankPaymentID == other.BankPaymentID &&
 		p.BankRequestTime == other.BankRequestTime &&
 		p.BankResponseTime == other.BankResponseTime &&
-		p.BankMessage == other.BankMessage
+		p.BankMessage == other.BankMessage &&
+		p.CaptureMode == other.CaptureMode &&
+		p.AuthorizationExpiresAt.Equal(other.AuthorizationExpiresAt) &&
+		p.CapturedAmount == other.CapturedAmount &&
+		p.QuotaConsumedAt.Equal(other.QuotaConsumedAt)
+}
+
+// Authorize records the bank approved a payment with ManualCapture, which
+// must be captured before expiresAt
+func (p *Payment) Authorize(expiresAt time.Time) error {
+	if err := p.Status.TransitionTo(Authorized); err != nil {
+		return err
+	}
+	p.Status = Authorized
+	p.AuthorizationExpiresAt = expiresAt
+	return nil
+}
+
+// Capture settles amount of an authorized payment, zero meaning all of it
+func (p *Payment) Capture(amount int64, now time.Time) error {
+	if err := p.Status.TransitionTo(Captured); err != nil {
+		return err
+	}
+	if now.After(p.AuthorizationExpiresAt) {
+		return fmt.Errorf("%w at %s", ErrAuthorizationExpired, p.AuthorizationExpiresAt.Format(time.RFC3339))
+	}
+	if amount == 0 {
+		amount = p.Amount
+	}
+	if amount < 0 || amount > p.Amount {
+		return fmt.Errorf("%w: %d of %d authorized", ErrInvalidCaptureAmount, amount, p.Amount)
+	}
+	p.Status = Captured
+	p.CapturedAmount = amount
+	return nil
+}
+
+// Void releases an authorized payment without capturing anything
+func (p *Payment) Void() error {
+	if err := p.Status.TransitionTo(Voided); err != nil {
+		return err
+	}
+	p.Status = Voided
+	return nil
+}
+
+// SettledAmount is how much of the payment was actually charged
+func (p Payment) SettledAmount() int64 {
+	switch p.Status {
+	case Success:
+		return p.Amount
+	case Captured:
+		return p.CapturedAmount
+	default:
+		return 0
+	}
 }
 
 func (p *Payment) SetPurchaseTimeFromStr(value string) error {
@@ -143,3 +285,30 @@ func (p *Payment) SetBankResponseTimeFromStr(value string) error {
 func (p *Payment) GetBankResponseTimeStr() string {
 	return p.BankResponseTime.Format("2006-01-02T15:04:05.000")
 }
+
+func (p *Payment) GetAuthorizationExpiresAtStr() string {
+	if p.AuthorizationExpiresAt.IsZero() {
+		return ""
+	}
+	return p.AuthorizationExpiresAt.Format("2006-01-02T15:04:05.000")
+}
+
+func (p *Payment) SetQuotaConsumedAtFromStr(value string) error {
+	if value == "" {
+		p.QuotaConsumedAt = time.Time{}
+		return nil
+	}
+	quotaConsumedAt, err := time.Parse("2006-01-02T15:04:05.000", value)
+	if err != nil {
+		return err
+	}
+	p.QuotaConsumedAt = quotaConsumedAt
+	return nil
+}
+
+func (p *Payment) GetQuotaConsumedAtStr() string {
+	if p.QuotaConsumedAt.IsZero() {
+		return ""
+	}
+	return p.QuotaConsumedAt.Format("2006-01-02T15:04:05.000")
+}
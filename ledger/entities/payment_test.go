@@ -3,6 +3,7 @@ package entity_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
@@ -12,7 +13,7 @@ func TestPayment_NewPayment(t *testing.T) {
 	type testCase struct {
 		testName         string
 		merchantID       string
-		amount           float64
+		amount           int64
 		currency         string
 		purchaseTimeUTC  string
 		validationMethod string
@@ -29,7 +30,7 @@ func TestPayment_NewPayment(t *testing.T) {
 		{
 			testName:         "valid_payment",
 			merchantID:       uuid.New().String(),
-			amount:           150.00,
+			amount:           15000,
 			currency:         "USD",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "push",
@@ -44,7 +45,7 @@ func TestPayment_NewPayment(t *testing.T) {
 		{
 			testName:         "negative_amount",
 			merchantID:       uuid.New().String(),
-			amount:           -150.00,
+			amount:           -15000,
 			currency:         "USD",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "push",
@@ -59,7 +60,7 @@ func TestPayment_NewPayment(t *testing.T) {
 		{
 			testName:         "missing_currency",
 			merchantID:       uuid.New().String(),
-			amount:           150.00,
+			amount:           15000,
 			currency:         "",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "push",
@@ -71,10 +72,25 @@ func TestPayment_NewPayment(t *testing.T) {
 			cardCvv:          123,
 			expectedErr:      entity.ErrMissingCurrency,
 		},
+		{
+			testName:         "unknown_currency",
+			merchantID:       uuid.New().String(),
+			amount:           15000,
+			currency:         "XYZ",
+			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
+			validationMethod: "push",
+			metadata:         "shopper-123",
+			cardName:         "name surname",
+			cardNumber:       "1111-2222-3333-4444",
+			cardExpireMonth:  10,
+			cardExpireYear:   2099,
+			cardCvv:          123,
+			expectedErr:      entity.ErrUnknownCurrency,
+		},
 		{
 			testName:         "valid_payment",
 			merchantID:       uuid.New().String(),
-			amount:           150.00,
+			amount:           15000,
 			currency:         "USD",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "",
@@ -112,3 +128,99 @@ func TestPayment_NewPayment(t *testing.T) {
 		})
 	}
 }
+
+func TestPaymentStatus_TransitionTo(t *testing.T) {
+	type testCase struct {
+		testName    string
+		from        entity.PaymentStatus
+		to          entity.PaymentStatus
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{testName: "created_to_created", from: entity.Created, to: entity.Created, expectedErr: nil},
+		{testName: "created_to_pending", from: entity.Created, to: entity.Pending, expectedErr: nil},
+		{testName: "created_to_fail", from: entity.Created, to: entity.Fail, expectedErr: nil},
+		{testName: "created_to_success", from: entity.Created, to: entity.Success, expectedErr: entity.ErrInvalidTransition},
+		{testName: "pending_to_pending", from: entity.Pending, to: entity.Pending, expectedErr: nil},
+		{testName: "pending_to_success", from: entity.Pending, to: entity.Success, expectedErr: nil},
+		{testName: "pending_to_fail", from: entity.Pending, to: entity.Fail, expectedErr: nil},
+		{testName: "pending_to_created", from: entity.Pending, to: entity.Created, expectedErr: entity.ErrInvalidTransition},
+		{testName: "pending_to_authorized", from: entity.Pending, to: entity.Authorized, expectedErr: nil},
+		{testName: "created_to_authorized", from: entity.Created, to: entity.Authorized, expectedErr: entity.ErrInvalidTransition},
+		{testName: "authorized_to_captured", from: entity.Authorized, to: entity.Captured, expectedErr: nil},
+		{testName: "authorized_to_voided", from: entity.Authorized, to: entity.Voided, expectedErr: nil},
+		{testName: "authorized_to_success", from: entity.Authorized, to: entity.Success, expectedErr: entity.ErrInvalidTransition},
+		{testName: "authorized_to_authorized", from: entity.Authorized, to: entity.Authorized, expectedErr: entity.ErrInvalidTransition},
+		{testName: "captured_to_voided", from: entity.Captured, to: entity.Voided, expectedErr: entity.ErrInvalidTransition},
+		{testName: "voided_to_captured", from: entity.Voided, to: entity.Captured, expectedErr: entity.ErrInvalidTransition},
+		{testName: "success_to_success", from: entity.Success, to: entity.Success, expectedErr: entity.ErrInvalidTransition},
+		{testName: "success_to_fail", from: entity.Success, to: entity.Fail, expectedErr: entity.ErrInvalidTransition},
+		{testName: "success_to_pending", from: entity.Success, to: entity.Pending, expectedErr: entity.ErrInvalidTransition},
+		{testName: "fail_to_fail", from: entity.Fail, to: entity.Fail, expectedErr: entity.ErrInvalidTransition},
+		{testName: "fail_to_success", from: entity.Fail, to: entity.Success, expectedErr: entity.ErrInvalidTransition},
+		{testName: "fail_to_created", from: entity.Fail, to: entity.Created, expectedErr: entity.ErrInvalidTransition},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := tc.from.TransitionTo(tc.to)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+			terminal := tc.from == entity.Success || tc.from == entity.Fail ||
+				tc.from == entity.Captured || tc.from == entity.Voided
+			if tc.from.Terminal() != terminal {
+				t.Errorf("unexpected Terminal()=%v for %s", tc.from.Terminal(), tc.from)
+			}
+		})
+	}
+}
+
+func TestPayment_Capture(t *testing.T) {
+	now := time.Date(2023, 5, 18, 1, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		testName       string
+		status         entity.PaymentStatus
+		expiresAt      time.Time
+		amount         int64
+		expectedAmount int64
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{testName: "full_capture", status: entity.Authorized, expiresAt: now.Add(time.Hour), amount: 0, expectedAmount: 1000, expectedErr: nil},
+		{testName: "partial_capture", status: entity.Authorized, expiresAt: now.Add(time.Hour), amount: 400, expectedAmount: 400, expectedErr: nil},
+		{testName: "more_than_authorized", status: entity.Authorized, expiresAt: now.Add(time.Hour), amount: 1001, expectedErr: entity.ErrInvalidCaptureAmount},
+		{testName: "negative_amount", status: entity.Authorized, expiresAt: now.Add(time.Hour), amount: -1, expectedErr: entity.ErrInvalidCaptureAmount},
+		{testName: "expired", status: entity.Authorized, expiresAt: now.Add(-time.Second), amount: 0, expectedErr: entity.ErrAuthorizationExpired},
+		{testName: "not_authorized", status: entity.Success, expiresAt: now.Add(time.Hour), amount: 0, expectedErr: entity.ErrInvalidTransition},
+		{testName: "already_voided", status: entity.Voided, expiresAt: now.Add(time.Hour), amount: 0, expectedErr: entity.ErrInvalidTransition},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			p := entity.Payment{Amount: 1000, Status: tc.status, AuthorizationExpiresAt: tc.expiresAt}
+
+			err := p.Capture(tc.amount, now)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				if p.Status != tc.status {
+					t.Errorf("expected status to stay %s, got %s", tc.status, p.Status)
+				}
+				return
+			}
+
+			if p.Status != entity.Captured || p.CapturedAmount != tc.expectedAmount {
+				t.Errorf("expected CAPTURED %d, got %s %d", tc.expectedAmount, p.Status, p.CapturedAmount)
+			}
+			if p.SettledAmount() != tc.expectedAmount {
+				t.Errorf("expected SettledAmount=%d, got %d", tc.expectedAmount, p.SettledAmount())
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidRefundAmount must be used when a refund amount is not positive
+	ErrInvalidRefundAmount = errors.New("refund amount must be positive")
+	// ErrRefundCurrencyMismatch must be used when a refund is not in the currency of its payment
+	ErrRefundCurrencyMismatch = errors.New("refund currency does not match payment")
+	// ErrPaymentNotRefundable must be used when refunding a payment that was not charged
+	ErrPaymentNotRefundable = errors.New("only successful or captured payments can be refunded")
+	// ErrRefundExceedsBalance must be used when a refund is larger than what is left to refund
+	ErrRefundExceedsBalance = errors.New("refund exceeds refundable balance")
+)
+
+// Refund returns part or all of a successful or captured payment to the shopper. It goes
+// through the same statuses as a payment, an Amount of zero before the
+// refund is created means whatever is left to refund.
+type Refund struct {
+	ID               uuid.UUID
+	PaymentID        uuid.UUID
+	MerchantID       uuid.UUID
+	Amount           int64
+	Currency         string
+	Status           PaymentStatus
+	BankRefundID     uuid.UUID
+	BankRequestTime  time.Time
+	BankResponseTime time.Time
+	BankMessage      string
+	CreatedAt        time.Time
+}
+
+// NewRefund is a factory for a refund just created
+func NewRefund(paymentID string, amount int64) (Refund, error) {
+	paymentUUID, err := uuid.Parse(paymentID)
+	if err != nil {
+		return Refund{}, err
+	}
+
+	if amount < 0 {
+		return Refund{}, ErrInvalidRefundAmount
+	}
+
+	return Refund{
+		PaymentID: paymentUUID,
+		Amount:    amount,
+		Status:    Created,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Validate runs some checks to assert a refund is valid
+func (r Refund) Validate() error {
+	if r.Amount <= 0 {
+		return ErrInvalidRefundAmount
+	}
+	if _, err := CurrencyExponent(r.Currency); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RefundableBalance is how much of p is left to refund, refunds that failed
+// do not count, refunds still in progress do
+func RefundableBalance(p Payment, refunds []Refund) int64 {
+	balance := p.SettledAmount()
+	for _, r := range refunds {
+		if r.Status != Fail {
+			balance -= r.Amount
+		}
+	}
+	return balance
+}
+
+// PrepareRefund fills r with what it inherits from p, and asserts it can be
+// created given the refunds p already has
+func PrepareRefund(p Payment, refunds []Refund, r Refund) (Refund, error) {
+	if p.Status != Success && p.Status != Captured {
+		return Refund{}, fmt.Errorf("%w: payment is %s", ErrPaymentNotRefundable, p.Status)
+	}
+
+	balance := RefundableBalance(p, refunds)
+	if r.Amount == 0 {
+		r.Amount = balance
+	}
+	if r.Currency == "" {
+		r.Currency = p.Currency
+	}
+	if r.Currency != p.Currency {
+		return Refund{}, ErrRefundCurrencyMismatch
+	}
+	if r.Amount > balance || balance == 0 {
+		return Refund{}, fmt.Errorf("%w: %d left, %d requested", ErrRefundExceedsBalance, balance, r.Amount)
+	}
+	r.MerchantID = p.MerchantID
+
+	return r, r.Validate()
+}
+
+func (r *Refund) SetBankRequestTimeFromStr(value string) error {
+	bankRequestTime, err := time.Parse("2006-01-02T15:04:05.000", value)
+	if err != nil {
+		return err
+	}
+	r.BankRequestTime = bankRequestTime
+	return nil
+}
+
+func (r *Refund) GetBankRequestTimeStr() string {
+	return r.BankRequestTime.Format("2006-01-02T15:04:05.000")
+}
+
+func (r *Refund) SetBankResponseTimeFromStr(value string) error {
+	bankResponseTime, err := time.Parse("2006-01-02T15:04:05.000", value)
+	if err != nil {
+		return err
+	}
+	r.BankResponseTime = bankResponseTime
+	return nil
+}
+
+func (r *Refund) GetBankResponseTimeStr() string {
+	return r.BankResponseTime.Format("2006-01-02T15:04:05.000")
+}
+
+func (r *Refund) GetCreatedAtStr() string {
+	return r.CreatedAt.Format("2006-01-02T15:04:05.000")
+}
@@ -0,0 +1,166 @@
+package entity_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
+)
+
+func TestRefund_NewRefund(t *testing.T) {
+	type testCase struct {
+		testName    string
+		paymentID   string
+		amount      int64
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{testName: "partial_refund", paymentID: uuid.New().String(), amount: 500, expectedErr: nil},
+		{testName: "full_refund", paymentID: uuid.New().String(), amount: 0, expectedErr: nil},
+		{testName: "negative_amount", paymentID: uuid.New().String(), amount: -1, expectedErr: entity.ErrInvalidRefundAmount},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			r, err := entity.NewRefund(tc.paymentID, tc.amount)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if r.Status != entity.Created {
+				t.Errorf("expected Status=%v, got %v", entity.Created, r.Status)
+			}
+		})
+	}
+}
+
+func TestRefund_PrepareRefund(t *testing.T) {
+	payment := entity.Payment{
+		ID:         uuid.New(),
+		MerchantID: uuid.New(),
+		Amount:     1000,
+		Currency:   "USD",
+		Status:     entity.Success,
+	}
+	pendingPayment := payment
+	pendingPayment.Status = entity.Pending
+	capturedPayment := payment
+	capturedPayment.Status = entity.Captured
+	capturedPayment.CapturedAmount = 600
+	voidedPayment := payment
+	voidedPayment.Status = entity.Voided
+
+	partial := []entity.Refund{
+		{Amount: 300, Status: entity.Success},
+		{Amount: 200, Status: entity.Pending},
+		{Amount: 400, Status: entity.Fail},
+	}
+	full := []entity.Refund{{Amount: 1000, Status: entity.Pending}}
+
+	type testCase struct {
+		testName       string
+		payment        entity.Payment
+		refunds        []entity.Refund
+		refund         entity.Refund
+		expectedAmount int64
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			testName:       "first_partial_refund",
+			payment:        payment,
+			refund:         entity.Refund{Amount: 100},
+			expectedAmount: 100,
+			expectedErr:    nil,
+		},
+		{
+			testName:       "failed_refunds_do_not_count",
+			payment:        payment,
+			refunds:        partial,
+			refund:         entity.Refund{Amount: 500},
+			expectedAmount: 500,
+			expectedErr:    nil,
+		},
+		{
+			testName:       "zero_refunds_what_is_left",
+			payment:        payment,
+			refunds:        partial,
+			refund:         entity.Refund{},
+			expectedAmount: 500,
+			expectedErr:    nil,
+		},
+		{
+			testName:    "over_refund",
+			payment:     payment,
+			refunds:     partial,
+			refund:      entity.Refund{Amount: 501},
+			expectedErr: entity.ErrRefundExceedsBalance,
+		},
+		{
+			testName:    "nothing_left",
+			payment:     payment,
+			refunds:     full,
+			refund:      entity.Refund{},
+			expectedErr: entity.ErrRefundExceedsBalance,
+		},
+		{
+			testName:    "payment_not_successful",
+			payment:     pendingPayment,
+			refund:      entity.Refund{Amount: 100},
+			expectedErr: entity.ErrPaymentNotRefundable,
+		},
+		{
+			testName:       "captured_up_to_captured_amount",
+			payment:        capturedPayment,
+			refunds:        partial,
+			refund:         entity.Refund{},
+			expectedAmount: 100,
+			expectedErr:    nil,
+		},
+		{
+			testName:    "over_captured_amount",
+			payment:     capturedPayment,
+			refund:      entity.Refund{Amount: 601},
+			expectedErr: entity.ErrRefundExceedsBalance,
+		},
+		{
+			testName:    "payment_voided",
+			payment:     voidedPayment,
+			refund:      entity.Refund{Amount: 100},
+			expectedErr: entity.ErrPaymentNotRefundable,
+		},
+		{
+			testName:    "other_currency",
+			payment:     payment,
+			refund:      entity.Refund{Amount: 100, Currency: "EUR"},
+			expectedErr: entity.ErrRefundCurrencyMismatch,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			r, err := entity.PrepareRefund(tc.payment, tc.refunds, tc.refund)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if r.Amount != tc.expectedAmount {
+				t.Errorf("expected Amount=%d, got %d", tc.expectedAmount, r.Amount)
+			}
+			if r.Currency != tc.payment.Currency || r.MerchantID != tc.payment.MerchantID {
+				t.Errorf("refund did not inherit currency and merchant: %v", r)
+			}
+		})
+	}
+}
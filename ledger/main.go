@@ -2,30 +2,49 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
 	"github.com/thiagolcmelo/payment-gateway/ledger/pb"
 	"github.com/thiagolcmelo/payment-gateway/ledger/storage"
+	"github.com/thiagolcmelo/payment-gateway/ledger/storage/file"
 	"github.com/thiagolcmelo/payment-gateway/ledger/storage/memory"
+	"github.com/thiagolcmelo/payment-gateway/ledger/vault"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 var (
-	portFlag      = flag.Int("port", 50053, "The server port")
-	hostFlag      = flag.String("host", "0.0.0.0", "The server host")
-	ipVersionFlag = flag.Int("ip-version", 4, "The server ip version (4 for IPv4, 6 for IPv6)")
+	portFlag          = flag.Int("port", 50053, "The server port")
+	hostFlag          = flag.String("host", "0.0.0.0", "The server host")
+	ipVersionFlag     = flag.Int("ip-version", 4, "The server ip version (4 for IPv4, 6 for IPv6)")
+	storageFlag       = flag.String("storage", "memory", "Where payments are kept (memory or file)")
+	dataDirFlag       = flag.String("data-dir", "data", "Directory for payments when using file storage")
+	snapshotFlag      = flag.Int("snapshot-every", 1000, "Number of log records between snapshots when using file storage")
+	vaultKeyFlag      = flag.String("vault-key", "", "Base64 encoded 32 bytes key that encrypts the card numbers in the vault")
+	authTTLFlag       = flag.String("authorization-ttl", "168h", "How long an authorized payment can wait to be captured before it is voided")
+	inProgressTTLFlag = flag.String("idempotency-in-progress-ttl", "24h", "How long a request holds its idempotency key before it is considered abandoned and the key can be claimed again")
+	sweepFlag         = flag.String("expiry-sweep-interval", "1m", "How often expired idempotency keys are forgotten")
 )
 
 type server struct {
-	storage storage.Storage
+	storage          storage.Storage
+	vault            *vault.Vault
+	authorizationTTL time.Duration
+	inProgressTTL    time.Duration
 	pb.UnimplementedLedgerServiceServer
 }
 
@@ -35,6 +54,52 @@ func newServerWithMemoryStorage() *server {
 	}
 }
 
+func newServerWithFileStorage(dir string, snapshotEvery int) (*server, error) {
+	fs, err := file.NewFileStorage(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+	return &server{
+		storage: fs,
+	}, nil
+}
+
+func newServer(storageType, dir string, snapshotEvery int) (*server, error) {
+	switch storageType {
+	case "memory":
+		return newServerWithMemoryStorage(), nil
+	case "file":
+		return newServerWithFileStorage(dir, snapshotEvery)
+	default:
+		return nil, fmt.Errorf("unknown storage: %s", storageType)
+	}
+}
+
+// newVault keeps card numbers next to the payments, in memory or in dir, a
+// memory vault without a key gets a random one since it is lost on restart
+func newVault(storageType, dir, key string) (*vault.Vault, error) {
+	masterKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("vault key is not base64: %w", err)
+	}
+
+	switch storageType {
+	case "memory":
+		if len(masterKey) == 0 {
+			log.Print("no vault key given, using a random one")
+			masterKey = make([]byte, vault.KeySize)
+			if _, err := rand.Read(masterKey); err != nil {
+				return nil, err
+			}
+		}
+		return vault.NewMemoryVault(masterKey)
+	case "file":
+		return vault.NewFileVault(masterKey, filepath.Join(dir, "vault.log"))
+	default:
+		return nil, fmt.Errorf("unknown storage: %s", storageType)
+	}
+}
+
 func (s *server) CreatePayment(ctx context.Context, req *pb.CreatePaymentRequest) (*pb.CreatePaymentResponse, error) {
 	card, err := entity.NewCreditCard(req.Card.Number, req.Card.Name, int(req.Card.ExpireMonth), int(req.Card.ExpireYear), int(req.Card.Cvv))
 	if err != nil {
@@ -42,12 +107,24 @@ func (s *server) CreatePayment(ctx context.Context, req *pb.CreatePaymentRequest
 		return nil, err
 	}
 
-	payment, err := entity.NewPayment(req.MerchantId, float64(req.Amount), req.Currency, req.PurchaseTimeUtc, req.ValidationMethod, card, req.Metadata)
+	payment, err := entity.NewPayment(req.MerchantId, req.AmountMinor, req.Currency, req.PurchaseTimeUtc, req.ValidationMethod, card, req.Metadata)
 	if err != nil {
 		log.Printf("error parsing payment in CreatePayment: %v", err)
 		return nil, err
 	}
 	payment.Status = entity.Created
+	payment.CaptureMode = entity.CaptureMode(req.CaptureMode)
+	if err := payment.SetQuotaConsumedAtFromStr(req.QuotaConsumedAtUtc); err != nil {
+		log.Printf("error parsing date in CreatePayment: %v", err)
+		return nil, err
+	}
+
+	// only the vault keeps the number, and the cvv is dropped with req
+	payment.Card.Token, err = s.vault.Tokenize(card.Number)
+	if err != nil {
+		log.Printf("error tokenizing card in CreatePayment: %v", err)
+		return nil, err
+	}
 
 	id, err := s.storage.Create(payment)
 	if err != nil {
@@ -74,27 +151,7 @@ func (s *server) ReadPayment(ctx context.Context, req *pb.ReadPaymentRequest) (*
 	}
 
 	return &pb.ReadPaymentResponse{
-		Payment: &pb.Payment{
-			Id:               payment.ID.String(),
-			MerchantId:       payment.MerchantID.String(),
-			Amount:           float32(payment.Amount), // TODO: find better solution
-			Currency:         payment.Currency,
-			PurchaseTimeUtc:  payment.GetPurchaseTimeStr(),
-			ValidationMethod: payment.ValidationMethod,
-			Card: &pb.CreditCard{
-				Number:      payment.Card.Number,
-				Name:        payment.Card.Name,
-				ExpireMonth: int32(payment.Card.ExpireMonth),
-				ExpireYear:  int32(payment.Card.ExpireYear),
-				Cvv:         int32(payment.Card.CVV),
-			},
-			Metadata:            payment.Metadata,
-			Status:              pb.PaymentStatus(payment.Status),
-			BankPaymentId:       payment.BankPaymentID.String(),
-			BankRequestTimeUtc:  payment.GetBankRequestTimeStr(),
-			BankResponseTimeUtc: payment.GetBankResponseTimeStr(),
-			BankMessage:         payment.BankMessage,
-		},
+		Payment: newPbPayment(payment),
 	}, nil
 }
 
@@ -112,27 +169,7 @@ func (s *server) ReadPaymentUsingBankReference(ctx context.Context, req *pb.Read
 	}
 
 	return &pb.ReadPaymentUsingBankReferenceResponse{
-		Payment: &pb.Payment{
-			Id:               payment.ID.String(),
-			MerchantId:       payment.MerchantID.String(),
-			Amount:           float32(payment.Amount), // TODO: find better solution
-			Currency:         payment.Currency,
-			PurchaseTimeUtc:  payment.GetPurchaseTimeStr(),
-			ValidationMethod: payment.ValidationMethod,
-			Card: &pb.CreditCard{
-				Number:      payment.Card.Number,
-				Name:        payment.Card.Name,
-				ExpireMonth: int32(payment.Card.ExpireMonth),
-				ExpireYear:  int32(payment.Card.ExpireYear),
-				Cvv:         int32(payment.Card.CVV),
-			},
-			Metadata:            payment.Metadata,
-			Status:              pb.PaymentStatus(payment.Status),
-			BankPaymentId:       payment.BankPaymentID.String(),
-			BankRequestTimeUtc:  payment.GetBankRequestTimeStr(),
-			BankResponseTimeUtc: payment.GetBankResponseTimeStr(),
-			BankMessage:         payment.BankMessage,
-		},
+		Payment: newPbPayment(payment),
 	}, nil
 }
 
@@ -166,7 +203,7 @@ func (s *server) UpdatePaymentToPending(ctx context.Context, req *pb.UpdatePayme
 	err = s.storage.Update(payment)
 	if err != nil {
 		log.Printf("error updating payment in UpdatePaymentToPending: %v", err)
-		return nil, err
+		return nil, storageError(err)
 	}
 
 	return &pb.UpdatePaymentToPendingResponse{}, nil
@@ -202,7 +239,7 @@ func (s *server) UpdatePaymentToSuccess(ctx context.Context, req *pb.UpdatePayme
 	err = s.storage.Update(payment)
 	if err != nil {
 		log.Printf("error updating payment in UpdatePaymentToSuccess: %v", err)
-		return nil, err
+		return nil, storageError(err)
 	}
 
 	return &pb.UpdatePaymentToSuccessResponse{}, nil
@@ -251,12 +288,529 @@ func (s *server) UpdatePaymentToFail(ctx context.Context, req *pb.UpdatePaymentT
 	err = s.storage.Update(payment)
 	if err != nil {
 		log.Printf("error updating payment in UpdatePaymentToSuccess: %v", err)
-		return nil, err
+		return nil, storageError(err)
 	}
 
 	return &pb.UpdatePaymentToFailResponse{}, nil
 }
 
+func (s *server) UpdatePaymentToAuthorized(ctx context.Context, req *pb.UpdatePaymentToAuthorizedRequest) (*pb.UpdatePaymentToAuthorizedResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in UpdatePaymentToAuthorized: %v", err)
+		return nil, err
+	}
+
+	bankPaymentID, err := uuid.Parse(req.BankPaymentId)
+	if err != nil {
+		log.Printf("error parsing bank uuid in UpdatePaymentToAuthorized: %v", err)
+		return nil, err
+	}
+
+	payment, err := s.storage.Read(id)
+	if err != nil {
+		log.Printf("error reading payment in UpdatePaymentToAuthorized: %v", err)
+		return nil, storageError(err)
+	}
+	if payment.CaptureMode != entity.ManualCapture {
+		return nil, status.Error(codes.FailedPrecondition, "payment is captured automatically")
+	}
+	err = payment.SetBankResponseTimeFromStr(req.BankResponseTimeUtc)
+	if err != nil {
+		log.Printf("error parsing date in UpdatePaymentToAuthorized: %v", err)
+		return nil, err
+	}
+	payment.BankPaymentID = bankPaymentID
+	payment.BankMessage = req.BankMessage
+	err = payment.Authorize(time.Now().UTC().Add(s.authorizationTTL))
+	if err != nil {
+		log.Printf("error authorizing payment in UpdatePaymentToAuthorized: %v", err)
+		return nil, storageError(err)
+	}
+
+	err = s.storage.Update(payment)
+	if err != nil {
+		log.Printf("error updating payment in UpdatePaymentToAuthorized: %v", err)
+		return nil, storageError(err)
+	}
+
+	return &pb.UpdatePaymentToAuthorizedResponse{
+		AuthorizationExpiresAtUtc: payment.GetAuthorizationExpiresAtStr(),
+	}, nil
+}
+
+func (s *server) CapturePayment(ctx context.Context, req *pb.CapturePaymentRequest) (*pb.CapturePaymentResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in CapturePayment: %v", err)
+		return nil, err
+	}
+
+	payment, err := s.storage.Read(id)
+	if err != nil {
+		log.Printf("error reading payment in CapturePayment: %v", err)
+		return nil, storageError(err)
+	}
+	err = payment.Capture(req.AmountMinor, time.Now().UTC())
+	if err != nil {
+		log.Printf("error capturing payment in CapturePayment: %v", err)
+		return nil, storageError(err)
+	}
+
+	err = s.storage.Update(payment)
+	if err != nil {
+		log.Printf("error updating payment in CapturePayment: %v", err)
+		return nil, storageError(err)
+	}
+
+	return &pb.CapturePaymentResponse{
+		CapturedAmountMinor: payment.CapturedAmount,
+	}, nil
+}
+
+func (s *server) VoidPayment(ctx context.Context, req *pb.VoidPaymentRequest) (*pb.VoidPaymentResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in VoidPayment: %v", err)
+		return nil, err
+	}
+
+	payment, err := s.storage.Read(id)
+	if err != nil {
+		log.Printf("error reading payment in VoidPayment: %v", err)
+		return nil, storageError(err)
+	}
+	err = payment.Void()
+	if err != nil {
+		log.Printf("error voiding payment in VoidPayment: %v", err)
+		return nil, storageError(err)
+	}
+
+	err = s.storage.Update(payment)
+	if err != nil {
+		log.Printf("error updating payment in VoidPayment: %v", err)
+		return nil, storageError(err)
+	}
+
+	return &pb.VoidPaymentResponse{}, nil
+}
+
+// ExpireAuthorizations voids the authorizations that were not captured in
+// time and returns them, so the caller can release them at the bank and tell
+// the merchants. Payments voided before an error are returned anyway, a later
+// call would not return them again.
+func (s *server) ExpireAuthorizations(ctx context.Context, req *pb.ExpireAuthorizationsRequest) (*pb.ExpireAuthorizationsResponse, error) {
+	expired, err := s.storage.ExpireAuthorizations(time.Now().UTC())
+	if err != nil {
+		log.Printf("error voiding expired authorizations: %v", err)
+		if len(expired) == 0 {
+			return nil, storageError(err)
+		}
+	}
+
+	resp := &pb.ExpireAuthorizationsResponse{
+		Payments: make([]*pb.Payment, 0, len(expired)),
+	}
+	for _, p := range expired {
+		log.Printf("authorization of payment %s expired", p.ID)
+		resp.Payments = append(resp.Payments, newPbPayment(p))
+	}
+
+	return resp, nil
+}
+
+// expireIdempotencyKeys forgets, every interval, the idempotency keys that
+// expired, so their responses do not pile up
+func (s *server) expireIdempotencyKeys(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		expired, err := s.storage.ExpireIdempotencyKeys(now.UTC())
+		if err != nil {
+			log.Printf("error forgetting expired idempotency keys: %v", err)
+		}
+		if expired > 0 {
+			log.Printf("forgot %d expired idempotency keys", expired)
+		}
+	}
+}
+
+func (s *server) ListPayments(ctx context.Context, req *pb.ListPaymentsRequest) (*pb.ListPaymentsResponse, error) {
+	filter, err := newPaymentFilter(req)
+	if err != nil {
+		log.Printf("error parsing filter in ListPayments: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	payments, next, err := s.storage.List(filter)
+	if err != nil {
+		log.Printf("error listing payments in ListPayments: %v", err)
+		return nil, err
+	}
+
+	resp := &pb.ListPaymentsResponse{
+		Payments: make([]*pb.Payment, 0, len(payments)),
+	}
+	for _, payment := range payments {
+		resp.Payments = append(resp.Payments, newPbPayment(payment))
+	}
+	if next != nil {
+		resp.NextCursor = next.Encode()
+	}
+
+	return resp, nil
+}
+
+// newPaymentFilter converts a ListPayments request into a valid filter
+func newPaymentFilter(req *pb.ListPaymentsRequest) (entity.PaymentFilter, error) {
+	merchantID, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		return entity.PaymentFilter{}, err
+	}
+
+	filter := entity.PaymentFilter{
+		MerchantID: merchantID,
+		Currency:   req.Currency,
+		MinAmount:  req.MinAmountMinor,
+		MaxAmount:  req.MaxAmountMinor,
+		Metadata:   req.Metadata,
+		Limit:      int(req.Limit),
+	}
+	if req.Status != nil {
+		paymentStatus := entity.PaymentStatus(*req.Status)
+		filter.Status = &paymentStatus
+	}
+	if req.PurchaseTimeFromUtc != "" {
+		filter.PurchasedFrom, err = time.Parse("2006-01-02T15:04:05.000", req.PurchaseTimeFromUtc)
+		if err != nil {
+			return entity.PaymentFilter{}, err
+		}
+	}
+	if req.PurchaseTimeToUtc != "" {
+		filter.PurchasedTo, err = time.Parse("2006-01-02T15:04:05.000", req.PurchaseTimeToUtc)
+		if err != nil {
+			return entity.PaymentFilter{}, err
+		}
+	}
+	if req.Cursor != "" {
+		cursor, err := entity.DecodePaymentCursor(req.Cursor)
+		if err != nil {
+			return entity.PaymentFilter{}, err
+		}
+		filter.Cursor = &cursor
+	}
+
+	return filter, filter.Validate()
+}
+
+// newPbPayment converts a payment into its gRPC message
+func newPbPayment(payment entity.Payment) *pb.Payment {
+	return &pb.Payment{
+		Id:               payment.ID.String(),
+		MerchantId:       payment.MerchantID.String(),
+		AmountMinor:      payment.Amount,
+		Currency:         payment.Currency,
+		PurchaseTimeUtc:  payment.GetPurchaseTimeStr(),
+		ValidationMethod: payment.ValidationMethod,
+		MaskedCard: &pb.MaskedCard{
+			Brand:       payment.Card.Brand,
+			Last4:       payment.Card.Last4,
+			Name:        payment.Card.Name,
+			ExpireMonth: int32(payment.Card.ExpireMonth),
+			ExpireYear:  int32(payment.Card.ExpireYear),
+		},
+		Metadata:                  payment.Metadata,
+		Status:                    pb.PaymentStatus(payment.Status),
+		BankPaymentId:             payment.BankPaymentID.String(),
+		BankRequestTimeUtc:        payment.GetBankRequestTimeStr(),
+		BankResponseTimeUtc:       payment.GetBankResponseTimeStr(),
+		BankMessage:               payment.BankMessage,
+		CaptureMode:               pb.CaptureMode(payment.CaptureMode),
+		AuthorizationExpiresAtUtc: payment.GetAuthorizationExpiresAtStr(),
+		CapturedAmountMinor:       payment.CapturedAmount,
+		QuotaConsumedAtUtc:        payment.GetQuotaConsumedAtStr(),
+	}
+}
+
+func (s *server) CreateRefund(ctx context.Context, req *pb.CreateRefundRequest) (*pb.CreateRefundResponse, error) {
+	refund, err := entity.NewRefund(req.PaymentId, req.AmountMinor)
+	if err != nil {
+		log.Printf("error parsing refund in CreateRefund: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	refund, err = s.storage.CreateRefund(refund)
+	if err != nil {
+		log.Printf("error saving refund in CreateRefund: %v", err)
+		return nil, storageError(err)
+	}
+
+	return &pb.CreateRefundResponse{
+		Refund: newPbRefund(refund),
+	}, nil
+}
+
+func (s *server) ReadRefund(ctx context.Context, req *pb.ReadRefundRequest) (*pb.ReadRefundResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in ReadRefund: %v", err)
+		return nil, err
+	}
+
+	refund, err := s.storage.ReadRefund(id)
+	if err != nil {
+		log.Printf("error reading refund in ReadRefund: %v", err)
+		return nil, err
+	}
+
+	return &pb.ReadRefundResponse{
+		Refund: newPbRefund(refund),
+	}, nil
+}
+
+func (s *server) ReadRefundUsingBankReference(ctx context.Context, req *pb.ReadRefundUsingBankReferenceRequest) (*pb.ReadRefundUsingBankReferenceResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in ReadRefundUsingBankReference: %v", err)
+		return nil, err
+	}
+
+	refund, err := s.storage.ReadRefundUsingBankReference(id)
+	if err != nil {
+		log.Printf("error reading refund in ReadRefundUsingBankReference: %v", err)
+		return nil, err
+	}
+
+	return &pb.ReadRefundUsingBankReferenceResponse{
+		Refund: newPbRefund(refund),
+	}, nil
+}
+
+func (s *server) UpdateRefundToPending(ctx context.Context, req *pb.UpdateRefundToPendingRequest) (*pb.UpdateRefundToPendingResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in UpdateRefundToPending: %v", err)
+		return nil, err
+	}
+
+	bankRefundID, err := uuid.Parse(req.BankRefundId)
+	if err != nil {
+		log.Printf("error parsing bank uuid in UpdateRefundToPending: %v", err)
+		return nil, err
+	}
+
+	refund, err := s.storage.ReadRefund(id)
+	if err != nil {
+		log.Printf("error reading refund in UpdateRefundToPending: %v", err)
+		return nil, err
+	}
+
+	err = refund.SetBankRequestTimeFromStr(req.BankRequestTimeUtc)
+	if err != nil {
+		log.Printf("error parsing date in UpdateRefundToPending: %v", err)
+		return nil, err
+	}
+	refund.BankRefundID = bankRefundID
+	refund.Status = entity.Pending
+
+	err = s.storage.UpdateRefund(refund)
+	if err != nil {
+		log.Printf("error updating refund in UpdateRefundToPending: %v", err)
+		return nil, storageError(err)
+	}
+
+	return &pb.UpdateRefundToPendingResponse{}, nil
+}
+
+func (s *server) UpdateRefundToSuccess(ctx context.Context, req *pb.UpdateRefundToSuccessRequest) (*pb.UpdateRefundToSuccessResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in UpdateRefundToSuccess: %v", err)
+		return nil, err
+	}
+
+	refund, err := s.storage.ReadRefund(id)
+	if err != nil {
+		log.Printf("error reading refund in UpdateRefundToSuccess: %v", err)
+		return nil, err
+	}
+
+	err = refund.SetBankResponseTimeFromStr(req.BankResponseTimeUtc)
+	if err != nil {
+		log.Printf("error parsing date in UpdateRefundToSuccess: %v", err)
+		return nil, err
+	}
+	refund.BankMessage = req.BankMessage
+	refund.Status = entity.Success
+
+	err = s.storage.UpdateRefund(refund)
+	if err != nil {
+		log.Printf("error updating refund in UpdateRefundToSuccess: %v", err)
+		return nil, storageError(err)
+	}
+
+	return &pb.UpdateRefundToSuccessResponse{}, nil
+}
+
+func (s *server) UpdateRefundToFail(ctx context.Context, req *pb.UpdateRefundToFailRequest) (*pb.UpdateRefundToFailResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in UpdateRefundToFail: %v", err)
+		return nil, err
+	}
+
+	refund, err := s.storage.ReadRefund(id)
+	if err != nil {
+		log.Printf("error reading refund in UpdateRefundToFail: %v", err)
+		return nil, err
+	}
+
+	// the bank may never have seen the refund, e.g. when it was unreachable
+	refund.BankResponseTime = time.Now().UTC()
+	if req.BankResponseTimeUtc != nil {
+		err = refund.SetBankResponseTimeFromStr(*req.BankResponseTimeUtc)
+		if err != nil {
+			log.Printf("error parsing date in UpdateRefundToFail: %v", err)
+			return nil, err
+		}
+	}
+	if req.BankMessage != nil {
+		refund.BankMessage = *req.BankMessage
+	}
+	refund.Status = entity.Fail
+
+	err = s.storage.UpdateRefund(refund)
+	if err != nil {
+		log.Printf("error updating refund in UpdateRefundToFail: %v", err)
+		return nil, storageError(err)
+	}
+
+	return &pb.UpdateRefundToFailResponse{}, nil
+}
+
+// newPbRefund converts a refund into its gRPC message
+func newPbRefund(refund entity.Refund) *pb.Refund {
+	return &pb.Refund{
+		Id:                  refund.ID.String(),
+		PaymentId:           refund.PaymentID.String(),
+		MerchantId:          refund.MerchantID.String(),
+		AmountMinor:         refund.Amount,
+		Currency:            refund.Currency,
+		Status:              pb.PaymentStatus(refund.Status),
+		BankRefundId:        refund.BankRefundID.String(),
+		BankRequestTimeUtc:  refund.GetBankRequestTimeStr(),
+		BankResponseTimeUtc: refund.GetBankResponseTimeStr(),
+		BankMessage:         refund.BankMessage,
+		CreatedAtUtc:        refund.GetCreatedAtStr(),
+	}
+}
+
+func (s *server) StartIdempotentRequest(ctx context.Context, req *pb.StartIdempotentRequestRequest) (*pb.StartIdempotentRequestResponse, error) {
+	key, err := entity.NewIdempotencyKey(req.MerchantId, req.Key, req.RequestHash)
+	if err != nil {
+		log.Printf("error parsing idempotency key in StartIdempotentRequest: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if s.inProgressTTL > 0 {
+		key.ExpiresAt = key.CreatedAt.Add(s.inProgressTTL)
+	}
+
+	existing, created, err := s.storage.CreateIdempotencyKey(key)
+	if err != nil {
+		log.Printf("error saving idempotency key in StartIdempotentRequest: %v", err)
+		return nil, err
+	}
+
+	switch {
+	case created:
+		return &pb.StartIdempotentRequestResponse{State: pb.IdempotentRequestState_STARTED}, nil
+	case existing.RequestHash != key.RequestHash:
+		return &pb.StartIdempotentRequestResponse{State: pb.IdempotentRequestState_MISMATCH}, nil
+	case existing.Status == entity.InProgress:
+		return &pb.StartIdempotentRequestResponse{State: pb.IdempotentRequestState_IN_PROGRESS}, nil
+	default:
+		return &pb.StartIdempotentRequestResponse{
+			State:          pb.IdempotentRequestState_COMPLETED,
+			ResponseStatus: int32(existing.ResponseStatus),
+			ResponseBody:   existing.ResponseBody,
+		}, nil
+	}
+}
+
+func (s *server) CompleteIdempotentRequest(ctx context.Context, req *pb.CompleteIdempotentRequestRequest) (*pb.CompleteIdempotentRequestResponse, error) {
+	merchantID, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing merchant uuid in CompleteIdempotentRequest: %v", err)
+		return nil, err
+	}
+
+	key, err := s.storage.ReadIdempotencyKey(merchantID, req.Key)
+	if err != nil {
+		log.Printf("error reading idempotency key in CompleteIdempotentRequest: %v", err)
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if key.Status != entity.InProgress {
+		return nil, status.Error(codes.FailedPrecondition, "idempotent request is not in progress")
+	}
+
+	key.Complete(int(req.ResponseStatus), req.ResponseBody, time.Now().UTC())
+
+	err = s.storage.UpdateIdempotencyKey(key)
+	if err != nil {
+		log.Printf("error updating idempotency key in CompleteIdempotentRequest: %v", err)
+		return nil, err
+	}
+
+	return &pb.CompleteIdempotentRequestResponse{}, nil
+}
+
+func (s *server) ReleaseIdempotentRequest(ctx context.Context, req *pb.ReleaseIdempotentRequestRequest) (*pb.ReleaseIdempotentRequestResponse, error) {
+	merchantID, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing merchant uuid in ReleaseIdempotentRequest: %v", err)
+		return nil, err
+	}
+
+	key, err := s.storage.ReadIdempotencyKey(merchantID, req.Key)
+	if err != nil {
+		log.Printf("error reading idempotency key in ReleaseIdempotentRequest: %v", err)
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if key.Status != entity.InProgress {
+		return nil, status.Error(codes.FailedPrecondition, "idempotent request is not in progress")
+	}
+
+	err = s.storage.DeleteIdempotencyKey(merchantID, req.Key)
+	if err != nil {
+		log.Printf("error deleting idempotency key in ReleaseIdempotentRequest: %v", err)
+		return nil, err
+	}
+
+	return &pb.ReleaseIdempotentRequestResponse{}, nil
+}
+
+// storageError converts storage errors that callers can act on into gRPC
+// status errors, anything else is returned as is
+func storageError(err error) error {
+	switch {
+	case errors.Is(err, entity.ErrInvalidTransition),
+		errors.Is(err, entity.ErrAuthorizationExpired),
+		errors.Is(err, entity.ErrPaymentNotRefundable),
+		errors.Is(err, entity.ErrRefundExceedsBalance):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, entity.ErrInvalidCaptureAmount),
+		errors.Is(err, entity.ErrInvalidRefundAmount),
+		errors.Is(err, entity.ErrRefundCurrencyMismatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, memory.ErrUnknownPayment),
+		errors.Is(err, file.ErrUnknownPayment):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return err
+	}
+}
+
 func getEnvOrFlag[T int | string](env string, flagVal *T, conv func(string) (T, error)) T {
 	if envVal := os.Getenv(env); envVal != "" {
 		v, err := conv(envVal)
@@ -269,11 +823,32 @@ func getEnvOrFlag[T int | string](env string, flagVal *T, conv func(string) (T,
 }
 
 func main() {
+	flag.Parse()
 	var network string = "tcp4"
+	dummyFunc := func(v string) (string, error) { return v, nil }
 
 	ipVersion := getEnvOrFlag("IP_VERSION", ipVersionFlag, strconv.Atoi)
-	host := getEnvOrFlag("LEDGER_SERVICE_HOST", hostFlag, func(v string) (string, error) { return v, nil })
+	host := getEnvOrFlag("LEDGER_SERVICE_HOST", hostFlag, dummyFunc)
 	port := getEnvOrFlag("LEDGER_SERVICE_PORT", portFlag, strconv.Atoi)
+	storageType := getEnvOrFlag("LEDGER_STORAGE", storageFlag, dummyFunc)
+	dataDir := getEnvOrFlag("LEDGER_DATA_DIR", dataDirFlag, dummyFunc)
+	snapshotEvery := getEnvOrFlag("LEDGER_SNAPSHOT_EVERY", snapshotFlag, strconv.Atoi)
+	vaultKey := getEnvOrFlag("LEDGER_VAULT_KEY", vaultKeyFlag, dummyFunc)
+	authTTL, err := time.ParseDuration(getEnvOrFlag("LEDGER_AUTHORIZATION_TTL", authTTLFlag, dummyFunc))
+	if err != nil {
+		log.Fatalf("invalid authorization ttl: %v", err)
+	}
+	inProgressTTL, err := time.ParseDuration(getEnvOrFlag("LEDGER_IDEMPOTENCY_IN_PROGRESS_TTL", inProgressTTLFlag, dummyFunc))
+	if err != nil {
+		log.Fatalf("invalid idempotency in progress ttl: %v", err)
+	}
+	if inProgressTTL <= 0 {
+		log.Fatalf("idempotency in progress ttl must be positive, got %s", inProgressTTL)
+	}
+	sweepInterval, err := time.ParseDuration(getEnvOrFlag("LEDGER_EXPIRY_SWEEP_INTERVAL", sweepFlag, dummyFunc))
+	if err != nil {
+		log.Fatalf("invalid expiry sweep interval: %v", err)
+	}
 
 	if ipVersion == 6 {
 		host = fmt.Sprintf("[%s]", host)
@@ -285,8 +860,21 @@ func main() {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
+	ledger, err := newServer(storageType, dataDir, snapshotEvery)
+	if err != nil {
+		log.Fatalf("failed to create storage: %v", err)
+	}
+	log.Printf("using %s storage", storageType)
+	ledger.vault, err = newVault(storageType, dataDir, vaultKey)
+	if err != nil {
+		log.Fatalf("failed to open vault: %v", err)
+	}
+	ledger.authorizationTTL = authTTL
+	ledger.inProgressTTL = inProgressTTL
+	go ledger.expireIdempotencyKeys(sweepInterval)
+
 	s := grpc.NewServer()
-	pb.RegisterLedgerServiceServer(s, newServerWithMemoryStorage())
+	pb.RegisterLedgerServiceServer(s, ledger)
 	reflection.Register(s)
 	log.Printf("server listening at %v", listener.Addr())
 	if err := s.Serve(listener); err != nil {
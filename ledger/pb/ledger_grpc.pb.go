@@ -28,6 +28,20 @@ type LedgerServiceClient interface {
 	UpdatePaymentToPending(ctx context.Context, in *UpdatePaymentToPendingRequest, opts ...grpc.CallOption) (*UpdatePaymentToPendingResponse, error)
 	UpdatePaymentToSuccess(ctx context.Context, in *UpdatePaymentToSuccessRequest, opts ...grpc.CallOption) (*UpdatePaymentToSuccessResponse, error)
 	UpdatePaymentToFail(ctx context.Context, in *UpdatePaymentToFailRequest, opts ...grpc.CallOption) (*UpdatePaymentToFailResponse, error)
+	UpdatePaymentToAuthorized(ctx context.Context, in *UpdatePaymentToAuthorizedRequest, opts ...grpc.CallOption) (*UpdatePaymentToAuthorizedResponse, error)
+	CapturePayment(ctx context.Context, in *CapturePaymentRequest, opts ...grpc.CallOption) (*CapturePaymentResponse, error)
+	VoidPayment(ctx context.Context, in *VoidPaymentRequest, opts ...grpc.CallOption) (*VoidPaymentResponse, error)
+	ExpireAuthorizations(ctx context.Context, in *ExpireAuthorizationsRequest, opts ...grpc.CallOption) (*ExpireAuthorizationsResponse, error)
+	ListPayments(ctx context.Context, in *ListPaymentsRequest, opts ...grpc.CallOption) (*ListPaymentsResponse, error)
+	CreateRefund(ctx context.Context, in *CreateRefundRequest, opts ...grpc.CallOption) (*CreateRefundResponse, error)
+	ReadRefund(ctx context.Context, in *ReadRefundRequest, opts ...grpc.CallOption) (*ReadRefundResponse, error)
+	ReadRefundUsingBankReference(ctx context.Context, in *ReadRefundUsingBankReferenceRequest, opts ...grpc.CallOption) (*ReadRefundUsingBankReferenceResponse, error)
+	UpdateRefundToPending(ctx context.Context, in *UpdateRefundToPendingRequest, opts ...grpc.CallOption) (*UpdateRefundToPendingResponse, error)
+	UpdateRefundToSuccess(ctx context.Context, in *UpdateRefundToSuccessRequest, opts ...grpc.CallOption) (*UpdateRefundToSuccessResponse, error)
+	UpdateRefundToFail(ctx context.Context, in *UpdateRefundToFailRequest, opts ...grpc.CallOption) (*UpdateRefundToFailResponse, error)
+	StartIdempotentRequest(ctx context.Context, in *StartIdempotentRequestRequest, opts ...grpc.CallOption) (*StartIdempotentRequestResponse, error)
+	CompleteIdempotentRequest(ctx context.Context, in *CompleteIdempotentRequestRequest, opts ...grpc.CallOption) (*CompleteIdempotentRequestResponse, error)
+	ReleaseIdempotentRequest(ctx context.Context, in *ReleaseIdempotentRequestRequest, opts ...grpc.CallOption) (*ReleaseIdempotentRequestResponse, error)
 }
 
 type ledgerServiceClient struct {
@@ -92,6 +106,132 @@ func (c *ledgerServiceClient) UpdatePaymentToFail(ctx context.Context, in *Updat
 	return out, nil
 }
 
+func (c *ledgerServiceClient) UpdatePaymentToAuthorized(ctx context.Context, in *UpdatePaymentToAuthorizedRequest, opts ...grpc.CallOption) (*UpdatePaymentToAuthorizedResponse, error) {
+	out := new(UpdatePaymentToAuthorizedResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/UpdatePaymentToAuthorized", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) CapturePayment(ctx context.Context, in *CapturePaymentRequest, opts ...grpc.CallOption) (*CapturePaymentResponse, error) {
+	out := new(CapturePaymentResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/CapturePayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) VoidPayment(ctx context.Context, in *VoidPaymentRequest, opts ...grpc.CallOption) (*VoidPaymentResponse, error) {
+	out := new(VoidPaymentResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/VoidPayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ExpireAuthorizations(ctx context.Context, in *ExpireAuthorizationsRequest, opts ...grpc.CallOption) (*ExpireAuthorizationsResponse, error) {
+	out := new(ExpireAuthorizationsResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/ExpireAuthorizations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ListPayments(ctx context.Context, in *ListPaymentsRequest, opts ...grpc.CallOption) (*ListPaymentsResponse, error) {
+	out := new(ListPaymentsResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/ListPayments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) CreateRefund(ctx context.Context, in *CreateRefundRequest, opts ...grpc.CallOption) (*CreateRefundResponse, error) {
+	out := new(CreateRefundResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/CreateRefund", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ReadRefund(ctx context.Context, in *ReadRefundRequest, opts ...grpc.CallOption) (*ReadRefundResponse, error) {
+	out := new(ReadRefundResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/ReadRefund", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ReadRefundUsingBankReference(ctx context.Context, in *ReadRefundUsingBankReferenceRequest, opts ...grpc.CallOption) (*ReadRefundUsingBankReferenceResponse, error) {
+	out := new(ReadRefundUsingBankReferenceResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/ReadRefundUsingBankReference", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) UpdateRefundToPending(ctx context.Context, in *UpdateRefundToPendingRequest, opts ...grpc.CallOption) (*UpdateRefundToPendingResponse, error) {
+	out := new(UpdateRefundToPendingResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/UpdateRefundToPending", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) UpdateRefundToSuccess(ctx context.Context, in *UpdateRefundToSuccessRequest, opts ...grpc.CallOption) (*UpdateRefundToSuccessResponse, error) {
+	out := new(UpdateRefundToSuccessResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/UpdateRefundToSuccess", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) UpdateRefundToFail(ctx context.Context, in *UpdateRefundToFailRequest, opts ...grpc.CallOption) (*UpdateRefundToFailResponse, error) {
+	out := new(UpdateRefundToFailResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/UpdateRefundToFail", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) StartIdempotentRequest(ctx context.Context, in *StartIdempotentRequestRequest, opts ...grpc.CallOption) (*StartIdempotentRequestResponse, error) {
+	out := new(StartIdempotentRequestResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/StartIdempotentRequest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) CompleteIdempotentRequest(ctx context.Context, in *CompleteIdempotentRequestRequest, opts ...grpc.CallOption) (*CompleteIdempotentRequestResponse, error) {
+	out := new(CompleteIdempotentRequestResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/CompleteIdempotentRequest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ReleaseIdempotentRequest(ctx context.Context, in *ReleaseIdempotentRequestRequest, opts ...grpc.CallOption) (*ReleaseIdempotentRequestResponse, error) {
+	out := new(ReleaseIdempotentRequestResponse)
+	err := c.cc.Invoke(ctx, "/ledger.LedgerService/ReleaseIdempotentRequest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LedgerServiceServer is the server API for LedgerService service.
 // All implementations must embed UnimplementedLedgerServiceServer
 // for forward compatibility
@@ -102,6 +242,20 @@ type LedgerServiceServer interface {
 	UpdatePaymentToPending(context.Context, *UpdatePaymentToPendingRequest) (*UpdatePaymentToPendingResponse, error)
 	UpdatePaymentToSuccess(context.Context, *UpdatePaymentToSuccessRequest) (*UpdatePaymentToSuccessResponse, error)
 	UpdatePaymentToFail(context.Context, *UpdatePaymentToFailRequest) (*UpdatePaymentToFailResponse, error)
+	UpdatePaymentToAuthorized(context.Context, *UpdatePaymentToAuthorizedRequest) (*UpdatePaymentToAuthorizedResponse, error)
+	CapturePayment(context.Context, *CapturePaymentRequest) (*CapturePaymentResponse, error)
+	VoidPayment(context.Context, *VoidPaymentRequest) (*VoidPaymentResponse, error)
+	ExpireAuthorizations(context.Context, *ExpireAuthorizationsRequest) (*ExpireAuthorizationsResponse, error)
+	ListPayments(context.Context, *ListPaymentsRequest) (*ListPaymentsResponse, error)
+	CreateRefund(context.Context, *CreateRefundRequest) (*CreateRefundResponse, error)
+	ReadRefund(context.Context, *ReadRefundRequest) (*ReadRefundResponse, error)
+	ReadRefundUsingBankReference(context.Context, *ReadRefundUsingBankReferenceRequest) (*ReadRefundUsingBankReferenceResponse, error)
+	UpdateRefundToPending(context.Context, *UpdateRefundToPendingRequest) (*UpdateRefundToPendingResponse, error)
+	UpdateRefundToSuccess(context.Context, *UpdateRefundToSuccessRequest) (*UpdateRefundToSuccessResponse, error)
+	UpdateRefundToFail(context.Context, *UpdateRefundToFailRequest) (*UpdateRefundToFailResponse, error)
+	StartIdempotentRequest(context.Context, *StartIdempotentRequestRequest) (*StartIdempotentRequestResponse, error)
+	CompleteIdempotentRequest(context.Context, *CompleteIdempotentRequestRequest) (*CompleteIdempotentRequestResponse, error)
+	ReleaseIdempotentRequest(context.Context, *ReleaseIdempotentRequestRequest) (*ReleaseIdempotentRequestResponse, error)
 	mustEmbedUnimplementedLedgerServiceServer()
 }
 
@@ -127,6 +281,48 @@ func (UnimplementedLedgerServiceServer) UpdatePaymentToSuccess(context.Context,
 func (UnimplementedLedgerServiceServer) UpdatePaymentToFail(context.Context, *UpdatePaymentToFailRequest) (*UpdatePaymentToFailResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdatePaymentToFail not implemented")
 }
+func (UnimplementedLedgerServiceServer) UpdatePaymentToAuthorized(context.Context, *UpdatePaymentToAuthorizedRequest) (*UpdatePaymentToAuthorizedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePaymentToAuthorized not implemented")
+}
+func (UnimplementedLedgerServiceServer) CapturePayment(context.Context, *CapturePaymentRequest) (*CapturePaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CapturePayment not implemented")
+}
+func (UnimplementedLedgerServiceServer) VoidPayment(context.Context, *VoidPaymentRequest) (*VoidPaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VoidPayment not implemented")
+}
+func (UnimplementedLedgerServiceServer) ExpireAuthorizations(context.Context, *ExpireAuthorizationsRequest) (*ExpireAuthorizationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExpireAuthorizations not implemented")
+}
+func (UnimplementedLedgerServiceServer) ListPayments(context.Context, *ListPaymentsRequest) (*ListPaymentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPayments not implemented")
+}
+func (UnimplementedLedgerServiceServer) CreateRefund(context.Context, *CreateRefundRequest) (*CreateRefundResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateRefund not implemented")
+}
+func (UnimplementedLedgerServiceServer) ReadRefund(context.Context, *ReadRefundRequest) (*ReadRefundResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadRefund not implemented")
+}
+func (UnimplementedLedgerServiceServer) ReadRefundUsingBankReference(context.Context, *ReadRefundUsingBankReferenceRequest) (*ReadRefundUsingBankReferenceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadRefundUsingBankReference not implemented")
+}
+func (UnimplementedLedgerServiceServer) UpdateRefundToPending(context.Context, *UpdateRefundToPendingRequest) (*UpdateRefundToPendingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateRefundToPending not implemented")
+}
+func (UnimplementedLedgerServiceServer) UpdateRefundToSuccess(context.Context, *UpdateRefundToSuccessRequest) (*UpdateRefundToSuccessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateRefundToSuccess not implemented")
+}
+func (UnimplementedLedgerServiceServer) UpdateRefundToFail(context.Context, *UpdateRefundToFailRequest) (*UpdateRefundToFailResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateRefundToFail not implemented")
+}
+func (UnimplementedLedgerServiceServer) StartIdempotentRequest(context.Context, *StartIdempotentRequestRequest) (*StartIdempotentRequestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartIdempotentRequest not implemented")
+}
+func (UnimplementedLedgerServiceServer) CompleteIdempotentRequest(context.Context, *CompleteIdempotentRequestRequest) (*CompleteIdempotentRequestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteIdempotentRequest not implemented")
+}
+func (UnimplementedLedgerServiceServer) ReleaseIdempotentRequest(context.Context, *ReleaseIdempotentRequestRequest) (*ReleaseIdempotentRequestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseIdempotentRequest not implemented")
+}
 func (UnimplementedLedgerServiceServer) mustEmbedUnimplementedLedgerServiceServer() {}
 
 // UnsafeLedgerServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -248,6 +444,258 @@ func _LedgerService_UpdatePaymentToFail_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LedgerService_UpdatePaymentToAuthorized_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePaymentToAuthorizedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).UpdatePaymentToAuthorized(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/UpdatePaymentToAuthorized",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).UpdatePaymentToAuthorized(ctx, req.(*UpdatePaymentToAuthorizedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_CapturePayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CapturePaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).CapturePayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/CapturePayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).CapturePayment(ctx, req.(*CapturePaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_VoidPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoidPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).VoidPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/VoidPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).VoidPayment(ctx, req.(*VoidPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ExpireAuthorizations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpireAuthorizationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).ExpireAuthorizations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/ExpireAuthorizations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).ExpireAuthorizations(ctx, req.(*ExpireAuthorizationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ListPayments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPaymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).ListPayments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/ListPayments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).ListPayments(ctx, req.(*ListPaymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_CreateRefund_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRefundRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).CreateRefund(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/CreateRefund",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).CreateRefund(ctx, req.(*CreateRefundRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ReadRefund_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadRefundRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).ReadRefund(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/ReadRefund",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).ReadRefund(ctx, req.(*ReadRefundRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ReadRefundUsingBankReference_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadRefundUsingBankReferenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).ReadRefundUsingBankReference(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/ReadRefundUsingBankReference",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).ReadRefundUsingBankReference(ctx, req.(*ReadRefundUsingBankReferenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_UpdateRefundToPending_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRefundToPendingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).UpdateRefundToPending(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/UpdateRefundToPending",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).UpdateRefundToPending(ctx, req.(*UpdateRefundToPendingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_UpdateRefundToSuccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRefundToSuccessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).UpdateRefundToSuccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/UpdateRefundToSuccess",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).UpdateRefundToSuccess(ctx, req.(*UpdateRefundToSuccessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_UpdateRefundToFail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRefundToFailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).UpdateRefundToFail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/UpdateRefundToFail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).UpdateRefundToFail(ctx, req.(*UpdateRefundToFailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_StartIdempotentRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartIdempotentRequestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).StartIdempotentRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/StartIdempotentRequest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).StartIdempotentRequest(ctx, req.(*StartIdempotentRequestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_CompleteIdempotentRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompleteIdempotentRequestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).CompleteIdempotentRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/CompleteIdempotentRequest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).CompleteIdempotentRequest(ctx, req.(*CompleteIdempotentRequestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ReleaseIdempotentRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseIdempotentRequestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).ReleaseIdempotentRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ledger.LedgerService/ReleaseIdempotentRequest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).ReleaseIdempotentRequest(ctx, req.(*ReleaseIdempotentRequestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LedgerService_ServiceDesc is the grpc.ServiceDesc for LedgerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -279,6 +727,62 @@ var LedgerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdatePaymentToFail",
 			Handler:    _LedgerService_UpdatePaymentToFail_Handler,
 		},
+		{
+			MethodName: "UpdatePaymentToAuthorized",
+			Handler:    _LedgerService_UpdatePaymentToAuthorized_Handler,
+		},
+		{
+			MethodName: "CapturePayment",
+			Handler:    _LedgerService_CapturePayment_Handler,
+		},
+		{
+			MethodName: "VoidPayment",
+			Handler:    _LedgerService_VoidPayment_Handler,
+		},
+		{
+			MethodName: "ExpireAuthorizations",
+			Handler:    _LedgerService_ExpireAuthorizations_Handler,
+		},
+		{
+			MethodName: "ListPayments",
+			Handler:    _LedgerService_ListPayments_Handler,
+		},
+		{
+			MethodName: "CreateRefund",
+			Handler:    _LedgerService_CreateRefund_Handler,
+		},
+		{
+			MethodName: "ReadRefund",
+			Handler:    _LedgerService_ReadRefund_Handler,
+		},
+		{
+			MethodName: "ReadRefundUsingBankReference",
+			Handler:    _LedgerService_ReadRefundUsingBankReference_Handler,
+		},
+		{
+			MethodName: "UpdateRefundToPending",
+			Handler:    _LedgerService_UpdateRefundToPending_Handler,
+		},
+		{
+			MethodName: "UpdateRefundToSuccess",
+			Handler:    _LedgerService_UpdateRefundToSuccess_Handler,
+		},
+		{
+			MethodName: "UpdateRefundToFail",
+			Handler:    _LedgerService_UpdateRefundToFail_Handler,
+		},
+		{
+			MethodName: "StartIdempotentRequest",
+			Handler:    _LedgerService_StartIdempotentRequest_Handler,
+		},
+		{
+			MethodName: "CompleteIdempotentRequest",
+			Handler:    _LedgerService_CompleteIdempotentRequest_Handler,
+		},
+		{
+			MethodName: "ReleaseIdempotentRequest",
+			Handler:    _LedgerService_ReleaseIdempotentRequest_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pb/ledger.proto",
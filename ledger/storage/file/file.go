@@ -0,0 +1,785 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
+	"github.com/thiagolcmelo/payment-gateway/ledger/storage/index"
+)
+
+const (
+	logFileName      = "payments.log"
+	snapshotFileName = "payments.snapshot"
+	// formatVersion is written in every record and snapshot. Records without
+	// it, and snapshots that are a plain array of payments, come from when
+	// amounts were decimals of the currency instead of minor units.
+	formatVersion = 1
+)
+
+var (
+	// ErrUnknownPayment must be use while trying to read payment with unknown id
+	ErrUnknownPayment = errors.New("there is no payment with given id")
+	// ErrUnknownBankReference must be used when trying to read using unknown bank reference
+	ErrUnknownBankReference = errors.New("unknown bank reference")
+	// ErrUnknownRefund must be used while trying to read or change a refund with unknown id
+	ErrUnknownRefund = errors.New("there is no refund with given id")
+	// ErrUnknownIdempotencyKey must be used when trying to read or change a key the merchant does not have
+	ErrUnknownIdempotencyKey = errors.New("unknown idempotency key")
+	// ErrCorruptedLog must be used when the log has an unreadable record before its last line
+	ErrCorruptedLog = errors.New("corrupted payments log")
+)
+
+// idempotencyKeyID identifies a key, keys are scoped per merchant
+type idempotencyKeyID struct {
+	MerchantID uuid.UUID `json:"merchant_id"`
+	Key        string    `json:"key"`
+}
+
+// record is a single line of the append only log, only one of its fields is
+// set. The latest record of a payment or key always holds its full state.
+type record struct {
+	Version               int                    `json:"version,omitempty"`
+	Payment               *entity.Payment        `json:"payment,omitempty"`
+	Refund                *entity.Refund         `json:"refund,omitempty"`
+	IdempotencyKey        *entity.IdempotencyKey `json:"idempotency_key,omitempty"`
+	DeletedIdempotencyKey *idempotencyKeyID      `json:"deleted_idempotency_key,omitempty"`
+}
+
+// state is the content of a snapshot
+type state struct {
+	Version         int                     `json:"version"`
+	Payments        []entity.Payment        `json:"payments"`
+	Refunds         []entity.Refund         `json:"refunds"`
+	IdempotencyKeys []entity.IdempotencyKey `json:"idempotency_keys"`
+}
+
+// storedRecord is a record as read back from the log, the amount of its
+// payment is kept as written until the version of the record is known
+type storedRecord struct {
+	record
+	Payment *storedPayment `json:"payment,omitempty"`
+}
+
+// storedPayment is a payment whose amount may be a decimal of its currency
+type storedPayment struct {
+	entity.Payment
+	Amount json.Number
+}
+
+// current converts a record written in any version into the current one
+func (s storedRecord) current() (record, error) {
+	r := s.record
+	if s.Payment != nil {
+		p, err := s.Payment.current(s.Version)
+		if err != nil {
+			return record{}, err
+		}
+		r.Payment = &p
+	}
+	r.Version = formatVersion
+	return r, nil
+}
+
+// current converts the amount of a payment written in version into minor
+// units of its currency
+func (s storedPayment) current(version int) (entity.Payment, error) {
+	var (
+		p      = s.Payment
+		amount int64
+		err    error
+	)
+	if version >= formatVersion {
+		amount, err = s.Amount.Int64()
+	} else {
+		amount, err = minorUnits(s.Amount, p.Currency)
+	}
+	if err != nil {
+		return entity.Payment{}, fmt.Errorf("could not read amount of payment %s: %w", p.ID, err)
+	}
+	p.Amount = amount
+	return p, nil
+}
+
+// minorUnits converts a decimal amount of currency into minor units, it
+// rounds to the nearest one since amounts used to be floating point numbers
+func minorUnits(amount json.Number, currency string) (int64, error) {
+	exponent, err := entity.CurrencyExponent(currency)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", err, currency)
+	}
+
+	r, ok := new(big.Rat).SetString(amount.String())
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+	r.Mul(r, new(big.Rat).SetInt(scale))
+
+	return strconv.ParseInt(r.FloatString(0), 10, 64)
+}
+
+// Storage is a durable implementation of a Ledger to store payments.
+//
+// Every change is appended to a log and synced to disk before it becomes
+// visible. Once the log reaches snapshotEvery records, the full state is
+// written to a snapshot and the log starts over.
+type Storage struct {
+	dir             string
+	snapshotEvery   int
+	log             *os.File
+	logSize         int64
+	logRecords      int
+	payments        map[uuid.UUID]entity.Payment
+	bankReferences  map[uuid.UUID]uuid.UUID
+	merchantIndex   *index.Payments
+	refunds         map[uuid.UUID]entity.Refund
+	paymentRefunds  map[uuid.UUID][]uuid.UUID
+	refundBankRefs  map[uuid.UUID]uuid.UUID
+	idempotencyKeys map[idempotencyKeyID]entity.IdempotencyKey
+	sync.RWMutex
+}
+
+// NewFileStorage is a factory for file backed Storage for payments, it
+// restores any state previously persisted in dir
+func NewFileStorage(dir string, snapshotEvery int) (*Storage, error) {
+	if snapshotEvery <= 0 {
+		return nil, fmt.Errorf("snapshot interval must be positive, got %d", snapshotEvery)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	l := &Storage{
+		dir:             dir,
+		snapshotEvery:   snapshotEvery,
+		payments:        make(map[uuid.UUID]entity.Payment),
+		bankReferences:  make(map[uuid.UUID]uuid.UUID),
+		merchantIndex:   index.NewPayments(),
+		refunds:         make(map[uuid.UUID]entity.Refund),
+		paymentRefunds:  make(map[uuid.UUID][]uuid.UUID),
+		refundBankRefs:  make(map[uuid.UUID]uuid.UUID),
+		idempotencyKeys: make(map[idempotencyKeyID]entity.IdempotencyKey),
+	}
+
+	oldSnapshot, err := l.loadSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	oldLog, err := l.replayLog()
+	if err != nil {
+		return nil, err
+	}
+
+	// whatever was written in an older format is converted only once, by
+	// writing it all again in a snapshot of the current one
+	if oldSnapshot || oldLog {
+		if err := l.snapshot(); err != nil {
+			l.log.Close()
+			return nil, fmt.Errorf("could not convert payments to the current format: %w", err)
+		}
+	}
+
+	return l, nil
+}
+
+// Close releases the log file, the Storage must not be used afterwards
+func (l *Storage) Close() error {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.log.Close()
+}
+
+// Create adds a new payment to the Ledger
+func (l *Storage) Create(p entity.Payment) (uuid.UUID, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	var id uuid.UUID
+
+	for {
+		id = uuid.New()
+		if _, ok := l.payments[id]; !ok {
+			break
+		}
+	}
+
+	p.ID = id
+
+	err := p.Validate()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := l.persist(record{Payment: &p}); err != nil {
+		return uuid.Nil, err
+	}
+
+	return id, nil
+}
+
+// Read returns details of a payment in the Ledger
+func (l *Storage) Read(id uuid.UUID) (entity.Payment, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	payment, ok := l.payments[id]
+	if !ok {
+		return entity.Payment{}, ErrUnknownPayment
+	}
+
+	return payment, nil
+}
+
+// ReadUsingBankReference returns details of a payment in the Ledger using a bank reference
+func (l *Storage) ReadUsingBankReference(id uuid.UUID) (entity.Payment, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	paymentID, ok := l.bankReferences[id]
+	if !ok {
+		return entity.Payment{}, ErrUnknownBankReference
+	}
+
+	payment, ok := l.payments[paymentID]
+	if !ok {
+		return entity.Payment{}, ErrUnknownPayment
+	}
+
+	return payment, nil
+}
+
+// Update edits information of a given payment, as long as its status can
+// move to the new one
+func (l *Storage) Update(p entity.Payment) error {
+	l.Lock()
+	defer l.Unlock()
+
+	current, ok := l.payments[p.ID]
+	if !ok {
+		return ErrUnknownPayment
+	}
+
+	err := p.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = current.Status.TransitionTo(p.Status)
+	if err != nil {
+		return err
+	}
+
+	return l.persist(record{Payment: &p})
+}
+
+// ExpireAuthorizations voids every authorized payment that was not captured
+// before now, and returns them
+func (l *Storage) ExpireAuthorizations(now time.Time) ([]entity.Payment, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	var expired []entity.Payment
+	for _, id := range l.merchantIndex.ExpiredAuthorizations(now) {
+		current := l.payments[id]
+		p := current
+		if err := p.Void(); err != nil {
+			return expired, err
+		}
+		if err := l.persist(record{Payment: &p}); err != nil {
+			return expired, err
+		}
+		expired = append(expired, p)
+	}
+
+	return expired, nil
+}
+
+// List returns one page of the payments of a merchant selected by the filter,
+// and the cursor of the next page, which is nil for the last one
+func (l *Storage) List(f entity.PaymentFilter) ([]entity.Payment, *entity.PaymentCursor, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	err := f.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, next := l.merchantIndex.List(f, l.payments)
+	return page, next, nil
+}
+
+// CreateRefund adds a new refund to the Ledger, the check against the
+// refundable balance happens under the same lock as the write, so concurrent
+// refunds cannot add up to more than the payment
+func (l *Storage) CreateRefund(r entity.Refund) (entity.Refund, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	p, ok := l.payments[r.PaymentID]
+	if !ok {
+		return entity.Refund{}, ErrUnknownPayment
+	}
+
+	r, err := entity.PrepareRefund(p, l.refundsOf(p.ID), r)
+	if err != nil {
+		return entity.Refund{}, err
+	}
+
+	for {
+		r.ID = uuid.New()
+		if _, ok := l.refunds[r.ID]; !ok {
+			break
+		}
+	}
+
+	if err := l.persist(record{Refund: &r}); err != nil {
+		return entity.Refund{}, err
+	}
+
+	return r, nil
+}
+
+// ReadRefund returns details of a refund in the Ledger
+func (l *Storage) ReadRefund(id uuid.UUID) (entity.Refund, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	r, ok := l.refunds[id]
+	if !ok {
+		return entity.Refund{}, ErrUnknownRefund
+	}
+
+	return r, nil
+}
+
+// ReadRefundUsingBankReference returns details of a refund in the Ledger using a bank reference
+func (l *Storage) ReadRefundUsingBankReference(id uuid.UUID) (entity.Refund, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	refundID, ok := l.refundBankRefs[id]
+	if !ok {
+		return entity.Refund{}, ErrUnknownBankReference
+	}
+
+	r, ok := l.refunds[refundID]
+	if !ok {
+		return entity.Refund{}, ErrUnknownRefund
+	}
+
+	return r, nil
+}
+
+// UpdateRefund edits information of a given refund, as long as its status
+// can move to the new one. The payment and amount of a refund cannot change.
+func (l *Storage) UpdateRefund(r entity.Refund) error {
+	l.Lock()
+	defer l.Unlock()
+
+	current, ok := l.refunds[r.ID]
+	if !ok {
+		return ErrUnknownRefund
+	}
+
+	r.PaymentID, r.MerchantID, r.Amount, r.Currency = current.PaymentID, current.MerchantID, current.Amount, current.Currency
+
+	err := current.Status.TransitionTo(r.Status)
+	if err != nil {
+		return err
+	}
+
+	return l.persist(record{Refund: &r})
+}
+
+// ListRefunds returns all refunds of a payment, oldest first
+func (l *Storage) ListRefunds(paymentID uuid.UUID) ([]entity.Refund, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	if _, ok := l.payments[paymentID]; !ok {
+		return nil, ErrUnknownPayment
+	}
+
+	return l.refundsOf(paymentID), nil
+}
+
+// refundsOf must be called with the lock held
+func (l *Storage) refundsOf(paymentID uuid.UUID) []entity.Refund {
+	refunds := make([]entity.Refund, 0, len(l.paymentRefunds[paymentID]))
+	for _, id := range l.paymentRefunds[paymentID] {
+		refunds = append(refunds, l.refunds[id])
+	}
+	return refunds
+}
+
+// CreateIdempotencyKey stores a new key, unless the merchant already has a
+// live key with the same name, in which case it is returned untouched
+func (l *Storage) CreateIdempotencyKey(k entity.IdempotencyKey) (entity.IdempotencyKey, bool, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	err := k.Validate()
+	if err != nil {
+		return entity.IdempotencyKey{}, false, err
+	}
+
+	id := idempotencyKeyID{MerchantID: k.MerchantID, Key: k.Key}
+	if existing, ok := l.idempotencyKeys[id]; ok && !existing.Expired(time.Now()) {
+		return existing, false, nil
+	}
+
+	if err := l.persist(record{IdempotencyKey: &k}); err != nil {
+		return entity.IdempotencyKey{}, false, err
+	}
+
+	return k, true, nil
+}
+
+// ReadIdempotencyKey returns a key of a merchant
+func (l *Storage) ReadIdempotencyKey(merchantID uuid.UUID, key string) (entity.IdempotencyKey, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	k, ok := l.idempotencyKeys[idempotencyKeyID{MerchantID: merchantID, Key: key}]
+	if !ok {
+		return entity.IdempotencyKey{}, ErrUnknownIdempotencyKey
+	}
+
+	return k, nil
+}
+
+// UpdateIdempotencyKey edits an existing key
+func (l *Storage) UpdateIdempotencyKey(k entity.IdempotencyKey) error {
+	l.Lock()
+	defer l.Unlock()
+
+	if _, ok := l.idempotencyKeys[idempotencyKeyID{MerchantID: k.MerchantID, Key: k.Key}]; !ok {
+		return ErrUnknownIdempotencyKey
+	}
+
+	err := k.Validate()
+	if err != nil {
+		return err
+	}
+
+	return l.persist(record{IdempotencyKey: &k})
+}
+
+// DeleteIdempotencyKey forgets a key, so the merchant can use it again
+func (l *Storage) DeleteIdempotencyKey(merchantID uuid.UUID, key string) error {
+	l.Lock()
+	defer l.Unlock()
+
+	id := idempotencyKeyID{MerchantID: merchantID, Key: key}
+	if _, ok := l.idempotencyKeys[id]; !ok {
+		return ErrUnknownIdempotencyKey
+	}
+
+	return l.persist(record{DeletedIdempotencyKey: &id})
+}
+
+// ExpireIdempotencyKeys forgets every key that expired before now, along with
+// its response, and returns how many. Nothing is written to the log: a key
+// that comes back from it on restart is still expired, and snapshots leave
+// expired keys out.
+func (l *Storage) ExpireIdempotencyKeys(now time.Time) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	expired := 0
+	for id, k := range l.idempotencyKeys {
+		if k.Expired(now) {
+			delete(l.idempotencyKeys, id)
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// persist appends the record to the log and only then applies it in memory,
+// it must be called with the lock held
+func (l *Storage) persist(r record) error {
+	r.Version = formatVersion
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if _, err := l.log.Write(data); err != nil {
+		l.rollback()
+		return err
+	}
+	if err := l.log.Sync(); err != nil {
+		l.rollback()
+		return err
+	}
+	l.logSize += int64(len(data))
+	l.logRecords++
+
+	l.apply(r)
+
+	// the record is already durable, a failed snapshot only means the log
+	// keeps growing until the next attempt
+	if l.logRecords >= l.snapshotEvery {
+		if err := l.snapshot(); err != nil {
+			log.Printf("could not snapshot payments: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// rollback drops whatever a failed write left after the last good record, so
+// later appends do not end up behind a partial line
+func (l *Storage) rollback() {
+	if err := l.log.Truncate(l.logSize); err != nil {
+		return
+	}
+	l.log.Seek(l.logSize, io.SeekStart)
+}
+
+// apply keeps payments, refunds, their indexes and idempotency keys up to
+// date
+func (l *Storage) apply(r record) {
+	if p := r.Payment; p != nil {
+		if current, ok := l.payments[p.ID]; ok {
+			l.merchantIndex.Replace(current, *p)
+		} else {
+			l.merchantIndex.Add(*p)
+		}
+		l.payments[p.ID] = *p
+		if p.BankPaymentID != uuid.Nil {
+			l.bankReferences[p.BankPaymentID] = p.ID
+		}
+	}
+	if rf := r.Refund; rf != nil {
+		if _, ok := l.refunds[rf.ID]; !ok {
+			l.paymentRefunds[rf.PaymentID] = append(l.paymentRefunds[rf.PaymentID], rf.ID)
+		}
+		l.refunds[rf.ID] = *rf
+		if rf.BankRefundID != uuid.Nil {
+			l.refundBankRefs[rf.BankRefundID] = rf.ID
+		}
+	}
+	if k := r.IdempotencyKey; k != nil {
+		l.idempotencyKeys[idempotencyKeyID{MerchantID: k.MerchantID, Key: k.Key}] = *k
+	}
+	if id := r.DeletedIdempotencyKey; id != nil {
+		delete(l.idempotencyKeys, *id)
+	}
+}
+
+// loadSnapshot restores the state written by the last snapshot, if any, and
+// tells whether it was written in an older format
+func (l *Storage) loadSnapshot() (bool, error) {
+	data, err := os.ReadFile(filepath.Join(l.dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var st state
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		// snapshots used to hold only the payments, as a plain array, and
+		// their amounts were decimals
+		var payments []storedPayment
+		if err := json.Unmarshal(data, &payments); err != nil {
+			return false, fmt.Errorf("could not parse snapshot: %w", err)
+		}
+		for _, sp := range payments {
+			p, err := sp.current(0)
+			if err != nil {
+				return false, err
+			}
+			st.Payments = append(st.Payments, p)
+		}
+	} else if err := json.Unmarshal(data, &st); err != nil {
+		return false, fmt.Errorf("could not parse snapshot: %w", err)
+	}
+
+	for i := range st.Payments {
+		l.apply(record{Payment: &st.Payments[i]})
+	}
+	for i := range st.Refunds {
+		l.apply(record{Refund: &st.Refunds[i]})
+	}
+	for i := range st.IdempotencyKeys {
+		l.apply(record{IdempotencyKey: &st.IdempotencyKeys[i]})
+	}
+
+	return st.Version < formatVersion, nil
+}
+
+// replayLog applies every record written after the last snapshot and leaves
+// the log open for appending. A partially written last record, which can only
+// be the result of a crash in the middle of a write, is discarded. It tells
+// whether any record was written in an older format.
+func (l *Storage) replayLog() (bool, error) {
+	f, err := os.OpenFile(filepath.Join(l.dir, logFileName), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		reader = bufio.NewReader(f)
+		offset int64
+		old    bool
+	)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// anything left without a trailing newline was never acknowledged
+			break
+		}
+		if err != nil {
+			f.Close()
+			return false, err
+		}
+
+		var sr storedRecord
+		if err := json.Unmarshal(bytes.TrimSpace(line), &sr); err != nil {
+			if _, peekErr := reader.Peek(1); errors.Is(peekErr, io.EOF) {
+				break
+			}
+			f.Close()
+			return false, fmt.Errorf("%w at offset %d: %v", ErrCorruptedLog, offset, err)
+		}
+
+		r, err := sr.current()
+		if err != nil {
+			f.Close()
+			return false, err
+		}
+		old = old || sr.Version < formatVersion
+
+		l.apply(r)
+		l.logRecords++
+		offset += int64(len(line))
+	}
+
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return false, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return false, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return false, err
+	}
+
+	l.log = f
+	l.logSize = offset
+	return old, nil
+}
+
+// snapshot writes the full state to disk and starts a new empty log, it must
+// be called with the lock held. Expired idempotency keys are left out.
+func (l *Storage) snapshot() error {
+	st := state{
+		Version:         formatVersion,
+		Payments:        make([]entity.Payment, 0, len(l.payments)),
+		Refunds:         make([]entity.Refund, 0, len(l.refunds)),
+		IdempotencyKeys: make([]entity.IdempotencyKey, 0, len(l.idempotencyKeys)),
+	}
+	for _, p := range l.payments {
+		st.Payments = append(st.Payments, p)
+		// keeps the refunds of each payment in the order they were created
+		st.Refunds = append(st.Refunds, l.refundsOf(p.ID)...)
+	}
+	now := time.Now()
+	for id, k := range l.idempotencyKeys {
+		if k.Expired(now) {
+			delete(l.idempotencyKeys, id)
+			continue
+		}
+		st.IdempotencyKeys = append(st.IdempotencyKeys, k)
+	}
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(l.dir, snapshotFileName, data); err != nil {
+		return err
+	}
+
+	// the snapshot already holds every record, so the log can be emptied
+	if err := l.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := l.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := l.log.Sync(); err != nil {
+		return err
+	}
+	l.logSize = 0
+	l.logRecords = 0
+
+	return nil
+}
+
+// writeFileAtomic replaces dir/name with data in a way that a reader either
+// sees the old content or the new one, never a partial write
+func writeFileAtomic(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// syncDir makes a rename inside dir durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
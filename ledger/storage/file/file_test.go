@@ -0,0 +1,778 @@
+package file_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
+	"github.com/thiagolcmelo/payment-gateway/ledger/storage/file"
+)
+
+func newPayment(t *testing.T) entity.Payment {
+	t.Helper()
+
+	payment, err := entity.NewPayment(
+		uuid.New().String(),
+		15000,
+		"USD",
+		"2023-05-18T01:00:00.000",
+		"push",
+		entity.CreditCard{
+			Number:      "1111-2222-3333-4444",
+			Name:        "name surname",
+			ExpireMonth: 10,
+			ExpireYear:  2099,
+			CVV:         123,
+		},
+		"shopper-123",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payment
+}
+
+func newStorage(t *testing.T, dir string, snapshotEvery int) *file.Storage {
+	t.Helper()
+
+	fs, err := file.NewFileStorage(dir, snapshotEvery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fs.Close() })
+	return fs
+}
+
+func TestFileLedger_NewFileStorage(t *testing.T) {
+	type testCase struct {
+		testName      string
+		snapshotEvery int
+		expectErr     bool
+	}
+
+	testCases := []testCase{
+		{
+			testName:      "positive_snapshot_interval",
+			snapshotEvery: 10,
+			expectErr:     false,
+		},
+		{
+			testName:      "zero_snapshot_interval",
+			snapshotEvery: 0,
+			expectErr:     true,
+		},
+		{
+			testName:      "negative_snapshot_interval",
+			snapshotEvery: -1,
+			expectErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			fs, err := file.NewFileStorage(t.TempDir(), tc.snapshotEvery)
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expected error=%v, got %v", tc.expectErr, err)
+			}
+			if err == nil {
+				fs.Close()
+			}
+		})
+	}
+}
+
+func TestFileLedger_Create(t *testing.T) {
+	validPayment := newPayment(t)
+
+	invalidPayment := newPayment(t)
+	invalidPayment.Card.Last4 = ""
+
+	type testCase struct {
+		testName    string
+		payment     entity.Payment
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "valid_new_payment",
+			payment:     validPayment,
+			expectedErr: nil,
+		},
+		{
+			testName:    "invalid_card_missing_last4",
+			payment:     invalidPayment,
+			expectedErr: entity.ErrInvalidNumber,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			fs := newStorage(t, t.TempDir(), 10)
+
+			id, err := fs.Create(tc.payment)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if id == uuid.Nil {
+				t.Error("id was not generated properly")
+			}
+		})
+	}
+}
+
+func TestFileLedger_Read(t *testing.T) {
+	fs := newStorage(t, t.TempDir(), 10)
+
+	paymentID, err := fs.Create(newPayment(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment, err := fs.Read(paymentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName        string
+		id              uuid.UUID
+		expectedPayment entity.Payment
+		expectedErr     error
+	}
+
+	testCases := []testCase{
+		{
+			testName:        "existing_payment_is_found",
+			id:              paymentID,
+			expectedPayment: payment,
+			expectedErr:     nil,
+		},
+		{
+			testName:        "unexisting_payment_returns_error",
+			id:              uuid.New(),
+			expectedPayment: entity.Payment{},
+			expectedErr:     file.ErrUnknownPayment,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			p, err := fs.Read(tc.id)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !tc.expectedPayment.Equal(p) {
+				t.Errorf("expected %v, got %v", tc.expectedPayment, p)
+			}
+		})
+	}
+}
+
+func TestFileLedger_Update(t *testing.T) {
+	fs := newStorage(t, t.TempDir(), 10)
+
+	paymentID, err := fs.Create(newPayment(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment, err := fs.Read(paymentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validPayment := payment // by value
+	validPayment.Status = entity.Pending
+	validPayment.BankPaymentID = uuid.New()
+
+	invalidPayment := payment // by value
+	invalidPayment.Amount = -100
+
+	successPayment := validPayment // by value
+	successPayment.Status = entity.Success
+
+	revertedPayment := successPayment // by value
+	revertedPayment.Status = entity.Fail
+
+	unknownPayment := newPayment(t)
+	unknownPayment.ID = uuid.New()
+
+	type testCase struct {
+		testName        string
+		payment         entity.Payment
+		expectedPayment entity.Payment
+		expectedErr     error
+	}
+
+	testCases := []testCase{
+		{
+			testName:        "update_existing_with_valid_values",
+			payment:         validPayment,
+			expectedPayment: validPayment,
+			expectedErr:     nil,
+		},
+		{
+			testName:        "update_existing_with_invalid_value_negative_amount",
+			payment:         invalidPayment,
+			expectedPayment: validPayment,
+			expectedErr:     entity.ErrNegativeAmount,
+		},
+		{
+			testName:        "update_pending_to_success",
+			payment:         successPayment,
+			expectedPayment: successPayment,
+			expectedErr:     nil,
+		},
+		{
+			testName:        "update_success_to_fail",
+			payment:         revertedPayment,
+			expectedPayment: successPayment,
+			expectedErr:     entity.ErrInvalidTransition,
+		},
+		{
+			testName:        "update_unexisting",
+			payment:         unknownPayment,
+			expectedPayment: entity.Payment{},
+			expectedErr:     file.ErrUnknownPayment,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := fs.Update(tc.payment)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if errors.Is(err, file.ErrUnknownPayment) {
+				return
+			}
+
+			p, err := fs.Read(tc.payment.ID)
+			if err != nil {
+				t.Errorf("update caused error: %v", err)
+			}
+
+			if !tc.expectedPayment.Equal(p) {
+				t.Errorf("payment was corrupted: expected %v, got %v", tc.expectedPayment, p)
+			}
+		})
+	}
+
+	p, err := fs.ReadUsingBankReference(validPayment.BankPaymentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !successPayment.Equal(p) {
+		t.Errorf("expected %v, got %v", successPayment, p)
+	}
+}
+
+func TestFileLedger_Reopen(t *testing.T) {
+	type testCase struct {
+		testName      string
+		snapshotEvery int
+		payments      int
+	}
+
+	testCases := []testCase{
+		{
+			testName:      "log_only",
+			snapshotEvery: 100,
+			payments:      5,
+		},
+		{
+			testName:      "snapshot_only",
+			snapshotEvery: 10,
+			payments:      5,
+		},
+		{
+			testName:      "snapshot_and_log",
+			snapshotEvery: 4,
+			payments:      5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			dir := t.TempDir()
+
+			fs, err := file.NewFileStorage(dir, tc.snapshotEvery)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// every payment is created and then updated, so there are two
+			// records per payment
+			expected := make(map[uuid.UUID]entity.Payment)
+			for i := 0; i < tc.payments; i++ {
+				id, err := fs.Create(newPayment(t))
+				if err != nil {
+					t.Fatal(err)
+				}
+				p, err := fs.Read(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				p.Status = entity.Pending
+				p.BankPaymentID = uuid.New()
+				if err := fs.Update(p); err != nil {
+					t.Fatal(err)
+				}
+				expected[id] = p
+			}
+			if err := fs.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			fs = newStorage(t, dir, tc.snapshotEvery)
+			for id, expectedPayment := range expected {
+				p, err := fs.Read(id)
+				if err != nil {
+					t.Fatalf("payment %s was lost: %v", id, err)
+				}
+				if !expectedPayment.Equal(p) {
+					t.Errorf("expected %v, got %v", expectedPayment, p)
+				}
+
+				p, err = fs.ReadUsingBankReference(expectedPayment.BankPaymentID)
+				if err != nil {
+					t.Fatalf("bank reference for %s was lost: %v", id, err)
+				}
+				if p.ID != id {
+					t.Errorf("expected %s, got %s", id, p.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestFileLedger_TornLog(t *testing.T) {
+	type testCase struct {
+		testName    string
+		tail        string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "partial_record_without_newline",
+			tail:        `{"payment":{"ID":"`,
+			expectedErr: nil,
+		},
+		{
+			testName:    "partial_record_with_newline",
+			tail:        "{\"payment\":{\"ID\":\"\n",
+			expectedErr: nil,
+		},
+		{
+			testName:    "garbage_before_valid_record",
+			tail:        "garbage\n{\"payment\":{}}\n",
+			expectedErr: file.ErrCorruptedLog,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			dir := t.TempDir()
+
+			fs, err := file.NewFileStorage(dir, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, err := fs.Create(newPayment(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := fs.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := os.OpenFile(filepath.Join(dir, "payments.log"), os.O_APPEND|os.O_WRONLY, 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.WriteString(tc.tail); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			fs, err = file.NewFileStorage(dir, 100)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { fs.Close() })
+
+			if _, err := fs.Read(id); err != nil {
+				t.Errorf("acknowledged payment was lost: %v", err)
+			}
+
+			// new records must not be appended after the discarded tail
+			newID, err := fs.Create(newPayment(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fs.Close()
+
+			fs = newStorage(t, dir, 100)
+			if _, err := fs.Read(newID); err != nil {
+				t.Errorf("payment created after recovery was lost: %v", err)
+			}
+		})
+	}
+}
+
+// legacyPayment encodes p the way it was written when amounts were decimals
+// of the currency
+func legacyPayment(t *testing.T, p entity.Payment, amount string) map[string]any {
+	t.Helper()
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	fields["Amount"] = json.Number(amount)
+	delete(fields, "CapturedAmount")
+	return fields
+}
+
+func TestFileLedger_LegacyAmounts(t *testing.T) {
+	type testCase struct {
+		testName       string
+		currency       string
+		amount         string
+		expectedAmount int64
+	}
+
+	testCases := []testCase{
+		{
+			testName:       "cents",
+			currency:       "USD",
+			amount:         "150.5",
+			expectedAmount: 15050,
+		},
+		{
+			testName:       "whole_units",
+			currency:       "USD",
+			amount:         "150",
+			expectedAmount: 15000,
+		},
+		{
+			testName:       "floating_point_error",
+			currency:       "USD",
+			amount:         "0.30000000000000004",
+			expectedAmount: 30,
+		},
+		{
+			testName:       "no_minor_units",
+			currency:       "JPY",
+			amount:         "1000",
+			expectedAmount: 1000,
+		},
+		{
+			testName:       "three_digits",
+			currency:       "KWD",
+			amount:         "1.25",
+			expectedAmount: 1250,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			inSnapshot, inLog := newPayment(t), newPayment(t)
+			for _, p := range []*entity.Payment{&inSnapshot, &inLog} {
+				p.ID = uuid.New()
+				p.Currency = tc.currency
+			}
+
+			dir := t.TempDir()
+			data, err := json.Marshal([]any{legacyPayment(t, inSnapshot, tc.amount)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "payments.snapshot"), data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			data, err = json.Marshal(map[string]any{"payment": legacyPayment(t, inLog, tc.amount)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "payments.log"), append(data, '\n'), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			// the second time the converted files are read, so the amounts
+			// must not be converted again
+			for i := 0; i < 2; i++ {
+				fs, err := file.NewFileStorage(dir, 10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, p := range []entity.Payment{inSnapshot, inLog} {
+					got, err := fs.Read(p.ID)
+					if err != nil {
+						t.Fatalf("legacy payment was lost: %v", err)
+					}
+					if got.Amount != tc.expectedAmount {
+						t.Errorf("expected %d, got %d", tc.expectedAmount, got.Amount)
+					}
+				}
+				fs.Close()
+			}
+		})
+	}
+}
+
+func TestFileLedger_LegacyUnknownCurrency(t *testing.T) {
+	p := newPayment(t)
+	p.ID = uuid.New()
+	p.Currency = "XXX"
+
+	dir := t.TempDir()
+	data, err := json.Marshal([]any{legacyPayment(t, p, "10.5")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "payments.snapshot"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := file.NewFileStorage(dir, 10); !errors.Is(err, entity.ErrUnknownCurrency) {
+		t.Errorf("expected %v, got %v", entity.ErrUnknownCurrency, err)
+	}
+}
+
+func TestFileLedger_ReopenIdempotencyKeys(t *testing.T) {
+	for _, snapshotEvery := range []int{2, 100} {
+		dir := t.TempDir()
+		merchantID := uuid.New()
+
+		fs, err := file.NewFileStorage(dir, snapshotEvery)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		completed, err := entity.NewIdempotencyKey(merchantID.String(), "completed", "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		released, err := entity.NewIdempotencyKey(merchantID.String(), "released", "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range []entity.IdempotencyKey{completed, released} {
+			if _, _, err := fs.CreateIdempotencyKey(k); err != nil {
+				t.Fatal(err)
+			}
+		}
+		completed.Status = entity.Completed
+		completed.ResponseStatus = 200
+		completed.ResponseBody = []byte(`{"id":"1"}`)
+		if err := fs.UpdateIdempotencyKey(completed); err != nil {
+			t.Fatal(err)
+		}
+		if err := fs.DeleteIdempotencyKey(merchantID, released.Key); err != nil {
+			t.Fatal(err)
+		}
+		fs.Close()
+
+		fs = newStorage(t, dir, snapshotEvery)
+		k, err := fs.ReadIdempotencyKey(merchantID, completed.Key)
+		if err != nil {
+			t.Fatalf("completed key was lost: %v", err)
+		}
+		if k.Status != entity.Completed || k.ResponseStatus != 200 || string(k.ResponseBody) != `{"id":"1"}` {
+			t.Errorf("completed key was corrupted: %v", k)
+		}
+		if _, err := fs.ReadIdempotencyKey(merchantID, released.Key); !errors.Is(err, file.ErrUnknownIdempotencyKey) {
+			t.Errorf("expected %v, got %v", file.ErrUnknownIdempotencyKey, err)
+		}
+	}
+}
+
+func TestFileLedger_ReopenList(t *testing.T) {
+	for _, snapshotEvery := range []int{2, 100} {
+		dir := t.TempDir()
+		fs := newStorage(t, dir, snapshotEvery)
+
+		p := newPayment(t)
+		var ids []uuid.UUID
+		for i := 0; i < 3; i++ {
+			p.PurchaseTime = p.PurchaseTime.Add(time.Hour)
+			id, err := fs.Create(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+
+		p, err := fs.Read(ids[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Status = entity.Pending
+		if err := fs.Update(p); err != nil {
+			t.Fatal(err)
+		}
+		fs.Close()
+
+		fs = newStorage(t, dir, snapshotEvery)
+		page, next, err := fs.List(entity.PaymentFilter{MerchantID: p.MerchantID, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) != 2 || page[0].ID != ids[2] || page[1].ID != ids[1] || next == nil {
+			t.Errorf("unexpected first page after reopen: %v, next=%v", page, next)
+		}
+
+		pending := entity.Pending
+		page, _, err = fs.List(entity.PaymentFilter{MerchantID: p.MerchantID, Status: &pending})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) != 1 || page[0].ID != ids[1] {
+			t.Errorf("expected only %s pending after reopen, got %v", ids[1], page)
+		}
+	}
+}
+
+func TestFileLedger_ReopenRefunds(t *testing.T) {
+	for _, snapshotEvery := range []int{2, 100} {
+		dir := t.TempDir()
+		fs := newStorage(t, dir, snapshotEvery)
+
+		p := newPayment(t)
+		p.Status = entity.Success
+		id, err := fs.Create(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var refundIDs []uuid.UUID
+		for _, amount := range []int64{5000, 4000} {
+			r, err := entity.NewRefund(id.String(), amount)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err = fs.CreateRefund(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			refundIDs = append(refundIDs, r.ID)
+		}
+
+		bankRefundID := uuid.New()
+		r, err := fs.ReadRefund(refundIDs[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Status = entity.Pending
+		r.BankRefundID = bankRefundID
+		if err := fs.UpdateRefund(r); err != nil {
+			t.Fatal(err)
+		}
+		fs.Close()
+
+		fs = newStorage(t, dir, snapshotEvery)
+		refunds, err := fs.ListRefunds(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(refunds) != 2 || refunds[0].ID != refundIDs[0] || refunds[1].ID != refundIDs[1] {
+			t.Fatalf("refunds were not restored in order: %v", refunds)
+		}
+		if r, err := fs.ReadRefundUsingBankReference(bankRefundID); err != nil || r.Status != entity.Pending {
+			t.Errorf("expected pending refund by bank reference, got %v, %v", r, err)
+		}
+
+		// the balance survives the restart too
+		over, err := entity.NewRefund(id.String(), 6001)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fs.CreateRefund(over); !errors.Is(err, entity.ErrRefundExceedsBalance) {
+			t.Errorf("expected %v, got %v", entity.ErrRefundExceedsBalance, err)
+		}
+	}
+}
+
+func TestFileLedger_ReopenAuthorizations(t *testing.T) {
+	now := time.Date(2023, 5, 18, 1, 0, 0, 0, time.UTC)
+
+	for _, snapshotEvery := range []int{2, 100} {
+		dir := t.TempDir()
+		fs := newStorage(t, dir, snapshotEvery)
+
+		p := newPayment(t)
+		p.CaptureMode = entity.ManualCapture
+		p.Status = entity.Pending
+		var ids []uuid.UUID
+		for _, expiresAt := range []time.Time{now.Add(-time.Minute), now.Add(time.Minute)} {
+			id, err := fs.Create(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			payment, err := fs.Read(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := payment.Authorize(expiresAt); err != nil {
+				t.Fatal(err)
+			}
+			if err := fs.Update(payment); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		fs.Close()
+
+		// authorizations are tracked again after a restart
+		fs = newStorage(t, dir, snapshotEvery)
+		expired, err := fs.ExpireAuthorizations(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(expired) != 1 || expired[0].ID != ids[0] {
+			t.Fatalf("expected only %s to expire, got %v", ids[0], expired)
+		}
+		fs.Close()
+
+		// and so are the voids
+		fs = newStorage(t, dir, snapshotEvery)
+		payment, err := fs.Read(ids[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if payment.Status != entity.Voided {
+			t.Errorf("expected %s, got %s", entity.Voided, payment.Status)
+		}
+		payment, err = fs.Read(ids[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if payment.Status != entity.Authorized || payment.CaptureMode != entity.ManualCapture {
+			t.Errorf("expected manual %s, got %v %s", entity.Authorized, payment.CaptureMode, payment.Status)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package index
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
+)
+
+// statusKey identifies the payments of a merchant in a given status
+type statusKey struct {
+	merchantID uuid.UUID
+	status     entity.PaymentStatus
+}
+
+// Payments keeps, for every merchant, the positions of its payments sorted
+// in listing order, overall and per status, and when each authorized payment
+// expires. It is not safe for concurrent use, storages must guard it with the
+// same lock as their payments.
+type Payments struct {
+	byMerchant     map[uuid.UUID][]entity.PaymentCursor
+	byStatus       map[statusKey][]entity.PaymentCursor
+	authorizations map[uuid.UUID]time.Time
+}
+
+// NewPayments is a factory for an empty index
+func NewPayments() *Payments {
+	return &Payments{
+		byMerchant:     make(map[uuid.UUID][]entity.PaymentCursor),
+		byStatus:       make(map[statusKey][]entity.PaymentCursor),
+		authorizations: make(map[uuid.UUID]time.Time),
+	}
+}
+
+// Add indexes a payment, it must not be indexed already
+func (ix *Payments) Add(p entity.Payment) {
+	c := entity.NewPaymentCursor(p)
+	ix.byMerchant[p.MerchantID] = insert(ix.byMerchant[p.MerchantID], c)
+
+	sk := statusKey{merchantID: p.MerchantID, status: p.Status}
+	ix.byStatus[sk] = insert(ix.byStatus[sk], c)
+
+	if p.Status == entity.Authorized {
+		ix.authorizations[p.ID] = p.AuthorizationExpiresAt
+	}
+}
+
+// Remove forgets a payment, it must be given as it was when added
+func (ix *Payments) Remove(p entity.Payment) {
+	c := entity.NewPaymentCursor(p)
+	ix.byMerchant[p.MerchantID] = remove(ix.byMerchant[p.MerchantID], c)
+
+	sk := statusKey{merchantID: p.MerchantID, status: p.Status}
+	ix.byStatus[sk] = remove(ix.byStatus[sk], c)
+
+	delete(ix.authorizations, p.ID)
+}
+
+// Replace updates the index after a payment changed from old to p
+func (ix *Payments) Replace(old, p entity.Payment) {
+	ix.Remove(old)
+	ix.Add(p)
+}
+
+// List returns one page of the payments selected by f, and the cursor of the
+// next page, which is nil for the last one. Only positions inside the
+// purchase time range of f are visited, in the status list if f has one.
+func (ix *Payments) List(f entity.PaymentFilter, payments map[uuid.UUID]entity.Payment) ([]entity.Payment, *entity.PaymentCursor) {
+	positions := ix.byMerchant[f.MerchantID]
+	if f.Status != nil {
+		positions = ix.byStatus[statusKey{merchantID: f.MerchantID, status: *f.Status}]
+	}
+
+	start := 0
+	if !f.PurchasedTo.IsZero() {
+		start = sort.Search(len(positions), func(i int) bool {
+			return positions[i].PurchaseTime.Before(f.PurchasedTo)
+		})
+	}
+	if f.Cursor != nil {
+		afterCursor := sort.Search(len(positions), func(i int) bool {
+			return f.Cursor.Before(positions[i])
+		})
+		if afterCursor > start {
+			start = afterCursor
+		}
+	}
+
+	size := f.PageSize()
+	page := make([]entity.Payment, 0, size)
+	for _, c := range positions[start:] {
+		if !f.PurchasedFrom.IsZero() && c.PurchaseTime.Before(f.PurchasedFrom) {
+			break
+		}
+
+		p, ok := payments[c.ID]
+		if !ok || !f.Match(p) {
+			continue
+		}
+
+		if len(page) == size {
+			next := entity.NewPaymentCursor(page[len(page)-1])
+			return page, &next
+		}
+		page = append(page, p)
+	}
+
+	return page, nil
+}
+
+// ExpiredAuthorizations returns the ids of authorized payments that expired
+// before now
+func (ix *Payments) ExpiredAuthorizations(now time.Time) []uuid.UUID {
+	var ids []uuid.UUID
+	for id, expiresAt := range ix.authorizations {
+		if now.After(expiresAt) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// insert adds c to positions keeping them sorted
+func insert(positions []entity.PaymentCursor, c entity.PaymentCursor) []entity.PaymentCursor {
+	i := sort.Search(len(positions), func(i int) bool {
+		return !positions[i].Before(c)
+	})
+	positions = append(positions, entity.PaymentCursor{})
+	copy(positions[i+1:], positions[i:])
+	positions[i] = c
+	return positions
+}
+
+// remove deletes c from positions, if it is there
+func remove(positions []entity.PaymentCursor, c entity.PaymentCursor) []entity.PaymentCursor {
+	i := sort.Search(len(positions), func(i int) bool {
+		return !positions[i].Before(c)
+	})
+	if i == len(positions) || positions[i].ID != c.ID {
+		return positions
+	}
+	return append(positions[:i], positions[i+1:]...)
+}
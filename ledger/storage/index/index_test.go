@@ -0,0 +1,198 @@
+package index_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
+	"github.com/thiagolcmelo/payment-gateway/ledger/storage/index"
+)
+
+var start = time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC)
+
+// newPayments indexes n payments of merchantID, purchased one minute apart,
+// the first one being the oldest
+func newPayments(merchantID uuid.UUID, n int) (*index.Payments, map[uuid.UUID]entity.Payment, []entity.Payment) {
+	ix := index.NewPayments()
+	byID := make(map[uuid.UUID]entity.Payment)
+	payments := make([]entity.Payment, 0, n)
+	for i := 0; i < n; i++ {
+		p := entity.Payment{
+			ID:           uuid.New(),
+			MerchantID:   merchantID,
+			Amount:       int64(i),
+			Currency:     "USD",
+			PurchaseTime: start.Add(time.Duration(i) * time.Minute),
+			Status:       entity.Created,
+		}
+		ix.Add(p)
+		byID[p.ID] = p
+		payments = append(payments, p)
+	}
+	return ix, byID, payments
+}
+
+// amounts is a short way to tell which payments were returned
+func amounts(payments []entity.Payment) []int64 {
+	result := make([]int64, 0, len(payments))
+	for _, p := range payments {
+		result = append(result, p.Amount)
+	}
+	return result
+}
+
+func equal(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPayments_List(t *testing.T) {
+	merchantID := uuid.New()
+	ix, byID, _ := newPayments(merchantID, 10)
+	_, otherByID, _ := newPayments(uuid.New(), 3)
+	for id, p := range otherByID {
+		ix.Add(p)
+		byID[id] = p
+	}
+
+	pending := entity.Pending
+	minAmount := int64(4)
+
+	type testCase struct {
+		testName    string
+		filter      entity.PaymentFilter
+		expected    []int64
+		expectedEnd bool
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "newest_first",
+			filter:      entity.PaymentFilter{MerchantID: merchantID},
+			expected:    []int64{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+			expectedEnd: true,
+		},
+		{
+			testName:    "limit",
+			filter:      entity.PaymentFilter{MerchantID: merchantID, Limit: 3},
+			expected:    []int64{9, 8, 7},
+			expectedEnd: false,
+		},
+		{
+			testName:    "limit_equal_to_matches",
+			filter:      entity.PaymentFilter{MerchantID: merchantID, Limit: 10},
+			expected:    []int64{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+			expectedEnd: true,
+		},
+		{
+			testName:    "purchase_time_range",
+			filter:      entity.PaymentFilter{MerchantID: merchantID, PurchasedFrom: start.Add(2 * time.Minute), PurchasedTo: start.Add(5 * time.Minute)},
+			expected:    []int64{4, 3, 2},
+			expectedEnd: true,
+		},
+		{
+			testName:    "other_filters",
+			filter:      entity.PaymentFilter{MerchantID: merchantID, MinAmount: &minAmount, Limit: 2},
+			expected:    []int64{9, 8},
+			expectedEnd: false,
+		},
+		{
+			testName:    "empty_status",
+			filter:      entity.PaymentFilter{MerchantID: merchantID, Status: &pending},
+			expected:    []int64{},
+			expectedEnd: true,
+		},
+		{
+			testName:    "unknown_merchant",
+			filter:      entity.PaymentFilter{MerchantID: uuid.New()},
+			expected:    []int64{},
+			expectedEnd: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			page, next := ix.List(tc.filter, byID)
+			if !equal(amounts(page), tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, amounts(page))
+			}
+			if (next == nil) != tc.expectedEnd {
+				t.Errorf("expected last page=%v, got next=%v", tc.expectedEnd, next)
+			}
+		})
+	}
+}
+
+func TestPayments_ListPages(t *testing.T) {
+	merchantID := uuid.New()
+	ix, byID, payments := newPayments(merchantID, 7)
+
+	// payments purchased at the same time are still listed once
+	for _, p := range payments[:3] {
+		delete(byID, p.ID)
+		ix.Remove(p)
+		p.PurchaseTime = start
+		ix.Add(p)
+		byID[p.ID] = p
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	filter := entity.PaymentFilter{MerchantID: merchantID, Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > len(payments) {
+			t.Fatal("pagination does not end")
+		}
+
+		page, next := ix.List(filter, byID)
+		for _, p := range page {
+			if seen[p.ID] {
+				t.Errorf("payment %s listed twice", p.ID)
+			}
+			seen[p.ID] = true
+		}
+		if next == nil {
+			break
+		}
+		filter.Cursor = next
+	}
+
+	if len(seen) != len(payments) {
+		t.Errorf("expected %d payments, got %d", len(payments), len(seen))
+	}
+}
+
+func TestPayments_Replace(t *testing.T) {
+	merchantID := uuid.New()
+	ix, byID, payments := newPayments(merchantID, 3)
+
+	p := payments[1]
+	updated := p
+	updated.Status = entity.Pending
+	ix.Replace(p, updated)
+	byID[p.ID] = updated
+
+	created, pending := entity.Created, entity.Pending
+
+	page, _ := ix.List(entity.PaymentFilter{MerchantID: merchantID, Status: &pending}, byID)
+	if !equal(amounts(page), []int64{1}) {
+		t.Errorf("expected [1] pending, got %v", amounts(page))
+	}
+
+	page, _ = ix.List(entity.PaymentFilter{MerchantID: merchantID, Status: &created}, byID)
+	if !equal(amounts(page), []int64{2, 0}) {
+		t.Errorf("expected [2 0] created, got %v", amounts(page))
+	}
+
+	page, _ = ix.List(entity.PaymentFilter{MerchantID: merchantID}, byID)
+	if !equal(amounts(page), []int64{2, 1, 0}) {
+		t.Errorf("expected [2 1 0], got %v", amounts(page))
+	}
+}
@@ -3,9 +3,11 @@ package memory
 import (
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
+	"github.com/thiagolcmelo/payment-gateway/ledger/storage/index"
 )
 
 var (
@@ -13,20 +15,40 @@ var (
 	ErrUnknownPayment = errors.New("there is no payment with given id")
 	// ErrUnknownBankReference must be used when trying to read using unknown bank reference
 	ErrUnknownBankReference = errors.New("unknown bank reference")
+	// ErrUnknownRefund must be used while trying to read or change a refund with unknown id
+	ErrUnknownRefund = errors.New("there is no refund with given id")
+	// ErrUnknownIdempotencyKey must be used when trying to read or change a key the merchant does not have
+	ErrUnknownIdempotencyKey = errors.New("unknown idempotency key")
 )
 
+// idempotencyKeyID identifies a key, keys are scoped per merchant
+type idempotencyKeyID struct {
+	merchantID uuid.UUID
+	key        string
+}
+
 // Storage is an in memory implementation of a Ledger to store payments
 type Storage struct {
-	payments       map[uuid.UUID]entity.Payment
-	bankReferences map[uuid.UUID]uuid.UUID
+	payments        map[uuid.UUID]entity.Payment
+	bankReferences  map[uuid.UUID]uuid.UUID
+	merchantIndex   *index.Payments
+	refunds         map[uuid.UUID]entity.Refund
+	paymentRefunds  map[uuid.UUID][]uuid.UUID
+	refundBankRefs  map[uuid.UUID]uuid.UUID
+	idempotencyKeys map[idempotencyKeyID]entity.IdempotencyKey
 	sync.RWMutex
 }
 
 // NewMemoryStorage is a factory for in memory Storage for payments
 func NewMemoryStorage() *Storage {
 	return &Storage{
-		payments:       make(map[uuid.UUID]entity.Payment),
-		bankReferences: make(map[uuid.UUID]uuid.UUID),
+		payments:        make(map[uuid.UUID]entity.Payment),
+		bankReferences:  make(map[uuid.UUID]uuid.UUID),
+		merchantIndex:   index.NewPayments(),
+		refunds:         make(map[uuid.UUID]entity.Refund),
+		paymentRefunds:  make(map[uuid.UUID][]uuid.UUID),
+		refundBankRefs:  make(map[uuid.UUID]uuid.UUID),
+		idempotencyKeys: make(map[idempotencyKeyID]entity.IdempotencyKey),
 	}
 }
 
@@ -52,6 +74,7 @@ func (l *Storage) Create(p entity.Payment) (uuid.UUID, error) {
 	}
 
 	l.payments[id] = p
+	l.merchantIndex.Add(p)
 	if p.BankPaymentID != uuid.Nil {
 		l.bankReferences[p.BankPaymentID] = p.ID
 	}
@@ -90,12 +113,14 @@ func (l *Storage) ReadUsingBankReference(id uuid.UUID) (entity.Payment, error) {
 	return payment, nil
 }
 
-// Update edits information of a given payment
+// Update edits information of a given payment, as long as its status can
+// move to the new one
 func (l *Storage) Update(p entity.Payment) error {
 	l.Lock()
 	defer l.Unlock()
 
-	if _, ok := l.payments[p.ID]; !ok {
+	current, ok := l.payments[p.ID]
+	if !ok {
 		return ErrUnknownPayment
 	}
 
@@ -104,10 +129,246 @@ func (l *Storage) Update(p entity.Payment) error {
 		return err
 	}
 
+	err = current.Status.TransitionTo(p.Status)
+	if err != nil {
+		return err
+	}
+
 	l.payments[p.ID] = p
+	l.merchantIndex.Replace(current, p)
 	if p.BankPaymentID != uuid.Nil {
 		l.bankReferences[p.BankPaymentID] = p.ID
 	}
 
 	return nil
 }
+
+// ExpireAuthorizations voids every authorized payment that was not captured
+// before now, and returns them
+func (l *Storage) ExpireAuthorizations(now time.Time) ([]entity.Payment, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	var expired []entity.Payment
+	for _, id := range l.merchantIndex.ExpiredAuthorizations(now) {
+		current := l.payments[id]
+		p := current
+		if err := p.Void(); err != nil {
+			return expired, err
+		}
+		l.merchantIndex.Replace(current, p)
+		l.payments[id] = p
+		expired = append(expired, p)
+	}
+
+	return expired, nil
+}
+
+// List returns one page of the payments of a merchant selected by the filter,
+// and the cursor of the next page, which is nil for the last one
+func (l *Storage) List(f entity.PaymentFilter) ([]entity.Payment, *entity.PaymentCursor, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	err := f.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, next := l.merchantIndex.List(f, l.payments)
+	return page, next, nil
+}
+
+// CreateRefund adds a new refund to the Ledger, the check against the
+// refundable balance happens under the same lock as the insert, so concurrent
+// refunds cannot add up to more than the payment
+func (l *Storage) CreateRefund(r entity.Refund) (entity.Refund, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	p, ok := l.payments[r.PaymentID]
+	if !ok {
+		return entity.Refund{}, ErrUnknownPayment
+	}
+
+	r, err := entity.PrepareRefund(p, l.refundsOf(p.ID), r)
+	if err != nil {
+		return entity.Refund{}, err
+	}
+
+	for {
+		r.ID = uuid.New()
+		if _, ok := l.refunds[r.ID]; !ok {
+			break
+		}
+	}
+
+	l.refunds[r.ID] = r
+	l.paymentRefunds[r.PaymentID] = append(l.paymentRefunds[r.PaymentID], r.ID)
+	if r.BankRefundID != uuid.Nil {
+		l.refundBankRefs[r.BankRefundID] = r.ID
+	}
+
+	return r, nil
+}
+
+// ReadRefund returns details of a refund in the Ledger
+func (l *Storage) ReadRefund(id uuid.UUID) (entity.Refund, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	r, ok := l.refunds[id]
+	if !ok {
+		return entity.Refund{}, ErrUnknownRefund
+	}
+
+	return r, nil
+}
+
+// ReadRefundUsingBankReference returns details of a refund in the Ledger using a bank reference
+func (l *Storage) ReadRefundUsingBankReference(id uuid.UUID) (entity.Refund, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	refundID, ok := l.refundBankRefs[id]
+	if !ok {
+		return entity.Refund{}, ErrUnknownBankReference
+	}
+
+	r, ok := l.refunds[refundID]
+	if !ok {
+		return entity.Refund{}, ErrUnknownRefund
+	}
+
+	return r, nil
+}
+
+// UpdateRefund edits information of a given refund, as long as its status
+// can move to the new one. The payment and amount of a refund cannot change.
+func (l *Storage) UpdateRefund(r entity.Refund) error {
+	l.Lock()
+	defer l.Unlock()
+
+	current, ok := l.refunds[r.ID]
+	if !ok {
+		return ErrUnknownRefund
+	}
+
+	r.PaymentID, r.MerchantID, r.Amount, r.Currency = current.PaymentID, current.MerchantID, current.Amount, current.Currency
+
+	err := current.Status.TransitionTo(r.Status)
+	if err != nil {
+		return err
+	}
+
+	l.refunds[r.ID] = r
+	if r.BankRefundID != uuid.Nil {
+		l.refundBankRefs[r.BankRefundID] = r.ID
+	}
+
+	return nil
+}
+
+// ListRefunds returns all refunds of a payment, oldest first
+func (l *Storage) ListRefunds(paymentID uuid.UUID) ([]entity.Refund, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	if _, ok := l.payments[paymentID]; !ok {
+		return nil, ErrUnknownPayment
+	}
+
+	return l.refundsOf(paymentID), nil
+}
+
+// refundsOf must be called with the lock held
+func (l *Storage) refundsOf(paymentID uuid.UUID) []entity.Refund {
+	refunds := make([]entity.Refund, 0, len(l.paymentRefunds[paymentID]))
+	for _, id := range l.paymentRefunds[paymentID] {
+		refunds = append(refunds, l.refunds[id])
+	}
+	return refunds
+}
+
+// CreateIdempotencyKey stores a new key, unless the merchant already has a
+// live key with the same name, in which case it is returned untouched
+func (l *Storage) CreateIdempotencyKey(k entity.IdempotencyKey) (entity.IdempotencyKey, bool, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	err := k.Validate()
+	if err != nil {
+		return entity.IdempotencyKey{}, false, err
+	}
+
+	id := idempotencyKeyID{merchantID: k.MerchantID, key: k.Key}
+	if existing, ok := l.idempotencyKeys[id]; ok && !existing.Expired(time.Now()) {
+		return existing, false, nil
+	}
+
+	l.idempotencyKeys[id] = k
+	return k, true, nil
+}
+
+// ReadIdempotencyKey returns a key of a merchant
+func (l *Storage) ReadIdempotencyKey(merchantID uuid.UUID, key string) (entity.IdempotencyKey, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	k, ok := l.idempotencyKeys[idempotencyKeyID{merchantID: merchantID, key: key}]
+	if !ok {
+		return entity.IdempotencyKey{}, ErrUnknownIdempotencyKey
+	}
+
+	return k, nil
+}
+
+// UpdateIdempotencyKey edits an existing key
+func (l *Storage) UpdateIdempotencyKey(k entity.IdempotencyKey) error {
+	l.Lock()
+	defer l.Unlock()
+
+	id := idempotencyKeyID{merchantID: k.MerchantID, key: k.Key}
+	if _, ok := l.idempotencyKeys[id]; !ok {
+		return ErrUnknownIdempotencyKey
+	}
+
+	err := k.Validate()
+	if err != nil {
+		return err
+	}
+
+	l.idempotencyKeys[id] = k
+	return nil
+}
+
+// DeleteIdempotencyKey forgets a key, so the merchant can use it again
+func (l *Storage) DeleteIdempotencyKey(merchantID uuid.UUID, key string) error {
+	l.Lock()
+	defer l.Unlock()
+
+	id := idempotencyKeyID{merchantID: merchantID, key: key}
+	if _, ok := l.idempotencyKeys[id]; !ok {
+		return ErrUnknownIdempotencyKey
+	}
+
+	delete(l.idempotencyKeys, id)
+	return nil
+}
+
+// ExpireIdempotencyKeys forgets every key that expired before now, along with
+// its response, and returns how many
+func (l *Storage) ExpireIdempotencyKeys(now time.Time) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	expired := 0
+	for id, k := range l.idempotencyKeys {
+		if k.Expired(now) {
+			delete(l.idempotencyKeys, id)
+			expired++
+		}
+	}
+
+	return expired, nil
+}
@@ -3,6 +3,7 @@ package memory_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
@@ -13,7 +14,7 @@ func TestMemoryLedger_Create(t *testing.T) {
 	type testCase struct {
 		testName         string
 		merchantID       string
-		amount           float64
+		amount           int64
 		currency         string
 		purchaseTimeUTC  string
 		validationMethod string
@@ -31,7 +32,7 @@ func TestMemoryLedger_Create(t *testing.T) {
 		{
 			testName:         "valid_new_payment",
 			merchantID:       uuid.New().String(),
-			amount:           150.00,
+			amount:           15000,
 			currency:         "USD",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "push",
@@ -47,7 +48,7 @@ func TestMemoryLedger_Create(t *testing.T) {
 		{
 			testName:         "invalid_card_cvv_too_big",
 			merchantID:       uuid.New().String(),
-			amount:           150.00,
+			amount:           15000,
 			currency:         "USD",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "push",
@@ -63,7 +64,7 @@ func TestMemoryLedger_Create(t *testing.T) {
 		{
 			testName:         "invalid_card_cvv_negative",
 			merchantID:       uuid.New().String(),
-			amount:           150.00,
+			amount:           15000,
 			currency:         "USD",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "push",
@@ -79,7 +80,7 @@ func TestMemoryLedger_Create(t *testing.T) {
 		{
 			testName:         "invalid_card_expired",
 			merchantID:       uuid.New().String(),
-			amount:           150.00,
+			amount:           15000,
 			currency:         "USD",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "push",
@@ -95,7 +96,7 @@ func TestMemoryLedger_Create(t *testing.T) {
 		{
 			testName:         "invalid_card_missing_name",
 			merchantID:       uuid.New().String(),
-			amount:           150.00,
+			amount:           15000,
 			currency:         "USD",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "push",
@@ -111,7 +112,7 @@ func TestMemoryLedger_Create(t *testing.T) {
 		{
 			testName:         "invalid_card_missing_number",
 			merchantID:       uuid.New().String(),
-			amount:           150.00,
+			amount:           15000,
 			currency:         "USD",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "push",
@@ -127,7 +128,7 @@ func TestMemoryLedger_Create(t *testing.T) {
 		{
 			testName:         "invalid_missing_validation_method",
 			merchantID:       uuid.New().String(),
-			amount:           150.00,
+			amount:           15000,
 			currency:         "USD",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "",
@@ -143,7 +144,7 @@ func TestMemoryLedger_Create(t *testing.T) {
 		{
 			testName:         "invalid_missing_currency",
 			merchantID:       uuid.New().String(),
-			amount:           150.00,
+			amount:           15000,
 			currency:         "",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "push",
@@ -159,7 +160,7 @@ func TestMemoryLedger_Create(t *testing.T) {
 		{
 			testName:         "invalid_negative_amoung",
 			merchantID:       uuid.New().String(),
-			amount:           -150.00,
+			amount:           -15000,
 			currency:         "USD",
 			purchaseTimeUTC:  "2023-05-18T01:00:00.000",
 			validationMethod: "push",
@@ -176,7 +177,7 @@ func TestMemoryLedger_Create(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.testName, func(t *testing.T) {
-			payment, _ := entity.NewPayment(
+			payment, err := entity.NewPayment(
 				tc.merchantID,
 				tc.amount,
 				tc.currency,
@@ -192,6 +193,13 @@ func TestMemoryLedger_Create(t *testing.T) {
 				},
 				tc.metadata,
 			)
+			// the cvv is never stored, so it can only be refused here
+			if errors.Is(err, entity.ErrInvalidCVV) {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("expected %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
 
 			id, err := tc.ms.Create(payment)
 			if !errors.Is(err, tc.expectedErr) {
@@ -214,13 +222,13 @@ func TestMemoryLedger_Read(t *testing.T) {
 
 	payment, err := entity.NewPayment(
 		uuid.New().String(),
-		150.00,
+		15000,
 		"USD",
 		"2023-05-18T01:00:00.000",
 		"push",
 		entity.CreditCard{
-			Number:      "name surname",
-			Name:        "1111-2222-3333-4444",
+			Number:      "1111-2222-3333-4444",
+			Name:        "name surname",
 			ExpireMonth: 10,
 			ExpireYear:  2099,
 			CVV:         123,
@@ -288,13 +296,13 @@ func TestMemoryLedger_ReadUsingBankReference(t *testing.T) {
 
 	payment, err := entity.NewPayment(
 		uuid.New().String(),
-		150.00,
+		15000,
 		"USD",
 		"2023-05-18T01:00:00.000",
 		"push",
 		entity.CreditCard{
-			Number:      "name surname",
-			Name:        "1111-2222-3333-4444",
+			Number:      "1111-2222-3333-4444",
+			Name:        "name surname",
 			ExpireMonth: 10,
 			ExpireYear:  2099,
 			CVV:         123,
@@ -368,13 +376,13 @@ func TestMemoryLedger_Update(t *testing.T) {
 
 	payment, err := entity.NewPayment(
 		uuid.New().String(),
-		150.00,
+		15000,
 		"USD",
 		"2023-05-18T01:00:00.000",
 		"push",
 		entity.CreditCard{
-			Number:      "name surname",
-			Name:        "1111-2222-3333-4444",
+			Number:      "1111-2222-3333-4444",
+			Name:        "name surname",
 			ExpireMonth: 10,
 			ExpireYear:  2099,
 			CVV:         123,
@@ -387,13 +395,13 @@ func TestMemoryLedger_Update(t *testing.T) {
 
 	unknonwPayment, err := entity.NewPayment(
 		uuid.New().String(),
-		150.00,
+		15000,
 		"USD",
 		"2023-05-18T01:00:00.000",
 		"push",
 		entity.CreditCard{
-			Number:      "name surname",
-			Name:        "1111-2222-3333-4444",
+			Number:      "1111-2222-3333-4444",
+			Name:        "name surname",
 			ExpireMonth: 10,
 			ExpireYear:  2099,
 			CVV:         123,
@@ -420,6 +428,12 @@ func TestMemoryLedger_Update(t *testing.T) {
 	invalidPayment := payment // by value
 	invalidPayment.Amount = -100
 
+	successPayment := validPayment // by value
+	successPayment.Status = entity.Success
+
+	revertedPayment := successPayment // by value
+	revertedPayment.Status = entity.Fail
+
 	type testCase struct {
 		testName        string
 		ms              *memory.Storage
@@ -443,6 +457,20 @@ func TestMemoryLedger_Update(t *testing.T) {
 			expectedPayment: payment,
 			expectedErr:     entity.ErrNegativeAmount,
 		},
+		{
+			testName:        "update_pending_to_success",
+			ms:              ms,
+			payment:         successPayment,
+			expectedPayment: successPayment,
+			expectedErr:     nil,
+		},
+		{
+			testName:        "update_success_to_fail",
+			ms:              ms,
+			payment:         revertedPayment,
+			expectedPayment: successPayment,
+			expectedErr:     entity.ErrInvalidTransition,
+		},
 		{
 			testName:        "update_unexisting",
 			ms:              ms,
@@ -474,3 +502,537 @@ func TestMemoryLedger_Update(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryLedger_IdempotencyKey(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	merchantID := uuid.New()
+
+	key, err := entity.NewIdempotencyKey(merchantID.String(), "order-123", "hash-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, created, err := ms.CreateIdempotencyKey(key)
+	if err != nil || !created {
+		t.Fatalf("expected key to be created, got created=%v, err=%v", created, err)
+	}
+
+	// same name, different merchant
+	otherMerchantKey := key
+	otherMerchantKey.MerchantID = uuid.New()
+
+	// same name and merchant, but a retry with another body
+	retryKey := key
+	retryKey.RequestHash = "hash-1"
+
+	abandonedKey := key
+	abandonedKey.Key = "order-456"
+	abandonedKey.ExpiresAt = time.Now().Add(-time.Minute)
+	if _, _, err := ms.CreateIdempotencyKey(abandonedKey); err != nil {
+		t.Fatal(err)
+	}
+	takeoverKey := abandonedKey
+	takeoverKey.ExpiresAt = time.Now().Add(entity.InProgressTTL)
+	takeoverKey.RequestHash = "hash-2"
+
+	type testCase struct {
+		testName        string
+		key             entity.IdempotencyKey
+		expectedCreated bool
+		expectedHash    string
+		expectedErr     error
+	}
+
+	testCases := []testCase{
+		{
+			testName:        "same_key_other_merchant",
+			key:             otherMerchantKey,
+			expectedCreated: true,
+			expectedHash:    "hash-0",
+			expectedErr:     nil,
+		},
+		{
+			testName:        "same_key_same_merchant",
+			key:             retryKey,
+			expectedCreated: false,
+			expectedHash:    "hash-0",
+			expectedErr:     nil,
+		},
+		{
+			testName:        "abandoned_key_is_taken_over",
+			key:             takeoverKey,
+			expectedCreated: true,
+			expectedHash:    "hash-2",
+			expectedErr:     nil,
+		},
+		{
+			testName:    "invalid_key",
+			key:         entity.IdempotencyKey{MerchantID: merchantID, RequestHash: "hash-0"},
+			expectedErr: entity.ErrInvalidIdempotencyKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			k, created, err := ms.CreateIdempotencyKey(tc.key)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if created != tc.expectedCreated {
+				t.Errorf("expected created=%v, got %v", tc.expectedCreated, created)
+			}
+
+			if k.RequestHash != tc.expectedHash {
+				t.Errorf("expected RequestHash=%s, got %s", tc.expectedHash, k.RequestHash)
+			}
+		})
+	}
+
+	key.Status = entity.Completed
+	key.ResponseStatus = 200
+	key.ResponseBody = []byte(`{"id":"1"}`)
+	if err := ms.UpdateIdempotencyKey(key); err != nil {
+		t.Fatal(err)
+	}
+	k, err := ms.ReadIdempotencyKey(merchantID, key.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.Status != entity.Completed || string(k.ResponseBody) != `{"id":"1"}` {
+		t.Errorf("key was not updated: %v", k)
+	}
+
+	if err := ms.DeleteIdempotencyKey(merchantID, key.Key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.ReadIdempotencyKey(merchantID, key.Key); !errors.Is(err, memory.ErrUnknownIdempotencyKey) {
+		t.Errorf("expected %v, got %v", memory.ErrUnknownIdempotencyKey, err)
+	}
+	if err := ms.DeleteIdempotencyKey(merchantID, key.Key); !errors.Is(err, memory.ErrUnknownIdempotencyKey) {
+		t.Errorf("expected %v, got %v", memory.ErrUnknownIdempotencyKey, err)
+	}
+}
+
+func TestMemoryLedger_List(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	merchantID := uuid.New()
+
+	var ids []uuid.UUID
+	for i, purchaseTime := range []string{"2023-05-18T01:00:00.000", "2023-05-18T02:00:00.000", "2023-05-18T03:00:00.000"} {
+		p, err := entity.NewPayment(merchantID.String(), int64(1000*(i+1)), "USD", purchaseTime, "push", entity.CreditCard{
+			Number:      "1111-2222-3333-4444",
+			Name:        "name surname",
+			ExpireMonth: 10,
+			ExpireYear:  2099,
+			CVV:         123,
+		}, "shopper-123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := ms.Create(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	p, err := ms.Read(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Status = entity.Pending
+	if err := ms.Update(p); err != nil {
+		t.Fatal(err)
+	}
+
+	pending := entity.Pending
+
+	type testCase struct {
+		testName    string
+		filter      entity.PaymentFilter
+		expectedIDs []uuid.UUID
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "all_payments",
+			filter:      entity.PaymentFilter{MerchantID: merchantID},
+			expectedIDs: []uuid.UUID{ids[2], ids[1], ids[0]},
+			expectedErr: nil,
+		},
+		{
+			testName:    "updated_status",
+			filter:      entity.PaymentFilter{MerchantID: merchantID, Status: &pending},
+			expectedIDs: []uuid.UUID{ids[0]},
+			expectedErr: nil,
+		},
+		{
+			testName:    "other_merchant",
+			filter:      entity.PaymentFilter{MerchantID: uuid.New()},
+			expectedIDs: []uuid.UUID{},
+			expectedErr: nil,
+		},
+		{
+			testName:    "invalid_filter",
+			filter:      entity.PaymentFilter{},
+			expectedErr: entity.ErrMissingMerchantID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			page, _, err := ms.List(tc.filter)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if len(page) != len(tc.expectedIDs) {
+				t.Fatalf("expected %d payments, got %d", len(tc.expectedIDs), len(page))
+			}
+			for i, p := range page {
+				if p.ID != tc.expectedIDs[i] {
+					t.Errorf("expected payment %d to be %s, got %s", i, tc.expectedIDs[i], p.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLedger_CreateRefund(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+
+	p, err := entity.NewPayment(uuid.New().String(), 1000, "USD", "2023-05-18T01:00:00.000", "push", entity.CreditCard{
+		Number:      "1111-2222-3333-4444",
+		Name:        "name surname",
+		ExpireMonth: 10,
+		ExpireYear:  2099,
+		CVV:         123,
+	}, "shopper-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	createdID, err := ms.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	successID, err := ms.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ID = successID
+	p.Status = entity.Pending
+	if err := ms.Update(p); err != nil {
+		t.Fatal(err)
+	}
+	p.Status = entity.Success
+	if err := ms.Update(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// steps run in order, each one sees the refunds created by the previous
+	type testCase struct {
+		testName       string
+		paymentID      uuid.UUID
+		amount         int64
+		expectedAmount int64
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{testName: "unknown_payment", paymentID: uuid.New(), amount: 100, expectedErr: memory.ErrUnknownPayment},
+		{testName: "payment_not_successful", paymentID: createdID, amount: 100, expectedErr: entity.ErrPaymentNotRefundable},
+		{testName: "partial_refund", paymentID: successID, amount: 600, expectedAmount: 600, expectedErr: nil},
+		{testName: "over_refund", paymentID: successID, amount: 401, expectedErr: entity.ErrRefundExceedsBalance},
+		{testName: "rest_of_payment", paymentID: successID, amount: 0, expectedAmount: 400, expectedErr: nil},
+		{testName: "fully_refunded", paymentID: successID, amount: 1, expectedErr: entity.ErrRefundExceedsBalance},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			r, err := entity.NewRefund(tc.paymentID.String(), tc.amount)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err = ms.CreateRefund(r)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if r.Amount != tc.expectedAmount {
+				t.Errorf("expected Amount=%d, got %d", tc.expectedAmount, r.Amount)
+			}
+			if _, err := ms.ReadRefund(r.ID); err != nil {
+				t.Errorf("refund was not stored: %v", err)
+			}
+		})
+	}
+
+	// a failed refund gives its amount back to the balance
+	refunds, err := ms.ListRefunds(successID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refunds) != 2 {
+		t.Fatalf("expected 2 refunds, got %d", len(refunds))
+	}
+	failed := refunds[0]
+	failed.Status = entity.Fail
+	if err := ms.UpdateRefund(failed); err != nil {
+		t.Fatal(err)
+	}
+	r, err := entity.NewRefund(successID.String(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = ms.CreateRefund(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Amount != 600 {
+		t.Errorf("expected the failed 600 to be refundable again, got %d", r.Amount)
+	}
+}
+
+func TestMemoryLedger_UpdateRefund(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+
+	p, err := entity.NewPayment(uuid.New().String(), 1000, "USD", "2023-05-18T01:00:00.000", "push", entity.CreditCard{
+		Number:      "1111-2222-3333-4444",
+		Name:        "name surname",
+		ExpireMonth: 10,
+		ExpireYear:  2099,
+		CVV:         123,
+	}, "shopper-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Status = entity.Success
+	p.ID, err = ms.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := entity.NewRefund(p.ID.String(), 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = ms.CreateRefund(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bankRefundID := uuid.New()
+
+	type testCase struct {
+		testName    string
+		change      func(entity.Refund) entity.Refund
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName: "unknown_refund",
+			change: func(r entity.Refund) entity.Refund {
+				r.ID = uuid.New()
+				return r
+			},
+			expectedErr: memory.ErrUnknownRefund,
+		},
+		{
+			testName: "created_to_pending",
+			change: func(r entity.Refund) entity.Refund {
+				r.Status = entity.Pending
+				r.BankRefundID = bankRefundID
+				return r
+			},
+			expectedErr: nil,
+		},
+		{
+			testName: "amount_cannot_change",
+			change: func(r entity.Refund) entity.Refund {
+				r.Amount = 1000
+				return r
+			},
+			expectedErr: nil,
+		},
+		{
+			testName: "pending_to_success",
+			change: func(r entity.Refund) entity.Refund {
+				r.Status = entity.Success
+				return r
+			},
+			expectedErr: nil,
+		},
+		{
+			testName: "success_to_fail",
+			change: func(r entity.Refund) entity.Refund {
+				r.Status = entity.Fail
+				return r
+			},
+			expectedErr: entity.ErrInvalidTransition,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			current, err := ms.ReadRefund(r.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = ms.UpdateRefund(tc.change(current))
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+
+	stored, err := ms.ReadRefundUsingBankReference(bankRefundID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != entity.Success || stored.Amount != 500 {
+		t.Errorf("expected a successful refund of 500, got %v %d", stored.Status, stored.Amount)
+	}
+}
+
+func TestMemoryLedger_ExpireIdempotencyKeys(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	merchantID := uuid.New()
+	now := time.Now()
+
+	for name, completedAt := range map[string]time.Time{
+		"fresh":     {},
+		"abandoned": {},
+		"completed": now.Add(-time.Hour),
+		"old":       now.Add(-2 * entity.CompletedTTL),
+	} {
+		k, err := entity.NewIdempotencyKey(merchantID.String(), name, "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "abandoned" {
+			k.ExpiresAt = now.Add(-time.Minute)
+		}
+		if !completedAt.IsZero() {
+			k.Complete(200, []byte(`{"id":"1"}`), completedAt)
+		}
+		if _, _, err := ms.CreateIdempotencyKey(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired, err := ms.ExpireIdempotencyKeys(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired != 2 {
+		t.Errorf("expected 2 keys to expire, got %d", expired)
+	}
+
+	for name, kept := range map[string]bool{"fresh": true, "abandoned": false, "completed": true, "old": false} {
+		_, err := ms.ReadIdempotencyKey(merchantID, name)
+		if kept && err != nil {
+			t.Errorf("expected %s to be kept, got %v", name, err)
+		}
+		if !kept && !errors.Is(err, memory.ErrUnknownIdempotencyKey) {
+			t.Errorf("expected %s to be forgotten, got %v", name, err)
+		}
+	}
+}
+
+func TestMemoryLedger_ExpireAuthorizations(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	now := time.Date(2023, 5, 18, 1, 0, 0, 0, time.UTC)
+
+	p, err := entity.NewPayment(uuid.New().String(), 1000, "USD", "2023-05-18T01:00:00.000", "push", entity.CreditCard{
+		Number:      "1111-2222-3333-4444",
+		Name:        "name surname",
+		ExpireMonth: 10,
+		ExpireYear:  2099,
+		CVV:         123,
+	}, "shopper-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.CaptureMode = entity.ManualCapture
+	p.Status = entity.Pending
+
+	authorize := func(expiresAt time.Time) uuid.UUID {
+		id, err := ms.Create(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payment, err := ms.Read(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := payment.Authorize(expiresAt); err != nil {
+			t.Fatal(err)
+		}
+		if err := ms.Update(payment); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	expiredID := authorize(now.Add(-time.Minute))
+	validID := authorize(now.Add(time.Minute))
+	capturedID := authorize(now.Add(-time.Minute))
+
+	captured, err := ms.Read(capturedID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := captured.Capture(0, now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.Update(captured); err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := ms.ExpireAuthorizations(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].ID != expiredID {
+		t.Fatalf("expected only %s to expire, got %v", expiredID, expired)
+	}
+
+	for id, status := range map[uuid.UUID]entity.PaymentStatus{
+		expiredID:  entity.Voided,
+		validID:    entity.Authorized,
+		capturedID: entity.Captured,
+	} {
+		payment, err := ms.Read(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if payment.Status != status {
+			t.Errorf("expected %s to be %s, got %s", id, status, payment.Status)
+		}
+	}
+
+	// voided authorizations are not swept twice
+	expired, err = ms.ExpireAuthorizations(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 0 {
+		t.Errorf("expected nothing else to expire, got %v", expired)
+	}
+}
@@ -1,15 +1,42 @@
 package storage
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 
 	entity "github.com/thiagolcmelo/payment-gateway/ledger/entities"
 )
 
-// Storage defines a common interface for persisting payments
+// Storage defines a common interface for persisting payments and their refunds
 type Storage interface {
 	Create(entity.Payment) (uuid.UUID, error)
 	Read(uuid.UUID) (entity.Payment, error)
 	ReadUsingBankReference(uuid.UUID) (entity.Payment, error)
 	Update(entity.Payment) error
+	// ExpireAuthorizations voids authorized payments that expired before the
+	// given time, and returns them
+	ExpireAuthorizations(time.Time) ([]entity.Payment, error)
+	// List returns one page of the payments selected by the filter and the
+	// cursor of the next page, nil for the last one
+	List(entity.PaymentFilter) ([]entity.Payment, *entity.PaymentCursor, error)
+
+	// CreateRefund stores a refund of an existing payment, as long as the
+	// payment can still be refunded by its amount, and returns it complete
+	CreateRefund(entity.Refund) (entity.Refund, error)
+	ReadRefund(uuid.UUID) (entity.Refund, error)
+	ReadRefundUsingBankReference(uuid.UUID) (entity.Refund, error)
+	UpdateRefund(entity.Refund) error
+	ListRefunds(uuid.UUID) ([]entity.Refund, error)
+
+	// CreateIdempotencyKey stores the key unless the merchant already has a
+	// live one with the same name, in which case that one is returned and
+	// the boolean is false
+	CreateIdempotencyKey(entity.IdempotencyKey) (entity.IdempotencyKey, bool, error)
+	ReadIdempotencyKey(uuid.UUID, string) (entity.IdempotencyKey, error)
+	UpdateIdempotencyKey(entity.IdempotencyKey) error
+	DeleteIdempotencyKey(uuid.UUID, string) error
+	// ExpireIdempotencyKeys forgets the keys that expired before the given
+	// time, and returns how many
+	ExpireIdempotencyKeys(time.Time) (int, error)
 }
@@ -0,0 +1,213 @@
+package vault
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// KeySize is the size in bytes of the master key, AES-256
+const KeySize = 32
+
+var (
+	// ErrInvalidKey must be used when the master key is not KeySize bytes long
+	ErrInvalidKey = fmt.Errorf("master key must have %d bytes", KeySize)
+	// ErrUnknownToken must be used when a token does not reference any card number
+	ErrUnknownToken = errors.New("token does not match any card number")
+	// ErrEmptyNumber must be used when tokenizing an empty card number
+	ErrEmptyNumber = errors.New("empty card number")
+)
+
+// record keeps one card number encrypted with its own data key, which is
+// itself encrypted with the master key, both bound to the token
+type record struct {
+	Token      string `json:"token"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Vault exchanges card numbers for tokens using envelope encryption, only the
+// master key can unwrap the data keys, and it is never written anywhere
+type Vault struct {
+	master  cipher.AEAD
+	records map[string]record
+	log     *os.File
+	sync.RWMutex
+}
+
+// NewMemoryVault is a factory for a Vault whose tokens are lost on restart
+func NewMemoryVault(masterKey []byte) (*Vault, error) {
+	master, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Vault{
+		master:  master,
+		records: make(map[string]record),
+	}, nil
+}
+
+// NewFileVault is a factory for a Vault that appends every record to the file
+// at path, and loads the records already there
+func NewFileVault(masterKey []byte, path string) (*Vault, error) {
+	v, err := NewMemoryVault(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// a partial last line can only come from a crash in the middle
+			// of a write, it is dropped so the next record starts clean
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("corrupted vault record at offset %d: %w", offset, err)
+		}
+		v.records[r.Token] = r
+		offset += int64(len(line))
+	}
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	v.log = f
+	return v, nil
+}
+
+// Close releases the file of the vault, if any
+func (v *Vault) Close() error {
+	v.Lock()
+	defer v.Unlock()
+
+	if v.log == nil {
+		return nil
+	}
+	err := v.log.Close()
+	v.log = nil
+	return err
+}
+
+// Tokenize stores number and returns the token that references it
+func (v *Vault) Tokenize(number string) (string, error) {
+	if number == "" {
+		return "", ErrEmptyNumber
+	}
+
+	token := "tok_" + uuid.NewString()
+
+	dataKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(data, []byte(number), token)
+	if err != nil {
+		return "", err
+	}
+	wrappedKey, err := seal(v.master, dataKey, token)
+	if err != nil {
+		return "", err
+	}
+	r := record{Token: token, WrappedKey: wrappedKey, Ciphertext: ciphertext}
+
+	v.Lock()
+	defer v.Unlock()
+
+	if v.log != nil {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return "", err
+		}
+		if _, err := v.log.Write(append(line, '\n')); err != nil {
+			return "", err
+		}
+		if err := v.log.Sync(); err != nil {
+			return "", err
+		}
+	}
+	v.records[token] = r
+
+	return token, nil
+}
+
+// Detokenize returns the card number referenced by token
+func (v *Vault) Detokenize(token string) (string, error) {
+	v.RLock()
+	r, ok := v.records[token]
+	v.RUnlock()
+	if !ok {
+		return "", ErrUnknownToken
+	}
+
+	dataKey, err := open(v.master, r.WrappedKey, token)
+	if err != nil {
+		return "", err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	number, err := open(data, r.Ciphertext, token)
+	if err != nil {
+		return "", err
+	}
+	return string(number), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, which is prepended to the
+// result, the token is authenticated so records cannot be swapped
+func seal(aead cipher.AEAD, plaintext []byte, token string) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(token)), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte, token string) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed data too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(token))
+}
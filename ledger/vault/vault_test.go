@@ -0,0 +1,131 @@
+package vault_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thiagolcmelo/payment-gateway/ledger/vault"
+)
+
+func newKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, vault.KeySize)
+}
+
+func TestVault_Tokenize(t *testing.T) {
+	v, err := vault.NewMemoryVault(newKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName    string
+		number      string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{testName: "card_number", number: "4111-1111-1111-1111", expectedErr: nil},
+		{testName: "same_number_again", number: "4111-1111-1111-1111", expectedErr: nil},
+		{testName: "empty_number", number: "", expectedErr: vault.ErrEmptyNumber},
+	}
+
+	tokens := make(map[string]bool)
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			token, err := v.Tokenize(tc.number)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if tokens[token] {
+				t.Errorf("token %s was issued twice", token)
+			}
+			tokens[token] = true
+
+			number, err := v.Detokenize(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if number != tc.number {
+				t.Errorf("expected %s, got %s", tc.number, number)
+			}
+		})
+	}
+
+	if _, err := v.Detokenize("tok_unknown"); !errors.Is(err, vault.ErrUnknownToken) {
+		t.Errorf("expected %v, got %v", vault.ErrUnknownToken, err)
+	}
+}
+
+func TestVault_InvalidKey(t *testing.T) {
+	if _, err := vault.NewMemoryVault(newKey(1)[:16]); !errors.Is(err, vault.ErrInvalidKey) {
+		t.Errorf("expected %v, got %v", vault.ErrInvalidKey, err)
+	}
+}
+
+func TestVault_FileVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.log")
+	number := "4111-1111-1111-1111"
+
+	v, err := vault.NewFileVault(newKey(1), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := v.Tokenize(number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(number)) || bytes.Contains(data, []byte("4111111111111111")) {
+		t.Fatal("card number written in clear")
+	}
+
+	// a crash in the middle of a write leaves a partial last line
+	if err := os.WriteFile(path, append(data, []byte(`{"token":"tok_`)...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err = vault.NewFileVault(newKey(1), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := v.Detokenize(token); err != nil || got != number {
+		t.Errorf("expected %s, got %s, %v", number, got, err)
+	}
+	other, err := v.Tokenize("5500-0000-0000-0004")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Close()
+
+	v, err = vault.NewFileVault(newKey(1), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+	if got, err := v.Detokenize(other); err != nil || got != "5500-0000-0000-0004" {
+		t.Errorf("expected the record after the torn line, got %s, %v", got, err)
+	}
+
+	// without the master key the records are useless
+	wrong, err := vault.NewFileVault(newKey(2), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wrong.Close()
+	if _, err := wrong.Detokenize(token); err == nil {
+		t.Error("expected an error with another master key")
+	}
+}
@@ -1,10 +1,6 @@
 import React, { useState } from 'react';
-import axios from 'axios';
 import './Dashboard.css'; // Import the CSS file for styling
-
-const paymentGatewayHost = process.env.REACT_APP_PAYMENT_GATEWAY_HOST;
-const paymentGatewayPort = process.env.REACT_APP_PAYMENT_GATEWAY_PORT;
-const apiBaseUrl = `http://${paymentGatewayHost}:${paymentGatewayPort}`;
+import { SessionExpired, authorizedRequest, logout } from './session';
 
 const Dashboard = ({ onLogout }) => {
   const [isLoggedIn, setIsLoggedIn] = useState(true); // Replace with your login state logic
@@ -34,7 +30,6 @@ const Dashboard = ({ onLogout }) => {
     const cur_shopper = hardCodedShoppers.find((s) => s.id === parseInt(shopper));
     const purchate_time = new Date().toISOString().slice(0, -1);
     
-    const url = `${apiBaseUrl}/payment`;
     const data = {
         "amount": parseFloat(amount),
         "currency": currency,
@@ -49,16 +44,7 @@ const Dashboard = ({ onLogout }) => {
         },
         "metadata": "shopper 0"
     };
-    const token = localStorage.getItem('jwt');
-    const config = {
-      headers: {
-        Authorization: `Bearer ${token}`,
-        'Content-Type': 'application/json',
-        Accept: '*/*',
-      },
-    };
-
-    axios.post(url, data, config).then((response) => {
+    authorizedRequest({ method: 'post', url: '/payment', data }).then((response) => {
         const id = response.data.id;
         const status = response.data.status;
         const bank_message = response.data.bank_message
@@ -82,23 +68,17 @@ const Dashboard = ({ onLogout }) => {
         setCurrency('USD');
         setAmount('');
     }).catch((error) => {
+      if (error instanceof SessionExpired) {
+        endSession();
+        return;
+      }
       console.log('Error fetching submission data:', error);
       setErrorMessage('Could not request payment');
     });
   };
 
   const handleCheckSubmission = (submission) => {
-    const token = localStorage.getItem('jwt');
-    const config = {
-      headers: {
-        Authorization: `Bearer ${token}`,
-        'Content-Type': 'application/json',
-        Accept: '*/*',
-      },
-    };
-
-    const url = `${apiBaseUrl}/payment/${submission.id}`;
-    axios.get(url, config)
+    authorizedRequest({ method: 'get', url: `/payment/${submission.id}` })
       .then((response) => {
         const status = response.data.status;
         const bank_message = response.data.bank_message;
@@ -115,16 +95,25 @@ const Dashboard = ({ onLogout }) => {
         setSubmissions(updatedSubmissions);
       })
       .catch((error) => {
+        if (error instanceof SessionExpired) {
+          endSession();
+          return;
+        }
         console.log('Error fetching submission data:', error);
       });
   };
 
-  const handleLogout = () => {
-    localStorage.setItem('jwt', '');
+  // endSession goes back to the login once the tokens are gone
+  const endSession = () => {
     setIsLoggedIn(false);
     onLogout();
   };
 
+  const handleLogout = async () => {
+    await logout();
+    endSession();
+  };
+
   const originalString = localStorage.getItem('username');
   const id = originalString.slice(-1);
   const firstLetter = originalString.charAt(0).toUpperCase();
@@ -1,6 +1,7 @@
 import React, { useState } from 'react';
 import axios from 'axios';
 import './Login.css';
+import { storeTokens } from './session';
 
 axios.defaults.headers.post['Access-Control-Allow-Origin'] = '*';
 
@@ -11,8 +12,19 @@ const apiBaseUrl = `http://${paymentGatewayHost}:${paymentGatewayPort}`;
 function Login({ onLogin }) {
   const [username, setUsername] = useState('');
   const [password, setPassword] = useState('');
+  // challenge is set once the password is right but TOTP is on, the login
+  // then waits for a code from the authenticator app
+  const [challenge, setChallenge] = useState('');
+  const [code, setCode] = useState('');
   const [errorMessage, setErrorMessage] = useState('');
 
+  const completeLogin = (data) => {
+    storeTokens(data);
+    localStorage.setItem('username', username);
+
+    onLogin();
+  };
+
   const handleSubmit = async (e) => {
     e.preventDefault();
     setErrorMessage('');
@@ -24,17 +36,84 @@ function Login({ onLogin }) {
           Accept: '*/*',
         },
       });
-      const jwt = response.data.token;
 
-      localStorage.setItem('jwt', jwt);
-      localStorage.setItem('username', username);
-
-      onLogin();
+      if (response.data.challenge) {
+        setChallenge(response.data.challenge);
+        setCode('');
+        return;
+      }
+      completeLogin(response.data);
     } catch (error) {
+      if (error.response && error.response.status === 429) {
+        setErrorMessage('Too many failed logins. Please try again later.');
+        return;
+      }
       setErrorMessage('Login failed. Please check your credentials.');
     }
   };
 
+  const handleVerify = async (e) => {
+    e.preventDefault();
+    setErrorMessage('');
+
+    try {
+      const response = await axios.post(`${apiBaseUrl}/login/verify`, { challenge, code }, {
+        headers: {
+          'Content-Type': 'application/json',
+          Accept: '*/*',
+        },
+      });
+      completeLogin(response.data);
+    } catch (error) {
+      const status = error.response && error.response.status;
+      const message = error.response && error.response.data && error.response.data.error;
+      if (status === 401 && message === 'invalid code') {
+        setErrorMessage('Invalid code. Please try again.');
+        setCode('');
+        return;
+      }
+      // an expired challenge, or too many codes, start over with the password
+      setChallenge('');
+      setPassword('');
+      if (status === 429) {
+        setErrorMessage('Too many failed logins. Please try again later.');
+      } else {
+        setErrorMessage('Login expired. Please log in again.');
+      }
+    }
+  };
+
+  const handleCancel = () => {
+    setChallenge('');
+    setCode('');
+    setPassword('');
+    setErrorMessage('');
+  };
+
+  if (challenge) {
+    return (
+      <div className="login-container">
+        <h2>Two-factor authentication</h2>
+        {errorMessage && <p className="error-message">{errorMessage}</p>}
+        <form onSubmit={handleVerify}>
+          <div>
+            <label>Code:</label>
+            <input
+              type="text"
+              value={code}
+              placeholder='code from your authenticator app, or a recovery code'
+              autoComplete='one-time-code'
+              autoFocus
+              onChange={(e) => setCode(e.target.value.trim())}
+            />
+          </div>
+          <button type="submit" disabled={!code}>Verify</button>
+          <button type="button" className="cancel-button" onClick={handleCancel}>Cancel</button>
+        </form>
+      </div>
+    );
+  }
+
   return (
     <div className="login-container">
       <h2>Login</h2>
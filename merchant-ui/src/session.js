@@ -0,0 +1,88 @@
+import axios from 'axios';
+
+const paymentGatewayHost = process.env.REACT_APP_PAYMENT_GATEWAY_HOST;
+const paymentGatewayPort = process.env.REACT_APP_PAYMENT_GATEWAY_PORT;
+const apiBaseUrl = `http://${paymentGatewayHost}:${paymentGatewayPort}`;
+
+// SessionExpired is thrown when the access token can not be refreshed, the
+// merchant has to log in again
+export class SessionExpired extends Error {}
+
+export const storeTokens = (data) => {
+  localStorage.setItem('jwt', data.token);
+  localStorage.setItem('refresh_token', data.refresh_token);
+};
+
+export const clearTokens = () => {
+  localStorage.setItem('jwt', '');
+  localStorage.setItem('refresh_token', '');
+};
+
+const authHeaders = () => ({
+  Authorization: `Bearer ${localStorage.getItem('jwt')}`,
+  'Content-Type': 'application/json',
+  Accept: '*/*',
+});
+
+// a refresh token can only be used once, requests failing together wait for
+// the same refresh
+let pendingRefresh = null;
+
+const refreshTokens = () => {
+  if (!pendingRefresh) {
+    const refreshToken = localStorage.getItem('refresh_token');
+    pendingRefresh = axios.post(`${apiBaseUrl}/token/refresh`, { refresh_token: refreshToken }, {
+      headers: {
+        'Content-Type': 'application/json',
+        Accept: '*/*',
+      },
+    }).then((response) => {
+      storeTokens(response.data);
+    }).catch(() => {
+      clearTokens();
+      throw new SessionExpired('session expired');
+    }).finally(() => {
+      pendingRefresh = null;
+    });
+  }
+  return pendingRefresh;
+};
+
+// authorizedRequest sends a request with the access token, when the token has
+// expired it is refreshed once and the request sent again. config may be a
+// function, called again for the second request, when it reads the tokens
+export const authorizedRequest = async (config) => {
+  const send = () => {
+    const c = typeof config === 'function' ? config() : config;
+    return axios({ ...c, url: `${apiBaseUrl}${c.url}`, headers: authHeaders() });
+  };
+
+  try {
+    return await send();
+  } catch (error) {
+    if (!error.response || error.response.status !== 401 || !localStorage.getItem('refresh_token')) {
+      throw error;
+    }
+  }
+  await refreshTokens();
+  return send();
+};
+
+// logout revokes the access token and the refresh token, the tokens are
+// forgotten even when the gateway can not be reached
+export const logout = async () => {
+  try {
+    await authorizedRequest(() => {
+      const refreshToken = localStorage.getItem('refresh_token');
+      return {
+        method: 'post',
+        url: '/logout',
+        data: refreshToken ? { refresh_token: refreshToken } : undefined,
+      };
+    });
+  } catch (error) {
+    console.log('Error logging out:', error);
+  } finally {
+    clearTokens();
+  }
+};
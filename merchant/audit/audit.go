@@ -0,0 +1,68 @@
+// Package audit keeps a record of every login attempt, one JSON object per
+// line, so they can be reviewed or shipped elsewhere.
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Outcome tells how a login attempt ended
+type Outcome string
+
+const (
+	// Success is a login with the right credentials, and code when needed, of
+	// an active merchant
+	Success Outcome = "success"
+	// Inactive is a login with the right credentials of an inactive merchant
+	Inactive Outcome = "inactive"
+	// InvalidCredentials is a login with an unknown username or a wrong password
+	InvalidCredentials Outcome = "invalid_credentials"
+	// SecondFactorRequired is a login with the right credentials of a
+	// merchant with TOTP enabled, which is waiting for a code
+	SecondFactorRequired Outcome = "second_factor_required"
+	// InvalidCode is a wrong TOTP or recovery code for a login
+	InvalidCode Outcome = "invalid_code"
+	// Locked is a login refused without checking the password, because of
+	// too many failures
+	Locked Outcome = "locked"
+)
+
+// LoginEvent is the record of a single login attempt
+type LoginEvent struct {
+	Time       time.Time `json:"time"`
+	Username   string    `json:"username"`
+	MerchantID string    `json:"merchant_id,omitempty"`
+	// UserID is set when the username is a user of the merchant
+	UserID   string  `json:"user_id,omitempty"`
+	SourceIP string  `json:"source_ip,omitempty"`
+	Outcome  Outcome `json:"outcome"`
+}
+
+// Log writes login events to a writer, it is safe for concurrent use
+type Log struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLog is a factory for a Log that writes to w
+func NewLog(w io.Writer) *Log {
+	return &Log{w: w}
+}
+
+// Record writes e as a single line
+func (l *Log) Record(e LoginEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err = l.w.Write(data)
+	return err
+}
@@ -0,0 +1,38 @@
+package audit_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/thiagolcmelo/payment-gateway/merchant/audit"
+)
+
+func TestLog_Record(t *testing.T) {
+	var buf bytes.Buffer
+	l := audit.NewLog(&buf)
+	now := time.Now().UTC()
+
+	events := []audit.LoginEvent{
+		{Time: now, Username: "merchant0", SourceIP: "10.0.0.1", Outcome: audit.InvalidCredentials},
+		{Time: now, Username: "merchant0", MerchantID: "e1211351-bb91-441f-9ea0-3b243189dec6", SourceIP: "10.0.0.1", Outcome: audit.Success},
+	}
+	for _, e := range events {
+		if err := l.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	for i := 0; scanner.Scan(); i++ {
+		var got audit.LoginEvent
+		if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+			t.Fatalf("line %d is not a record: %v", i, err)
+		}
+		if !got.Time.Equal(events[i].Time) || got.Username != events[i].Username || got.MerchantID != events[i].MerchantID || got.Outcome != events[i].Outcome {
+			t.Errorf("expected %+v, got %+v", events[i], got)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package challenge keeps the logins waiting for a second factor, between a
+// right password and a right code.
+package challenge
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUnknownChallenge must be used when a challenge was never issued, expired, was completed or ran out of attempts
+	ErrUnknownChallenge = errors.New("unknown challenge")
+)
+
+// MaxAttempts is how many codes a challenge takes before it is dropped and
+// the login has to start over with the password
+const MaxAttempts = 5
+
+// Challenge is a login whose password was right
+type Challenge struct {
+	MerchantID uuid.UUID
+	Username   string
+	ExpiresAt  time.Time
+	attempts   int
+}
+
+// Store keeps challenges in memory, by the SHA-256 of their token. It is
+// safe for concurrent use
+type Store struct {
+	ttl time.Duration
+
+	mu         sync.Mutex
+	challenges map[string]Challenge
+}
+
+// NewStore is a factory for a Store of challenges that expire after ttl
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		ttl:        ttl,
+		challenges: make(map[string]Challenge),
+	}
+}
+
+// TTL is how long a challenge lasts
+func (s *Store) TTL() time.Duration {
+	return s.ttl
+}
+
+// Create issues a challenge for the login of a merchant, and returns the
+// token the second step must present
+func (s *Store) Create(merchantID uuid.UUID, username string, now time.Time) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	s.challenges[hash(token)] = Challenge{
+		MerchantID: merchantID,
+		Username:   username,
+		ExpiresAt:  now.Add(s.ttl),
+	}
+	return token, nil
+}
+
+// Attempt returns the challenge of token and counts an attempt against it,
+// the last attempt drops it
+func (s *Store) Attempt(token string, now time.Time) (Challenge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := hash(token)
+	c, ok := s.challenges[h]
+	if !ok || !now.Before(c.ExpiresAt) {
+		delete(s.challenges, h)
+		return Challenge{}, ErrUnknownChallenge
+	}
+
+	c.attempts++
+	if c.attempts >= MaxAttempts {
+		delete(s.challenges, h)
+	} else {
+		s.challenges[h] = c
+	}
+	return c, nil
+}
+
+// Complete drops the challenge of token, once its code was right
+func (s *Store) Complete(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.challenges, hash(token))
+}
+
+// sweep drops expired challenges, it must be called with mu held
+func (s *Store) sweep(now time.Time) {
+	for h, c := range s.challenges {
+		if !now.Before(c.ExpiresAt) {
+			delete(s.challenges, h)
+		}
+	}
+}
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,85 @@
+package challenge_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/merchant/challenge"
+)
+
+func TestStore_Attempt(t *testing.T) {
+	store := challenge.NewStore(5 * time.Minute)
+	now := time.Now()
+	merchantID := uuid.New()
+
+	token, err := store.Create(merchantID, "username0", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < challenge.MaxAttempts; i++ {
+		c, err := store.Attempt(token, now)
+		if err != nil {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+		if c.MerchantID != merchantID || c.Username != "username0" {
+			t.Errorf("unexpected challenge %+v", c)
+		}
+	}
+	if _, err := store.Attempt(token, now); !errors.Is(err, challenge.ErrUnknownChallenge) {
+		t.Errorf("expected %v after %d attempts, got %v", challenge.ErrUnknownChallenge, challenge.MaxAttempts, err)
+	}
+}
+
+func TestStore_Expiry(t *testing.T) {
+	store := challenge.NewStore(5 * time.Minute)
+	now := time.Now()
+
+	type testCase struct {
+		testName    string
+		at          time.Time
+		complete    bool
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "in_time",
+			at:          now.Add(4 * time.Minute),
+			expectedErr: nil,
+		},
+		{
+			testName:    "expired",
+			at:          now.Add(5 * time.Minute),
+			expectedErr: challenge.ErrUnknownChallenge,
+		},
+		{
+			testName:    "completed",
+			at:          now,
+			complete:    true,
+			expectedErr: challenge.ErrUnknownChallenge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			token, err := store.Create(uuid.New(), "username0", now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.complete {
+				store.Complete(token)
+			}
+			_, err = store.Attempt(token, tc.at)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+
+	if _, err := store.Attempt("unknown", now); !errors.Is(err, challenge.ErrUnknownChallenge) {
+		t.Errorf("expected %v, got %v", challenge.ErrUnknownChallenge, err)
+	}
+}
@@ -0,0 +1,231 @@
+// pgadmin manages the merchants of the payment gateway through the
+// administration endpoints of the merchant service.
+//
+// Usage:
+//
+//	pgadmin [--addr host:port] [--token token] <command> [flags]
+//
+// The commands are list, create, update, deactivate, passwd and unlock, run
+// "pgadmin <command> --help" to see their flags.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thiagolcmelo/payment-gateway/merchant/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	addrFlag    = flag.String("addr", envOr("MERCHANT_SERVICE_ADDR", "localhost:50051"), "Address of the merchant service")
+	tokenFlag   = flag.String("token", os.Getenv("MERCHANT_ADMIN_TOKEN"), "Admin token of the merchant service")
+	timeoutFlag = flag.Duration("timeout", 10*time.Second, "How long to wait for the merchant service")
+)
+
+var commands = map[string]func(context.Context, pb.MerchantServiceClient, []string) error{
+	"list":       list,
+	"create":     create,
+	"update":     update,
+	"deactivate": deactivate,
+	"passwd":     passwd,
+	"unlock":     unlock,
+}
+
+func envOr(env, fallback string) string {
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] list|create|update|deactivate|passwd|unlock [command flags]\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	command, ok := commands[flag.Arg(0)]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	conn, err := grpc.Dial(*addrFlag, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not connect to %s: %v\n", *addrFlag, err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeoutFlag)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+*tokenFlag)
+
+	err = command(ctx, pb.NewMerchantServiceClient(conn), flag.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+func list(ctx context.Context, c pb.MerchantServiceClient, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.Parse(args)
+
+	res, err := c.ListMerchants(ctx, &pb.ListMerchantsRequest{})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tNAME\tACTIVE\tMAX QPS\tPLAN\tWEBHOOK")
+	for _, m := range res.Merchants {
+		plan := m.Plan
+		if plan == "" {
+			plan = "(default)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%d\t%s\t%s\n", m.Id, m.Username, m.Name, m.Active, m.MaxQps, plan, m.WebhookUrl)
+	}
+	return w.Flush()
+}
+
+func create(ctx context.Context, c pb.MerchantServiceClient, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	username := fs.String("username", "", "Username of the merchant, required")
+	name := fs.String("name", "", "Name of the merchant, required")
+	maxQPS := fs.Int("max-qps", 100, "Maximum queries per second of the merchant")
+	inactive := fs.Bool("inactive", false, "Create the merchant turned off")
+	plan := fs.String("plan", "", "Plan of the merchant, the default one when empty")
+	password := fs.String("password", "", "Password of the merchant, read from stdin when empty")
+	fs.Parse(args)
+
+	pw, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.CreateMerchant(ctx, &pb.CreateMerchantRequest{
+		Username: *username,
+		Password: pw,
+		Name:     *name,
+		Active:   !*inactive,
+		MaxQps:   int32(*maxQPS),
+		Plan:     *plan,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(res.Id)
+	return nil
+}
+
+func update(ctx context.Context, c pb.MerchantServiceClient, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	id := fs.String("id", "", "ID of the merchant, required")
+	name := fs.String("name", "", "New name of the merchant")
+	maxQPS := fs.Int("max-qps", -1, "New maximum queries per second of the merchant")
+	active := fs.String("active", "", "Turn the merchant on (true) or off (false)")
+	plan := fs.String("plan", "", "New plan of the merchant")
+	fs.Parse(args)
+
+	// the update replaces name and qps, keep whatever was not given
+	current, err := c.GetMerchant(ctx, &pb.GetMerchantRequest{Id: *id})
+	if err != nil {
+		return err
+	}
+
+	req := &pb.UpdateMerchantRequest{
+		Id:     *id,
+		Name:   current.Name,
+		MaxQps: current.MaxQps,
+	}
+	if *name != "" {
+		req.Name = *name
+	}
+	if *maxQPS >= 0 {
+		req.MaxQps = int32(*maxQPS)
+	}
+	if *plan != "" {
+		req.Plan = plan
+	}
+	switch *active {
+	case "":
+	case "true", "false":
+		v := *active == "true"
+		req.Active = &v
+	default:
+		return fmt.Errorf("--active must be true or false, got %q", *active)
+	}
+
+	_, err = c.UpdateMerchant(ctx, req)
+	return err
+}
+
+func deactivate(ctx context.Context, c pb.MerchantServiceClient, args []string) error {
+	fs := flag.NewFlagSet("deactivate", flag.ExitOnError)
+	id := fs.String("id", "", "ID of the merchant, required")
+	fs.Parse(args)
+
+	_, err := c.DeactivateMerchant(ctx, &pb.DeactivateMerchantRequest{Id: *id})
+	return err
+}
+
+func passwd(ctx context.Context, c pb.MerchantServiceClient, args []string) error {
+	fs := flag.NewFlagSet("passwd", flag.ExitOnError)
+	id := fs.String("id", "", "ID of the merchant, required")
+	password := fs.String("password", "", "New password of the merchant, read from stdin when empty")
+	fs.Parse(args)
+
+	pw, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.ChangePassword(ctx, &pb.ChangePasswordRequest{Id: *id, Password: pw})
+	return err
+}
+
+func unlock(ctx context.Context, c pb.MerchantServiceClient, args []string) error {
+	fs := flag.NewFlagSet("unlock", flag.ExitOnError)
+	username := fs.String("username", "", "Username whose failed logins are forgotten")
+	ip := fs.String("ip", "", "Source ip whose failed logins are forgotten")
+	fs.Parse(args)
+
+	_, err := c.UnlockLogin(ctx, &pb.UnlockLoginRequest{Username: *username, SourceIp: *ip})
+	return err
+}
+
+// readPassword takes the first line of stdin when no password was given
+// as a flag, so that it does not end up in the shell history
+func readPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("empty password")
+	}
+	return line, nil
+}
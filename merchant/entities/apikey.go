@@ -0,0 +1,118 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrMalformedAPIKey must be used when a string does not have the shape of an API key
+	ErrMalformedAPIKey = errors.New("malformed api key")
+	// ErrUnknownAPIKey must be used when an API key was never issued, or belongs to another merchant
+	ErrUnknownAPIKey = errors.New("unknown api key")
+	// ErrAPIKeyRevoked must be used when an API key was revoked
+	ErrAPIKeyRevoked = errors.New("api key revoked")
+	// ErrAPIKeyLabelTooLong must be used when the label of an API key has more than MaxAPIKeyLabelLength characters
+	ErrAPIKeyLabelTooLong = errors.New("api key label too long")
+)
+
+const (
+	// APIKeyPrefix starts every API key, so they are easy to tell apart from
+	// access tokens and to spot when leaked
+	APIKeyPrefix = "pk_live_"
+	// MaxAPIKeyLabelLength is the longest label an API key can have
+	MaxAPIKeyLabelLength = 64
+	// apiKeyLookupLength is the length of the random part of the prefix
+	apiKeyLookupLength = 12
+)
+
+// APIKey lets a merchant system call the API without a login. The key looks
+// like pk_live_<lookup>_<secret>, Prefix (pk_live_<lookup>) is public and is
+// how the key is found, while only the SHA-256 of the whole key is kept
+type APIKey struct {
+	ID         uuid.UUID `json:"id"`
+	MerchantID uuid.UUID `json:"merchant_id"`
+	Prefix     string    `json:"prefix"`
+	Hash       string    `json:"hash"`
+	Label      string    `json:"label"`
+	CreatedAt  time.Time `json:"created_at"`
+	// RevokedAt is zero while the key can be used
+	RevokedAt time.Time `json:"revoked_at,omitempty"`
+}
+
+// NewAPIKey is a factory for an APIKey of a merchant, it returns the key to
+// give to the merchant too, which can not be recovered afterwards
+func NewAPIKey(merchantID uuid.UUID, label string, now time.Time) (APIKey, string, error) {
+	if err := ValidateAPIKeyLabel(label); err != nil {
+		return APIKey{}, "", err
+	}
+
+	lookup := make([]byte, apiKeyLookupLength/2)
+	if _, err := rand.Read(lookup); err != nil {
+		return APIKey{}, "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return APIKey{}, "", err
+	}
+
+	prefix := APIKeyPrefix + hex.EncodeToString(lookup)
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	return APIKey{
+		ID:         uuid.New(),
+		MerchantID: merchantID,
+		Prefix:     prefix,
+		Hash:       HashAPIKey(key),
+		Label:      label,
+		CreatedAt:  now,
+	}, key, nil
+}
+
+// ValidateAPIKeyLabel asserts label is not longer than MaxAPIKeyLabelLength
+func ValidateAPIKeyLabel(label string) error {
+	if len([]rune(label)) > MaxAPIKeyLabelLength {
+		return ErrAPIKeyLabelTooLong
+	}
+	return nil
+}
+
+// APIKeyPrefixOf returns the prefix an API key is found by
+func APIKeyPrefixOf(key string) (string, error) {
+	n := len(APIKeyPrefix) + apiKeyLookupLength
+	if len(key) <= n+1 || !strings.HasPrefix(key, APIKeyPrefix) || key[n] != '_' {
+		return "", ErrMalformedAPIKey
+	}
+	return key[:n], nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of key, as kept in APIKey.Hash
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Check asserts key is the API key k was created for, and that it was not
+// revoked
+func (k APIKey) Check(key string) error {
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(k.Hash)) != 1 {
+		return ErrUnknownAPIKey
+	}
+	if k.Revoked() {
+		return ErrAPIKeyRevoked
+	}
+	return nil
+}
+
+// Revoked tells if the key can no longer be used
+func (k APIKey) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
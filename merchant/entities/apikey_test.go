@@ -0,0 +1,98 @@
+package entity_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
+)
+
+func TestAPIKey_NewAPIKey(t *testing.T) {
+	merchantID := uuid.New()
+	now := time.Now()
+
+	k, key, err := entity.NewAPIKey(merchantID, "checkout", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, k.Prefix+"_") || !strings.HasPrefix(k.Prefix, entity.APIKeyPrefix) {
+		t.Errorf("expected key %q to start with prefix %q", key, k.Prefix)
+	}
+	if k.Hash == key || k.Hash != entity.HashAPIKey(key) {
+		t.Error("expected only the hash of the key to be kept")
+	}
+	if k.MerchantID != merchantID || k.Label != "checkout" || !k.CreatedAt.Equal(now) {
+		t.Errorf("unexpected key %+v", k)
+	}
+
+	prefix, err := entity.APIKeyPrefixOf(key)
+	if err != nil || prefix != k.Prefix {
+		t.Errorf("expected prefix %q, got %q, %v", k.Prefix, prefix, err)
+	}
+
+	if _, _, err := entity.NewAPIKey(merchantID, strings.Repeat("a", entity.MaxAPIKeyLabelLength+1), now); !errors.Is(err, entity.ErrAPIKeyLabelTooLong) {
+		t.Errorf("expected %v, got %v", entity.ErrAPIKeyLabelTooLong, err)
+	}
+}
+
+func TestAPIKey_APIKeyPrefixOf(t *testing.T) {
+	type testCase struct {
+		testName    string
+		key         string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "valid",
+			key:         "pk_live_0123456789ab_secret",
+			expectedErr: nil,
+		},
+		{
+			testName:    "jwt",
+			key:         "eyJhbGciOiJFZERTQSJ9.e30.sig",
+			expectedErr: entity.ErrMalformedAPIKey,
+		},
+		{
+			testName:    "no_secret",
+			key:         "pk_live_0123456789ab_",
+			expectedErr: entity.ErrMalformedAPIKey,
+		},
+		{
+			testName:    "short_lookup",
+			key:         "pk_live_0123_secret",
+			expectedErr: entity.ErrMalformedAPIKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			_, err := entity.APIKeyPrefixOf(tc.key)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestAPIKey_Check(t *testing.T) {
+	k, key, err := entity.NewAPIKey(uuid.New(), "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := k.Check(key); err != nil {
+		t.Errorf("expected key to be accepted, got %v", err)
+	}
+	if err := k.Check(key + "x"); !errors.Is(err, entity.ErrUnknownAPIKey) {
+		t.Errorf("expected %v, got %v", entity.ErrUnknownAPIKey, err)
+	}
+
+	k.RevokedAt = time.Now()
+	if err := k.Check(key); !errors.Is(err, entity.ErrAPIKeyRevoked) {
+		t.Errorf("expected %v, got %v", entity.ErrAPIKeyRevoked, err)
+	}
+}
@@ -2,6 +2,8 @@ package entity
 
 import (
 	"errors"
+	"net/url"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -14,8 +16,15 @@ var (
 	ErrNameEmpty = errors.New("name empty")
 	// ErrMaxQPSCannotBeNegative must be used when MaxQPS is negative
 	ErrMaxQPSCannotBeNegative = errors.New("max qps cannot be negative")
+	// ErrInvalidWebhookURL must be used when a webhook url is not an absolute http or https url
+	ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http or https url")
+	// ErrWebhookSecretTooShort must be used when a webhook secret is too easy to guess
+	ErrWebhookSecretTooShort = errors.New("webhook secret must have at least 16 characters")
 )
 
+// MinWebhookSecretLength is the shortest secret accepted to sign webhooks
+const MinWebhookSecretLength = 16
+
 // Merchant represent a merchant for authentication and authorization purposes
 type Merchant struct {
 	ID       uuid.UUID `json:"id"`
@@ -24,6 +33,15 @@ type Merchant struct {
 	Name     string    `json:"name"`
 	Active   bool      `json:"active"`
 	MaxQPS   int       `json:"max_qps"`
+	// Plan names the plan of the merchant, the default one when empty
+	Plan string `json:"plan,omitempty"`
+	// WebhookURL receives events about payments of the merchant, signed
+	// with WebhookSecret, both are empty when no webhook is registered
+	WebhookURL    string `json:"webhook_url,omitempty"`
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+	// TokensValidAfter revokes every access token issued before it, it is
+	// moved forward when the merchant is deactivated
+	TokensValidAfter time.Time `json:"tokens_valid_after,omitempty"`
 }
 
 // Validate asserts Username and Password are not empty, and that MaxQPS is not negative
@@ -37,6 +55,45 @@ func (m Merchant) Validate() error {
 	if m.Name == "" {
 		return ErrNameEmpty
 	}
+	return validateWebhook(m.WebhookURL, m.WebhookSecret)
+}
+
+// SetWebhook registers where events about the merchant payments are sent,
+// empty url and secret remove the webhook
+func (m *Merchant) SetWebhook(webhookURL, secret string) error {
+	err := validateWebhook(webhookURL, secret)
+	if err != nil {
+		return err
+	}
+	m.WebhookURL = webhookURL
+	m.WebhookSecret = secret
+	return nil
+}
+
+func validateWebhook(webhookURL, secret string) error {
+	if webhookURL == "" && secret == "" {
+		return nil
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	if len(secret) < MinWebhookSecretLength {
+		return ErrWebhookSecretTooShort
+	}
+	return nil
+}
+
+// SetPassword replaces the password of the merchant with the hash of password
+func (m *Merchant) SetPassword(password string) error {
+	if password == "" {
+		return ErrUsernameOrPasswordEmpty
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	m.Password = string(hash)
 	return nil
 }
 
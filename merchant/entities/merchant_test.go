@@ -101,3 +101,44 @@ func TestMechant_NewMerchant(t *testing.T) {
 		})
 	}
 }
+
+func TestMechant_SetWebhook(t *testing.T) {
+	type testCase struct {
+		testName    string
+		url         string
+		secret      string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{testName: "valid_https", url: "https://merchant.example/webhooks", secret: "0123456789abcdef", expectedErr: nil},
+		{testName: "valid_http", url: "http://127.0.0.1:9000/", secret: "0123456789abcdef", expectedErr: nil},
+		{testName: "remove", url: "", secret: "", expectedErr: nil},
+		{testName: "relative_url", url: "/webhooks", secret: "0123456789abcdef", expectedErr: entity.ErrInvalidWebhookURL},
+		{testName: "other_scheme", url: "ftp://merchant.example/", secret: "0123456789abcdef", expectedErr: entity.ErrInvalidWebhookURL},
+		{testName: "missing_url", url: "", secret: "0123456789abcdef", expectedErr: entity.ErrInvalidWebhookURL},
+		{testName: "short_secret", url: "https://merchant.example/webhooks", secret: "0123456789", expectedErr: entity.ErrWebhookSecretTooShort},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			m := entity.Merchant{WebhookURL: "https://old.example/", WebhookSecret: "old-secret-0123456789"}
+
+			err := m.SetWebhook(tc.url, tc.secret)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if tc.expectedErr != nil {
+				if m.WebhookURL != "https://old.example/" {
+					t.Errorf("expected webhook to be kept, got %s", m.WebhookURL)
+				}
+				return
+			}
+
+			if m.WebhookURL != tc.url || m.WebhookSecret != tc.secret {
+				t.Errorf("expected webhook %s, got %s", tc.url, m.WebhookURL)
+			}
+		})
+	}
+}
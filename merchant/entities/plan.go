@@ -0,0 +1,164 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrPlanNameEmpty must be used when a plan has no name
+	ErrPlanNameEmpty = errors.New("plan name empty")
+	// ErrUnknownAlgorithm must be used when a plan names an algorithm the rate limiter does not have
+	ErrUnknownAlgorithm = errors.New("unknown rate limiting algorithm")
+	// ErrInvalidBurst must be used when the burst of a plan does not fit its algorithm
+	ErrInvalidBurst = errors.New("invalid burst")
+	// ErrUnknownPeriod must be used when a quota is not per one of Periods
+	ErrUnknownPeriod = errors.New("unknown quota period")
+	// ErrInvalidQuota must be used when a quota caps neither the count nor the amount, or caps them below 0
+	ErrInvalidQuota = errors.New("invalid quota")
+	// ErrInvalidQuotaCurrency must be used when a quota caps an amount without a currency, or names an invalid one
+	ErrInvalidQuotaCurrency = errors.New("invalid quota currency")
+	// ErrDuplicateQuota must be used when a plan has two quotas for the same period and currency
+	ErrDuplicateQuota = errors.New("duplicate quota")
+)
+
+// Algorithm is how the rate limiter spreads the MaxQPS of a merchant over time
+type Algorithm string
+
+const (
+	// AlgorithmTokenBucket refills MaxQPS tokens per second into a bucket of
+	// Burst tokens, each request takes one
+	AlgorithmTokenBucket Algorithm = "token_bucket"
+	// AlgorithmSlidingLog allows MaxQPS requests in any second, remembering
+	// the time of each one
+	AlgorithmSlidingLog Algorithm = "sliding_log"
+	// AlgorithmSlidingWindow approximates AlgorithmSlidingLog with the counts
+	// of the current and previous second
+	AlgorithmSlidingWindow Algorithm = "sliding_window"
+	// AlgorithmGCRA spaces requests 1/MaxQPS seconds apart, letting Burst of
+	// them arrive at once
+	AlgorithmGCRA Algorithm = "gcra"
+)
+
+// Algorithms lists every valid algorithm
+var Algorithms = []Algorithm{AlgorithmTokenBucket, AlgorithmSlidingLog, AlgorithmSlidingWindow, AlgorithmGCRA}
+
+// DefaultPlanName is the plan of merchants that do not name one
+const DefaultPlanName = "standard"
+
+// DefaultPlan is used when no plans are configured, it is the token bucket
+// with a burst of 10 the rate limiter always had
+var DefaultPlan = Plan{
+	Name:      DefaultPlanName,
+	Algorithm: AlgorithmTokenBucket,
+	Burst:     10,
+}
+
+// Plan is what a merchant bought, it tells the rate limiter how to enforce
+// the MaxQPS of the merchant
+type Plan struct {
+	Name      string    `json:"name"`
+	Algorithm Algorithm `json:"algorithm"`
+	// Burst is how many requests may arrive at once, only the token bucket
+	// and GCRA have one, sliding windows allow MaxQPS in any second
+	Burst int `json:"burst,omitempty"`
+	// Quotas cap the payments of the merchant per day or month, it has no
+	// cap without them
+	Quotas []Quota `json:"quotas,omitempty"`
+}
+
+// Period is how long a quota lasts before it starts over, days and months
+// start at midnight UTC
+type Period string
+
+const (
+	// PeriodDay starts over every midnight UTC
+	PeriodDay Period = "day"
+	// PeriodMonth starts over every first of the month at midnight UTC
+	PeriodMonth Period = "month"
+)
+
+// Periods lists every valid period
+var Periods = []Period{PeriodDay, PeriodMonth}
+
+// Quota caps the payments of a merchant in each Period. Payments in any
+// currency count when Currency is empty, which only works for MaxCount as
+// amounts of different currencies can not be added up
+type Quota struct {
+	Period   Period `json:"period"`
+	Currency string `json:"currency,omitempty"`
+	// MaxCount is how many payments fit in the period, 0 for no cap
+	MaxCount int64 `json:"max_count,omitempty"`
+	// MaxAmountMinor is the total amount, in minor units of Currency, the
+	// payments of the period may add up to, 0 for no cap
+	MaxAmountMinor int64 `json:"max_amount_minor,omitempty"`
+}
+
+// Validate asserts the quota has a known period, caps something, and has a
+// currency when it caps an amount
+func (q Quota) Validate() error {
+	if q.Period != PeriodDay && q.Period != PeriodMonth {
+		return ErrUnknownPeriod
+	}
+	if q.MaxCount < 0 || q.MaxAmountMinor < 0 || (q.MaxCount == 0 && q.MaxAmountMinor == 0) {
+		return ErrInvalidQuota
+	}
+	if q.MaxAmountMinor > 0 && q.Currency == "" {
+		return ErrInvalidQuotaCurrency
+	}
+	if q.Currency != "" && !isCurrencyCode(q.Currency) {
+		return ErrInvalidQuotaCurrency
+	}
+	return nil
+}
+
+// isCurrencyCode tells whether code looks like an ISO 4217 code, the API is
+// the one that knows which currencies it takes
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate asserts the plan has a name, a known algorithm, a burst when its
+// algorithm takes one, and valid quotas
+func (p Plan) Validate() error {
+	if p.Name == "" {
+		return ErrPlanNameEmpty
+	}
+	switch p.Algorithm {
+	case AlgorithmTokenBucket, AlgorithmGCRA:
+		if p.Burst < 1 {
+			return ErrInvalidBurst
+		}
+	case AlgorithmSlidingLog, AlgorithmSlidingWindow:
+		if p.Burst != 0 {
+			return ErrInvalidBurst
+		}
+	default:
+		return ErrUnknownAlgorithm
+	}
+
+	type key struct {
+		period   Period
+		currency string
+	}
+	seen := make(map[key]bool, len(p.Quotas))
+	for i, q := range p.Quotas {
+		if err := q.Validate(); err != nil {
+			return fmt.Errorf("quota %d: %w", i, err)
+		}
+		k := key{q.Period, q.Currency}
+		if seen[k] {
+			return fmt.Errorf("quota %d: %w", i, ErrDuplicateQuota)
+		}
+		seen[k] = true
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package entity_test
+
+import (
+	"errors"
+	"testing"
+
+	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
+)
+
+func TestPlan_Validate(t *testing.T) {
+	type testCase struct {
+		testName    string
+		plan        entity.Plan
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "default",
+			plan:        entity.DefaultPlan,
+			expectedErr: nil,
+		},
+		{
+			testName:    "sliding_window",
+			plan:        entity.Plan{Name: "volume", Algorithm: entity.AlgorithmSlidingWindow},
+			expectedErr: nil,
+		},
+		{
+			testName:    "empty_name",
+			plan:        entity.Plan{Algorithm: entity.AlgorithmGCRA, Burst: 1},
+			expectedErr: entity.ErrPlanNameEmpty,
+		},
+		{
+			testName:    "unknown_algorithm",
+			plan:        entity.Plan{Name: "leaky", Algorithm: "leaky_bucket", Burst: 1},
+			expectedErr: entity.ErrUnknownAlgorithm,
+		},
+		{
+			testName:    "gcra_without_burst",
+			plan:        entity.Plan{Name: "smooth", Algorithm: entity.AlgorithmGCRA},
+			expectedErr: entity.ErrInvalidBurst,
+		},
+		{
+			testName:    "sliding_log_with_burst",
+			plan:        entity.Plan{Name: "strict", Algorithm: entity.AlgorithmSlidingLog, Burst: 5},
+			expectedErr: entity.ErrInvalidBurst,
+		},
+		{
+			testName: "quotas",
+			plan: entity.Plan{Name: "volume", Algorithm: entity.AlgorithmSlidingWindow, Quotas: []entity.Quota{
+				{Period: entity.PeriodDay, MaxCount: 1000},
+				{Period: entity.PeriodDay, Currency: "USD", MaxAmountMinor: 1000000},
+				{Period: entity.PeriodMonth, Currency: "USD", MaxCount: 20000, MaxAmountMinor: 20000000},
+			}},
+			expectedErr: nil,
+		},
+		{
+			testName: "unknown_period",
+			plan: entity.Plan{Name: "volume", Algorithm: entity.AlgorithmSlidingWindow, Quotas: []entity.Quota{
+				{Period: "week", MaxCount: 1000},
+			}},
+			expectedErr: entity.ErrUnknownPeriod,
+		},
+		{
+			testName: "quota_without_cap",
+			plan: entity.Plan{Name: "volume", Algorithm: entity.AlgorithmSlidingWindow, Quotas: []entity.Quota{
+				{Period: entity.PeriodDay, Currency: "USD"},
+			}},
+			expectedErr: entity.ErrInvalidQuota,
+		},
+		{
+			testName: "negative_quota",
+			plan: entity.Plan{Name: "volume", Algorithm: entity.AlgorithmSlidingWindow, Quotas: []entity.Quota{
+				{Period: entity.PeriodDay, MaxCount: -1},
+			}},
+			expectedErr: entity.ErrInvalidQuota,
+		},
+		{
+			testName: "amount_without_currency",
+			plan: entity.Plan{Name: "volume", Algorithm: entity.AlgorithmSlidingWindow, Quotas: []entity.Quota{
+				{Period: entity.PeriodDay, MaxAmountMinor: 1000},
+			}},
+			expectedErr: entity.ErrInvalidQuotaCurrency,
+		},
+		{
+			testName: "invalid_currency",
+			plan: entity.Plan{Name: "volume", Algorithm: entity.AlgorithmSlidingWindow, Quotas: []entity.Quota{
+				{Period: entity.PeriodDay, Currency: "usd", MaxAmountMinor: 1000},
+			}},
+			expectedErr: entity.ErrInvalidQuotaCurrency,
+		},
+		{
+			testName: "duplicate_quota",
+			plan: entity.Plan{Name: "volume", Algorithm: entity.AlgorithmSlidingWindow, Quotas: []entity.Quota{
+				{Period: entity.PeriodMonth, Currency: "EUR", MaxCount: 10},
+				{Period: entity.PeriodMonth, Currency: "EUR", MaxAmountMinor: 1000},
+			}},
+			expectedErr: entity.ErrDuplicateQuota,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := tc.plan.Validate()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUnknownRefreshToken must be used when a refresh token was never issued
+	ErrUnknownRefreshToken = errors.New("unknown refresh token")
+	// ErrRefreshTokenExpired must be used when a refresh token is past its expiry
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	// ErrRefreshTokenRevoked must be used when a refresh token was revoked
+	ErrRefreshTokenRevoked = errors.New("refresh token revoked")
+	// ErrRefreshTokenReused must be used when a refresh token that was already exchanged is presented again
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	// ErrMerchantInactive must be used when a merchant that is not active asks for tokens
+	ErrMerchantInactive = errors.New("merchant is not active")
+)
+
+// RefreshToken lets a merchant get new access tokens without its password.
+// Each one is exchanged only once, for a new one of the same Family, so a
+// stolen token that is used twice gives itself away
+type RefreshToken struct {
+	// Hash is the SHA-256 of the token, the token itself is never kept
+	Hash       string    `json:"hash"`
+	MerchantID uuid.UUID `json:"merchant_id"`
+	// UserID is the user of the merchant that logged in, nil for the login
+	// of the merchant itself
+	UserID    uuid.UUID `json:"user_id,omitempty"`
+	Family    uuid.UUID `json:"family"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Used      bool      `json:"used"`
+	Revoked   bool      `json:"revoked"`
+}
+
+// NewRefreshToken is a factory for a RefreshToken of family, it returns the
+// token to give to the merchant too
+func NewRefreshToken(merchantID, family uuid.UUID, expiresAt time.Time) (RefreshToken, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return RefreshToken{}, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return RefreshToken{
+		Hash:       HashRefreshToken(token),
+		MerchantID: merchantID,
+		Family:     family,
+		ExpiresAt:  expiresAt,
+	}, token, nil
+}
+
+// HashRefreshToken returns the hash a token is kept under
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Check asserts the token can still be exchanged at now
+func (t RefreshToken) Check(now time.Time) error {
+	switch {
+	case t.Revoked:
+		return ErrRefreshTokenRevoked
+	case t.Used:
+		return ErrRefreshTokenReused
+	case !now.Before(t.ExpiresAt):
+		return ErrRefreshTokenExpired
+	}
+	return nil
+}
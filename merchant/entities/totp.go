@@ -0,0 +1,174 @@
+package entity
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrTOTPNotEnrolled must be used when a merchant has no TOTP secret, or has not confirmed it
+	ErrTOTPNotEnrolled = errors.New("totp not enrolled")
+	// ErrTOTPAlreadyEnabled must be used when enrolling a merchant that already has TOTP enabled
+	ErrTOTPAlreadyEnabled = errors.New("totp already enabled")
+	// ErrInvalidTOTPCode must be used when a code is neither the current TOTP code nor an unused recovery code
+	ErrInvalidTOTPCode = errors.New("invalid totp code")
+)
+
+const (
+	// TOTPPeriod is how long each code lasts
+	TOTPPeriod = 30 * time.Second
+	// TOTPDigits is the length of each code
+	TOTPDigits = 6
+	// RecoveryCodes is how many recovery codes a merchant gets
+	RecoveryCodes = 10
+	// totpSkew is how many periods before and after now are accepted, for
+	// clocks that drift
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTP is the second factor of a merchant, as in RFC 6238 with HMAC-SHA1,
+// 6 digits and 30 second periods, which is what authenticator apps expect.
+// It only takes part in logins once Enabled, which happens when a first code
+// is confirmed
+type TOTP struct {
+	MerchantID uuid.UUID `json:"merchant_id"`
+	// Secret is base32 encoded, without padding
+	Secret  string `json:"secret"`
+	Enabled bool   `json:"enabled"`
+	// LastStep is the period of the last code accepted, codes of that
+	// period or older are refused so a code can not be used twice
+	LastStep int64 `json:"last_step"`
+	// RecoveryCodes are the SHA-256 of the recovery codes not used yet
+	RecoveryCodes []string  `json:"recovery_codes,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// NewTOTP is a factory for a TOTP of a merchant with a new random secret,
+// not enabled yet
+func NewTOTP(merchantID uuid.UUID, now time.Time) (TOTP, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return TOTP{}, err
+	}
+	return TOTP{
+		MerchantID: merchantID,
+		Secret:     totpEncoding.EncodeToString(secret),
+		CreatedAt:  now,
+	}, nil
+}
+
+// URL returns the otpauth:// url authenticator apps enrol from, usually as
+// a QR code
+func (t TOTP) URL(issuer, account string) string {
+	v := url.Values{}
+	v.Set("secret", t.Secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(TOTPDigits))
+	v.Set("period", fmt.Sprint(int(TOTPPeriod/time.Second)))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// TOTPCode returns the code of the base32 secret for the period step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", TOTPDigits, value%1000000), nil
+}
+
+// TOTPStep returns the period now falls in
+func TOTPStep(now time.Time) int64 {
+	return now.Unix() / int64(TOTPPeriod/time.Second)
+}
+
+// Verify accepts code if it is the TOTP code of a period around now newer
+// than LastStep, or one of the RecoveryCodes, which is then removed. It
+// updates t, so the same code is not accepted twice
+func (t *TOTP) Verify(code string, now time.Time) error {
+	code = strings.TrimSpace(code)
+	if len(code) == TOTPDigits {
+		return t.verifyCode(code, now)
+	}
+	return t.useRecoveryCode(code)
+}
+
+func (t *TOTP) verifyCode(code string, now time.Time) error {
+	current := TOTPStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= t.LastStep {
+			continue
+		}
+		expected, err := TOTPCode(t.Secret, step)
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(code), []byte(expected)) == 1 {
+			t.LastStep = step
+			return nil
+		}
+	}
+	return ErrInvalidTOTPCode
+}
+
+func (t *TOTP) useRecoveryCode(code string) error {
+	hash := HashRecoveryCode(code)
+	for i, h := range t.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(h)) == 1 {
+			t.RecoveryCodes = append(t.RecoveryCodes[:i:i], t.RecoveryCodes[i+1:]...)
+			return nil
+		}
+	}
+	return ErrInvalidTOTPCode
+}
+
+// NewRecoveryCodes returns RecoveryCodes random codes to give to the
+// merchant, and their hashes to keep in TOTP.RecoveryCodes
+func NewRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RecoveryCodes)
+	hashes := make([]string, RecoveryCodes)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		c := strings.ToLower(totpEncoding.EncodeToString(b))
+		codes[i] = c[:8] + "-" + c[8:]
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode returns the hash a recovery code is kept under, case and
+// dashes do not matter
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
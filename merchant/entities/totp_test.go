@@ -0,0 +1,105 @@
+package entity_test
+
+import (
+	"encoding/base32"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
+)
+
+func TestTOTP_TOTPCode(t *testing.T) {
+	// the SHA1 vectors of RFC 6238, appendix B, cut to 6 digits
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	type testCase struct {
+		testName string
+		unix     int64
+		expected string
+	}
+
+	testCases := []testCase{
+		{testName: "59", unix: 59, expected: "287082"},
+		{testName: "1111111109", unix: 1111111109, expected: "081804"},
+		{testName: "1111111111", unix: 1111111111, expected: "050471"},
+		{testName: "1234567890", unix: 1234567890, expected: "005924"},
+		{testName: "2000000000", unix: 2000000000, expected: "279037"},
+		{testName: "20000000000", unix: 20000000000, expected: "353130"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			code, err := entity.TOTPCode(secret, entity.TOTPStep(time.Unix(tc.unix, 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, code)
+			}
+		})
+	}
+}
+
+func TestTOTP_Verify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	totp, err := entity.NewTOTP(uuid.New(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codeAt := func(at time.Time) string {
+		code, err := entity.TOTPCode(totp.Secret, entity.TOTPStep(at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	if err := totp.Verify(codeAt(now.Add(-5*entity.TOTPPeriod)), now); !errors.Is(err, entity.ErrInvalidTOTPCode) {
+		t.Errorf("expected an old code to be refused, got %v", err)
+	}
+	if err := totp.Verify(codeAt(now.Add(-entity.TOTPPeriod)), now); err != nil {
+		t.Errorf("expected the previous code to be accepted, got %v", err)
+	}
+	if err := totp.Verify(codeAt(now), now); err != nil {
+		t.Errorf("expected the current code to be accepted, got %v", err)
+	}
+	if err := totp.Verify(codeAt(now), now); !errors.Is(err, entity.ErrInvalidTOTPCode) {
+		t.Errorf("expected a code to be refused the second time, got %v", err)
+	}
+	if err := totp.Verify(codeAt(now.Add(-entity.TOTPPeriod)), now); !errors.Is(err, entity.ErrInvalidTOTPCode) {
+		t.Errorf("expected a code older than the last one to be refused, got %v", err)
+	}
+
+	codes, hashes, err := entity.NewRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != entity.RecoveryCodes || len(hashes) != entity.RecoveryCodes {
+		t.Fatalf("expected %d recovery codes, got %d", entity.RecoveryCodes, len(codes))
+	}
+	totp.RecoveryCodes = hashes
+
+	if err := totp.Verify(strings.ToUpper(codes[3]), now); err != nil {
+		t.Errorf("expected a recovery code to be accepted, got %v", err)
+	}
+	if err := totp.Verify(codes[3], now); !errors.Is(err, entity.ErrInvalidTOTPCode) {
+		t.Errorf("expected a recovery code to be refused the second time, got %v", err)
+	}
+	if len(totp.RecoveryCodes) != entity.RecoveryCodes-1 {
+		t.Errorf("expected %d recovery codes left, got %d", entity.RecoveryCodes-1, len(totp.RecoveryCodes))
+	}
+	if err := totp.Verify(strings.ReplaceAll(codes[0], "-", ""), now); err != nil {
+		t.Errorf("expected a recovery code without dash to be accepted, got %v", err)
+	}
+}
+
+func TestTOTP_URL(t *testing.T) {
+	totp := entity.TOTP{Secret: "JBSWY3DPEHPK3PXP"}
+	u := totp.URL("Payment Gateway", "merchant0")
+	if !strings.HasPrefix(u, "otpauth://totp/Payment%20Gateway:merchant0?") || !strings.Contains(u, "secret=JBSWY3DPEHPK3PXP") {
+		t.Errorf("unexpected url %s", u)
+	}
+}
@@ -0,0 +1,147 @@
+package entity
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrUnknownUser must be used when a merchant has no user with a given id
+	ErrUnknownUser = errors.New("unknown user")
+	// ErrUserInactive must be used when a user that is not active asks for tokens
+	ErrUserInactive = errors.New("user is not active")
+	// ErrInvalidRole must be used when a role is not one of Roles
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrNoRoles must be used when a user would have no role at all
+	ErrNoRoles = errors.New("user must have at least one role")
+)
+
+// Role allows a user of a merchant to do part of what the merchant can
+type Role string
+
+const (
+	// RoleAdmin can do everything, including managing users, api keys and
+	// the webhook
+	RoleAdmin Role = "admin"
+	// RolePaymentsWrite creates, captures and voids payments
+	RolePaymentsWrite Role = "payments:write"
+	// RolePaymentsRead reads and lists payments
+	RolePaymentsRead Role = "payments:read"
+	// RoleRefunds refunds payments
+	RoleRefunds Role = "refunds"
+)
+
+// Roles lists every valid role
+var Roles = []Role{RoleAdmin, RolePaymentsWrite, RolePaymentsRead, RoleRefunds}
+
+// OwnerRoles are the roles of the login of the merchant itself
+var OwnerRoles = []Role{RoleAdmin}
+
+// APIKeyRoles are the roles of requests made with an api key, everything
+// but administration
+var APIKeyRoles = []Role{RolePaymentsWrite, RolePaymentsRead, RoleRefunds}
+
+// User is one more login of a merchant, e.g. for a member of its staff or
+// one of its systems, limited to what its Roles allow
+type User struct {
+	ID         uuid.UUID `json:"id"`
+	MerchantID uuid.UUID `json:"merchant_id"`
+	Username   string    `json:"username"`
+	Password   string    `json:"password"`
+	Roles      []Role    `json:"roles"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	// TokensValidAfter revokes every access token of the user issued before
+	// it, it is moved forward when the user is turned off or its password
+	// changes
+	TokensValidAfter time.Time `json:"tokens_valid_after,omitempty"`
+}
+
+// NewUser is a factory for an active User of a merchant
+func NewUser(merchantID uuid.UUID, username, password string, roles []Role, now time.Time) (User, error) {
+	u := User{
+		ID:         uuid.New(),
+		MerchantID: merchantID,
+		Username:   username,
+		Active:     true,
+		CreatedAt:  now,
+	}
+	if username == "" {
+		return User{}, ErrUsernameOrPasswordEmpty
+	}
+	if err := u.SetPassword(password); err != nil {
+		return User{}, err
+	}
+	if err := u.SetRoles(roles); err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+// Validate asserts Username and Password are not empty, and Roles are valid
+func (u User) Validate() error {
+	if u.Username == "" || u.Password == "" {
+		return ErrUsernameOrPasswordEmpty
+	}
+	_, err := NormalizeRoles(u.Roles)
+	return err
+}
+
+// SetPassword replaces the password of the user with the hash of password
+func (u *User) SetPassword(password string) error {
+	if password == "" {
+		return ErrUsernameOrPasswordEmpty
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hash)
+	return nil
+}
+
+// SetRoles replaces the roles of the user
+func (u *User) SetRoles(roles []Role) error {
+	roles, err := NormalizeRoles(roles)
+	if err != nil {
+		return err
+	}
+	u.Roles = roles
+	return nil
+}
+
+// NormalizeRoles returns roles sorted and without repetitions, if they are
+// all valid and there is at least one
+func NormalizeRoles(roles []Role) ([]Role, error) {
+	if len(roles) == 0 {
+		return nil, ErrNoRoles
+	}
+
+	seen := make(map[Role]bool, len(roles))
+	res := make([]Role, 0, len(roles))
+	for _, r := range roles {
+		if !r.Valid() {
+			return nil, ErrInvalidRole
+		}
+		if !seen[r] {
+			seen[r] = true
+			res = append(res, r)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res, nil
+}
+
+// Valid tells if r is one of Roles
+func (r Role) Valid() bool {
+	for _, known := range Roles {
+		if r == known {
+			return true
+		}
+	}
+	return false
+}
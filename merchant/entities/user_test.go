@@ -0,0 +1,84 @@
+package entity_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUser_NewUser(t *testing.T) {
+	type testCase struct {
+		testName      string
+		username      string
+		password      string
+		roles         []entity.Role
+		expectedRoles []entity.Role
+		expectedErr   error
+	}
+
+	testCases := []testCase{
+		{
+			testName:      "valid",
+			username:      "finance",
+			password:      "password0",
+			roles:         []entity.Role{entity.RoleRefunds, entity.RolePaymentsRead, entity.RoleRefunds},
+			expectedRoles: []entity.Role{entity.RolePaymentsRead, entity.RoleRefunds},
+			expectedErr:   nil,
+		},
+		{
+			testName:    "empty_username",
+			username:    "",
+			password:    "password0",
+			roles:       []entity.Role{entity.RoleAdmin},
+			expectedErr: entity.ErrUsernameOrPasswordEmpty,
+		},
+		{
+			testName:    "empty_password",
+			username:    "finance",
+			password:    "",
+			roles:       []entity.Role{entity.RoleAdmin},
+			expectedErr: entity.ErrUsernameOrPasswordEmpty,
+		},
+		{
+			testName:    "no_roles",
+			username:    "finance",
+			password:    "password0",
+			roles:       nil,
+			expectedErr: entity.ErrNoRoles,
+		},
+		{
+			testName:    "unknown_role",
+			username:    "finance",
+			password:    "password0",
+			roles:       []entity.Role{entity.RolePaymentsRead, "payments:delete"},
+			expectedErr: entity.ErrInvalidRole,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			merchantID := uuid.New()
+			u, err := entity.NewUser(merchantID, tc.username, tc.password, tc.roles, time.Now())
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if u.MerchantID != merchantID || !u.Active || u.ID == uuid.Nil {
+				t.Errorf("unexpected user %+v", u)
+			}
+			if !reflect.DeepEqual(u.Roles, tc.expectedRoles) {
+				t.Errorf("expected roles %v, got %v", tc.expectedRoles, u.Roles)
+			}
+			if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(tc.password)) != nil {
+				t.Error("expected the password to be hashed")
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Package lockout keeps track of failed logins, by username and by source
+// address, and locks them out for exponentially longer after too many.
+package lockout
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidPolicy must be used when a Policy would never lock anything out, or would do it forever
+	ErrInvalidPolicy = errors.New("invalid lockout policy")
+)
+
+// forgetAfter is how long failures are remembered without a new one
+const forgetAfter = 24 * time.Hour
+
+// Policy tells when and for how long logins are locked out. Once a username
+// has MaxFailures failed logins in a row, every new failure locks it for
+// BaseLockout, doubled for each failure past MaxFailures, up to MaxLockout.
+// The same goes for addresses with MaxFailuresPerAddress, which is higher
+// since many users can share an address.
+type Policy struct {
+	MaxFailures           int
+	MaxFailuresPerAddress int
+	BaseLockout           time.Duration
+	MaxLockout            time.Duration
+}
+
+// DefaultPolicy locks a username out after 5 failures and an address after
+// 20, starting at 30 seconds and up to an hour
+var DefaultPolicy = Policy{
+	MaxFailures:           5,
+	MaxFailuresPerAddress: 20,
+	BaseLockout:           30 * time.Second,
+	MaxLockout:            time.Hour,
+}
+
+// Validate asserts failures are positive and lockouts are positive, with
+// BaseLockout not above MaxLockout
+func (p Policy) Validate() error {
+	if p.MaxFailures <= 0 || p.MaxFailuresPerAddress <= 0 {
+		return ErrInvalidPolicy
+	}
+	if p.BaseLockout <= 0 || p.MaxLockout < p.BaseLockout {
+		return ErrInvalidPolicy
+	}
+	return nil
+}
+
+type attempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// Tracker counts failed logins, it is safe for concurrent use
+type Tracker struct {
+	policy Policy
+
+	mu        sync.Mutex
+	usernames map[string]attempts
+	addresses map[string]attempts
+	lastSweep time.Time
+}
+
+// NewTracker is a factory for a Tracker that locks logins out as policy says
+func NewTracker(policy Policy) *Tracker {
+	return &Tracker{
+		policy:    policy,
+		usernames: make(map[string]attempts),
+		addresses: make(map[string]attempts),
+	}
+}
+
+// Locked returns how long logins for username, or from address, must wait,
+// zero when they are allowed. It must be called before the password is
+// checked, so a locked out login costs nothing
+func (t *Tracker) Locked(username, address string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	wait := t.usernames[username].lockedUntil.Sub(now)
+	if address != "" {
+		if w := t.addresses[address].lockedUntil.Sub(now); w > wait {
+			wait = w
+		}
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// Fail records a failed login for username from address
+func (t *Tracker) Fail(username, address string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.usernames[username] = t.fail(t.usernames[username], t.policy.MaxFailures, now)
+	if address != "" {
+		t.addresses[address] = t.fail(t.addresses[address], t.policy.MaxFailuresPerAddress, now)
+	}
+	t.sweep(now)
+}
+
+func (t *Tracker) fail(a attempts, maxFailures int, now time.Time) attempts {
+	if now.Sub(a.lastFailure) > forgetAfter {
+		a = attempts{}
+	}
+	a.failures++
+	a.lastFailure = now
+
+	if over := a.failures - maxFailures; over >= 0 {
+		lockout := t.policy.MaxLockout
+		// past 2^30 the shift overflows, and the cap is hit long before
+		if over < 30 {
+			if d := t.policy.BaseLockout << over; d > 0 && d < lockout {
+				lockout = d
+			}
+		}
+		a.lockedUntil = now.Add(lockout)
+	}
+	return a
+}
+
+// Succeed forgets the failures of username after a successful login. The
+// failures of the address are kept, or anyone with one valid account could
+// keep guessing the passwords of the others
+func (t *Tracker) Succeed(username string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.usernames, username)
+}
+
+// Unlock forgets the failures of username and of address, either can be
+// empty
+func (t *Tracker) Unlock(username, address string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.usernames, username)
+	delete(t.addresses, address)
+}
+
+// sweep drops what is no longer remembered, at most once per hour, so
+// guessing random usernames does not grow the maps forever. It must be
+// called with mu held
+func (t *Tracker) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < time.Hour {
+		return
+	}
+	t.lastSweep = now
+	for username, a := range t.usernames {
+		if now.Sub(a.lastFailure) > forgetAfter && !now.Before(a.lockedUntil) {
+			delete(t.usernames, username)
+		}
+	}
+	for address, a := range t.addresses {
+		if now.Sub(a.lastFailure) > forgetAfter && !now.Before(a.lockedUntil) {
+			delete(t.addresses, address)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package lockout_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thiagolcmelo/payment-gateway/merchant/lockout"
+)
+
+var policy = lockout.Policy{
+	MaxFailures:           3,
+	MaxFailuresPerAddress: 5,
+	BaseLockout:           time.Minute,
+	MaxLockout:            5 * time.Minute,
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	type testCase struct {
+		testName    string
+		policy      lockout.Policy
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "default",
+			policy:      lockout.DefaultPolicy,
+			expectedErr: nil,
+		},
+		{
+			testName:    "no_failures",
+			policy:      lockout.Policy{MaxFailures: 0, MaxFailuresPerAddress: 5, BaseLockout: time.Minute, MaxLockout: time.Hour},
+			expectedErr: lockout.ErrInvalidPolicy,
+		},
+		{
+			testName:    "no_lockout",
+			policy:      lockout.Policy{MaxFailures: 3, MaxFailuresPerAddress: 5, BaseLockout: 0, MaxLockout: time.Hour},
+			expectedErr: lockout.ErrInvalidPolicy,
+		},
+		{
+			testName:    "base_above_max",
+			policy:      lockout.Policy{MaxFailures: 3, MaxFailuresPerAddress: 5, BaseLockout: time.Hour, MaxLockout: time.Minute},
+			expectedErr: lockout.ErrInvalidPolicy,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := tc.policy.Validate()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestTracker_Username(t *testing.T) {
+	tr := lockout.NewTracker(policy)
+	now := time.Now()
+
+	for i := 0; i < policy.MaxFailures-1; i++ {
+		tr.Fail("merchant0", "", now)
+	}
+	if wait := tr.Locked("merchant0", "", now); wait != 0 {
+		t.Fatalf("expected no lockout before %d failures, got %v", policy.MaxFailures, wait)
+	}
+
+	// every further failure doubles the lockout, up to the maximum
+	expected := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for _, e := range expected {
+		tr.Fail("merchant0", "", now)
+		if wait := tr.Locked("merchant0", "", now); wait != e {
+			t.Errorf("expected lockout of %v, got %v", e, wait)
+		}
+	}
+
+	if wait := tr.Locked("merchant1", "", now); wait != 0 {
+		t.Errorf("expected other usernames not to be locked, got %v", wait)
+	}
+	if wait := tr.Locked("merchant0", "", now.Add(policy.MaxLockout)); wait != 0 {
+		t.Errorf("expected lockout to end, got %v", wait)
+	}
+
+	tr.Succeed("merchant0")
+	tr.Fail("merchant0", "", now)
+	if wait := tr.Locked("merchant0", "", now); wait != 0 {
+		t.Errorf("expected a success to forget failures, got %v", wait)
+	}
+
+	// failures a day apart are not in a row
+	tr.Succeed("merchant0")
+	for i := 0; i < policy.MaxFailures; i++ {
+		now = now.Add(25 * time.Hour)
+		tr.Fail("merchant0", "", now)
+	}
+	if wait := tr.Locked("merchant0", "", now); wait != 0 {
+		t.Errorf("expected old failures to be forgotten, got %v", wait)
+	}
+}
+
+func TestTracker_Address(t *testing.T) {
+	tr := lockout.NewTracker(policy)
+	now := time.Now()
+
+	// guessing a different username each time still locks the address
+	for i := 0; i < policy.MaxFailuresPerAddress; i++ {
+		tr.Fail(string(rune('a'+i)), "10.0.0.1", now)
+	}
+	if wait := tr.Locked("z", "10.0.0.1", now); wait != policy.BaseLockout {
+		t.Errorf("expected address lockout of %v, got %v", policy.BaseLockout, wait)
+	}
+	if wait := tr.Locked("z", "10.0.0.2", now); wait != 0 {
+		t.Errorf("expected other addresses not to be locked, got %v", wait)
+	}
+
+	// a success does not unlock the address, an admin does
+	tr.Succeed("z")
+	if wait := tr.Locked("z", "10.0.0.1", now); wait == 0 {
+		t.Error("expected address to stay locked after a success")
+	}
+	tr.Unlock("", "10.0.0.1")
+	if wait := tr.Locked("z", "10.0.0.1", now); wait != 0 {
+		t.Errorf("expected address to be unlocked, got %v", wait)
+	}
+}
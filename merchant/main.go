@@ -2,78 +2,73 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/subtle"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"net"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/merchant/audit"
+	"github.com/thiagolcmelo/payment-gateway/merchant/challenge"
 	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
+	"github.com/thiagolcmelo/payment-gateway/merchant/lockout"
 	"github.com/thiagolcmelo/payment-gateway/merchant/pb"
+	"github.com/thiagolcmelo/payment-gateway/merchant/plans"
+	"github.com/thiagolcmelo/payment-gateway/merchant/seed"
 	"github.com/thiagolcmelo/payment-gateway/merchant/storage"
+	"github.com/thiagolcmelo/payment-gateway/merchant/storage/file"
 	"github.com/thiagolcmelo/payment-gateway/merchant/storage/memory"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 var (
-	portFlag      = flag.Int("port", 50051, "The server port")
-	hostFlag      = flag.String("host", "0.0.0.0", "The server host")
-	ipVersionFlag = flag.Int("ip-version", 4, "The server ip version (4 for IPv4, 6 for IPv6)")
+	portFlag          = flag.Int("port", 50051, "The server port")
+	hostFlag          = flag.String("host", "0.0.0.0", "The server host")
+	ipVersionFlag     = flag.Int("ip-version", 4, "The server ip version (4 for IPv4, 6 for IPv6)")
+	adminTokenFlag    = flag.String("admin-token", "", "Token required by the administration endpoints, they are disabled without it")
+	storageFlag       = flag.String("storage", "memory", "Where merchants are kept (memory or file)")
+	dataDirFlag       = flag.String("data-dir", "data", "Directory for merchants when using file storage")
+	seedFileFlag      = flag.String("seed-file", "data/merchants.json", "JSON file with merchants to add or update on start, empty for none")
+	seedWatchFlag     = flag.String("seed-watch-interval", "0s", "How often the seed file is checked for changes, 0 to only read it on start")
+	auditLogFlag      = flag.String("audit-log", "data/login-audit.log", "File every login attempt is appended to")
+	maxFailuresFlag   = flag.Int("login-max-failures", lockout.DefaultPolicy.MaxFailures, "Failed logins in a row before a username is locked out")
+	maxIPFailuresFlag = flag.Int("login-max-failures-per-ip", lockout.DefaultPolicy.MaxFailuresPerAddress, "Failed logins before a source ip is locked out")
+	lockoutFlag       = flag.String("login-lockout", lockout.DefaultPolicy.BaseLockout.String(), "First lockout, doubled with every further failure")
+	maxLockoutFlag    = flag.String("login-max-lockout", lockout.DefaultPolicy.MaxLockout.String(), "Longest lockout")
+	challengeTTLFlag  = flag.String("login-challenge-ttl", "5m", "How long a login of a merchant with TOTP waits for the code")
+	totpIssuerFlag    = flag.String("totp-issuer", "Payment Gateway", "Name authenticator apps show next to TOTP codes")
+	plansFileFlag     = flag.String("plans-file", "data/plans.json", "JSON file with the plans merchants can be on, empty for the default plan only")
 )
 
-func generateMemoryStorage() *memory.Storage {
-	ms := memory.NewMemoryStorage()
-
-	dir, err := os.Getwd()
-	if err != nil {
-		fmt.Println(err)
-	}
-
-	jsonFile, err := os.Open(fmt.Sprintf("%s/data/merchants.json", dir))
-	if err != nil {
-		fmt.Println(err)
-	}
-	defer func() {
-		if err := jsonFile.Close(); err != nil {
-			log.Fatal(err)
-		}
-	}()
-	data, err := io.ReadAll(jsonFile)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	var merchants []entity.Merchant
-	err = json.Unmarshal(data, &merchants)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	for _, merchant := range merchants {
-		_, err := ms.CreateMerchant(merchant)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-
-	return ms
-}
-
 type server struct {
 	storage storage.Storage
+	logins  *lockout.Tracker
+	audit   *audit.Log
+	// challenges keep logins waiting for a TOTP code
+	challenges *challenge.Store
+	totpIssuer string
+	plans      *plans.Catalog
 	pb.UnimplementedMerchantServiceServer
 }
 
-func newServerWithMemoryStorage() *server {
-	ms := generateMemoryStorage()
-
-	return &server{
-		storage: ms,
+func newStorage(storageType, dir string) (storage.Storage, error) {
+	switch storageType {
+	case "memory":
+		return memory.NewMemoryStorage(), nil
+	case "file":
+		return file.NewFileStorage(dir)
+	default:
+		return nil, fmt.Errorf("unknown storage: %s", storageType)
 	}
 }
 
@@ -97,6 +92,7 @@ func (s *server) GetMerchant(ctx context.Context, req *pb.GetMerchantRequest) (*
 		Name:     merchant.Name,
 		Active:   merchant.Active,
 		MaxQps:   int32(merchant.MaxQPS),
+		Plan:     merchant.Plan,
 	}, nil
 }
 
@@ -113,11 +109,53 @@ func (s *server) GetQPS(ctx context.Context, req *pb.GetQPSRequest) (*pb.GetQPSR
 		return nil, err
 	}
 
+	// a merchant seeded with a plan that was removed since is not left
+	// without a limit
+	plan, err := s.plans.Get(merchant.Plan)
+	if err != nil {
+		log.Printf("merchant %s falls back to the default plan: %v", id, err)
+		plan, _ = s.plans.Get("")
+	}
+
 	return &pb.GetQPSResponse{
-		MaxQps: int32(merchant.MaxQPS),
+		MaxQps:    int32(merchant.MaxQPS),
+		Plan:      plan.Name,
+		Algorithm: string(plan.Algorithm),
+		Burst:     int32(plan.Burst),
 	}, nil
 }
 
+func (s *server) GetQuotas(ctx context.Context, req *pb.GetQuotasRequest) (*pb.GetQuotasResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in GetQuotas: %v", err)
+		return nil, err
+	}
+
+	merchant, err := s.storage.ReadMerchant(id)
+	if err != nil {
+		log.Printf("error reading storage in GetQuotas: %v", err)
+		return nil, err
+	}
+
+	plan, err := s.plans.Get(merchant.Plan)
+	if err != nil {
+		log.Printf("merchant %s falls back to the default plan: %v", id, err)
+		plan, _ = s.plans.Get("")
+	}
+
+	resp := &pb.GetQuotasResponse{Plan: plan.Name}
+	for _, q := range plan.Quotas {
+		resp.Quotas = append(resp.Quotas, &pb.Quota{
+			Period:         string(q.Period),
+			Currency:       q.Currency,
+			MaxCount:       q.MaxCount,
+			MaxAmountMinor: q.MaxAmountMinor,
+		})
+	}
+	return resp, nil
+}
+
 func (s *server) MerchantActive(ctx context.Context, req *pb.MerchantActiveRequest) (*pb.MerchantActiveResponse, error) {
 	id, err := uuid.Parse(req.Id)
 	if err != nil {
@@ -137,21 +175,866 @@ func (s *server) MerchantActive(ctx context.Context, req *pb.MerchantActiveReque
 }
 
 func (s *server) FindMerchant(ctx context.Context, req *pb.FindMerchantRequest) (*pb.FindMerchantResponse, error) {
+	now := time.Now()
+	event := audit.LoginEvent{
+		Time:     now,
+		Username: req.Username,
+		SourceIP: req.SourceIp,
+	}
+
+	// checking the lockout first keeps bcrypt out of reach of guessers
+	if wait := s.logins.Locked(req.Username, req.SourceIp, now); wait > 0 {
+		event.Outcome = audit.Locked
+		s.recordLogin(event)
+		return &pb.FindMerchantResponse{
+			Exists:            false,
+			RetryAfterSeconds: int64((wait + time.Second - 1) / time.Second),
+		}, nil
+	}
+
 	id, err := s.storage.FindMerchantID(req.Username, req.Password)
+	if errors.Is(err, memory.ErrUnknownMerchantUsername) {
+		return s.findUser(req, event, now)
+	}
 	if err != nil {
 		log.Printf("error checking is merchant exists: %v", err)
+		s.logins.Fail(req.Username, req.SourceIp, now)
+		event.Outcome = audit.InvalidCredentials
+		s.recordLogin(event)
 		return &pb.FindMerchantResponse{
 			Exists: false,
 			Id:     nil,
 		}, nil
 	}
+
+	merchant, err := s.storage.ReadMerchant(id)
+	if err != nil {
+		log.Printf("error reading storage in FindMerchant: %v", err)
+		return nil, err
+	}
 	idStr := id.String()
+	event.MerchantID = idStr
+
+	if !merchant.Active {
+		s.logins.Succeed(req.Username)
+		event.Outcome = audit.Inactive
+		s.recordLogin(event)
+		return &pb.FindMerchantResponse{Exists: true, Id: &idStr, Active: false}, nil
+	}
+
+	totp, err := s.storage.ReadTOTP(id)
+	if err != nil && !errors.Is(err, entity.ErrTOTPNotEnrolled) {
+		log.Printf("error reading storage in FindMerchant: %v", err)
+		return nil, err
+	}
+	if totp.Enabled {
+		// the failures are only forgotten once the code is right too, or
+		// a known password would allow endless guessing of codes
+		challenge, err := s.challenges.Create(id, merchant.Username, now)
+		if err != nil {
+			log.Printf("error creating challenge in FindMerchant: %v", err)
+			return nil, err
+		}
+		event.Outcome = audit.SecondFactorRequired
+		s.recordLogin(event)
+		return &pb.FindMerchantResponse{
+			Exists:             true,
+			Id:                 &idStr,
+			Active:             true,
+			Challenge:          challenge,
+			ChallengeExpiresIn: int64(s.challenges.TTL() / time.Second),
+		}, nil
+	}
+
+	s.logins.Succeed(req.Username)
+	event.Outcome = audit.Success
+	s.recordLogin(event)
+
 	return &pb.FindMerchantResponse{
 		Exists: true,
 		Id:     &idStr,
+		Active: true,
+		Roles:  rolesToPb(entity.OwnerRoles),
+	}, nil
+}
+
+// findUser is FindMerchant for the users of merchants, a user is only
+// active while its merchant is
+func (s *server) findUser(req *pb.FindMerchantRequest, event audit.LoginEvent, now time.Time) (*pb.FindMerchantResponse, error) {
+	user, err := s.storage.FindUser(req.Username, req.Password)
+	if err != nil {
+		log.Printf("error checking is merchant exists: %v", err)
+		s.logins.Fail(req.Username, req.SourceIp, now)
+		event.Outcome = audit.InvalidCredentials
+		s.recordLogin(event)
+		return &pb.FindMerchantResponse{
+			Exists: false,
+			Id:     nil,
+		}, nil
+	}
+	s.logins.Succeed(req.Username)
+
+	merchant, err := s.storage.ReadMerchant(user.MerchantID)
+	if err != nil {
+		log.Printf("error reading storage in FindMerchant: %v", err)
+		return nil, err
+	}
+
+	idStr := merchant.ID.String()
+	active := merchant.Active && user.Active
+	event.MerchantID = idStr
+	event.UserID = user.ID.String()
+	event.Outcome = audit.Success
+	if !active {
+		event.Outcome = audit.Inactive
+	}
+	s.recordLogin(event)
+
+	return &pb.FindMerchantResponse{
+		Exists: true,
+		Id:     &idStr,
+		Active: active,
+		UserId: user.ID.String(),
+		Roles:  rolesToPb(user.Roles),
+	}, nil
+}
+
+func (s *server) VerifyLogin(ctx context.Context, req *pb.VerifyLoginRequest) (*pb.VerifyLoginResponse, error) {
+	now := time.Now()
+	c, err := s.challenges.Attempt(req.Challenge, now)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	event := audit.LoginEvent{
+		Time:       now,
+		Username:   c.Username,
+		MerchantID: c.MerchantID.String(),
+		SourceIP:   req.SourceIp,
+	}
+
+	if wait := s.logins.Locked(c.Username, req.SourceIp, now); wait > 0 {
+		event.Outcome = audit.Locked
+		s.recordLogin(event)
+		return &pb.VerifyLoginResponse{
+			Verified:          false,
+			RetryAfterSeconds: int64((wait + time.Second - 1) / time.Second),
+		}, nil
+	}
+
+	err = s.storage.VerifyTOTP(c.MerchantID, req.Code, now)
+	switch {
+	case errors.Is(err, entity.ErrInvalidTOTPCode), errors.Is(err, entity.ErrTOTPNotEnrolled):
+		s.logins.Fail(c.Username, req.SourceIp, now)
+		event.Outcome = audit.InvalidCode
+		s.recordLogin(event)
+		return &pb.VerifyLoginResponse{Verified: false}, nil
+	case err != nil:
+		log.Printf("error updating storage in VerifyLogin: %v", err)
+		return nil, err
+	}
+
+	s.challenges.Complete(req.Challenge)
+	s.logins.Succeed(c.Username)
+	event.Outcome = audit.Success
+	s.recordLogin(event)
+
+	return &pb.VerifyLoginResponse{
+		Verified:   true,
+		MerchantId: c.MerchantID.String(),
+		Username:   c.Username,
+		Roles:      rolesToPb(entity.OwnerRoles),
+	}, nil
+}
+
+// recordLogin writes e to the audit log, a login is not refused because its
+// record could not be written
+func (s *server) recordLogin(e audit.LoginEvent) {
+	if err := s.audit.Record(e); err != nil {
+		log.Printf("error writing login audit record: %v", err)
+	}
+}
+
+func (s *server) SetWebhook(ctx context.Context, req *pb.SetWebhookRequest) (*pb.SetWebhookResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in SetWebhook: %v", err)
+		return nil, err
+	}
+
+	err = s.storage.UpdateWebhook(id, req.Url, req.Secret)
+	switch {
+	case errors.Is(err, entity.ErrInvalidWebhookURL), errors.Is(err, entity.ErrWebhookSecretTooShort):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		log.Printf("error updating storage in SetWebhook: %v", err)
+		return nil, err
+	}
+
+	return &pb.SetWebhookResponse{}, nil
+}
+
+func (s *server) GetWebhook(ctx context.Context, req *pb.GetWebhookRequest) (*pb.GetWebhookResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in GetWebhook: %v", err)
+		return nil, err
+	}
+
+	merchant, err := s.storage.ReadMerchant(id)
+	if err != nil {
+		log.Printf("error reading storage in GetWebhook: %v", err)
+		return nil, err
+	}
+
+	return &pb.GetWebhookResponse{
+		Url:    merchant.WebhookURL,
+		Secret: merchant.WebhookSecret,
+	}, nil
+}
+
+func (s *server) CreateRefreshToken(ctx context.Context, req *pb.CreateRefreshTokenRequest) (*pb.CreateRefreshTokenResponse, error) {
+	id, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in CreateRefreshToken: %v", err)
+		return nil, err
+	}
+	userID, err := parseOptionalUUID(req.UserId)
+	if err != nil {
+		log.Printf("error parsing user uuid in CreateRefreshToken: %v", err)
+		return nil, err
+	}
+
+	// every login starts a new family
+	t, token, err := entity.NewRefreshToken(id, uuid.New(), time.Now().Add(time.Duration(req.TtlSeconds)*time.Second))
+	if err != nil {
+		log.Printf("error generating token in CreateRefreshToken: %v", err)
+		return nil, err
+	}
+	t.UserID = userID
+
+	err = s.storage.CreateRefreshToken(t)
+	if errors.Is(err, entity.ErrMerchantInactive) || errors.Is(err, entity.ErrUserInactive) || errors.Is(err, entity.ErrUnknownUser) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		log.Printf("error saving token in CreateRefreshToken: %v", err)
+		return nil, err
+	}
+
+	return &pb.CreateRefreshTokenResponse{RefreshToken: token}, nil
+}
+
+func (s *server) RotateRefreshToken(ctx context.Context, req *pb.RotateRefreshTokenRequest) (*pb.RotateRefreshTokenResponse, error) {
+	now := time.Now()
+	// the merchant and family are taken from the token it replaces
+	next, token, err := entity.NewRefreshToken(uuid.Nil, uuid.Nil, now.Add(time.Duration(req.TtlSeconds)*time.Second))
+	if err != nil {
+		log.Printf("error generating token in RotateRefreshToken: %v", err)
+		return nil, err
+	}
+
+	hash := entity.HashRefreshToken(req.RefreshToken)
+	kept, err := s.storage.RotateRefreshToken(hash, next, now)
+	switch {
+	case errors.Is(err, entity.ErrMerchantInactive), errors.Is(err, entity.ErrUserInactive), errors.Is(err, entity.ErrUnknownUser):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, entity.ErrRefreshTokenReused):
+		log.Printf("refresh token reused, its family was revoked")
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, entity.ErrUnknownRefreshToken), errors.Is(err, entity.ErrRefreshTokenExpired), errors.Is(err, entity.ErrRefreshTokenRevoked):
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	case err != nil:
+		log.Printf("error updating storage in RotateRefreshToken: %v", err)
+		return nil, err
+	}
+
+	res := &pb.RotateRefreshTokenResponse{
+		MerchantId:   kept.MerchantID.String(),
+		RefreshToken: token,
+	}
+	// roles are read again, so changes apply from the next access token
+	if kept.UserID != uuid.Nil {
+		user, err := s.storage.ReadUser(kept.MerchantID, kept.UserID)
+		if err != nil {
+			log.Printf("error reading storage in RotateRefreshToken: %v", err)
+			return nil, err
+		}
+		res.UserId = user.ID.String()
+		res.Username = user.Username
+		res.Roles = rolesToPb(user.Roles)
+	} else {
+		merchant, err := s.storage.ReadMerchant(kept.MerchantID)
+		if err != nil {
+			log.Printf("error reading storage in RotateRefreshToken: %v", err)
+			return nil, err
+		}
+		res.Username = merchant.Username
+		res.Roles = rolesToPb(entity.OwnerRoles)
+	}
+
+	return res, nil
+}
+
+func (s *server) RevokeRefreshToken(ctx context.Context, req *pb.RevokeRefreshTokenRequest) (*pb.RevokeRefreshTokenResponse, error) {
+	err := s.storage.RevokeRefreshToken(entity.HashRefreshToken(req.RefreshToken))
+	if errors.Is(err, entity.ErrUnknownRefreshToken) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		log.Printf("error updating storage in RevokeRefreshToken: %v", err)
+		return nil, err
+	}
+
+	return &pb.RevokeRefreshTokenResponse{}, nil
+}
+
+func (s *server) RevokeAccessToken(ctx context.Context, req *pb.RevokeAccessTokenRequest) (*pb.RevokeAccessTokenResponse, error) {
+	if req.Jti == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing jti")
+	}
+
+	err := s.storage.RevokeAccessToken(req.Jti, time.Unix(req.ExpiresAt, 0), time.Now())
+	if err != nil {
+		log.Printf("error updating storage in RevokeAccessToken: %v", err)
+		return nil, err
+	}
+
+	return &pb.RevokeAccessTokenResponse{}, nil
+}
+
+func (s *server) AccessTokenRevoked(ctx context.Context, req *pb.AccessTokenRevokedRequest) (*pb.AccessTokenRevokedResponse, error) {
+	id, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in AccessTokenRevoked: %v", err)
+		return nil, err
+	}
+	userID, err := parseOptionalUUID(req.UserId)
+	if err != nil {
+		log.Printf("error parsing user uuid in AccessTokenRevoked: %v", err)
+		return nil, err
+	}
+
+	revoked, err := s.storage.AccessTokenRevoked(req.Jti, id, userID, time.Unix(req.IssuedAt, 0))
+	if err != nil {
+		log.Printf("error reading storage in AccessTokenRevoked: %v", err)
+		return nil, err
+	}
+
+	return &pb.AccessTokenRevokedResponse{Revoked: revoked}, nil
+}
+
+func (s *server) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	id, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in CreateAPIKey: %v", err)
+		return nil, err
+	}
+
+	k, key, err := entity.NewAPIKey(id, req.Label, time.Now())
+	if errors.Is(err, entity.ErrAPIKeyLabelTooLong) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		log.Printf("error generating key in CreateAPIKey: %v", err)
+		return nil, err
+	}
+
+	err = s.storage.CreateAPIKey(k)
+	if err != nil {
+		return nil, apiKeyError("CreateAPIKey", err)
+	}
+
+	return &pb.CreateAPIKeyResponse{Key: apiKeyToPb(k), ApiKey: key}, nil
+}
+
+func (s *server) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	id, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in ListAPIKeys: %v", err)
+		return nil, err
+	}
+
+	keys, err := s.storage.ListAPIKeys(id)
+	if err != nil {
+		return nil, apiKeyError("ListAPIKeys", err)
+	}
+
+	res := &pb.ListAPIKeysResponse{Keys: make([]*pb.APIKey, 0, len(keys))}
+	for _, k := range keys {
+		res.Keys = append(res.Keys, apiKeyToPb(k))
+	}
+	return res, nil
+}
+
+func (s *server) UpdateAPIKeyLabel(ctx context.Context, req *pb.UpdateAPIKeyLabelRequest) (*pb.UpdateAPIKeyLabelResponse, error) {
+	merchantID, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in UpdateAPIKeyLabel: %v", err)
+		return nil, err
+	}
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, entity.ErrUnknownAPIKey.Error())
+	}
+
+	err = s.storage.UpdateAPIKeyLabel(merchantID, id, req.Label)
+	if err != nil {
+		return nil, apiKeyError("UpdateAPIKeyLabel", err)
+	}
+
+	return &pb.UpdateAPIKeyLabelResponse{}, nil
+}
+
+func (s *server) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyResponse, error) {
+	merchantID, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in RevokeAPIKey: %v", err)
+		return nil, err
+	}
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, entity.ErrUnknownAPIKey.Error())
+	}
+
+	err = s.storage.RevokeAPIKey(merchantID, id, time.Now())
+	if err != nil {
+		return nil, apiKeyError("RevokeAPIKey", err)
+	}
+
+	return &pb.RevokeAPIKeyResponse{}, nil
+}
+
+func (s *server) ValidateAPIKey(ctx context.Context, req *pb.ValidateAPIKeyRequest) (*pb.ValidateAPIKeyResponse, error) {
+	prefix, err := entity.APIKeyPrefixOf(req.ApiKey)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	k, err := s.storage.FindAPIKey(prefix)
+	if err == nil {
+		err = k.Check(req.ApiKey)
+	}
+	switch {
+	case errors.Is(err, entity.ErrUnknownAPIKey), errors.Is(err, entity.ErrAPIKeyRevoked):
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	case err != nil:
+		log.Printf("error reading storage in ValidateAPIKey: %v", err)
+		return nil, err
+	}
+
+	merchant, err := s.storage.ReadMerchant(k.MerchantID)
+	if err != nil {
+		log.Printf("error reading storage in ValidateAPIKey: %v", err)
+		return nil, err
+	}
+	if !merchant.Active {
+		return nil, status.Error(codes.PermissionDenied, entity.ErrMerchantInactive.Error())
+	}
+
+	return &pb.ValidateAPIKeyResponse{
+		MerchantId: merchant.ID.String(),
+		KeyId:      k.ID.String(),
+		Username:   merchant.Username,
+		Roles:      rolesToPb(entity.APIKeyRoles),
+	}, nil
+}
+
+func apiKeyToPb(k entity.APIKey) *pb.APIKey {
+	res := &pb.APIKey{
+		Id:        k.ID.String(),
+		Prefix:    k.Prefix,
+		Label:     k.Label,
+		CreatedAt: k.CreatedAt.Unix(),
+	}
+	if k.Revoked() {
+		res.RevokedAt = k.RevokedAt.Unix()
+	}
+	return res
+}
+
+// apiKeyError maps storage errors of the api key rpcs to gRPC codes
+func apiKeyError(rpc string, err error) error {
+	switch {
+	case errors.Is(err, entity.ErrUnknownAPIKey), errors.Is(err, memory.ErrUnknownMerchantID):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, entity.ErrAPIKeyLabelTooLong):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	log.Printf("error updating storage in %s: %v", rpc, err)
+	return err
+}
+
+func (s *server) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	id, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in EnrollTOTP: %v", err)
+		return nil, err
+	}
+
+	merchant, err := s.storage.ReadMerchant(id)
+	if err != nil {
+		return nil, totpError("EnrollTOTP", err)
+	}
+	totp, err := entity.NewTOTP(id, time.Now())
+	if err != nil {
+		log.Printf("error generating secret in EnrollTOTP: %v", err)
+		return nil, err
+	}
+	if err := s.storage.EnrollTOTP(totp); err != nil {
+		return nil, totpError("EnrollTOTP", err)
+	}
+
+	return &pb.EnrollTOTPResponse{
+		Secret: totp.Secret,
+		Url:    totp.URL(s.totpIssuer, merchant.Username),
 	}, nil
 }
 
+func (s *server) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	id, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in ConfirmTOTP: %v", err)
+		return nil, err
+	}
+
+	recoveryCodes, hashes, err := entity.NewRecoveryCodes()
+	if err != nil {
+		log.Printf("error generating recovery codes in ConfirmTOTP: %v", err)
+		return nil, err
+	}
+	if err := s.storage.EnableTOTP(id, req.Code, hashes, time.Now()); err != nil {
+		return nil, totpError("ConfirmTOTP", err)
+	}
+
+	return &pb.ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (s *server) DisableTOTP(ctx context.Context, req *pb.DisableTOTPRequest) (*pb.DisableTOTPResponse, error) {
+	id, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in DisableTOTP: %v", err)
+		return nil, err
+	}
+
+	if err := s.storage.DisableTOTP(id, req.Code, time.Now()); err != nil {
+		return nil, totpError("DisableTOTP", err)
+	}
+
+	return &pb.DisableTOTPResponse{}, nil
+}
+
+// totpError maps storage errors of the TOTP rpcs to gRPC codes
+func totpError(rpc string, err error) error {
+	switch {
+	case errors.Is(err, memory.ErrUnknownMerchantID):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, entity.ErrTOTPAlreadyEnabled):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, entity.ErrTOTPNotEnrolled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, entity.ErrInvalidTOTPCode):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	log.Printf("error updating storage in %s: %v", rpc, err)
+	return err
+}
+
+func (s *server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	merchantID, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in CreateUser: %v", err)
+		return nil, err
+	}
+
+	user, err := entity.NewUser(merchantID, req.Username, req.Password, rolesFromPb(req.Roles), time.Now())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err := s.storage.CreateUser(user); err != nil {
+		return nil, userError("CreateUser", err)
+	}
+
+	return &pb.CreateUserResponse{User: userToPb(user)}, nil
+}
+
+func (s *server) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	merchantID, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in ListUsers: %v", err)
+		return nil, err
+	}
+
+	users, err := s.storage.ListUsers(merchantID)
+	if err != nil {
+		return nil, userError("ListUsers", err)
+	}
+
+	res := &pb.ListUsersResponse{Users: make([]*pb.User, 0, len(users))}
+	for _, u := range users {
+		res.Users = append(res.Users, userToPb(u))
+	}
+	return res, nil
+}
+
+func (s *server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	merchantID, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in UpdateUser: %v", err)
+		return nil, err
+	}
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, entity.ErrUnknownUser.Error())
+	}
+
+	now := time.Now()
+	if req.SetRoles {
+		if err := s.storage.UpdateUserRoles(merchantID, id, rolesFromPb(req.Roles)); err != nil {
+			return nil, userError("UpdateUser", err)
+		}
+	}
+	if req.Password != "" {
+		if err := s.storage.UpdateUserPassword(merchantID, id, req.Password, now); err != nil {
+			return nil, userError("UpdateUser", err)
+		}
+	}
+	if req.Active != nil {
+		if err := s.storage.UpdateUserActive(merchantID, id, *req.Active, now); err != nil {
+			return nil, userError("UpdateUser", err)
+		}
+	}
+
+	user, err := s.storage.ReadUser(merchantID, id)
+	if err != nil {
+		return nil, userError("UpdateUser", err)
+	}
+	return &pb.UpdateUserResponse{User: userToPb(user)}, nil
+}
+
+func (s *server) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	merchantID, err := uuid.Parse(req.MerchantId)
+	if err != nil {
+		log.Printf("error parsing uuid in DeleteUser: %v", err)
+		return nil, err
+	}
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, entity.ErrUnknownUser.Error())
+	}
+
+	if err := s.storage.DeleteUser(merchantID, id); err != nil {
+		return nil, userError("DeleteUser", err)
+	}
+
+	return &pb.DeleteUserResponse{}, nil
+}
+
+func userToPb(u entity.User) *pb.User {
+	return &pb.User{
+		Id:        u.ID.String(),
+		Username:  u.Username,
+		Roles:     rolesToPb(u.Roles),
+		Active:    u.Active,
+		CreatedAt: u.CreatedAt.Unix(),
+	}
+}
+
+func rolesToPb(roles []entity.Role) []string {
+	res := make([]string, len(roles))
+	for i, r := range roles {
+		res[i] = string(r)
+	}
+	return res
+}
+
+func rolesFromPb(roles []string) []entity.Role {
+	res := make([]entity.Role, len(roles))
+	for i, r := range roles {
+		res[i] = entity.Role(r)
+	}
+	return res
+}
+
+// parseOptionalUUID parses id, an empty one is uuid.Nil
+func parseOptionalUUID(id string) (uuid.UUID, error) {
+	if id == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(id)
+}
+
+// userError maps storage errors of the user rpcs to gRPC codes
+func userError(rpc string, err error) error {
+	switch {
+	case errors.Is(err, entity.ErrUnknownUser), errors.Is(err, memory.ErrUnknownMerchantID):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, memory.ErrUsernameAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, entity.ErrInvalidRole), errors.Is(err, entity.ErrNoRoles), errors.Is(err, entity.ErrUsernameOrPasswordEmpty):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	log.Printf("error updating storage in %s: %v", rpc, err)
+	return err
+}
+
+func (s *server) CreateMerchant(ctx context.Context, req *pb.CreateMerchantRequest) (*pb.CreateMerchantResponse, error) {
+	merchant, err := entity.NewMerchant(req.Username, req.Password, req.Name, req.Active, int(req.MaxQps))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err := s.plans.Get(req.Plan); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	merchant.Plan = req.Plan
+
+	id, err := s.storage.CreateMerchant(merchant)
+	switch {
+	case errors.Is(err, memory.ErrUsernameAlreadyExists):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	case err != nil:
+		log.Printf("error updating storage in CreateMerchant: %v", err)
+		return nil, err
+	}
+
+	log.Printf("merchant %s created as %s", req.Username, id)
+	return &pb.CreateMerchantResponse{Id: id.String()}, nil
+}
+
+func (s *server) UpdateMerchant(ctx context.Context, req *pb.UpdateMerchantRequest) (*pb.UpdateMerchantResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if req.Plan != nil {
+		if _, err := s.plans.Get(*req.Plan); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	err = s.storage.UpdateMerchant(id, req.Name, int(req.MaxQps))
+	if err == nil && req.Plan != nil {
+		err = s.storage.UpdatePlan(id, *req.Plan)
+	}
+	if err == nil && req.Active != nil {
+		err = s.storage.UpdateActive(id, *req.Active, time.Now())
+	}
+	if err != nil {
+		return nil, adminError("UpdateMerchant", err)
+	}
+
+	return &pb.UpdateMerchantResponse{}, nil
+}
+
+func (s *server) DeactivateMerchant(ctx context.Context, req *pb.DeactivateMerchantRequest) (*pb.DeactivateMerchantResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = s.storage.UpdateActive(id, false, time.Now())
+	if err != nil {
+		return nil, adminError("DeactivateMerchant", err)
+	}
+
+	return &pb.DeactivateMerchantResponse{}, nil
+}
+
+func (s *server) ListMerchants(ctx context.Context, req *pb.ListMerchantsRequest) (*pb.ListMerchantsResponse, error) {
+	merchants, err := s.storage.ListMerchants()
+	if err != nil {
+		log.Printf("error reading storage in ListMerchants: %v", err)
+		return nil, err
+	}
+
+	res := &pb.ListMerchantsResponse{Merchants: make([]*pb.Merchant, 0, len(merchants))}
+	for _, merchant := range merchants {
+		res.Merchants = append(res.Merchants, &pb.Merchant{
+			Id:         merchant.ID.String(),
+			Username:   merchant.Username,
+			Name:       merchant.Name,
+			Active:     merchant.Active,
+			MaxQps:     int32(merchant.MaxQPS),
+			WebhookUrl: merchant.WebhookURL,
+			Plan:       merchant.Plan,
+		})
+	}
+
+	return res, nil
+}
+
+func (s *server) UnlockLogin(ctx context.Context, req *pb.UnlockLoginRequest) (*pb.UnlockLoginResponse, error) {
+	if req.Username == "" && req.SourceIp == "" {
+		return nil, status.Error(codes.InvalidArgument, "username or source ip required")
+	}
+
+	s.logins.Unlock(req.Username, req.SourceIp)
+	log.Printf("login unlocked for username %q and source ip %q", req.Username, req.SourceIp)
+	return &pb.UnlockLoginResponse{}, nil
+}
+
+func (s *server) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = s.storage.UpdatePassword(id, req.Password, time.Now())
+	if err != nil {
+		return nil, adminError("ChangePassword", err)
+	}
+
+	return &pb.ChangePasswordResponse{}, nil
+}
+
+// adminError maps storage errors of the administration rpcs to gRPC codes
+func adminError(rpc string, err error) error {
+	switch {
+	case errors.Is(err, memory.ErrUnknownMerchantID):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, entity.ErrUsernameOrPasswordEmpty), errors.Is(err, entity.ErrNameEmpty),
+		errors.Is(err, entity.ErrMaxQPSCannotBeNegative):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	log.Printf("error updating storage in %s: %v", rpc, err)
+	return err
+}
+
+// adminMethods are the rpcs that require the admin token
+var adminMethods = map[string]bool{
+	"/merchant.MerchantService/CreateMerchant":     true,
+	"/merchant.MerchantService/UpdateMerchant":     true,
+	"/merchant.MerchantService/DeactivateMerchant": true,
+	"/merchant.MerchantService/ListMerchants":      true,
+	"/merchant.MerchantService/ChangePassword":     true,
+	"/merchant.MerchantService/UnlockLogin":        true,
+}
+
+// adminInterceptor refuses the administration rpcs unless the request
+// carries "authorization: Bearer <token>", with no token they are disabled
+func adminInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !adminMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		if token == "" {
+			return nil, status.Error(codes.PermissionDenied, "administration is disabled")
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, v := range md.Get("authorization") {
+			given := strings.TrimPrefix(v, "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+				return handler(ctx, req)
+			}
+		}
+
+		log.Printf("refused %s without a valid admin token", info.FullMethod)
+		return nil, status.Error(codes.Unauthenticated, "invalid admin token")
+	}
+}
+
 func getEnvOrFlag[T int | string](env string, flagVal *T, conv func(string) (T, error)) T {
 	if envVal := os.Getenv(env); envVal != "" {
 		v, err := conv(envVal)
@@ -163,25 +1046,136 @@ func getEnvOrFlag[T int | string](env string, flagVal *T, conv func(string) (T,
 	return *flagVal
 }
 
+// config is what the merchant service is started with, read from flags or
+// environment variables
+type config struct {
+	ipVersion    int
+	host         string
+	port         int
+	adminToken   string
+	storageType  string
+	dataDir      string
+	seedFile     string
+	seedWatch    time.Duration
+	auditLog     string
+	policy       lockout.Policy
+	challengeTTL time.Duration
+	totpIssuer   string
+	plansFile    string
+}
+
+// readConfig reads the config from the environment, falling back to the
+// flags, which must be parsed first
+func readConfig() (config, error) {
+	dummyFunc := func(v string) (string, error) { return v, nil }
+	c := config{
+		ipVersion:   getEnvOrFlag("IP_VERSION", ipVersionFlag, strconv.Atoi),
+		host:        getEnvOrFlag("MERCHANT_SERVICE_HOST", hostFlag, dummyFunc),
+		port:        getEnvOrFlag("MERCHANT_SERVICE_PORT", portFlag, strconv.Atoi),
+		adminToken:  getEnvOrFlag("MERCHANT_ADMIN_TOKEN", adminTokenFlag, dummyFunc),
+		storageType: getEnvOrFlag("MERCHANT_STORAGE", storageFlag, dummyFunc),
+		dataDir:     getEnvOrFlag("MERCHANT_DATA_DIR", dataDirFlag, dummyFunc),
+		seedFile:    getEnvOrFlag("MERCHANT_SEED_FILE", seedFileFlag, dummyFunc),
+		auditLog:    getEnvOrFlag("MERCHANT_AUDIT_LOG", auditLogFlag, dummyFunc),
+		policy: lockout.Policy{
+			MaxFailures:           getEnvOrFlag("MERCHANT_LOGIN_MAX_FAILURES", maxFailuresFlag, strconv.Atoi),
+			MaxFailuresPerAddress: getEnvOrFlag("MERCHANT_LOGIN_MAX_FAILURES_PER_IP", maxIPFailuresFlag, strconv.Atoi),
+		},
+		totpIssuer: getEnvOrFlag("MERCHANT_TOTP_ISSUER", totpIssuerFlag, dummyFunc),
+		plansFile:  getEnvOrFlag("MERCHANT_PLANS_FILE", plansFileFlag, dummyFunc),
+	}
+
+	var err error
+	c.seedWatch, err = time.ParseDuration(getEnvOrFlag("MERCHANT_SEED_WATCH_INTERVAL", seedWatchFlag, dummyFunc))
+	if err != nil {
+		return config{}, fmt.Errorf("invalid seed watch interval: %w", err)
+	}
+	c.policy.BaseLockout, err = time.ParseDuration(getEnvOrFlag("MERCHANT_LOGIN_LOCKOUT", lockoutFlag, dummyFunc))
+	if err != nil {
+		return config{}, fmt.Errorf("invalid login lockout: %w", err)
+	}
+	c.policy.MaxLockout, err = time.ParseDuration(getEnvOrFlag("MERCHANT_LOGIN_MAX_LOCKOUT", maxLockoutFlag, dummyFunc))
+	if err != nil {
+		return config{}, fmt.Errorf("invalid login max lockout: %w", err)
+	}
+	if err := c.policy.Validate(); err != nil {
+		return config{}, fmt.Errorf("%w: %+v", err, c.policy)
+	}
+
+	c.challengeTTL, err = time.ParseDuration(getEnvOrFlag("MERCHANT_LOGIN_CHALLENGE_TTL", challengeTTLFlag, dummyFunc))
+	if err != nil {
+		return config{}, fmt.Errorf("invalid login challenge ttl: %w", err)
+	}
+	if c.challengeTTL <= 0 {
+		return config{}, fmt.Errorf("login challenge ttl must be positive, got %v", c.challengeTTL)
+	}
+	return c, nil
+}
+
 func main() {
+	flag.Parse()
+
 	var network string = "tcp4"
 
-	ipVersion := getEnvOrFlag("IP_VERSION", ipVersionFlag, strconv.Atoi)
-	host := getEnvOrFlag("MERCHANT_SERVICE_HOST", hostFlag, func(v string) (string, error) { return v, nil })
-	port := getEnvOrFlag("MERCHANT_SERVICE_PORT", portFlag, strconv.Atoi)
+	cfg, err := readConfig()
+	if err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+	if cfg.adminToken == "" {
+		log.Printf("no admin token, administration endpoints are disabled")
+	}
+
+	planCatalog, _ := plans.NewCatalog([]entity.Plan{entity.DefaultPlan})
+	if cfg.plansFile != "" {
+		planCatalog, err = plans.Load(cfg.plansFile)
+		if err != nil {
+			log.Fatalf("failed to load plans: %v", err)
+		}
+	}
+
+	auditFile, err := os.OpenFile(cfg.auditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.Fatalf("failed to open audit log: %v", err)
+	}
+	defer auditFile.Close()
+
+	st, err := newStorage(cfg.storageType, cfg.dataDir)
+	if err != nil {
+		log.Fatalf("failed to open storage: %v", err)
+	}
+
+	if cfg.seedFile != "" {
+		watcher, err := seed.NewWatcher(cfg.seedFile, st)
+		if err != nil {
+			log.Fatalf("failed to seed merchants: %v", err)
+		}
+		if cfg.seedWatch > 0 {
+			go watcher.Run(context.Background(), cfg.seedWatch)
+			log.Printf("watching %s for merchants every %v", cfg.seedFile, cfg.seedWatch)
+		}
+	}
 
-	if ipVersion == 6 {
+	host := cfg.host
+	if cfg.ipVersion == 6 {
 		host = fmt.Sprintf("[%s]", host)
 		network = "tcp6"
 	}
 
-	listener, err := net.Listen(network, fmt.Sprintf("%s:%d", host, port))
+	listener, err := net.Listen(network, fmt.Sprintf("%s:%d", host, cfg.port))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	s := grpc.NewServer()
-	pb.RegisterMerchantServiceServer(s, newServerWithMemoryStorage())
+	s := grpc.NewServer(grpc.UnaryInterceptor(adminInterceptor(cfg.adminToken)))
+	pb.RegisterMerchantServiceServer(s, &server{
+		storage: st,
+		logins:  lockout.NewTracker(cfg.policy),
+		audit:   audit.NewLog(auditFile),
+
+		challenges: challenge.NewStore(cfg.challengeTTL),
+		totpIssuer: cfg.totpIssuer,
+		plans:      planCatalog,
+	})
 	reflection.Register(s)
 	log.Printf("server listening at %v", listener.Addr())
 
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestReadConfig(t *testing.T) {
+	if err := flag.CommandLine.Parse([]string{"--admin-token=flag-token", "--storage=file", "--login-challenge-ttl=2m", "--login-max-failures=7"}); err != nil {
+		t.Fatal(err)
+	}
+	// the environment wins over the flags
+	t.Setenv("MERCHANT_LOGIN_MAX_FAILURES", "9")
+
+	cfg, err := readConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.adminToken != "flag-token" || cfg.storageType != "file" || cfg.challengeTTL != 2*time.Minute {
+		t.Errorf("expected the flags to be honoured, got %+v", cfg)
+	}
+	if cfg.policy.MaxFailures != 9 {
+		t.Errorf("expected 9 failures from the environment, got %d", cfg.policy.MaxFailures)
+	}
+	if cfg.port != 50051 {
+		t.Errorf("expected the default port, got %d", cfg.port)
+	}
+
+	if err := flag.CommandLine.Parse([]string{"--login-challenge-ttl=0s"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readConfig(); err == nil {
+		t.Error("expected a challenge ttl of 0 to be refused")
+	}
+}
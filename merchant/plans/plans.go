@@ -0,0 +1,92 @@
+// Package plans keeps the plans merchants can be on, loaded from a JSON file.
+package plans
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
+)
+
+var (
+	// ErrDuplicatePlan must be used when a plans file lists the same name twice
+	ErrDuplicatePlan = errors.New("plan listed twice")
+	// ErrNoDefaultPlan must be used when a plans file has no plan named entity.DefaultPlanName
+	ErrNoDefaultPlan = errors.New("no default plan")
+	// ErrUnknownPlan must be used when a merchant is put on a plan that does not exist
+	ErrUnknownPlan = errors.New("unknown plan")
+)
+
+// Catalog is the set of plans, by name. It is not changed once built, so it
+// is safe for concurrent use
+type Catalog struct {
+	plans map[string]entity.Plan
+}
+
+// NewCatalog is a factory for a Catalog of plans, one of them must be named
+// entity.DefaultPlanName
+func NewCatalog(plans []entity.Plan) (*Catalog, error) {
+	c := &Catalog{plans: make(map[string]entity.Plan, len(plans))}
+	for i, p := range plans {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("plan %d: %w", i, err)
+		}
+		if _, ok := c.plans[p.Name]; ok {
+			return nil, fmt.Errorf("plan %d: %w", i, ErrDuplicatePlan)
+		}
+		c.plans[p.Name] = p
+	}
+	if _, ok := c.plans[entity.DefaultPlanName]; !ok {
+		return nil, ErrNoDefaultPlan
+	}
+	return c, nil
+}
+
+// Load reads the plans file at path, unknown fields are refused so typos do
+// not go unnoticed
+func Load(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var plans []entity.Plan
+	if err := dec.Decode(&plans); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+
+	c, err := NewCatalog(plans)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Get returns the plan called name, an empty name is the default plan
+func (c *Catalog) Get(name string) (entity.Plan, error) {
+	if name == "" {
+		name = entity.DefaultPlanName
+	}
+	p, ok := c.plans[name]
+	if !ok {
+		return entity.Plan{}, fmt.Errorf("%w: %q", ErrUnknownPlan, name)
+	}
+	return p, nil
+}
+
+// List returns every plan, sorted by name
+func (c *Catalog) List() []entity.Plan {
+	res := make([]entity.Plan, 0, len(c.plans))
+	for _, p := range c.plans {
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
@@ -0,0 +1,134 @@
+package plans_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
+	"github.com/thiagolcmelo/payment-gateway/merchant/plans"
+)
+
+func TestLoad(t *testing.T) {
+	type testCase struct {
+		testName    string
+		content     string
+		expectErr   bool
+		expectedErr error
+		expectedLen int
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "valid_file",
+			content:     `[{"name": "standard", "algorithm": "token_bucket", "burst": 10}, {"name": "strict", "algorithm": "sliding_log"}]`,
+			expectedLen: 2,
+		},
+		{
+			testName:    "quotas",
+			content:     `[{"name": "standard", "algorithm": "token_bucket", "burst": 10, "quotas": [{"period": "day", "max_count": 100}, {"period": "month", "currency": "USD", "max_amount_minor": 1000000}]}]`,
+			expectedLen: 1,
+		},
+		{
+			testName:    "invalid_quota",
+			content:     `[{"name": "standard", "algorithm": "token_bucket", "burst": 10, "quotas": [{"period": "week", "max_count": 100}]}]`,
+			expectErr:   true,
+			expectedErr: entity.ErrUnknownPeriod,
+		},
+		{
+			testName:  "not_json",
+			content:   `[{"name": `,
+			expectErr: true,
+		},
+		{
+			testName:  "unknown_field",
+			content:   `[{"name": "standard", "algorithm": "token_bucket", "brust": 10}]`,
+			expectErr: true,
+		},
+		{
+			testName:    "invalid_plan",
+			content:     `[{"name": "standard", "algorithm": "leaky_bucket", "burst": 10}]`,
+			expectErr:   true,
+			expectedErr: entity.ErrUnknownAlgorithm,
+		},
+		{
+			testName:    "duplicate_plan",
+			content:     `[{"name": "standard", "algorithm": "token_bucket", "burst": 10}, {"name": "standard", "algorithm": "gcra", "burst": 1}]`,
+			expectErr:   true,
+			expectedErr: plans.ErrDuplicatePlan,
+		},
+		{
+			testName:    "no_default_plan",
+			content:     `[{"name": "strict", "algorithm": "sliding_log"}]`,
+			expectErr:   true,
+			expectedErr: plans.ErrNoDefaultPlan,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "plans.json")
+			if err := os.WriteFile(path, []byte(tc.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := plans.Load(path)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error: %t, got %v", tc.expectErr, err)
+			}
+			if tc.expectedErr != nil && !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if err == nil && len(c.List()) != tc.expectedLen {
+				t.Errorf("expected %d plans, got %d", tc.expectedLen, len(c.List()))
+			}
+		})
+	}
+}
+
+func TestCatalog_Get(t *testing.T) {
+	strict := entity.Plan{Name: "strict", Algorithm: entity.AlgorithmSlidingLog}
+	c, err := plans.NewCatalog([]entity.Plan{entity.DefaultPlan, strict})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName     string
+		name         string
+		expectedPlan entity.Plan
+		expectedErr  error
+	}
+
+	testCases := []testCase{
+		{
+			testName:     "named",
+			name:         "strict",
+			expectedPlan: strict,
+		},
+		{
+			testName:     "empty_is_default",
+			name:         "",
+			expectedPlan: entity.DefaultPlan,
+		},
+		{
+			testName:    "unknown",
+			name:        "gold",
+			expectedErr: plans.ErrUnknownPlan,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			p, err := c.Get(tc.name)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(p, tc.expectedPlan) {
+				t.Errorf("expected %+v, got %+v", tc.expectedPlan, p)
+			}
+		})
+	}
+}
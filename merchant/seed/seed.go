@@ -0,0 +1,148 @@
+// Package seed loads merchants from a JSON file into a Storage, and can keep
+// watching the file to apply later edits without a restart.
+package seed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
+	"github.com/thiagolcmelo/payment-gateway/merchant/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrDuplicateMerchant must be used when a seed file lists the same id or username twice
+	ErrDuplicateMerchant = errors.New("merchant listed twice")
+	// ErrPasswordNotHashed must be used when a seed file has a password that is not a bcrypt hash
+	ErrPasswordNotHashed = errors.New("password must be a bcrypt hash")
+)
+
+// Load reads the merchants of the seed file at path, every merchant must be
+// valid, and unknown fields are refused so typos do not go unnoticed
+func Load(path string) ([]entity.Merchant, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var merchants []entity.Merchant
+	if err := dec.Decode(&merchants); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+
+	ids := make(map[uuid.UUID]bool, len(merchants))
+	usernames := make(map[string]bool, len(merchants))
+	for i, m := range merchants {
+		if err := m.Validate(); err != nil {
+			return nil, fmt.Errorf("merchant %d of %s: %w", i, path, err)
+		}
+		if _, err := bcrypt.Cost([]byte(m.Password)); err != nil {
+			return nil, fmt.Errorf("merchant %d of %s: %w", i, path, ErrPasswordNotHashed)
+		}
+		if (m.ID != uuid.Nil && ids[m.ID]) || usernames[m.Username] {
+			return nil, fmt.Errorf("merchant %d of %s: %w", i, path, ErrDuplicateMerchant)
+		}
+		ids[m.ID] = true
+		usernames[m.Username] = true
+	}
+
+	return merchants, nil
+}
+
+// Apply adds the merchants to s, or updates the ones whose entry changed
+// since it was last applied, and returns how many changed
+func Apply(s storage.Storage, merchants []entity.Merchant, now time.Time) (int, error) {
+	changed := 0
+	for _, m := range merchants {
+		ok, err := s.SeedMerchant(m, now)
+		if err != nil {
+			return changed, fmt.Errorf("could not seed %s: %w", m.Username, err)
+		}
+		if ok {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// Watcher applies the seed file to a Storage whenever the file changes.
+// Merchants removed from the file are kept, they can be turned off instead.
+type Watcher struct {
+	path    string
+	storage storage.Storage
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher is a factory for a Watcher of the seed file at path, it loads
+// the file right away and fails if it cannot be applied
+func NewWatcher(path string, s storage.Storage) (*Watcher, error) {
+	w := &Watcher{
+		path:    path,
+		storage: s,
+	}
+	if _, err := w.Check(time.Now()); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Check applies the seed file if it changed since the last check, and
+// returns how many merchants changed
+func (w *Watcher) Check(now time.Time) (int, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return 0, err
+	}
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return 0, nil
+	}
+
+	merchants, err := Load(w.path)
+	if err != nil {
+		return 0, err
+	}
+	changed, err := Apply(w.storage, merchants, now)
+	if err != nil {
+		return changed, err
+	}
+
+	// a file that failed is retried on the next check, even if untouched
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+	return changed, nil
+}
+
+// Run checks the seed file every interval until ctx is done, a broken file
+// is logged and the merchants already loaded are kept
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			changed, err := w.Check(now)
+			if err != nil {
+				log.Printf("error applying seed file %s: %v", w.path, err)
+				continue
+			}
+			if changed > 0 {
+				log.Printf("applied %d merchants from seed file %s", changed, w.path)
+			}
+		}
+	}
+}
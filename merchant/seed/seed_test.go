@@ -0,0 +1,136 @@
+package seed_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/merchant/seed"
+	"github.com/thiagolcmelo/payment-gateway/merchant/storage/memory"
+)
+
+// hash is the bcrypt hash of password0
+const hash = "$2a$10$TfTXwd7PA.rUrioJrkPbEutsp8WxvJrFDPfOgtTRwolNN3O7m0zKS"
+
+func writeSeed(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	type testCase struct {
+		testName    string
+		content     string
+		expectErr   bool
+		expectedErr error
+		expectedLen int
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "valid_file",
+			content:     `[{"id": "e1211351-bb91-441f-9ea0-3b243189dec6", "username": "merchant0", "password": "` + hash + `", "name": "Merchant 0", "active": true, "max_qps": 100}]`,
+			expectedLen: 1,
+		},
+		{
+			testName:  "not_json",
+			content:   `[{"username": `,
+			expectErr: true,
+		},
+		{
+			testName:  "unknown_field",
+			content:   `[{"username": "merchant0", "password": "` + hash + `", "name": "Merchant 0", "max_qsp": 100}]`,
+			expectErr: true,
+		},
+		{
+			testName:  "invalid_merchant",
+			content:   `[{"username": "merchant0", "password": "` + hash + `", "name": ""}]`,
+			expectErr: true,
+		},
+		{
+			testName:    "plain_password",
+			content:     `[{"username": "merchant0", "password": "password0", "name": "Merchant 0"}]`,
+			expectErr:   true,
+			expectedErr: seed.ErrPasswordNotHashed,
+		},
+		{
+			testName:    "duplicate_username",
+			content:     `[{"username": "merchant0", "password": "` + hash + `", "name": "Merchant 0"}, {"username": "merchant0", "password": "` + hash + `", "name": "Merchant 1"}]`,
+			expectErr:   true,
+			expectedErr: seed.ErrDuplicateMerchant,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "merchants.json")
+			writeSeed(t, path, tc.content)
+
+			merchants, err := seed.Load(path)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error=%v, got %v", tc.expectErr, err)
+			}
+			if tc.expectedErr != nil && !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if len(merchants) != tc.expectedLen {
+				t.Errorf("expected %d merchants, got %d", tc.expectedLen, len(merchants))
+			}
+		})
+	}
+
+	if _, err := seed.Load(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected %v, got %v", os.ErrNotExist, err)
+	}
+}
+
+func TestWatcher_Check(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "merchants.json")
+	id := uuid.MustParse("e1211351-bb91-441f-9ea0-3b243189dec6")
+	writeSeed(t, path, `[{"id": "`+id.String()+`", "username": "merchant0", "password": "`+hash+`", "name": "Merchant 0", "active": true, "max_qps": 100}]`)
+
+	ms := memory.NewMemoryStorage()
+	w, err := seed.NewWatcher(path, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ms.FindMerchantID("merchant0", "password0"); err != nil || got != id {
+		t.Fatalf("expected %v, got %v, %v", id, got, err)
+	}
+
+	now := time.Now()
+	if changed, err := w.Check(now); err != nil || changed != 0 {
+		t.Errorf("expected no changes, got %d, %v", changed, err)
+	}
+
+	// a broken edit keeps what was loaded, and is retried once fixed
+	writeSeed(t, path, `[{"id": `)
+	if _, err := w.Check(now); err == nil {
+		t.Error("expected an error for a broken seed file")
+	}
+
+	writeSeed(t, path, `[
+		{"id": "`+id.String()+`", "username": "merchant0", "password": "`+hash+`", "name": "Merchant 0 Ltd.", "active": true, "max_qps": 10},
+		{"username": "merchant1", "password": "`+hash+`", "name": "Merchant 1", "active": true, "max_qps": 100}
+	]`)
+	if changed, err := w.Check(now); err != nil || changed != 2 {
+		t.Fatalf("expected 2 changes, got %d, %v", changed, err)
+	}
+
+	merchant, err := ms.ReadMerchant(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merchant.Name != "Merchant 0 Ltd." || merchant.MaxQPS != 10 {
+		t.Errorf("expected merchant to be updated, got %+v", merchant)
+	}
+	if _, err := ms.FindMerchantID("merchant1", "password0"); err != nil {
+		t.Errorf("expected merchant1 to be added, got %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
+	"github.com/thiagolcmelo/payment-gateway/merchant/storage/memory"
+)
+
+const stateFileName = "merchants.state.json"
+
+// Storage is a durable implementation of the Storage interface.
+//
+// Merchants and tokens are served from memory, and every change writes the
+// full state to disk before it is acknowledged. A change that cannot be
+// written is undone, so memory never gets ahead of the disk.
+type Storage struct {
+	*memory.Storage
+	dir string
+	// mu makes each change and its write one step
+	mu sync.Mutex
+}
+
+// NewFileStorage is a factory for file backed Storage for merchants, it
+// restores any state previously persisted in dir
+func NewFileStorage(dir string) (*Storage, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	s := &Storage{
+		Storage: memory.NewMemoryStorage(),
+		dir:     dir,
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, stateFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snap memory.Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", stateFileName, err)
+	}
+	s.Storage.Restore(snap)
+
+	return s, nil
+}
+
+// CreateMerchant stores a merchant
+func (s *Storage) CreateMerchant(m entity.Merchant) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := s.change(func() (err error) {
+		id, err = s.Storage.CreateMerchant(m)
+		return err
+	})
+	return id, err
+}
+
+// UpdateWebhook registers the webhook of a merchant, empty url and secret
+// remove it
+func (s *Storage) UpdateWebhook(id uuid.UUID, url, secret string) error {
+	return s.change(func() error {
+		return s.Storage.UpdateWebhook(id, url, secret)
+	})
+}
+
+// UpdateMerchant changes the name and the maximum QPS of a merchant
+func (s *Storage) UpdateMerchant(id uuid.UUID, name string, maxQPS int) error {
+	return s.change(func() error {
+		return s.Storage.UpdateMerchant(id, name, maxQPS)
+	})
+}
+
+// UpdatePlan puts a merchant on another plan
+func (s *Storage) UpdatePlan(id uuid.UUID, plan string) error {
+	return s.change(func() error {
+		return s.Storage.UpdatePlan(id, plan)
+	})
+}
+
+// UpdatePassword changes the password of a merchant and revokes its tokens
+func (s *Storage) UpdatePassword(id uuid.UUID, password string, now time.Time) error {
+	return s.change(func() error {
+		return s.Storage.UpdatePassword(id, password, now)
+	})
+}
+
+// SeedMerchant makes a merchant look like m, see memory.Storage.SeedMerchant
+func (s *Storage) SeedMerchant(m entity.Merchant, now time.Time) (bool, error) {
+	var changed bool
+	err := s.change(func() (err error) {
+		changed, err = s.Storage.SeedMerchant(m, now)
+		return err
+	})
+	return changed, err
+}
+
+// UpdateActive turns a merchant on or off, turning it off revokes all of its
+// access and refresh tokens
+func (s *Storage) UpdateActive(id uuid.UUID, active bool, now time.Time) error {
+	return s.change(func() error {
+		return s.Storage.UpdateActive(id, active, now)
+	})
+}
+
+// CreateRefreshToken keeps the first refresh token of a login
+func (s *Storage) CreateRefreshToken(t entity.RefreshToken) error {
+	return s.change(func() error {
+		return s.Storage.CreateRefreshToken(t)
+	})
+}
+
+// RotateRefreshToken exchanges the refresh token with hash for next, a
+// reused token revokes its family, which must be kept even though the
+// rotation fails
+func (s *Storage) RotateRefreshToken(hash string, next entity.RefreshToken, now time.Time) (entity.RefreshToken, error) {
+	var (
+		kept      entity.RefreshToken
+		rotateErr error
+	)
+	err := s.change(func() error {
+		kept, rotateErr = s.Storage.RotateRefreshToken(hash, next, now)
+		if errors.Is(rotateErr, entity.ErrRefreshTokenReused) {
+			return nil
+		}
+		return rotateErr
+	})
+	if err != nil {
+		return entity.RefreshToken{}, err
+	}
+	return kept, rotateErr
+}
+
+// RevokeRefreshToken revokes the family of the refresh token with hash
+func (s *Storage) RevokeRefreshToken(hash string) error {
+	return s.change(func() error {
+		return s.Storage.RevokeRefreshToken(hash)
+	})
+}
+
+// RevokeAccessToken revokes the access token jti until it expires
+func (s *Storage) RevokeAccessToken(jti string, expiresAt time.Time, now time.Time) error {
+	return s.change(func() error {
+		return s.Storage.RevokeAccessToken(jti, expiresAt, now)
+	})
+}
+
+// CreateAPIKey keeps a new API key of a merchant
+func (s *Storage) CreateAPIKey(k entity.APIKey) error {
+	return s.change(func() error {
+		return s.Storage.CreateAPIKey(k)
+	})
+}
+
+// UpdateAPIKeyLabel changes the label of an API key of a merchant
+func (s *Storage) UpdateAPIKeyLabel(merchantID, id uuid.UUID, label string) error {
+	return s.change(func() error {
+		return s.Storage.UpdateAPIKeyLabel(merchantID, id, label)
+	})
+}
+
+// RevokeAPIKey revokes an API key of a merchant
+func (s *Storage) RevokeAPIKey(merchantID, id uuid.UUID, now time.Time) error {
+	return s.change(func() error {
+		return s.Storage.RevokeAPIKey(merchantID, id, now)
+	})
+}
+
+// EnrollTOTP keeps a new TOTP secret of a merchant
+func (s *Storage) EnrollTOTP(t entity.TOTP) error {
+	return s.change(func() error {
+		return s.Storage.EnrollTOTP(t)
+	})
+}
+
+// EnableTOTP turns on the TOTP a merchant enrolled
+func (s *Storage) EnableTOTP(merchantID uuid.UUID, code string, recoveryCodes []string, now time.Time) error {
+	return s.change(func() error {
+		return s.Storage.EnableTOTP(merchantID, code, recoveryCodes, now)
+	})
+}
+
+// VerifyTOTP checks a TOTP or recovery code of a merchant, the code is used
+// up on disk before it is accepted
+func (s *Storage) VerifyTOTP(merchantID uuid.UUID, code string, now time.Time) error {
+	return s.change(func() error {
+		return s.Storage.VerifyTOTP(merchantID, code, now)
+	})
+}
+
+// DisableTOTP removes the TOTP of a merchant
+func (s *Storage) DisableTOTP(merchantID uuid.UUID, code string, now time.Time) error {
+	return s.change(func() error {
+		return s.Storage.DisableTOTP(merchantID, code, now)
+	})
+}
+
+// CreateUser stores a new user of a merchant
+func (s *Storage) CreateUser(u entity.User) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := s.change(func() (err error) {
+		id, err = s.Storage.CreateUser(u)
+		return err
+	})
+	return id, err
+}
+
+// UpdateUserRoles replaces the roles of a user of a merchant
+func (s *Storage) UpdateUserRoles(merchantID, id uuid.UUID, roles []entity.Role) error {
+	return s.change(func() error {
+		return s.Storage.UpdateUserRoles(merchantID, id, roles)
+	})
+}
+
+// UpdateUserActive turns a user of a merchant on or off
+func (s *Storage) UpdateUserActive(merchantID, id uuid.UUID, active bool, now time.Time) error {
+	return s.change(func() error {
+		return s.Storage.UpdateUserActive(merchantID, id, active, now)
+	})
+}
+
+// UpdateUserPassword changes the password of a user of a merchant
+func (s *Storage) UpdateUserPassword(merchantID, id uuid.UUID, password string, now time.Time) error {
+	return s.change(func() error {
+		return s.Storage.UpdateUserPassword(merchantID, id, password, now)
+	})
+}
+
+// DeleteUser removes a user of a merchant
+func (s *Storage) DeleteUser(merchantID, id uuid.UUID) error {
+	return s.change(func() error {
+		return s.Storage.DeleteUser(merchantID, id)
+	})
+}
+
+// change applies fn in memory and writes the result to disk, if fn fails
+// nothing is written, and if the write fails fn is undone
+func (s *Storage) change(fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := s.Storage.Snapshot()
+	if err := fn(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s.Storage.Snapshot())
+	if err == nil {
+		err = writeFileAtomic(s.dir, stateFileName, data)
+	}
+	if err != nil {
+		s.Storage.Restore(before)
+		return err
+	}
+
+	return nil
+}
+
+// writeFileAtomic replaces dir/name with data in a way that a reader either
+// sees the old content or the new one, never a partial write
+func writeFileAtomic(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// syncDir makes a rename inside dir durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
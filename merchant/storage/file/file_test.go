@@ -0,0 +1,223 @@
+package file_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
+	"github.com/thiagolcmelo/payment-gateway/merchant/storage/file"
+	"github.com/thiagolcmelo/payment-gateway/merchant/storage/memory"
+)
+
+func newStorage(t *testing.T, dir string) *file.Storage {
+	t.Helper()
+
+	fs, err := file.NewFileStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+func newMerchant(t *testing.T, username string) entity.Merchant {
+	t.Helper()
+
+	merchant, err := entity.NewMerchant(username, "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return merchant
+}
+
+func TestFileStorage_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	fs := newStorage(t, dir)
+
+	id, err := fs.CreateMerchant(newMerchant(t, "username0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.UpdateMerchant(id, "new name", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.UpdateWebhook(id, "http://127.0.0.1:9000/", "0123456789abcdef"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.UpdatePassword(id, "password1", now); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := entity.NewRefreshToken(id, uuid.New(), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.CreateRefreshToken(token); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.RevokeAccessToken("jti-0", now.Add(time.Hour), now); err != nil {
+		t.Fatal(err)
+	}
+	k, key, err := entity.NewAPIKey(id, "checkout", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.CreateAPIKey(k); err != nil {
+		t.Fatal(err)
+	}
+	totp, err := entity.NewTOTP(id, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.EnrollTOTP(totp); err != nil {
+		t.Fatal(err)
+	}
+	code, _ := entity.TOTPCode(totp.Secret, entity.TOTPStep(now))
+	if err := fs.EnableTOTP(id, code, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	user, err := entity.NewUser(id, "finance", "secret0", []entity.Role{entity.RolePaymentsRead}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, err := fs.CreateUser(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := newStorage(t, dir)
+
+	merchant, err := reopened.ReadMerchant(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merchant.Name != "new name" || merchant.MaxQPS != 10 || merchant.WebhookURL != "http://127.0.0.1:9000/" {
+		t.Errorf("merchant was not restored, got %+v", merchant)
+	}
+	if got, err := reopened.FindMerchantID("username0", "password1"); err != nil || got != id {
+		t.Errorf("expected %v, got %v, %v", id, got, err)
+	}
+	if revoked, _ := reopened.AccessTokenRevoked("jti-0", id, uuid.Nil, now.Add(time.Minute)); !revoked {
+		t.Error("expected revoked access token to stay revoked")
+	}
+
+	n, _, _ := entity.NewRefreshToken(uuid.Nil, uuid.Nil, now.Add(time.Hour))
+	if got, err := reopened.RotateRefreshToken(token.Hash, n, now); err != nil || got.MerchantID != id {
+		t.Errorf("expected %v, got %v, %v", id, got.MerchantID, err)
+	}
+	found, err := reopened.FindAPIKey(k.Prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := found.Check(key); err != nil {
+		t.Errorf("expected api key to be restored, got %v", err)
+	}
+	if err := reopened.VerifyTOTP(id, code, now); !errors.Is(err, entity.ErrInvalidTOTPCode) {
+		t.Errorf("expected used totp code to stay used, got %v", err)
+	}
+	if got, err := reopened.FindUser("finance", "secret0"); err != nil || got.ID != userID {
+		t.Errorf("expected user %v to be restored, got %v, %v", userID, got.ID, err)
+	}
+}
+
+func TestFileStorage_ReopenReusedRefreshToken(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	fs := newStorage(t, dir)
+
+	id, err := fs.CreateMerchant(newMerchant(t, "username0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _, _ := entity.NewRefreshToken(id, uuid.New(), now.Add(time.Hour))
+	if err := fs.CreateRefreshToken(first); err != nil {
+		t.Fatal(err)
+	}
+	second, _, _ := entity.NewRefreshToken(uuid.Nil, uuid.Nil, now.Add(time.Hour))
+	if _, err := fs.RotateRefreshToken(first.Hash, second, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// the reuse fails, but the revoked family must be written anyway
+	third, _, _ := entity.NewRefreshToken(uuid.Nil, uuid.Nil, now.Add(time.Hour))
+	if _, err := fs.RotateRefreshToken(first.Hash, third, now); !errors.Is(err, entity.ErrRefreshTokenReused) {
+		t.Fatalf("expected %v, got %v", entity.ErrRefreshTokenReused, err)
+	}
+
+	reopened := newStorage(t, dir)
+	if _, err := reopened.RotateRefreshToken(second.Hash, third, now); !errors.Is(err, entity.ErrRefreshTokenRevoked) {
+		t.Errorf("expected %v, got %v", entity.ErrRefreshTokenRevoked, err)
+	}
+}
+
+func TestFileStorage_ReopenSeed(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	fs := newStorage(t, dir)
+
+	merchant := newMerchant(t, "username0")
+	merchant.ID = uuid.New()
+	if _, err := fs.SeedMerchant(merchant, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.UpdateActive(merchant.ID, false, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same seed on the next start does not undo the deactivation
+	reopened := newStorage(t, dir)
+	if changed, err := reopened.SeedMerchant(merchant, now); err != nil || changed {
+		t.Errorf("expected no change, got %v, %v", changed, err)
+	}
+	if got, _ := reopened.ReadMerchant(merchant.ID); got.Active {
+		t.Error("expected merchant to stay inactive")
+	}
+}
+
+func TestFileStorage_FailedChange(t *testing.T) {
+	dir := t.TempDir()
+	fs := newStorage(t, dir)
+
+	id, err := fs.CreateMerchant(newMerchant(t, "username0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.CreateMerchant(newMerchant(t, "username0")); !errors.Is(err, memory.ErrUsernameAlreadyExists) {
+		t.Errorf("expected %v, got %v", memory.ErrUsernameAlreadyExists, err)
+	}
+
+	// a change that cannot be written must not be visible, the directory is
+	// swapped for a regular file so that nothing can be created in it
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(dir) })
+
+	if err := fs.UpdateMerchant(id, "new name", 10); err == nil {
+		t.Fatal("expected the change to fail")
+	}
+	merchant, err := fs.ReadMerchant(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merchant.Name != "user" {
+		t.Errorf("expected the failed change to be undone, got name %q", merchant.Name)
+	}
+}
+
+func TestFileStorage_CorruptedState(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "merchants.state.json"), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := file.NewFileStorage(dir); err == nil {
+		t.Error("expected an error for a corrupted state file")
+	}
+}
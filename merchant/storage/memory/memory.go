@@ -1,8 +1,13 @@
 package memory
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
@@ -13,6 +18,20 @@ import (
 type Storage struct {
 	mechants  map[uuid.UUID]entity.Merchant
 	usernames map[string]uuid.UUID
+	// refresh tokens by hash, and access tokens revoked before they expire
+	refreshTokens map[string]entity.RefreshToken
+	revokedTokens map[string]time.Time
+	// api keys by id, and their ids by prefix
+	apiKeys        map[uuid.UUID]entity.APIKey
+	apiKeyPrefixes map[string]uuid.UUID
+	// second factors by merchant id
+	totp map[uuid.UUID]entity.TOTP
+	// users of merchants by id, and their ids by username, which no merchant
+	// can have too
+	users         map[uuid.UUID]entity.User
+	userUsernames map[string]uuid.UUID
+	// hash of the seed entry last applied to each merchant
+	seeds map[uuid.UUID]string
 	sync.Mutex
 }
 
@@ -25,13 +44,23 @@ var (
 	ErrUnknownMerchantUsername = errors.New("username does not match any merchant")
 	// ErrInvalidPassword must be used when a password is incorrect for a given username
 	ErrInvalidPassword = errors.New("invalid password")
+	// ErrDuplicateAPIKey must be used when an API key has the id or prefix of another one
+	ErrDuplicateAPIKey = errors.New("api key already exists")
 )
 
 // NewMemoryStorage is a factory for an in memory Storage
 func NewMemoryStorage() *Storage {
 	return &Storage{
-		mechants:  make(map[uuid.UUID]entity.Merchant),
-		usernames: make(map[string]uuid.UUID),
+		mechants:       make(map[uuid.UUID]entity.Merchant),
+		usernames:      make(map[string]uuid.UUID),
+		refreshTokens:  make(map[string]entity.RefreshToken),
+		revokedTokens:  make(map[string]time.Time),
+		apiKeys:        make(map[uuid.UUID]entity.APIKey),
+		apiKeyPrefixes: make(map[string]uuid.UUID),
+		totp:           make(map[uuid.UUID]entity.TOTP),
+		users:          make(map[uuid.UUID]entity.User),
+		userUsernames:  make(map[string]uuid.UUID),
+		seeds:          make(map[uuid.UUID]string),
 	}
 }
 
@@ -45,7 +74,7 @@ func (s *Storage) CreateMerchant(m entity.Merchant) (uuid.UUID, error) {
 		return uuid.Nil, err
 	}
 
-	if _, ok := s.usernames[m.Username]; ok {
+	if s.usernameTaken(m.Username) {
 		return uuid.Nil, ErrUsernameAlreadyExists
 	}
 
@@ -97,3 +126,821 @@ func (s *Storage) FindMerchantID(username string, password string) (uuid.UUID, e
 
 	return id, nil
 }
+
+// UpdateWebhook registers the webhook of a merchant, empty url and secret
+// remove it
+func (s *Storage) UpdateWebhook(id uuid.UUID, url, secret string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	merchant, ok := s.mechants[id]
+	if !ok {
+		return ErrUnknownMerchantID
+	}
+
+	err := merchant.SetWebhook(url, secret)
+	if err != nil {
+		return err
+	}
+	s.mechants[id] = merchant
+
+	return nil
+}
+
+// UpdateMerchant changes the name and the maximum QPS of a merchant
+func (s *Storage) UpdateMerchant(id uuid.UUID, name string, maxQPS int) error {
+	s.Lock()
+	defer s.Unlock()
+
+	merchant, ok := s.mechants[id]
+	if !ok {
+		return ErrUnknownMerchantID
+	}
+
+	merchant.Name = name
+	merchant.MaxQPS = maxQPS
+	err := merchant.Validate()
+	if err != nil {
+		return err
+	}
+	s.mechants[id] = merchant
+
+	return nil
+}
+
+// UpdatePlan puts a merchant on another plan
+func (s *Storage) UpdatePlan(id uuid.UUID, plan string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	merchant, ok := s.mechants[id]
+	if !ok {
+		return ErrUnknownMerchantID
+	}
+
+	merchant.Plan = plan
+	s.mechants[id] = merchant
+
+	return nil
+}
+
+// UpdatePassword changes the password of a merchant, and revokes all of its
+// tokens since they were given to whoever knew the previous one
+func (s *Storage) UpdatePassword(id uuid.UUID, password string, now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	merchant, ok := s.mechants[id]
+	if !ok {
+		return ErrUnknownMerchantID
+	}
+
+	err := merchant.SetPassword(password)
+	if err != nil {
+		return err
+	}
+	merchant.TokensValidAfter = now
+	s.mechants[id] = merchant
+	s.revokeMerchantTokens(id)
+
+	return nil
+}
+
+// ListMerchants returns every merchant, sorted by username
+func (s *Storage) ListMerchants() ([]entity.Merchant, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	merchants := make([]entity.Merchant, 0, len(s.mechants))
+	for _, merchant := range s.mechants {
+		merchants = append(merchants, merchant)
+	}
+	sort.Slice(merchants, func(i, j int) bool {
+		return merchants[i].Username < merchants[j].Username
+	})
+
+	return merchants, nil
+}
+
+// SeedMerchant makes the merchant with the id (or, without one, the username)
+// of m look like m, creating it if needed, and tells if anything changed.
+// An existing merchant is only updated when m differs from the seed entry
+// last applied to it, so changes made since through the admin rpcs are kept
+// until the entry is edited. A merchant no entry was applied to yet, e.g. one
+// kept from before seeds were tracked, is left as is and m recorded.
+// Tokens and webhook are kept, unless the seed sets a webhook, changes the
+// password or turns the merchant off, which revokes its tokens.
+func (s *Storage) SeedMerchant(m entity.Merchant, now time.Time) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	err := m.Validate()
+	if err != nil {
+		return false, err
+	}
+	hash, err := seedHash(m)
+	if err != nil {
+		return false, err
+	}
+
+	id := m.ID
+	if id == uuid.Nil {
+		id = s.usernames[m.Username]
+	}
+	current, ok := s.mechants[id]
+	if !ok {
+		if s.usernameTaken(m.Username) {
+			return false, ErrUsernameAlreadyExists
+		}
+		if m.ID == uuid.Nil {
+			m.ID = uuid.New()
+		}
+		s.mechants[m.ID] = m
+		s.usernames[m.Username] = m.ID
+		s.seeds[m.ID] = hash
+		return true, nil
+	}
+
+	applied, seeded := s.seeds[id]
+	if applied == hash {
+		return false, nil
+	}
+	if !seeded {
+		s.seeds[id] = hash
+		return false, nil
+	}
+
+	if other, taken := s.usernames[m.Username]; taken && other != id {
+		return false, ErrUsernameAlreadyExists
+	}
+	if _, taken := s.userUsernames[m.Username]; taken {
+		return false, ErrUsernameAlreadyExists
+	}
+
+	s.seeds[id] = hash
+	next := current
+	next.Username = m.Username
+	next.Password = m.Password
+	next.Name = m.Name
+	next.Active = m.Active
+	next.MaxQPS = m.MaxQPS
+	next.Plan = m.Plan
+	if m.WebhookURL != "" {
+		next.WebhookURL = m.WebhookURL
+		next.WebhookSecret = m.WebhookSecret
+	}
+	if next == current {
+		return false, nil
+	}
+
+	if next.Password != current.Password || (current.Active && !next.Active) {
+		next.TokensValidAfter = now
+		s.revokeMerchantTokens(id)
+	}
+	delete(s.usernames, current.Username)
+	s.usernames[next.Username] = id
+	s.mechants[id] = next
+
+	return true, nil
+}
+
+// seedHash identifies the content of a seed entry
+func seedHash(m entity.Merchant) (string, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// UpdateActive turns a merchant on or off, turning it off revokes all of its
+// access and refresh tokens
+func (s *Storage) UpdateActive(id uuid.UUID, active bool, now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	merchant, ok := s.mechants[id]
+	if !ok {
+		return ErrUnknownMerchantID
+	}
+
+	if merchant.Active && !active {
+		merchant.TokensValidAfter = now
+		s.revokeMerchantTokens(id)
+	}
+	merchant.Active = active
+	s.mechants[id] = merchant
+
+	return nil
+}
+
+// CreateRefreshToken keeps the first refresh token of a login
+func (s *Storage) CreateRefreshToken(t entity.RefreshToken) error {
+	s.Lock()
+	defer s.Unlock()
+
+	merchant, ok := s.mechants[t.MerchantID]
+	if !ok {
+		return ErrUnknownMerchantID
+	}
+	if !merchant.Active {
+		return entity.ErrMerchantInactive
+	}
+	if err := s.checkUser(t.MerchantID, t.UserID); err != nil {
+		return err
+	}
+
+	s.refreshTokens[t.Hash] = t
+	return nil
+}
+
+// RotateRefreshToken exchanges the refresh token with hash for next, which
+// joins its family and login, and returns next as kept, reusing a token
+// revokes the whole family
+func (s *Storage) RotateRefreshToken(hash string, next entity.RefreshToken, now time.Time) (entity.RefreshToken, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	t, ok := s.refreshTokens[hash]
+	if !ok {
+		return entity.RefreshToken{}, entity.ErrUnknownRefreshToken
+	}
+	if err := t.Check(now); err != nil {
+		if errors.Is(err, entity.ErrRefreshTokenReused) {
+			s.revokeFamily(t.Family)
+		}
+		return entity.RefreshToken{}, err
+	}
+	if merchant := s.mechants[t.MerchantID]; !merchant.Active {
+		return entity.RefreshToken{}, entity.ErrMerchantInactive
+	}
+	if err := s.checkUser(t.MerchantID, t.UserID); err != nil {
+		return entity.RefreshToken{}, err
+	}
+
+	t.Used = true
+	s.refreshTokens[hash] = t
+
+	next.MerchantID = t.MerchantID
+	next.UserID = t.UserID
+	next.Family = t.Family
+	s.refreshTokens[next.Hash] = next
+
+	// expired tokens are of no use, even to detect reuse
+	for h, old := range s.refreshTokens {
+		if !now.Before(old.ExpiresAt) {
+			delete(s.refreshTokens, h)
+		}
+	}
+	return next, nil
+}
+
+// RevokeRefreshToken revokes the family of the refresh token with hash, so a
+// logout also ends the tokens rotated from the same login
+func (s *Storage) RevokeRefreshToken(hash string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	t, ok := s.refreshTokens[hash]
+	if !ok {
+		return entity.ErrUnknownRefreshToken
+	}
+	s.revokeFamily(t.Family)
+	return nil
+}
+
+func (s *Storage) revokeMerchantTokens(id uuid.UUID) {
+	for hash, t := range s.refreshTokens {
+		if t.MerchantID == id {
+			t.Revoked = true
+			s.refreshTokens[hash] = t
+		}
+	}
+}
+
+func (s *Storage) revokeUserTokens(id uuid.UUID) {
+	for hash, t := range s.refreshTokens {
+		if t.UserID == id {
+			t.Revoked = true
+			s.refreshTokens[hash] = t
+		}
+	}
+}
+
+func (s *Storage) revokeFamily(family uuid.UUID) {
+	for hash, t := range s.refreshTokens {
+		if t.Family == family {
+			t.Revoked = true
+			s.refreshTokens[hash] = t
+		}
+	}
+}
+
+// RevokeAccessToken revokes the access token jti until it expires, there is
+// no point in keeping it afterwards
+func (s *Storage) RevokeAccessToken(jti string, expiresAt time.Time, now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for id, exp := range s.revokedTokens {
+		if !now.Before(exp) {
+			delete(s.revokedTokens, id)
+		}
+	}
+	if now.Before(expiresAt) {
+		s.revokedTokens[jti] = expiresAt
+	}
+	return nil
+}
+
+// AccessTokenRevoked tells if the access token jti of a merchant, issued at
+// issuedAt, can no longer be used. userID is the user of the merchant the
+// token was issued to, nil for the login of the merchant itself
+func (s *Storage) AccessTokenRevoked(jti string, merchantID, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	merchant, ok := s.mechants[merchantID]
+	if !ok {
+		return true, ErrUnknownMerchantID
+	}
+	if !merchant.Active || issuedBefore(issuedAt, merchant.TokensValidAfter) {
+		return true, nil
+	}
+	if userID != uuid.Nil {
+		user, ok := s.users[userID]
+		if !ok || user.MerchantID != merchantID || !user.Active || issuedBefore(issuedAt, user.TokensValidAfter) {
+			return true, nil
+		}
+	}
+	_, revoked := s.revokedTokens[jti]
+	return revoked, nil
+}
+
+// issuedBefore tells if a token issued at issuedAt predates validAfter. The
+// iat claim of tokens is in whole seconds, so a token issued in the same
+// second as validAfter, e.g. right after a password change, is not before it
+func issuedBefore(issuedAt, validAfter time.Time) bool {
+	return issuedAt.Before(validAfter.Truncate(time.Second))
+}
+
+// CreateAPIKey keeps a new API key of a merchant
+func (s *Storage) CreateAPIKey(k entity.APIKey) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.mechants[k.MerchantID]; !ok {
+		return ErrUnknownMerchantID
+	}
+	if err := entity.ValidateAPIKeyLabel(k.Label); err != nil {
+		return err
+	}
+	// both are random, a clash means something is very wrong
+	if _, ok := s.apiKeyPrefixes[k.Prefix]; ok {
+		return ErrDuplicateAPIKey
+	}
+	if _, ok := s.apiKeys[k.ID]; ok {
+		return ErrDuplicateAPIKey
+	}
+
+	s.apiKeys[k.ID] = k
+	s.apiKeyPrefixes[k.Prefix] = k.ID
+	return nil
+}
+
+// ListAPIKeys returns the API keys of a merchant, revoked ones included,
+// from the oldest to the newest
+func (s *Storage) ListAPIKeys(merchantID uuid.UUID) ([]entity.APIKey, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.mechants[merchantID]; !ok {
+		return nil, ErrUnknownMerchantID
+	}
+
+	keys := []entity.APIKey{}
+	for _, k := range s.apiKeys {
+		if k.MerchantID == merchantID {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// UpdateAPIKeyLabel changes the label of an API key of a merchant
+func (s *Storage) UpdateAPIKeyLabel(merchantID, id uuid.UUID, label string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	k, ok := s.apiKeys[id]
+	if !ok || k.MerchantID != merchantID {
+		return entity.ErrUnknownAPIKey
+	}
+	if err := entity.ValidateAPIKeyLabel(label); err != nil {
+		return err
+	}
+
+	k.Label = label
+	s.apiKeys[id] = k
+	return nil
+}
+
+// RevokeAPIKey revokes an API key of a merchant, revoking it again keeps the
+// first revocation time
+func (s *Storage) RevokeAPIKey(merchantID, id uuid.UUID, now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	k, ok := s.apiKeys[id]
+	if !ok || k.MerchantID != merchantID {
+		return entity.ErrUnknownAPIKey
+	}
+	if !k.Revoked() {
+		k.RevokedAt = now
+		s.apiKeys[id] = k
+	}
+	return nil
+}
+
+// FindAPIKey returns the API key with prefix
+func (s *Storage) FindAPIKey(prefix string) (entity.APIKey, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	id, ok := s.apiKeyPrefixes[prefix]
+	if !ok {
+		return entity.APIKey{}, entity.ErrUnknownAPIKey
+	}
+	return s.apiKeys[id], nil
+}
+
+// EnrollTOTP keeps a new TOTP secret of a merchant, replacing one that was
+// never confirmed
+func (s *Storage) EnrollTOTP(t entity.TOTP) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.mechants[t.MerchantID]; !ok {
+		return ErrUnknownMerchantID
+	}
+	if s.totp[t.MerchantID].Enabled {
+		return entity.ErrTOTPAlreadyEnabled
+	}
+
+	s.totp[t.MerchantID] = t
+	return nil
+}
+
+// ReadTOTP returns the TOTP of a merchant, enabled or not
+func (s *Storage) ReadTOTP(merchantID uuid.UUID) (entity.TOTP, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	t, ok := s.totp[merchantID]
+	if !ok {
+		return entity.TOTP{}, entity.ErrTOTPNotEnrolled
+	}
+	return t, nil
+}
+
+// EnableTOTP turns on the TOTP a merchant enrolled, if code is right, along
+// with the hashes of its recovery codes
+func (s *Storage) EnableTOTP(merchantID uuid.UUID, code string, recoveryCodes []string, now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	t, ok := s.totp[merchantID]
+	if !ok {
+		return entity.ErrTOTPNotEnrolled
+	}
+	if t.Enabled {
+		return entity.ErrTOTPAlreadyEnabled
+	}
+	// a recovery code can not confirm the enrolment
+	if len(code) != entity.TOTPDigits {
+		return entity.ErrInvalidTOTPCode
+	}
+	if err := t.Verify(code, now); err != nil {
+		return err
+	}
+
+	t.Enabled = true
+	t.RecoveryCodes = recoveryCodes
+	s.totp[merchantID] = t
+	return nil
+}
+
+// VerifyTOTP checks a TOTP or recovery code of a merchant with TOTP enabled,
+// a code is only accepted once
+func (s *Storage) VerifyTOTP(merchantID uuid.UUID, code string, now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	t, ok := s.totp[merchantID]
+	if !ok || !t.Enabled {
+		return entity.ErrTOTPNotEnrolled
+	}
+	if err := t.Verify(code, now); err != nil {
+		return err
+	}
+
+	s.totp[merchantID] = t
+	return nil
+}
+
+// DisableTOTP removes the TOTP of a merchant, if code is a TOTP or recovery
+// code of it
+func (s *Storage) DisableTOTP(merchantID uuid.UUID, code string, now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	t, ok := s.totp[merchantID]
+	if !ok || !t.Enabled {
+		return entity.ErrTOTPNotEnrolled
+	}
+	if err := t.Verify(code, now); err != nil {
+		return err
+	}
+
+	delete(s.totp, merchantID)
+	return nil
+}
+
+// usernameTaken tells if a merchant or a user has username, it must be
+// called with the lock held
+func (s *Storage) usernameTaken(username string) bool {
+	if _, ok := s.usernames[username]; ok {
+		return true
+	}
+	_, ok := s.userUsernames[username]
+	return ok
+}
+
+// checkUser asserts userID, unless nil, is an active user of merchantID, it
+// must be called with the lock held
+func (s *Storage) checkUser(merchantID, userID uuid.UUID) error {
+	if userID == uuid.Nil {
+		return nil
+	}
+	user, ok := s.users[userID]
+	if !ok || user.MerchantID != merchantID {
+		return entity.ErrUnknownUser
+	}
+	if !user.Active {
+		return entity.ErrUserInactive
+	}
+	return nil
+}
+
+// CreateUser stores a new user of a merchant, its username can not be the
+// one of another user or merchant
+func (s *Storage) CreateUser(u entity.User) (uuid.UUID, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := u.Validate(); err != nil {
+		return uuid.Nil, err
+	}
+	if _, ok := s.mechants[u.MerchantID]; !ok {
+		return uuid.Nil, ErrUnknownMerchantID
+	}
+	if s.usernameTaken(u.Username) {
+		return uuid.Nil, ErrUsernameAlreadyExists
+	}
+
+	if _, ok := s.users[u.ID]; ok || u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	s.users[u.ID] = u
+	s.userUsernames[u.Username] = u.ID
+
+	return u.ID, nil
+}
+
+// ReadUser retrieves a user of a merchant
+func (s *Storage) ReadUser(merchantID, id uuid.UUID) (entity.User, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.MerchantID != merchantID {
+		return entity.User{}, entity.ErrUnknownUser
+	}
+	return user, nil
+}
+
+// FindUser returns the user with username and password
+func (s *Storage) FindUser(username, password string) (entity.User, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	id, ok := s.userUsernames[username]
+	if !ok {
+		return entity.User{}, ErrUnknownMerchantUsername
+	}
+	user := s.users[id]
+
+	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	if err != nil {
+		return entity.User{}, ErrInvalidPassword
+	}
+
+	return user, nil
+}
+
+// ListUsers returns the users of a merchant sorted by username
+func (s *Storage) ListUsers(merchantID uuid.UUID) ([]entity.User, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.mechants[merchantID]; !ok {
+		return nil, ErrUnknownMerchantID
+	}
+
+	users := []entity.User{}
+	for _, u := range s.users {
+		if u.MerchantID == merchantID {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Username < users[j].Username
+	})
+
+	return users, nil
+}
+
+// UpdateUserRoles replaces the roles of a user of a merchant, tokens already
+// issued keep the old roles until they expire
+func (s *Storage) UpdateUserRoles(merchantID, id uuid.UUID, roles []entity.Role) error {
+	s.Lock()
+	defer s.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.MerchantID != merchantID {
+		return entity.ErrUnknownUser
+	}
+	if err := user.SetRoles(roles); err != nil {
+		return err
+	}
+
+	s.users[id] = user
+	return nil
+}
+
+// UpdateUserActive turns a user of a merchant on or off, turning it off
+// revokes all of its access and refresh tokens
+func (s *Storage) UpdateUserActive(merchantID, id uuid.UUID, active bool, now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.MerchantID != merchantID {
+		return entity.ErrUnknownUser
+	}
+
+	if user.Active && !active {
+		user.TokensValidAfter = now
+		s.revokeUserTokens(id)
+	}
+	user.Active = active
+	s.users[id] = user
+
+	return nil
+}
+
+// UpdateUserPassword changes the password of a user of a merchant, and
+// revokes its tokens
+func (s *Storage) UpdateUserPassword(merchantID, id uuid.UUID, password string, now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.MerchantID != merchantID {
+		return entity.ErrUnknownUser
+	}
+	if err := user.SetPassword(password); err != nil {
+		return err
+	}
+
+	user.TokensValidAfter = now
+	s.revokeUserTokens(id)
+	s.users[id] = user
+
+	return nil
+}
+
+// DeleteUser removes a user of a merchant, its tokens stop working with it
+func (s *Storage) DeleteUser(merchantID, id uuid.UUID) error {
+	s.Lock()
+	defer s.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.MerchantID != merchantID {
+		return entity.ErrUnknownUser
+	}
+
+	s.revokeUserTokens(id)
+	delete(s.userUsernames, user.Username)
+	delete(s.users, id)
+
+	return nil
+}
+
+// Snapshot is a copy of everything kept by a Storage
+type Snapshot struct {
+	Merchants     []entity.Merchant     `json:"merchants"`
+	RefreshTokens []entity.RefreshToken `json:"refresh_tokens"`
+	RevokedTokens map[string]time.Time  `json:"revoked_tokens"`
+	APIKeys       []entity.APIKey       `json:"api_keys"`
+	TOTP          []entity.TOTP         `json:"totp"`
+	Users         []entity.User         `json:"users"`
+	// Seeds has the hash of the seed entry last applied to each merchant
+	Seeds map[uuid.UUID]string `json:"seeds,omitempty"`
+}
+
+// Snapshot copies the merchants and tokens kept by the Storage
+func (s *Storage) Snapshot() Snapshot {
+	s.Lock()
+	defer s.Unlock()
+
+	snap := Snapshot{
+		Merchants:     make([]entity.Merchant, 0, len(s.mechants)),
+		RefreshTokens: make([]entity.RefreshToken, 0, len(s.refreshTokens)),
+		RevokedTokens: make(map[string]time.Time, len(s.revokedTokens)),
+		APIKeys:       make([]entity.APIKey, 0, len(s.apiKeys)),
+		TOTP:          make([]entity.TOTP, 0, len(s.totp)),
+		Users:         make([]entity.User, 0, len(s.users)),
+		Seeds:         make(map[uuid.UUID]string, len(s.seeds)),
+	}
+	for _, merchant := range s.mechants {
+		snap.Merchants = append(snap.Merchants, merchant)
+	}
+	for _, t := range s.refreshTokens {
+		snap.RefreshTokens = append(snap.RefreshTokens, t)
+	}
+	for jti, exp := range s.revokedTokens {
+		snap.RevokedTokens[jti] = exp
+	}
+	for _, k := range s.apiKeys {
+		snap.APIKeys = append(snap.APIKeys, k)
+	}
+	for _, t := range s.totp {
+		snap.TOTP = append(snap.TOTP, t)
+	}
+	for _, u := range s.users {
+		snap.Users = append(snap.Users, u)
+	}
+	for id, hash := range s.seeds {
+		snap.Seeds[id] = hash
+	}
+
+	return snap
+}
+
+// Restore replaces everything kept by the Storage with snap
+func (s *Storage) Restore(snap Snapshot) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.mechants = make(map[uuid.UUID]entity.Merchant, len(snap.Merchants))
+	s.usernames = make(map[string]uuid.UUID, len(snap.Merchants))
+	s.refreshTokens = make(map[string]entity.RefreshToken, len(snap.RefreshTokens))
+	s.revokedTokens = make(map[string]time.Time, len(snap.RevokedTokens))
+	s.apiKeys = make(map[uuid.UUID]entity.APIKey, len(snap.APIKeys))
+	s.apiKeyPrefixes = make(map[string]uuid.UUID, len(snap.APIKeys))
+	s.totp = make(map[uuid.UUID]entity.TOTP, len(snap.TOTP))
+	s.users = make(map[uuid.UUID]entity.User, len(snap.Users))
+	s.userUsernames = make(map[string]uuid.UUID, len(snap.Users))
+	s.seeds = make(map[uuid.UUID]string, len(snap.Seeds))
+
+	for _, merchant := range snap.Merchants {
+		s.mechants[merchant.ID] = merchant
+		s.usernames[merchant.Username] = merchant.ID
+	}
+	for _, t := range snap.RefreshTokens {
+		s.refreshTokens[t.Hash] = t
+	}
+	for jti, exp := range snap.RevokedTokens {
+		s.revokedTokens[jti] = exp
+	}
+	for _, k := range snap.APIKeys {
+		s.apiKeys[k.ID] = k
+		s.apiKeyPrefixes[k.Prefix] = k.ID
+	}
+	for _, t := range snap.TOTP {
+		s.totp[t.MerchantID] = t
+	}
+	for _, u := range snap.Users {
+		s.users[u.ID] = u
+		s.userUsernames[u.Username] = u.ID
+	}
+	for id, hash := range snap.Seeds {
+		s.seeds[id] = hash
+	}
+}
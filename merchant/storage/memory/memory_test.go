@@ -2,7 +2,10 @@ package memory_test
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
@@ -314,3 +317,811 @@ func TestMemoryStorage_FindMerchantID(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryStorage_UpdateWebhook(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validId, err := ms.CreateMerchant(merchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName    string
+		id          uuid.UUID
+		url         string
+		secret      string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{testName: "register", id: validId, url: "https://merchant.example/webhooks", secret: "0123456789abcdef", expectedErr: nil},
+		{testName: "invalid_url", id: validId, url: "merchant.example", secret: "0123456789abcdef", expectedErr: entity.ErrInvalidWebhookURL},
+		{testName: "unknown_merchant", id: uuid.New(), url: "https://merchant.example/webhooks", secret: "0123456789abcdef", expectedErr: memory.ErrUnknownMerchantID},
+		{testName: "remove", id: validId, url: "", secret: "", expectedErr: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			before, _ := ms.ReadMerchant(validId)
+
+			err := ms.UpdateWebhook(tc.id, tc.url, tc.secret)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			m, err := ms.ReadMerchant(validId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedURL := tc.url
+			if tc.expectedErr != nil {
+				expectedURL = before.WebhookURL
+			}
+			if m.WebhookURL != expectedURL {
+				t.Errorf("expected WebhookURL=%s, got %s", expectedURL, m.WebhookURL)
+			}
+		})
+	}
+}
+
+func TestMemoryStorage_RotateRefreshToken(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	now := time.Now()
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ms.CreateMerchant(merchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(expiresAt time.Time) string {
+		first, token, err := entity.NewRefreshToken(id, uuid.New(), expiresAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ms.CreateRefreshToken(first); err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	next := func() (entity.RefreshToken, string) {
+		n, token, err := entity.NewRefreshToken(uuid.Nil, uuid.Nil, now.Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n, token
+	}
+
+	// rotated once, so using it again is a reuse
+	used := login(now.Add(time.Hour))
+	n, rotated := next()
+	if _, err := ms.RotateRefreshToken(entity.HashRefreshToken(used), n, now); err != nil {
+		t.Fatal(err)
+	}
+
+	logout := login(now.Add(time.Hour))
+	if err := ms.RevokeRefreshToken(entity.HashRefreshToken(logout)); err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName    string
+		token       string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		// first, a successful rotation forgets expired tokens
+		{testName: "expired", token: login(now), expectedErr: entity.ErrRefreshTokenExpired},
+		{testName: "valid", token: login(now.Add(time.Hour)), expectedErr: nil},
+		{testName: "unknown", token: "unknown", expectedErr: entity.ErrUnknownRefreshToken},
+		{testName: "revoked", token: logout, expectedErr: entity.ErrRefreshTokenRevoked},
+		{testName: "reused", token: used, expectedErr: entity.ErrRefreshTokenReused},
+		// reusing a token revoked the one that replaced it too
+		{testName: "rotated_from_reused", token: rotated, expectedErr: entity.ErrRefreshTokenRevoked},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			n, _ := next()
+			kept, err := ms.RotateRefreshToken(entity.HashRefreshToken(tc.token), n, now)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if err == nil && kept.MerchantID != id {
+				t.Errorf("expected merchant %v, got %v", id, kept.MerchantID)
+			}
+		})
+	}
+}
+
+func TestMemoryStorage_UpdateActive(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	now := time.Now()
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ms.CreateMerchant(merchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, _, err := entity.NewRefreshToken(id, uuid.New(), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.CreateRefreshToken(first); err != nil {
+		t.Fatal(err)
+	}
+
+	issuedBefore := now.Add(-time.Minute)
+	if revoked, err := ms.AccessTokenRevoked("jti-0", id, uuid.Nil, issuedBefore); err != nil || revoked {
+		t.Fatalf("expected token to be valid, got %v, %v", revoked, err)
+	}
+
+	if err := ms.UpdateActive(id, false, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.UpdateActive(uuid.New(), false, now); !errors.Is(err, memory.ErrUnknownMerchantID) {
+		t.Errorf("expected %v, got %v", memory.ErrUnknownMerchantID, err)
+	}
+
+	if revoked, _ := ms.AccessTokenRevoked("jti-0", id, uuid.Nil, issuedBefore); !revoked {
+		t.Error("expected access token to be revoked while inactive")
+	}
+	n, _, _ := entity.NewRefreshToken(uuid.Nil, uuid.Nil, now.Add(time.Hour))
+	if _, err := ms.RotateRefreshToken(first.Hash, n, now); !errors.Is(err, entity.ErrRefreshTokenRevoked) {
+		t.Errorf("expected %v, got %v", entity.ErrRefreshTokenRevoked, err)
+	}
+	again, _, _ := entity.NewRefreshToken(id, uuid.New(), now.Add(time.Hour))
+	if err := ms.CreateRefreshToken(again); !errors.Is(err, entity.ErrMerchantInactive) {
+		t.Errorf("expected %v, got %v", entity.ErrMerchantInactive, err)
+	}
+
+	// tokens issued before the deactivation stay revoked once reactivated
+	if err := ms.UpdateActive(id, true, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := ms.AccessTokenRevoked("jti-0", id, uuid.Nil, issuedBefore); !revoked {
+		t.Error("expected access token issued before deactivation to stay revoked")
+	}
+	if revoked, _ := ms.AccessTokenRevoked("jti-1", id, uuid.Nil, now.Add(2*time.Minute)); revoked {
+		t.Error("expected access token issued after reactivation to be valid")
+	}
+
+	// iat is in whole seconds, a token issued in the second of the
+	// reactivation comes right after it
+	reactivatedAt := now.Add(3*time.Minute).Truncate(time.Second).Add(500 * time.Millisecond)
+	if err := ms.UpdateActive(id, false, reactivatedAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.UpdateActive(id, true, reactivatedAt); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := ms.AccessTokenRevoked("jti-2", id, uuid.Nil, reactivatedAt.Truncate(time.Second)); revoked {
+		t.Error("expected access token issued in the second of the reactivation to be valid")
+	}
+	if revoked, _ := ms.AccessTokenRevoked("jti-2", id, uuid.Nil, reactivatedAt.Truncate(time.Second).Add(-time.Second)); !revoked {
+		t.Error("expected access token issued the second before the reactivation to be revoked")
+	}
+}
+
+func TestMemoryStorage_RevokeAccessToken(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	now := time.Now()
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ms.CreateMerchant(merchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ms.RevokeAccessToken("jti-0", now.Add(time.Minute), now); err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		testName        string
+		jti             string
+		merchantID      uuid.UUID
+		expectedRevoked bool
+		expectedErr     error
+	}
+
+	testCases := []testCase{
+		{testName: "revoked", jti: "jti-0", merchantID: id, expectedRevoked: true, expectedErr: nil},
+		{testName: "not_revoked", jti: "jti-1", merchantID: id, expectedRevoked: false, expectedErr: nil},
+		{testName: "unknown_merchant", jti: "jti-1", merchantID: uuid.New(), expectedRevoked: true, expectedErr: memory.ErrUnknownMerchantID},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			revoked, err := ms.AccessTokenRevoked(tc.jti, tc.merchantID, uuid.Nil, now)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if revoked != tc.expectedRevoked {
+				t.Errorf("expected revoked=%v, got %v", tc.expectedRevoked, revoked)
+			}
+		})
+	}
+}
+
+func TestMemoryStorage_UpdateMerchant(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ms.CreateMerchant(merchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		testName    string
+		id          uuid.UUID
+		name        string
+		maxQPS      int
+		expectedErr error
+	}{
+		{"valid update", id, "new name", 10, nil},
+		{"unknown merchant", uuid.New(), "new name", 10, memory.ErrUnknownMerchantID},
+		{"empty name", id, "", 10, entity.ErrNameEmpty},
+		{"negative qps", id, "new name", -1, entity.ErrMaxQPSCannotBeNegative},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := ms.UpdateMerchant(tc.id, tc.name, tc.maxQPS)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+
+	// failed updates must not change the merchant
+	got, err := ms.ReadMerchant(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "new name" || got.MaxQPS != 10 {
+		t.Errorf("expected name %q and qps %d, got %q and %d", "new name", 10, got.Name, got.MaxQPS)
+	}
+}
+
+func TestMemoryStorage_UpdatePlan(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ms.CreateMerchant(merchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ms.UpdatePlan(uuid.New(), "strict"); !errors.Is(err, memory.ErrUnknownMerchantID) {
+		t.Errorf("expected %v, got %v", memory.ErrUnknownMerchantID, err)
+	}
+	if err := ms.UpdatePlan(id, "strict"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ms.ReadMerchant(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Plan != "strict" {
+		t.Errorf("expected plan %q, got %q", "strict", got.Plan)
+	}
+	// a seed entry edited to have no plan puts the merchant back on the
+	// default one
+	if _, err := ms.SeedMerchant(got, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	got.Plan = ""
+	if _, err := ms.SeedMerchant(got, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ms.ReadMerchant(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Plan != "" {
+		t.Errorf("expected the default plan, got %q", got.Plan)
+	}
+}
+
+func TestMemoryStorage_UpdatePassword(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	now := time.Now()
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ms.CreateMerchant(merchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := entity.NewRefreshToken(id, uuid.New(), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.CreateRefreshToken(token); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ms.UpdatePassword(uuid.New(), "password1", now); !errors.Is(err, memory.ErrUnknownMerchantID) {
+		t.Errorf("expected %v, got %v", memory.ErrUnknownMerchantID, err)
+	}
+	if err := ms.UpdatePassword(id, "", now); !errors.Is(err, entity.ErrUsernameOrPasswordEmpty) {
+		t.Errorf("expected %v, got %v", entity.ErrUsernameOrPasswordEmpty, err)
+	}
+	if err := ms.UpdatePassword(id, "password1", now); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ms.FindMerchantID("username0", "password0"); !errors.Is(err, memory.ErrInvalidPassword) {
+		t.Errorf("expected %v, got %v", memory.ErrInvalidPassword, err)
+	}
+	if got, err := ms.FindMerchantID("username0", "password1"); err != nil || got != id {
+		t.Errorf("expected %v, got %v, %v", id, got, err)
+	}
+
+	if revoked, _ := ms.AccessTokenRevoked("jti-0", id, uuid.Nil, now.Add(-time.Minute)); !revoked {
+		t.Error("expected access token issued before the change to be revoked")
+	}
+	n, _, _ := entity.NewRefreshToken(uuid.Nil, uuid.Nil, now.Add(time.Hour))
+	if _, err := ms.RotateRefreshToken(token.Hash, n, now); !errors.Is(err, entity.ErrRefreshTokenRevoked) {
+		t.Errorf("expected %v, got %v", entity.ErrRefreshTokenRevoked, err)
+	}
+}
+
+func TestMemoryStorage_ListMerchants(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+
+	merchants, err := ms.ListMerchants()
+	if err != nil || len(merchants) != 0 {
+		t.Fatalf("expected no merchants, got %v, %v", merchants, err)
+	}
+
+	for _, username := range []string{"username1", "username0", "username2"} {
+		merchant, err := entity.NewMerchant(username, "password", "user", true, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ms.CreateMerchant(merchant); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	merchants, err = ms.ListMerchants()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merchants) != 3 {
+		t.Fatalf("expected 3 merchants, got %d", len(merchants))
+	}
+	for i, m := range merchants {
+		if expected := fmt.Sprintf("username%d", i); m.Username != expected {
+			t.Errorf("expected %s at %d, got %s", expected, i, m.Username)
+		}
+	}
+}
+
+func TestMemoryStorage_SeedMerchant(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	now := time.Now()
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merchant.ID = uuid.New()
+
+	if changed, err := ms.SeedMerchant(merchant, now); err != nil || !changed {
+		t.Fatalf("expected merchant to be created, got %v, %v", changed, err)
+	}
+	if changed, err := ms.SeedMerchant(merchant, now); err != nil || changed {
+		t.Errorf("expected no change, got %v, %v", changed, err)
+	}
+	if err := ms.UpdateWebhook(merchant.ID, "http://127.0.0.1:9000/", "0123456789abcdef"); err != nil {
+		t.Fatal(err)
+	}
+	token, _, _ := entity.NewRefreshToken(merchant.ID, uuid.New(), now.Add(time.Hour))
+	if err := ms.CreateRefreshToken(token); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new name keeps the webhook and the tokens
+	renamed := merchant
+	renamed.Name = "new name"
+	if changed, err := ms.SeedMerchant(renamed, now); err != nil || !changed {
+		t.Fatalf("expected merchant to change, got %v, %v", changed, err)
+	}
+	got, _ := ms.ReadMerchant(merchant.ID)
+	if got.Name != "new name" || got.WebhookURL == "" {
+		t.Errorf("expected name to change and webhook to stay, got %+v", got)
+	}
+	if revoked, _ := ms.AccessTokenRevoked("jti-0", merchant.ID, uuid.Nil, now.Add(-time.Minute)); revoked {
+		t.Error("expected access token to stay valid")
+	}
+
+	// a new password revokes the tokens
+	other, _ := entity.NewMerchant("username0", "password1", "new name", true, 100)
+	other.ID = merchant.ID
+	if _, err := ms.SeedMerchant(other, now); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := ms.AccessTokenRevoked("jti-0", merchant.ID, uuid.Nil, now.Add(-time.Minute)); !revoked {
+		t.Error("expected access token to be revoked")
+	}
+
+	// changes made through the admin rpcs survive the same entry
+	if err := ms.UpdatePassword(merchant.ID, "password2", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.UpdateActive(merchant.ID, false, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.UpdatePlan(merchant.ID, "gold"); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := ms.SeedMerchant(other, now); err != nil || changed {
+		t.Errorf("expected no change, got %v, %v", changed, err)
+	}
+	got, _ = ms.ReadMerchant(merchant.ID)
+	if got.Active || got.Plan != "gold" || got.Password == other.Password {
+		t.Errorf("expected admin changes to be kept, got %+v", got)
+	}
+
+	// until the entry is edited
+	edited := other
+	edited.Name = "edited name"
+	if changed, err := ms.SeedMerchant(edited, now); err != nil || !changed {
+		t.Fatalf("expected merchant to change, got %v, %v", changed, err)
+	}
+	got, _ = ms.ReadMerchant(merchant.ID)
+	if !got.Active || got.Plan != "" || got.Password != other.Password || got.Name != "edited name" {
+		t.Errorf("expected seed to be applied, got %+v", got)
+	}
+
+	taken, _ := entity.NewMerchant("username0", "password0", "user", true, 100)
+	taken.ID = uuid.New()
+	if _, err := ms.SeedMerchant(taken, now); !errors.Is(err, memory.ErrUsernameAlreadyExists) {
+		t.Errorf("expected %v, got %v", memory.ErrUsernameAlreadyExists, err)
+	}
+	if _, err := ms.SeedMerchant(entity.Merchant{Username: "username1"}, now); !errors.Is(err, entity.ErrUsernameOrPasswordEmpty) {
+		t.Errorf("expected %v, got %v", entity.ErrUsernameOrPasswordEmpty, err)
+	}
+}
+
+func TestMemoryStorage_SeedMerchantUntracked(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	now := time.Now()
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ms.CreateMerchant(merchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a merchant no entry was applied to keeps what it has
+	seeded := merchant
+	seeded.ID = id
+	seeded.Name = "seed name"
+	if changed, err := ms.SeedMerchant(seeded, now); err != nil || changed {
+		t.Errorf("expected no change, got %v, %v", changed, err)
+	}
+	if got, _ := ms.ReadMerchant(id); got.Name != "user" {
+		t.Errorf("expected name to be kept, got %q", got.Name)
+	}
+
+	// the entry is recorded, and its edits are applied
+	restored := memory.NewMemoryStorage()
+	restored.Restore(ms.Snapshot())
+	seeded.Name = "edited name"
+	if changed, err := restored.SeedMerchant(seeded, now); err != nil || !changed {
+		t.Fatalf("expected merchant to change, got %v, %v", changed, err)
+	}
+	if got, _ := restored.ReadMerchant(id); got.Name != "edited name" {
+		t.Errorf("expected name to change, got %q", got.Name)
+	}
+}
+
+func TestMemoryStorage_APIKeys(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	now := time.Now()
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ms.CreateMerchant(merchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, _, _ := entity.NewAPIKey(id, "first", now)
+	second, _, _ := entity.NewAPIKey(id, "second", now.Add(time.Second))
+	for _, k := range []entity.APIKey{second, first} {
+		if err := ms.CreateAPIKey(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ms.CreateAPIKey(first); !errors.Is(err, memory.ErrDuplicateAPIKey) {
+		t.Errorf("expected %v, got %v", memory.ErrDuplicateAPIKey, err)
+	}
+	orphan, _, _ := entity.NewAPIKey(uuid.New(), "", now)
+	if err := ms.CreateAPIKey(orphan); !errors.Is(err, memory.ErrUnknownMerchantID) {
+		t.Errorf("expected %v, got %v", memory.ErrUnknownMerchantID, err)
+	}
+
+	keys, err := ms.ListAPIKeys(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].ID != first.ID || keys[1].ID != second.ID {
+		t.Errorf("expected keys from oldest to newest, got %+v", keys)
+	}
+
+	other := uuid.New()
+	testCases := []struct {
+		testName    string
+		merchantID  uuid.UUID
+		id          uuid.UUID
+		label       string
+		expectedErr error
+	}{
+		{"own key", id, first.ID, "renamed", nil},
+		{"key of another merchant", other, first.ID, "renamed", entity.ErrUnknownAPIKey},
+		{"unknown key", id, uuid.New(), "renamed", entity.ErrUnknownAPIKey},
+		{"long label", id, first.ID, strings.Repeat("a", entity.MaxAPIKeyLabelLength+1), entity.ErrAPIKeyLabelTooLong},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := ms.UpdateAPIKeyLabel(tc.merchantID, tc.id, tc.label)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+
+	if err := ms.RevokeAPIKey(other, first.ID, now); !errors.Is(err, entity.ErrUnknownAPIKey) {
+		t.Errorf("expected %v, got %v", entity.ErrUnknownAPIKey, err)
+	}
+	if err := ms.RevokeAPIKey(id, first.ID, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.RevokeAPIKey(id, first.ID, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := ms.FindAPIKey(first.Prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Label != "renamed" || !found.RevokedAt.Equal(now) {
+		t.Errorf("expected renamed key revoked at %v, got %+v", now, found)
+	}
+	if _, err := ms.FindAPIKey("pk_live_000000000000"); !errors.Is(err, entity.ErrUnknownAPIKey) {
+		t.Errorf("expected %v, got %v", entity.ErrUnknownAPIKey, err)
+	}
+}
+
+func TestMemoryStorage_TOTP(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	now := time.Unix(1700000000, 0)
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ms.CreateMerchant(merchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orphan, _ := entity.NewTOTP(uuid.New(), now)
+	if err := ms.EnrollTOTP(orphan); !errors.Is(err, memory.ErrUnknownMerchantID) {
+		t.Errorf("expected %v, got %v", memory.ErrUnknownMerchantID, err)
+	}
+	if err := ms.VerifyTOTP(id, "000000", now); !errors.Is(err, entity.ErrTOTPNotEnrolled) {
+		t.Errorf("expected %v, got %v", entity.ErrTOTPNotEnrolled, err)
+	}
+
+	totp, err := entity.NewTOTP(id, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.EnrollTOTP(totp); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.VerifyTOTP(id, "000000", now); !errors.Is(err, entity.ErrTOTPNotEnrolled) {
+		t.Errorf("expected a pending totp to be refused with %v, got %v", entity.ErrTOTPNotEnrolled, err)
+	}
+
+	codes, hashes, err := entity.NewRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.EnableTOTP(id, codes[0], hashes, now); !errors.Is(err, entity.ErrInvalidTOTPCode) {
+		t.Errorf("expected %v, got %v", entity.ErrInvalidTOTPCode, err)
+	}
+	code, _ := entity.TOTPCode(totp.Secret, entity.TOTPStep(now))
+	if err := ms.EnableTOTP(id, code, hashes, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.EnrollTOTP(totp); !errors.Is(err, entity.ErrTOTPAlreadyEnabled) {
+		t.Errorf("expected %v, got %v", entity.ErrTOTPAlreadyEnabled, err)
+	}
+
+	later := now.Add(entity.TOTPPeriod)
+	next, _ := entity.TOTPCode(totp.Secret, entity.TOTPStep(later))
+	testCases := []struct {
+		testName    string
+		code        string
+		expectedErr error
+	}{
+		{"code used to enable", code, entity.ErrInvalidTOTPCode},
+		{"next code", next, nil},
+		{"next code again", next, entity.ErrInvalidTOTPCode},
+		{"recovery code", codes[1], nil},
+		{"recovery code again", codes[1], entity.ErrInvalidTOTPCode},
+		{"wrong code", "12345", entity.ErrInvalidTOTPCode},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := ms.VerifyTOTP(id, tc.code, later)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+
+	if err := ms.DisableTOTP(id, "wrong", later); !errors.Is(err, entity.ErrInvalidTOTPCode) {
+		t.Errorf("expected %v, got %v", entity.ErrInvalidTOTPCode, err)
+	}
+	if err := ms.DisableTOTP(id, codes[2], later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.ReadTOTP(id); !errors.Is(err, entity.ErrTOTPNotEnrolled) {
+		t.Errorf("expected %v, got %v", entity.ErrTOTPNotEnrolled, err)
+	}
+}
+
+func TestMemoryStorage_Users(t *testing.T) {
+	ms := memory.NewMemoryStorage()
+	now := time.Now()
+
+	merchant, err := entity.NewMerchant("username0", "password0", "user", true, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ms.CreateMerchant(merchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newUser := func(merchantID uuid.UUID, username string) entity.User {
+		u, err := entity.NewUser(merchantID, username, "secret0", []entity.Role{entity.RolePaymentsRead}, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	createCases := []struct {
+		testName    string
+		user        entity.User
+		expectedErr error
+	}{
+		{"valid", newUser(id, "finance"), nil},
+		{"username of a user", newUser(id, "finance"), memory.ErrUsernameAlreadyExists},
+		{"username of a merchant", newUser(id, "username0"), memory.ErrUsernameAlreadyExists},
+		{"unknown merchant", newUser(uuid.New(), "checkout"), memory.ErrUnknownMerchantID},
+		{"no roles", entity.User{MerchantID: id, Username: "checkout", Password: "hash"}, entity.ErrNoRoles},
+	}
+	for _, tc := range createCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			_, err := ms.CreateUser(tc.user)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+
+	if _, err := ms.CreateMerchant(entity.Merchant{Username: "finance", Password: "hash", Name: "other"}); !errors.Is(err, memory.ErrUsernameAlreadyExists) {
+		t.Errorf("expected a merchant to be refused the username of a user, got %v", err)
+	}
+
+	user, err := ms.FindUser("finance", "secret0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.FindUser("finance", "wrong"); !errors.Is(err, memory.ErrInvalidPassword) {
+		t.Errorf("expected %v, got %v", memory.ErrInvalidPassword, err)
+	}
+	if _, err := ms.ReadUser(uuid.New(), user.ID); !errors.Is(err, entity.ErrUnknownUser) {
+		t.Errorf("expected the user to be hidden from other merchants, got %v", err)
+	}
+	if err := ms.UpdateUserRoles(id, user.ID, []entity.Role{"owner"}); !errors.Is(err, entity.ErrInvalidRole) {
+		t.Errorf("expected %v, got %v", entity.ErrInvalidRole, err)
+	}
+	if err := ms.UpdateUserRoles(id, user.ID, []entity.Role{entity.RoleRefunds}); err != nil {
+		t.Fatal(err)
+	}
+
+	token, _, _ := entity.NewRefreshToken(id, uuid.New(), now.Add(time.Hour))
+	token.UserID = user.ID
+	if err := ms.CreateRefreshToken(token); err != nil {
+		t.Fatal(err)
+	}
+	n, _, _ := entity.NewRefreshToken(uuid.Nil, uuid.Nil, now.Add(time.Hour))
+	kept, err := ms.RotateRefreshToken(token.Hash, n, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept.UserID != user.ID {
+		t.Errorf("expected the rotated token to keep user %v, got %v", user.ID, kept.UserID)
+	}
+
+	if revoked, _ := ms.AccessTokenRevoked("jti-0", id, user.ID, now.Add(-time.Minute)); revoked {
+		t.Error("expected the token of an active user to be valid")
+	}
+	if err := ms.UpdateUserActive(id, user.ID, false, now); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := ms.AccessTokenRevoked("jti-0", id, user.ID, now.Add(-time.Minute)); !revoked {
+		t.Error("expected the tokens of an inactive user to be revoked")
+	}
+	n, _, _ = entity.NewRefreshToken(uuid.Nil, uuid.Nil, now.Add(time.Hour))
+	if _, err := ms.RotateRefreshToken(kept.Hash, n, now); !errors.Is(err, entity.ErrRefreshTokenRevoked) {
+		t.Errorf("expected %v, got %v", entity.ErrRefreshTokenRevoked, err)
+	}
+	if err := ms.CreateRefreshToken(token); !errors.Is(err, entity.ErrUserInactive) {
+		t.Errorf("expected %v, got %v", entity.ErrUserInactive, err)
+	}
+
+	if _, err := ms.CreateUser(newUser(id, "checkout")); err != nil {
+		t.Fatal(err)
+	}
+	users, err := ms.ListUsers(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Username != "checkout" || users[1].Username != "finance" {
+		t.Errorf("expected users sorted by username, got %+v", users)
+	}
+
+	if err := ms.DeleteUser(id, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.FindUser("finance", "secret0"); !errors.Is(err, memory.ErrUnknownMerchantUsername) {
+		t.Errorf("expected %v, got %v", memory.ErrUnknownMerchantUsername, err)
+	}
+	if revoked, _ := ms.AccessTokenRevoked("jti-1", id, user.ID, now); !revoked {
+		t.Error("expected the tokens of a deleted user to be revoked")
+	}
+}
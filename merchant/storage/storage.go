@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	entity "github.com/thiagolcmelo/payment-gateway/merchant/entities"
 )
@@ -10,4 +12,34 @@ type Storage interface {
 	CreateMerchant(entity.Merchant) (uuid.UUID, error)
 	ReadMerchant(uuid.UUID) (entity.Merchant, error)
 	FindMerchantID(string, string) (uuid.UUID, error)
+	UpdateWebhook(id uuid.UUID, url, secret string) error
+	UpdateMerchant(id uuid.UUID, name string, maxQPS int) error
+	UpdatePlan(id uuid.UUID, plan string) error
+	UpdatePassword(id uuid.UUID, password string, now time.Time) error
+	ListMerchants() ([]entity.Merchant, error)
+	SeedMerchant(m entity.Merchant, now time.Time) (bool, error)
+	UpdateActive(id uuid.UUID, active bool, now time.Time) error
+	CreateRefreshToken(entity.RefreshToken) error
+	RotateRefreshToken(hash string, next entity.RefreshToken, now time.Time) (entity.RefreshToken, error)
+	RevokeRefreshToken(hash string) error
+	RevokeAccessToken(jti string, expiresAt time.Time, now time.Time) error
+	AccessTokenRevoked(jti string, merchantID, userID uuid.UUID, issuedAt time.Time) (bool, error)
+	CreateAPIKey(entity.APIKey) error
+	ListAPIKeys(merchantID uuid.UUID) ([]entity.APIKey, error)
+	UpdateAPIKeyLabel(merchantID, id uuid.UUID, label string) error
+	RevokeAPIKey(merchantID, id uuid.UUID, now time.Time) error
+	FindAPIKey(prefix string) (entity.APIKey, error)
+	EnrollTOTP(entity.TOTP) error
+	ReadTOTP(merchantID uuid.UUID) (entity.TOTP, error)
+	EnableTOTP(merchantID uuid.UUID, code string, recoveryCodes []string, now time.Time) error
+	VerifyTOTP(merchantID uuid.UUID, code string, now time.Time) error
+	DisableTOTP(merchantID uuid.UUID, code string, now time.Time) error
+	CreateUser(entity.User) (uuid.UUID, error)
+	ReadUser(merchantID, id uuid.UUID) (entity.User, error)
+	FindUser(username, password string) (entity.User, error)
+	ListUsers(merchantID uuid.UUID) ([]entity.User, error)
+	UpdateUserRoles(merchantID, id uuid.UUID, roles []entity.Role) error
+	UpdateUserActive(merchantID, id uuid.UUID, active bool, now time.Time) error
+	UpdateUserPassword(merchantID, id uuid.UUID, password string, now time.Time) error
+	DeleteUser(merchantID, id uuid.UUID) error
 }
@@ -0,0 +1,200 @@
+// Package cluster lets rate limiter replicas share the budget of each
+// merchant. Every merchant is owned by one replica, picked by consistent
+// hashing over a static list of peers, and the other replicas forward its
+// requests to the owner instead of counting them themselves.
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// ErrSelfNotPeer must be used when the address of this replica is not in
+// the list of peers
+var ErrSelfNotPeer = errors.New("own address is not one of the peers")
+
+// ErrUnknownPeer must be used when forwarding to an address that is not a
+// peer
+var ErrUnknownPeer = errors.New("unknown peer")
+
+// forwardedKey marks requests forwarded by a peer, which the receiving
+// replica must answer itself even if it disagrees on the owner, so requests
+// never bounce between peers
+const forwardedKey = "x-ratelimiter-forwarded"
+
+// Cluster routes the requests of each merchant to the replica owning it
+type Cluster struct {
+	self    string
+	ring    *Ring
+	timeout time.Duration
+	conns   map[string]*grpc.ClientConn
+	clients map[string]pb.RateLimiterServiceClient
+}
+
+// New is a factory for the Cluster of the replica reachable at self, which
+// must be one of peers. Forwarded requests give up after timeout, 0 meaning
+// the deadline of the request
+func New(self string, peers []string, timeout time.Duration) (*Cluster, error) {
+	ring, err := NewRing(peers)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{
+		self:    self,
+		ring:    ring,
+		timeout: timeout,
+		conns:   make(map[string]*grpc.ClientConn),
+		clients: make(map[string]pb.RateLimiterServiceClient),
+	}
+	isPeer := false
+	for _, peer := range ring.Peers() {
+		if peer == self {
+			isPeer = true
+			continue
+		}
+		// connections are established on the first request, so peers can
+		// start in any order
+		conn, err := grpc.Dial(peer, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("could not dial peer %s: %w", peer, err)
+		}
+		c.conns[peer] = conn
+		c.clients[peer] = pb.NewRateLimiterServiceClient(conn)
+	}
+	if !isPeer {
+		c.Close()
+		return nil, fmt.Errorf("%w: %s", ErrSelfNotPeer, self)
+	}
+	return c, nil
+}
+
+// Self returns the address of this replica
+func (c *Cluster) Self() string {
+	return c.self
+}
+
+// Owner returns the address of the replica owning the merchant id, and
+// whether it is this one
+func (c *Cluster) Owner(id uuid.UUID) (string, bool) {
+	owner := c.ring.Owner(id)
+	return owner, owner == c.self
+}
+
+// Forward asks the peer to answer the request of the merchant id
+func (c *Cluster) Forward(ctx context.Context, peer string, id uuid.UUID) (*pb.AllowResponse, error) {
+	var resp *pb.AllowResponse
+	err := c.call(ctx, peer, func(ctx context.Context, client pb.RateLimiterServiceClient) (err error) {
+		resp, err = client.Allow(ctx, &pb.AllowRequest{Id: id.String()})
+		return err
+	})
+	return resp, err
+}
+
+// ForwardConsume asks the peer to count the payment in req
+func (c *Cluster) ForwardConsume(ctx context.Context, peer string, req *pb.ConsumeRequest) (*pb.ConsumeResponse, error) {
+	var resp *pb.ConsumeResponse
+	err := c.call(ctx, peer, func(ctx context.Context, client pb.RateLimiterServiceClient) (err error) {
+		resp, err = client.Consume(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// ForwardRelease asks the peer to give back the payment in req
+func (c *Cluster) ForwardRelease(ctx context.Context, peer string, req *pb.ReleaseRequest) error {
+	return c.call(ctx, peer, func(ctx context.Context, client pb.RateLimiterServiceClient) error {
+		_, err := client.Release(ctx, req)
+		return err
+	})
+}
+
+// ForwardUsage asks the peer for the usage in req
+func (c *Cluster) ForwardUsage(ctx context.Context, peer string, req *pb.UsageRequest) (*pb.UsageResponse, error) {
+	var resp *pb.UsageResponse
+	err := c.call(ctx, peer, func(ctx context.Context, client pb.RateLimiterServiceClient) (err error) {
+		resp, err = client.Usage(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// Collect asks every other peer at once to hand over the counters of the
+// merchant id, it returns the ones handed over even when some peers could
+// not be asked
+func (c *Cluster) Collect(ctx context.Context, id uuid.UUID) ([]*pb.QuotaCounter, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		counters []*pb.QuotaCounter
+		errs     []error
+	)
+	for _, peer := range c.ring.Peers() {
+		if peer == c.self {
+			continue
+		}
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+			var resp *pb.HandOverResponse
+			err := c.call(ctx, peer, func(ctx context.Context, client pb.RateLimiterServiceClient) (err error) {
+				resp, err = client.HandOver(ctx, &pb.HandOverRequest{Id: id.String()})
+				return err
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", peer, err))
+				return
+			}
+			counters = append(counters, resp.Counters...)
+		}(peer)
+	}
+	wg.Wait()
+	return counters, errors.Join(errs...)
+}
+
+// call runs f with the client of peer, within the timeout, marking the
+// request as forwarded
+func (c *Cluster) call(ctx context.Context, peer string, f func(context.Context, pb.RateLimiterServiceClient) error) error {
+	client, ok := c.clients[peer]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPeer, peer)
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, forwardedKey, c.self)
+	return f(ctx, client)
+}
+
+// Forwarded tells whether the request in ctx was forwarded by a peer
+func Forwarded(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	return ok && len(md.Get(forwardedKey)) > 0
+}
+
+// Close closes the connections to the peers
+func (c *Cluster) Close() error {
+	var errs []error
+	for _, conn := range c.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
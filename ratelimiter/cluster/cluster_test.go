@@ -0,0 +1,240 @@
+package cluster_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/cluster"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakePeer answers every request it gets, remembering whether it was
+// forwarded
+type fakePeer struct {
+	mu        sync.Mutex
+	ids       []string
+	forwarded []bool
+	delay     time.Duration
+	pb.UnimplementedRateLimiterServiceServer
+}
+
+func (f *fakePeer) Allow(ctx context.Context, req *pb.AllowRequest) (*pb.AllowResponse, error) {
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ids = append(f.ids, req.Id)
+	f.forwarded = append(f.forwarded, cluster.Forwarded(ctx))
+	return &pb.AllowResponse{Allow: true}, nil
+}
+
+func (f *fakePeer) Consume(ctx context.Context, req *pb.ConsumeRequest) (*pb.ConsumeResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ids = append(f.ids, req.Id)
+	f.forwarded = append(f.forwarded, cluster.Forwarded(ctx))
+	return &pb.ConsumeResponse{Allow: req.AmountMinor <= 1000}, nil
+}
+
+func (f *fakePeer) Release(ctx context.Context, req *pb.ReleaseRequest) (*pb.ReleaseResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ids = append(f.ids, req.Id)
+	f.forwarded = append(f.forwarded, cluster.Forwarded(ctx))
+	return &pb.ReleaseResponse{}, nil
+}
+
+func (f *fakePeer) HandOver(ctx context.Context, req *pb.HandOverRequest) (*pb.HandOverResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ids = append(f.ids, req.Id)
+	f.forwarded = append(f.forwarded, cluster.Forwarded(ctx))
+	return &pb.HandOverResponse{Counters: []*pb.QuotaCounter{{Period: "month", Count: 1}}}, nil
+}
+
+// startPeer serves f on a free port of localhost and returns its address
+func startPeer(t *testing.T, f *fakePeer) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	pb.RegisterRateLimiterServiceServer(s, f)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+	return listener.Addr().String()
+}
+
+func TestNew(t *testing.T) {
+	type testCase struct {
+		testName    string
+		self        string
+		peers       []string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "alone",
+			self:        "a:50052",
+			peers:       []string{"a:50052"},
+			expectedErr: nil,
+		},
+		{
+			testName:    "with_peers",
+			self:        "a:50052",
+			peers:       []string{"a:50052", "b:50052"},
+			expectedErr: nil,
+		},
+		{
+			testName:    "self_not_peer",
+			self:        "c:50052",
+			peers:       []string{"a:50052", "b:50052"},
+			expectedErr: cluster.ErrSelfNotPeer,
+		},
+		{
+			testName:    "no_peers",
+			self:        "a:50052",
+			peers:       nil,
+			expectedErr: cluster.ErrNoPeers,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			c, err := cluster.New(tc.self, tc.peers, 0)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if err == nil {
+				c.Close()
+			}
+		})
+	}
+}
+
+func TestCluster_Forward(t *testing.T) {
+	f := &fakePeer{}
+	peer := startPeer(t, f)
+
+	c, err := cluster.New("self:50052", []string{"self:50052", peer}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// find a merchant of each replica
+	var own, other uuid.UUID
+	for own == uuid.Nil || other == uuid.Nil {
+		id := uuid.New()
+		if owner, self := c.Owner(id); self {
+			own = id
+		} else if owner == peer {
+			other = id
+		}
+	}
+
+	resp, err := c.Forward(context.Background(), peer, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Allow {
+		t.Error("expected the answer of the peer")
+	}
+	if len(f.ids) != 1 || f.ids[0] != other.String() || !f.forwarded[0] {
+		t.Errorf("expected the peer to get one forwarded request for %v, got %v %v", other, f.ids, f.forwarded)
+	}
+
+	consumed, err := c.ForwardConsume(context.Background(), peer, &pb.ConsumeRequest{Id: other.String(), Currency: "USD", AmountMinor: 5000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consumed.Allow {
+		t.Error("expected the answer of the peer to the payment")
+	}
+	if len(f.ids) != 2 || !f.forwarded[1] {
+		t.Errorf("expected the peer to get a forwarded payment, got %v %v", f.ids, f.forwarded)
+	}
+
+	if err := c.ForwardRelease(context.Background(), peer, &pb.ReleaseRequest{Id: other.String(), Currency: "USD", AmountMinor: 500}); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.ids) != 3 || !f.forwarded[2] {
+		t.Errorf("expected the peer to get a forwarded release, got %v %v", f.ids, f.forwarded)
+	}
+
+	if _, err := c.Forward(context.Background(), "self:50052", own); !errors.Is(err, cluster.ErrUnknownPeer) {
+		t.Errorf("expected error %v forwarding to itself, got %v", cluster.ErrUnknownPeer, err)
+	}
+}
+
+func TestCluster_Collect(t *testing.T) {
+	f := &fakePeer{}
+	peer := startPeer(t, f)
+
+	// nothing listens on the second peer
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := listener.Addr().String()
+	listener.Close()
+
+	c, err := cluster.New("self:50052", []string{"self:50052", peer, down}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	id := uuid.New()
+	counters, err := c.Collect(context.Background(), id)
+	if err == nil {
+		t.Error("expected an error for the peer that is down")
+	}
+	if len(counters) != 1 || counters[0].Count != 1 {
+		t.Errorf("expected the counters of the peer that is up, got %v", counters)
+	}
+	if len(f.ids) != 1 || f.ids[0] != id.String() || !f.forwarded[0] {
+		t.Errorf("expected the peer to get one forwarded hand over for %v, got %v %v", id, f.ids, f.forwarded)
+	}
+}
+
+func TestCluster_ForwardTimeout(t *testing.T) {
+	f := &fakePeer{delay: time.Second}
+	peer := startPeer(t, f)
+
+	c, err := cluster.New("self:50052", []string{"self:50052", peer}, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	start := time.Now()
+	_, err = c.Forward(context.Background(), peer, uuid.New())
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected to give up after the timeout, took %v", elapsed)
+	}
+}
+
+func TestForwarded(t *testing.T) {
+	if cluster.Forwarded(context.Background()) {
+		t.Error("expected a request from outside the cluster to not be forwarded")
+	}
+}
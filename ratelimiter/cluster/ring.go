@@ -0,0 +1,84 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoPeers must be used when a ring is built without any peer
+var ErrNoPeers = errors.New("at least one peer is required")
+
+// ErrDuplicatePeer must be used when the same peer is given twice
+var ErrDuplicatePeer = errors.New("duplicate peer")
+
+// virtualNodes is how many points each peer has on the ring, enough to
+// split merchants evenly between a handful of peers
+const virtualNodes = 128
+
+// Ring assigns each merchant to one of a static list of peers by consistent
+// hashing. Rings built from the same peers agree on every owner, whatever
+// the order the peers are given in, and adding or removing a peer only moves
+// the merchants of its share
+type Ring struct {
+	points []point
+	peers  []string
+}
+
+type point struct {
+	hash uint64
+	peer string
+}
+
+// NewRing is a factory for a Ring over peers
+func NewRing(peers []string) (*Ring, error) {
+	if len(peers) == 0 {
+		return nil, ErrNoPeers
+	}
+
+	r := &Ring{points: make([]point, 0, len(peers)*virtualNodes)}
+	seen := make(map[string]bool, len(peers))
+	for _, peer := range peers {
+		if seen[peer] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicatePeer, peer)
+		}
+		seen[peer] = true
+		r.peers = append(r.peers, peer)
+
+		for i := 0; i < virtualNodes; i++ {
+			r.points = append(r.points, point{hash: hash([]byte(fmt.Sprintf("%s#%d", peer, i))), peer: peer})
+		}
+	}
+	sort.Strings(r.peers)
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash == r.points[j].hash {
+			return r.points[i].peer < r.points[j].peer
+		}
+		return r.points[i].hash < r.points[j].hash
+	})
+	return r, nil
+}
+
+// Owner returns the peer that keeps the limiter of the merchant id
+func (r *Ring) Owner(id uuid.UUID) string {
+	h := hash(id[:])
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].peer
+}
+
+// Peers returns the peers of the ring, sorted
+func (r *Ring) Peers() []string {
+	return append([]string(nil), r.peers...)
+}
+
+func hash(b []byte) uint64 {
+	sum := sha256.Sum256(b)
+	return binary.BigEndian.Uint64(sum[:8])
+}
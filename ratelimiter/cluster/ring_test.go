@@ -0,0 +1,98 @@
+package cluster_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/cluster"
+)
+
+func TestNewRing(t *testing.T) {
+	type testCase struct {
+		testName    string
+		peers       []string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			testName:    "single_peer",
+			peers:       []string{"a:50052"},
+			expectedErr: nil,
+		},
+		{
+			testName:    "many_peers",
+			peers:       []string{"a:50052", "b:50052", "c:50052"},
+			expectedErr: nil,
+		},
+		{
+			testName:    "no_peers",
+			peers:       nil,
+			expectedErr: cluster.ErrNoPeers,
+		},
+		{
+			testName:    "duplicate_peer",
+			peers:       []string{"a:50052", "b:50052", "a:50052"},
+			expectedErr: cluster.ErrDuplicatePeer,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			_, err := cluster.NewRing(tc.peers)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestRing_Owner(t *testing.T) {
+	const merchants = 30000
+
+	ids := make([]uuid.UUID, merchants)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+
+	three, err := cluster.NewRing([]string{"a:50052", "b:50052", "c:50052"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shuffled, err := cluster.NewRing([]string{"c:50052", "a:50052", "b:50052"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	four, err := cluster.NewRing([]string{"a:50052", "b:50052", "c:50052", "d:50052"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owned := make(map[string]int)
+	moved := 0
+	for _, id := range ids {
+		owner := three.Owner(id)
+		owned[owner]++
+		if shuffled.Owner(id) != owner {
+			t.Fatalf("rings with the same peers disagree on the owner of %v", id)
+		}
+		if newOwner := four.Owner(id); newOwner != owner {
+			moved++
+			if newOwner != "d:50052" {
+				t.Fatalf("merchant %v moved from %s to %s instead of the new peer", id, owner, newOwner)
+			}
+		}
+	}
+
+	// every peer gets its share give or take a fifth
+	for _, peer := range three.Peers() {
+		if n := owned[peer]; n < merchants/3*4/5 || n > merchants/3*6/5 {
+			t.Errorf("expected about %d merchants on %s, got %d", merchants/3, peer, n)
+		}
+	}
+	// the new peer takes about a fourth of the merchants, from every other
+	if moved < merchants/4*4/5 || moved > merchants/4*6/5 {
+		t.Errorf("expected about %d merchants to move, got %d", merchants/4, moved)
+	}
+}
@@ -0,0 +1,60 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// gcra keeps the theoretical arrival time of the next request, which moves
+// one emission interval forward with every allowed request; a request may
+// arrive up to tolerance before it
+type gcra struct {
+	burst     int
+	interval  time.Duration
+	tolerance time.Duration
+
+	mu  sync.Mutex
+	tat time.Time
+}
+
+// NewGCRA is a factory for a GCRA Limiter
+func NewGCRA(qps, burst int, now time.Time) Limiter {
+	interval := window / time.Duration(qps)
+	return &gcra{
+		burst:     burst,
+		interval:  interval,
+		tolerance: interval * time.Duration(burst-1),
+		tat:       now,
+	}
+}
+
+func (g *gcra) Allow(now time.Time) Decision {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tat := g.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	if ahead := tat.Sub(now); ahead > g.tolerance {
+		return Decision{Limit: g.burst, Reset: ahead, RetryAfter: ahead - g.tolerance}
+	}
+	g.tat = tat.Add(g.interval)
+
+	d := Decision{Allowed: true, Limit: g.burst, Reset: g.tat.Sub(now)}
+	if ahead := g.tat.Sub(now); ahead <= g.tolerance {
+		d.Remaining = int((g.tolerance-ahead)/g.interval) + 1
+	}
+	return d
+}
+
+// SetLimit keeps the theoretical arrival time, so requests allowed ahead of
+// it are still paid for
+func (g *gcra) SetLimit(qps, burst int, _ time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.burst = burst
+	g.interval = window / time.Duration(qps)
+	g.tolerance = g.interval * time.Duration(burst-1)
+}
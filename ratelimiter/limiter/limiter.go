@@ -0,0 +1,138 @@
+// Package limiter has the algorithms the rate limiter can enforce the MaxQPS
+// of a merchant with. Every Limiter is safe for concurrent use, and takes the
+// time of each request so it can be tested without waiting.
+package limiter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	// ErrUnknownAlgorithm must be used when an algorithm is not one of Algorithms
+	ErrUnknownAlgorithm = errors.New("unknown rate limiting algorithm")
+	// ErrInvalidBurst must be used when the burst does not fit the algorithm
+	ErrInvalidBurst = errors.New("invalid burst")
+)
+
+// Algorithm names a Limiter, the names are the ones of the plans kept by the
+// merchant service
+type Algorithm string
+
+const (
+	// TokenBucket refills qps tokens per second into a bucket of burst
+	// tokens, each request takes one
+	TokenBucket Algorithm = "token_bucket"
+	// SlidingLog allows qps requests in any second, remembering the time of
+	// each one
+	SlidingLog Algorithm = "sliding_log"
+	// SlidingWindow approximates SlidingLog with the counts of the current
+	// and the previous second, weighting the previous one by how much of it
+	// is still inside the last second
+	SlidingWindow Algorithm = "sliding_window"
+	// GCRA, the generic cell rate algorithm, spaces requests 1/qps seconds
+	// apart and lets burst of them arrive at once
+	GCRA Algorithm = "gcra"
+)
+
+// Algorithms lists every valid algorithm
+var Algorithms = []Algorithm{TokenBucket, SlidingLog, SlidingWindow, GCRA}
+
+// window is the period qps is counted over by the sliding algorithms
+const window = time.Second
+
+// Decision is the answer to one request, with what is left of the limit
+// after it
+type Decision struct {
+	Allowed bool
+	// Limit is how many requests fit at once
+	Limit int
+	// Remaining is how many more requests would be allowed right now
+	Remaining int
+	// Reset is how long until the whole Limit is available again
+	Reset time.Duration
+	// RetryAfter is how long until a request is allowed, only set when this
+	// one was not
+	RetryAfter time.Duration
+}
+
+// Limiter tells whether one more request fits in the limit
+type Limiter interface {
+	// Allow counts a request made at now, if it is allowed
+	Allow(now time.Time) Decision
+	// SetLimit changes qps and burst from now on, keeping what was already
+	// counted
+	SetLimit(qps, burst int, now time.Time)
+}
+
+// Config is how a Limiter is built, Burst is only used by TokenBucket and
+// GCRA
+type Config struct {
+	Algorithm Algorithm
+	QPS       int
+	Burst     int
+}
+
+// New is a factory for the Limiter of c.Algorithm, starting at now. A QPS of
+// 0 or less refuses every request
+func New(c Config, now time.Time) (Limiter, error) {
+	if c.QPS <= 0 {
+		switch c.Algorithm {
+		case TokenBucket, SlidingLog, SlidingWindow, GCRA:
+			return deny{}, nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, c.Algorithm)
+	}
+
+	switch c.Algorithm {
+	case TokenBucket:
+		if c.Burst < 1 {
+			return nil, ErrInvalidBurst
+		}
+		return NewTokenBucket(c.QPS, c.Burst, now), nil
+	case SlidingLog:
+		return NewSlidingLog(c.QPS), nil
+	case SlidingWindow:
+		return NewSlidingWindow(c.QPS, now), nil
+	case GCRA:
+		if c.Burst < 1 {
+			return nil, ErrInvalidBurst
+		}
+		return NewGCRA(c.QPS, c.Burst, now), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, c.Algorithm)
+}
+
+// Reconfigure returns l, built from the from config, changed to the to
+// config. The same limiter is kept, with the requests it already counted,
+// unless the algorithm changes or either config has no QPS, in which case a
+// new one is built
+func Reconfigure(l Limiter, from, to Config, now time.Time) (Limiter, error) {
+	if from.Algorithm != to.Algorithm || from.QPS <= 0 || to.QPS <= 0 {
+		return New(to, now)
+	}
+	if (to.Algorithm == TokenBucket || to.Algorithm == GCRA) && to.Burst < 1 {
+		return nil, ErrInvalidBurst
+	}
+	if from != to {
+		l.SetLimit(to.QPS, to.Burst, now)
+	}
+	return l, nil
+}
+
+// deny is the Limiter of merchants without any QPS
+type deny struct{}
+
+// Allow has no limit to report, no request is ever allowed
+func (deny) Allow(time.Time) Decision {
+	return Decision{}
+}
+
+func (deny) SetLimit(int, int, time.Time) {}
+
+// seconds converts a fractional number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
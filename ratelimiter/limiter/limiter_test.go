@@ -0,0 +1,367 @@
+package limiter_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/limiter"
+)
+
+var start = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+// traffic returns the times of n requests, every interval from start+offset
+func traffic(offset, interval time.Duration, n int) []time.Time {
+	times := make([]time.Time, n)
+	for i := range times {
+		times[i] = start.Add(offset + time.Duration(i)*interval)
+	}
+	return times
+}
+
+func concat(parts ...[]time.Time) []time.Time {
+	var res []time.Time
+	for _, p := range parts {
+		res = append(res, p...)
+	}
+	return res
+}
+
+func allowed(t *testing.T, c limiter.Config, times []time.Time) []time.Time {
+	t.Helper()
+
+	l, err := limiter.New(c, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []time.Time
+	for _, now := range times {
+		if l.Allow(now).Allowed {
+			res = append(res, now)
+		}
+	}
+	return res
+}
+
+func TestLimiters_Traffic(t *testing.T) {
+	var (
+		tokenBucket   = limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 10}
+		slidingLog    = limiter.Config{Algorithm: limiter.SlidingLog, QPS: 10}
+		slidingWindow = limiter.Config{Algorithm: limiter.SlidingWindow, QPS: 10}
+		gcra          = limiter.Config{Algorithm: limiter.GCRA, QPS: 10, Burst: 10}
+		gcraNoBurst   = limiter.Config{Algorithm: limiter.GCRA, QPS: 10, Burst: 1}
+	)
+
+	// 30 requests at once
+	spike := traffic(0, 0, 30)
+	// 10 requests per second for 3 seconds
+	steady := traffic(0, 100*time.Millisecond, 30)
+	// 20 requests per second for 2 seconds
+	double := traffic(0, 50*time.Millisecond, 40)
+	// 10 requests at the end of a second and 10 at the start of the next one
+	boundary := concat(traffic(900*time.Millisecond, 10*time.Millisecond, 10), traffic(time.Second, 10*time.Millisecond, 10))
+
+	type testCase struct {
+		testName        string
+		config          limiter.Config
+		traffic         []time.Time
+		expectedAllowed int
+	}
+
+	testCases := []testCase{
+		// a spike only gets the burst, or qps for the sliding windows
+		{"token_bucket_spike", tokenBucket, spike, 10},
+		{"sliding_log_spike", slidingLog, spike, 10},
+		{"sliding_window_spike", slidingWindow, spike, 10},
+		{"gcra_spike", gcra, spike, 10},
+		{"gcra_no_burst_spike", gcraNoBurst, spike, 1},
+
+		// traffic at the limit goes through, but for the one request where
+		// the sliding window takes the whole previous second as still in it
+		{"token_bucket_steady", tokenBucket, steady, 30},
+		{"sliding_log_steady", slidingLog, steady, 30},
+		{"sliding_window_steady", slidingWindow, steady, 29},
+		{"gcra_steady", gcra, steady, 30},
+		{"gcra_no_burst_steady", gcraNoBurst, steady, 30},
+
+		// twice the limit gets the burst plus qps per second that passed,
+		// the sliding windows get qps in each second
+		{"token_bucket_double", tokenBucket, double, 29},
+		{"sliding_log_double", slidingLog, double, 20},
+		{"sliding_window_double", slidingWindow, double, 20},
+		{"gcra_double", gcra, double, 29},
+		{"gcra_no_burst_double", gcraNoBurst, double, 20},
+
+		// a fixed window would allow all 20, these allow about qps in the
+		// 100ms the traffic lasts
+		{"token_bucket_boundary", tokenBucket, boundary, 11},
+		{"sliding_log_boundary", slidingLog, boundary, 10},
+		{"sliding_window_boundary", slidingWindow, boundary, 11},
+		{"gcra_boundary", gcra, boundary, 11},
+		{"gcra_no_burst_boundary", gcraNoBurst, boundary, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			got := len(allowed(t, tc.config, tc.traffic))
+			if got != tc.expectedAllowed {
+				t.Errorf("expected %d allowed, got %d", tc.expectedAllowed, got)
+			}
+		})
+	}
+}
+
+// TestLimiters_Window checks how many requests, under traffic well above the
+// limit, any second can have allowed: qps plus the burst, only qps for the
+// sliding log, and up to twice qps for the estimate of the sliding window
+func TestLimiters_Window(t *testing.T) {
+	type testCase struct {
+		testName    string
+		config      limiter.Config
+		expectedMax int
+	}
+
+	testCases := []testCase{
+		{"token_bucket", limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 5}, 15},
+		{"sliding_log", limiter.Config{Algorithm: limiter.SlidingLog, QPS: 10}, 10},
+		{"sliding_window", limiter.Config{Algorithm: limiter.SlidingWindow, QPS: 10}, 20},
+		{"gcra", limiter.Config{Algorithm: limiter.GCRA, QPS: 10, Burst: 5}, 15},
+	}
+
+	// 1000 requests per second for 5 seconds
+	times := traffic(0, time.Millisecond, 5000)
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			got := allowed(t, tc.config, times)
+			for i := range got {
+				j := i
+				for j < len(got) && got[j].Sub(got[i]) < time.Second {
+					j++
+				}
+				if j-i > tc.expectedMax {
+					t.Fatalf("%d requests allowed in the second from %v, expected at most %d", j-i, got[i], tc.expectedMax)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiters_Decision(t *testing.T) {
+	type testCase struct {
+		testName string
+		config   limiter.Config
+		// first is the decision on the first request at start
+		first limiter.Decision
+		// denied is the decision on the first request past the limit, all
+		// of them at start
+		denied limiter.Decision
+	}
+
+	testCases := []testCase{
+		{
+			testName: "token_bucket",
+			config:   limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 10},
+			first:    limiter.Decision{Allowed: true, Limit: 10, Remaining: 9, Reset: 100 * time.Millisecond},
+			denied:   limiter.Decision{Limit: 10, Reset: time.Second, RetryAfter: 100 * time.Millisecond},
+		},
+		{
+			testName: "sliding_log",
+			config:   limiter.Config{Algorithm: limiter.SlidingLog, QPS: 10},
+			first:    limiter.Decision{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second},
+			denied:   limiter.Decision{Limit: 10, Reset: time.Second, RetryAfter: time.Second},
+		},
+		{
+			// the count of this second leaves the estimate by the end of
+			// the next one
+			testName: "sliding_window",
+			config:   limiter.Config{Algorithm: limiter.SlidingWindow, QPS: 10},
+			first:    limiter.Decision{Allowed: true, Limit: 10, Remaining: 9, Reset: 2 * time.Second},
+			denied:   limiter.Decision{Limit: 10, Reset: 2 * time.Second, RetryAfter: time.Second},
+		},
+		{
+			testName: "gcra",
+			config:   limiter.Config{Algorithm: limiter.GCRA, QPS: 10, Burst: 10},
+			first:    limiter.Decision{Allowed: true, Limit: 10, Remaining: 9, Reset: 100 * time.Millisecond},
+			denied:   limiter.Decision{Limit: 10, Reset: time.Second, RetryAfter: 100 * time.Millisecond},
+		},
+		{
+			testName: "no_qps",
+			config:   limiter.Config{Algorithm: limiter.GCRA, QPS: 0, Burst: 10},
+			first:    limiter.Decision{},
+			denied:   limiter.Decision{},
+		},
+	}
+
+	// durations computed from float rates may be off by a little
+	near := func(a, b limiter.Decision) bool {
+		close := func(x, y time.Duration) bool { return x-y < time.Millisecond && y-x < time.Millisecond }
+		return a.Allowed == b.Allowed && a.Limit == b.Limit && a.Remaining == b.Remaining &&
+			close(a.Reset, b.Reset) && close(a.RetryAfter, b.RetryAfter)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			l, err := limiter.New(tc.config, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := l.Allow(start); !near(got, tc.first) {
+				t.Errorf("expected first decision %+v, got %+v", tc.first, got)
+			}
+			for i := 1; i < tc.config.QPS; i++ {
+				l.Allow(start)
+			}
+			denied := l.Allow(start)
+			if !near(denied, tc.denied) {
+				t.Errorf("expected denied decision %+v, got %+v", tc.denied, denied)
+			}
+			if tc.config.QPS > 0 && !l.Allow(start.Add(denied.RetryAfter)).Allowed {
+				t.Errorf("expected a request after %v to be allowed", denied.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	type testCase struct {
+		testName    string
+		config      limiter.Config
+		expectAllow bool
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{"token_bucket", limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 1}, true, nil},
+		{"no_qps", limiter.Config{Algorithm: limiter.TokenBucket, QPS: 0, Burst: 1}, false, nil},
+		{"no_burst", limiter.Config{Algorithm: limiter.GCRA, QPS: 10}, false, limiter.ErrInvalidBurst},
+		{"unknown_algorithm", limiter.Config{Algorithm: "leaky_bucket", QPS: 10}, false, limiter.ErrUnknownAlgorithm},
+		{"unknown_algorithm_no_qps", limiter.Config{Algorithm: "leaky_bucket"}, false, limiter.ErrUnknownAlgorithm},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			l, err := limiter.New(tc.config, start)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if got := l.Allow(start).Allowed; got != tc.expectAllow {
+				t.Errorf("expected allow=%t, got %t", tc.expectAllow, got)
+			}
+		})
+	}
+}
+
+func TestReconfigure(t *testing.T) {
+	type testCase struct {
+		testName        string
+		from            limiter.Config
+		to              limiter.Config
+		before          []time.Time
+		expectedAllowed int
+	}
+
+	// 30 requests at once, before and after the change
+	before := traffic(0, 0, 30)
+	after := traffic(100*time.Millisecond, 0, 30)
+
+	testCases := []testCase{
+		{
+			// an empty bucket is not refilled by the change, only the 100ms
+			// before it add a token
+			testName:        "token_bucket_faster",
+			from:            limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 10},
+			to:              limiter.Config{Algorithm: limiter.TokenBucket, QPS: 20, Burst: 20},
+			before:          before,
+			expectedAllowed: 1,
+		},
+		{
+			// a full bucket is cut down to the new burst
+			testName:        "token_bucket_smaller_burst",
+			from:            limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 10},
+			to:              limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 2},
+			before:          nil,
+			expectedAllowed: 2,
+		},
+		{
+			// the requests of the last second count against the new qps
+			testName:        "sliding_log_faster",
+			from:            limiter.Config{Algorithm: limiter.SlidingLog, QPS: 10},
+			to:              limiter.Config{Algorithm: limiter.SlidingLog, QPS: 20},
+			before:          before,
+			expectedAllowed: 10,
+		},
+		{
+			testName:        "sliding_log_slower",
+			from:            limiter.Config{Algorithm: limiter.SlidingLog, QPS: 10},
+			to:              limiter.Config{Algorithm: limiter.SlidingLog, QPS: 5},
+			before:          before,
+			expectedAllowed: 0,
+		},
+		{
+			testName:        "sliding_window_faster",
+			from:            limiter.Config{Algorithm: limiter.SlidingWindow, QPS: 10},
+			to:              limiter.Config{Algorithm: limiter.SlidingWindow, QPS: 20},
+			before:          before,
+			expectedAllowed: 10,
+		},
+		{
+			// the 10 requests before are still a second ahead, only the
+			// larger tolerance lets 2 more in
+			testName:        "gcra_faster",
+			from:            limiter.Config{Algorithm: limiter.GCRA, QPS: 10, Burst: 10},
+			to:              limiter.Config{Algorithm: limiter.GCRA, QPS: 20, Burst: 20},
+			before:          before,
+			expectedAllowed: 2,
+		},
+		{
+			// nothing carries over to another algorithm
+			testName:        "other_algorithm",
+			from:            limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 10},
+			to:              limiter.Config{Algorithm: limiter.GCRA, QPS: 10, Burst: 10},
+			before:          before,
+			expectedAllowed: 10,
+		},
+		{
+			testName:        "no_qps",
+			from:            limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 10},
+			to:              limiter.Config{Algorithm: limiter.TokenBucket, QPS: 0, Burst: 10},
+			before:          nil,
+			expectedAllowed: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			l, err := limiter.New(tc.from, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, now := range tc.before {
+				l.Allow(now)
+			}
+
+			l, err = limiter.Reconfigure(l, tc.from, tc.to, after[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := 0
+			for _, now := range after {
+				if l.Allow(now).Allowed {
+					got++
+				}
+			}
+			if got != tc.expectedAllowed {
+				t.Errorf("expected %d allowed, got %d", tc.expectedAllowed, got)
+			}
+		})
+	}
+
+	if _, err := limiter.Reconfigure(nil, limiter.Config{Algorithm: limiter.GCRA, QPS: 10, Burst: 1},
+		limiter.Config{Algorithm: limiter.GCRA, QPS: 10}, start); !errors.Is(err, limiter.ErrInvalidBurst) {
+		t.Errorf("expected %v, got %v", limiter.ErrInvalidBurst, err)
+	}
+}
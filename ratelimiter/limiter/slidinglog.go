@@ -0,0 +1,72 @@
+package limiter
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// slidingLog keeps the time of the last qps allowed requests in a ring, a
+// request is allowed when the oldest of them left the window
+type slidingLog struct {
+	mu    sync.Mutex
+	times []time.Time
+	// next is the oldest entry of times once it is full
+	next int
+}
+
+// NewSlidingLog is a factory for a SlidingLog Limiter
+func NewSlidingLog(qps int) Limiter {
+	return &slidingLog{times: make([]time.Time, 0, qps)}
+}
+
+func (sl *slidingLog) Allow(now time.Time) Decision {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	allowed := true
+	switch {
+	case len(sl.times) < cap(sl.times):
+		sl.times = append(sl.times, now)
+	case now.Sub(sl.times[sl.next]) < window:
+		allowed = false
+	default:
+		sl.times[sl.next] = now
+		sl.next = (sl.next + 1) % len(sl.times)
+	}
+
+	// the times are ordered, so the ones still in the window are the newest
+	outside := sort.Search(len(sl.times), func(i int) bool { return now.Sub(sl.at(i)) < window })
+	d := Decision{
+		Allowed:   allowed,
+		Limit:     cap(sl.times),
+		Remaining: cap(sl.times) - (len(sl.times) - outside),
+	}
+	if outside < len(sl.times) {
+		d.Reset = sl.at(len(sl.times) - 1).Add(window).Sub(now)
+	}
+	if !allowed {
+		d.RetryAfter = sl.at(0).Add(window).Sub(now)
+	}
+	return d
+}
+
+// at returns the i-th oldest time
+func (sl *slidingLog) at(i int) time.Time {
+	return sl.times[(sl.next+i)%len(sl.times)]
+}
+
+// SetLimit keeps the times of the last qps allowed requests, the burst is
+// not used
+func (sl *slidingLog) SetLimit(qps, _ int, _ time.Time) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	// oldest first
+	ordered := append(append([]time.Time{}, sl.times[sl.next:]...), sl.times[:sl.next]...)
+	if len(ordered) > qps {
+		ordered = ordered[len(ordered)-qps:]
+	}
+	sl.times = append(make([]time.Time, 0, qps), ordered...)
+	sl.next = 0
+}
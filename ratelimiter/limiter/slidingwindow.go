@@ -0,0 +1,86 @@
+package limiter
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// slidingWindow counts requests per fixed window, and estimates the last
+// second as the current count plus the share of the previous count that
+// still overlaps it
+type slidingWindow struct {
+	qps int
+
+	mu       sync.Mutex
+	start    time.Time
+	current  int
+	previous int
+}
+
+// NewSlidingWindow is a factory for a SlidingWindow Limiter
+func NewSlidingWindow(qps int, now time.Time) Limiter {
+	return &slidingWindow{qps: qps, start: now.Truncate(window)}
+}
+
+func (sw *slidingWindow) Allow(now time.Time) Decision {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if elapsed := now.Sub(sw.start); elapsed >= window {
+		windows := elapsed / window
+		if windows == 1 {
+			sw.previous = sw.current
+		} else {
+			sw.previous = 0
+		}
+		sw.current = 0
+		sw.start = sw.start.Add(windows * window)
+	}
+
+	elapsed := now.Sub(sw.start)
+	overlap := 1 - float64(elapsed)/float64(window)
+	allowed := float64(sw.previous)*overlap+float64(sw.current) < float64(sw.qps)
+	if allowed {
+		sw.current++
+	}
+
+	d := Decision{Allowed: allowed, Limit: sw.qps}
+	if left := float64(sw.qps) - (float64(sw.previous)*overlap + float64(sw.current)); left > 0 {
+		d.Remaining = int(math.Ceil(left))
+	}
+	// the current count leaves the estimate by the end of the next window,
+	// the previous one by the end of this one
+	switch {
+	case sw.current > 0:
+		d.Reset = 2*window - elapsed
+	case sw.previous > 0:
+		d.Reset = window - elapsed
+	}
+	if !allowed {
+		// the estimate has to drop below qps, not to it, hence the extra
+		// nanosecond
+		if sw.current < sw.qps {
+			// the previous count fades out enough within this window
+			d.RetryAfter = fraction(1-float64(sw.qps-sw.current)/float64(sw.previous)) - elapsed + 1
+		} else {
+			// the current count has to become the previous one and fade
+			d.RetryAfter = window - elapsed + fraction(1-float64(sw.qps)/float64(sw.current)) + 1
+		}
+	}
+	return d
+}
+
+// SetLimit keeps the counts of the current and previous windows, the burst
+// is not used
+func (sw *slidingWindow) SetLimit(qps, _ int, _ time.Time) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	sw.qps = qps
+}
+
+// fraction returns the share f of the window
+func fraction(f float64) time.Duration {
+	return time.Duration(math.Ceil(f * float64(window)))
+}
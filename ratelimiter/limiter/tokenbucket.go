@@ -0,0 +1,47 @@
+package limiter
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// tokenBucket wraps rate.Limiter, which is a token bucket already
+type tokenBucket struct {
+	limiter *rate.Limiter
+}
+
+// NewTokenBucket is a factory for a TokenBucket Limiter that starts full
+func NewTokenBucket(qps, burst int, now time.Time) Limiter {
+	l := rate.NewLimiter(rate.Limit(qps), burst)
+	// the bucket is full at now, whatever time it was created at
+	l.SetBurstAt(now, burst)
+	return &tokenBucket{limiter: l}
+}
+
+func (tb *tokenBucket) Allow(now time.Time) Decision {
+	allowed := tb.limiter.AllowN(now, 1)
+
+	// concurrent requests may take tokens in between, which only makes the
+	// report a little optimistic
+	tokens := tb.limiter.TokensAt(now)
+	qps := float64(tb.limiter.Limit())
+	d := Decision{
+		Allowed: allowed,
+		Limit:   tb.limiter.Burst(),
+		Reset:   seconds((float64(tb.limiter.Burst()) - tokens) / qps),
+	}
+	if tokens > 0 {
+		d.Remaining = int(tokens)
+	}
+	if !allowed {
+		d.RetryAfter = seconds((1 - tokens) / qps)
+	}
+	return d
+}
+
+// SetLimit keeps the tokens in the bucket, up to the new burst
+func (tb *tokenBucket) SetLimit(qps, burst int, now time.Time) {
+	tb.limiter.SetLimitAt(now, rate.Limit(qps))
+	tb.limiter.SetBurstAt(now, burst)
+}
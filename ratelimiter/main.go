@@ -5,16 +5,22 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"net"
 	"os"
+	"os/signal"
 	"strconv"
-	"sync"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/google/uuid"
 	merchant "github.com/thiagolcmelo/payment-gateway/merchant/pb"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/cluster"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/limiter"
 	"github.com/thiagolcmelo/payment-gateway/ratelimiter/pb"
-	"golang.org/x/time/rate"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/quota"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/registry"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
@@ -26,31 +32,44 @@ var (
 	ipVersionFlag    = flag.Int("ip-version", 4, "The server ip version (4 for IPv4, 6 for IPv6)")
 	merchantHostFlag = flag.String("merchant-host", "0.0.0.0", "Merchant Service host")
 	merchantPortFlag = flag.Int("merchant-port", 50051, "Merchant Service port")
+	idleTTLFlag      = flag.String("idle-ttl", "10m", "How long the limiter of a merchant without requests is kept, 0 to keep them forever")
+	refreshFlag      = flag.String("refresh-interval", "30s", "How often the limits of merchants are read again from the Merchant Service, 0 to never")
+	peersFlag        = flag.String("peers", "", "Comma separated addresses of every rate limiter replica, this one included, empty to run alone")
+	advertiseFlag    = flag.String("advertise-address", "", "Address of this replica as it appears in --peers")
+	peerTimeoutFlag  = flag.String("peer-timeout", "200ms", "How long a request forwarded to another replica may take, 0 for no limit")
+	quotaFileFlag    = flag.String("quota-state-file", "", "File the quota counters are kept in across restarts, empty to keep them only in memory")
+	quotaSaveFlag    = flag.String("quota-save-interval", "5s", "How often the quota counters are written to --quota-state-file")
 )
 
-type client struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
-}
+// defaultConfig is used for merchants whose plan the merchant service does
+// not tell, the token bucket with a burst of 10 this service always had
+var defaultConfig = limiter.Config{Algorithm: limiter.TokenBucket, Burst: 10}
 
 type server struct {
 	merchantServiceAddress string
-	clients                map[uuid.UUID]*client
+	limiters               *registry.Registry
+	quotas                 *quota.Tracker
+	// cluster is nil when this replica runs alone
+	cluster *cluster.Cluster
 	pb.UnimplementedRateLimiterServiceServer
-	sync.Mutex
 }
 
-func newServerWithMemoryLimiter(merchantServiceAddress string) *server {
-	return &server{
+// newServerWithMemoryLimiter is a factory for a server keeping limiters and
+// quota counts in memory, quotas are read again once older than quotaTTL
+func newServerWithMemoryLimiter(merchantServiceAddress string, quotaTTL time.Duration) *server {
+	s := &server{
 		merchantServiceAddress: merchantServiceAddress,
-		clients:                make(map[uuid.UUID]*client),
 	}
+	s.limiters = registry.New(s.getConfig, defaultConfig)
+	s.quotas = quota.New(s.getQuotas, s.collectCounters, quotaTTL)
+	return s
 }
 
-func (s *server) getMaxQPS(ctx context.Context, id uuid.UUID) (int, error) {
+// getConfig reads the MaxQPS of a merchant and the algorithm of its plan
+func (s *server) getConfig(ctx context.Context, id uuid.UUID) (limiter.Config, error) {
 	conn, err := grpc.Dial(s.merchantServiceAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		return 0, fmt.Errorf("merchant service is unreachable at address: %s", s.merchantServiceAddress)
+		return limiter.Config{}, fmt.Errorf("merchant service is unreachable at address: %s", s.merchantServiceAddress)
 	}
 	defer conn.Close()
 	merchantClient := merchant.NewMerchantServiceClient(conn)
@@ -60,31 +79,120 @@ func (s *server) getMaxQPS(ctx context.Context, id uuid.UUID) (int, error) {
 	}
 	merchantResp, err := merchantClient.GetQPS(ctx, merchantReq)
 	if err != nil {
-		return 0, fmt.Errorf("error reading max qps: %v", err)
+		return limiter.Config{}, fmt.Errorf("error reading max qps: %v", err)
+	}
+
+	c := limiter.Config{
+		Algorithm: limiter.Algorithm(merchantResp.Algorithm),
+		QPS:       int(merchantResp.MaxQps),
+		Burst:     int(merchantResp.Burst),
+	}
+	if c.Algorithm == "" {
+		c.Algorithm, c.Burst = defaultConfig.Algorithm, defaultConfig.Burst
 	}
-	return int(merchantResp.MaxQps), nil
+	return c, nil
 }
 
-func (s *server) allowClient(ctx context.Context, id uuid.UUID) (bool, error) {
-	s.Lock()
-	defer s.Unlock()
+// getQuotas reads the quotas of the plan of a merchant
+func (s *server) getQuotas(ctx context.Context, id uuid.UUID) ([]quota.Quota, error) {
+	conn, err := grpc.Dial(s.merchantServiceAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("merchant service is unreachable at address: %s", s.merchantServiceAddress)
+	}
+	defer conn.Close()
+	merchantClient := merchant.NewMerchantServiceClient(conn)
+
+	merchantResp, err := merchantClient.GetQuotas(ctx, &merchant.GetQuotasRequest{Id: id.String()})
+	if err != nil {
+		return nil, fmt.Errorf("error reading quotas: %v", err)
+	}
 
-	if _, ok := s.clients[id]; !ok {
-		maxQPS, err := s.getMaxQPS(ctx, id)
-		if err != nil {
-			log.Printf("could not read from merchant service: %v", err)
-			return false, err
-		}
-		if maxQPS > 0 {
-			s.clients[id] = &client{limiter: rate.NewLimiter(rate.Limit(maxQPS), 10)}
-		} else {
-			s.clients[id] = &client{limiter: &rate.Limiter{}}
+	quotas := make([]quota.Quota, 0, len(merchantResp.Quotas))
+	for _, q := range merchantResp.Quotas {
+		quotas = append(quotas, quota.Quota{
+			Period:         quota.Period(q.Period),
+			Currency:       q.Currency,
+			MaxCount:       q.MaxCount,
+			MaxAmountMinor: q.MaxAmountMinor,
+		})
+	}
+	return quotas, nil
+}
+
+// collectCounters gathers the counters the other replicas kept of a
+// merchant this one starts counting for, the ones counted while this
+// replica was away or before it owned the merchant
+func (s *server) collectCounters(ctx context.Context, id uuid.UUID) []quota.Counter {
+	if s.cluster == nil {
+		return nil
+	}
+
+	resp, err := s.cluster.Collect(ctx, id)
+	if err != nil {
+		log.Printf("could not collect every quota counter of merchant %s: %v", id, err)
+	}
+	counters := make([]quota.Counter, 0, len(resp))
+	for _, c := range resp {
+		counters = append(counters, quota.Counter{
+			Period:      quota.Period(c.Period),
+			Currency:    c.Currency,
+			Start:       time.Unix(c.PeriodStart, 0).UTC(),
+			Count:       c.Count,
+			AmountMinor: c.AmountMinor,
+		})
+	}
+	return counters
+}
+
+func (s *server) allowClient(ctx context.Context, id uuid.UUID) (limiter.Decision, error) {
+	d, err := s.limiters.Allow(ctx, id, time.Now())
+	if err != nil {
+		log.Printf("could not read from merchant service: %v", err)
+	}
+	return d, err
+}
+
+// every calls f every interval until ctx is done
+func every(ctx context.Context, interval time.Duration, f func(now time.Time)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			f(now)
 		}
+	}
+}
 
+// owner returns the replica owning the merchant, when the request has to
+// be forwarded to it
+func (s *server) owner(ctx context.Context, id uuid.UUID) (string, bool) {
+	if s.cluster == nil || cluster.Forwarded(ctx) {
+		return "", false
 	}
+	owner, self := s.cluster.Owner(id)
+	return owner, !self
+}
 
-	s.clients[id].lastSeen = time.Now()
-	return s.clients[id].limiter.Allow(), nil
+// forward sends the request to the replica owning the merchant, if that is
+// not this one. It tells whether the request was answered there
+func (s *server) forward(ctx context.Context, id uuid.UUID) (*pb.AllowResponse, bool) {
+	owner, ok := s.owner(ctx, id)
+	if !ok {
+		return nil, false
+	}
+
+	resp, err := s.cluster.Forward(ctx, owner, id)
+	if err != nil {
+		// the budget is split while the owner is away, instead of refusing
+		// every request of its merchants
+		log.Printf("could not forward to %s, limiting locally: %v", owner, err)
+		return nil, false
+	}
+	return resp, true
 }
 
 func (s *server) Allow(ctx context.Context, req *pb.AllowRequest) (*pb.AllowResponse, error) {
@@ -95,15 +203,140 @@ func (s *server) Allow(ctx context.Context, req *pb.AllowRequest) (*pb.AllowResp
 		return nil, err
 	}
 
-	allow, err := s.allowClient(ctx, id)
+	if resp, ok := s.forward(ctx, id); ok {
+		return resp, nil
+	}
+
+	d, err := s.allowClient(ctx, id)
 	if err != nil {
 		log.Printf("could not rate limit: %v", err)
 	}
 	return &pb.AllowResponse{
-		Allow: allow,
+		Allow:        d.Allowed,
+		Limit:        int64(d.Limit),
+		Remaining:    int64(d.Remaining),
+		ResetMs:      millis(d.Reset),
+		RetryAfterMs: millis(d.RetryAfter),
 	}, err
 }
 
+func (s *server) Consume(ctx context.Context, req *pb.ConsumeRequest) (*pb.ConsumeResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in Consume: %v", err)
+		return nil, err
+	}
+
+	if owner, ok := s.owner(ctx, id); ok {
+		resp, err := s.cluster.ForwardConsume(ctx, owner, req)
+		if err == nil {
+			return resp, nil
+		}
+		log.Printf("could not forward to %s, counting locally: %v", owner, err)
+	}
+
+	now := time.Now()
+	res, err := s.quotas.Consume(ctx, id, quota.Payment{Currency: req.Currency, AmountMinor: req.AmountMinor}, now)
+	if err != nil {
+		log.Printf("could not read quotas: %v", err)
+		return nil, err
+	}
+	resp := &pb.ConsumeResponse{Allow: res.Allowed}
+	if res.Allowed {
+		resp.ConsumedAt = now.Unix()
+	} else {
+		resp.Exceeded = usageToPb(res.Exceeded)
+	}
+	return resp, nil
+}
+
+func (s *server) Release(ctx context.Context, req *pb.ReleaseRequest) (*pb.ReleaseResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in Release: %v", err)
+		return nil, err
+	}
+
+	if owner, ok := s.owner(ctx, id); ok {
+		err := s.cluster.ForwardRelease(ctx, owner, req)
+		if err == nil {
+			return &pb.ReleaseResponse{}, nil
+		}
+		log.Printf("could not forward to %s, releasing locally: %v", owner, err)
+	}
+
+	s.quotas.Release(id, quota.Payment{Currency: req.Currency, AmountMinor: req.AmountMinor}, time.Unix(req.ConsumedAt, 0))
+	return &pb.ReleaseResponse{}, nil
+}
+
+// HandOver is only asked by peers, it is answered here whoever owns the
+// merchant
+func (s *server) HandOver(ctx context.Context, req *pb.HandOverRequest) (*pb.HandOverResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in HandOver: %v", err)
+		return nil, err
+	}
+
+	resp := &pb.HandOverResponse{}
+	for _, c := range s.quotas.HandOver(id) {
+		resp.Counters = append(resp.Counters, &pb.QuotaCounter{
+			Period:      string(c.Period),
+			Currency:    c.Currency,
+			PeriodStart: c.Start.Unix(),
+			Count:       c.Count,
+			AmountMinor: c.AmountMinor,
+		})
+	}
+	return resp, nil
+}
+
+func (s *server) Usage(ctx context.Context, req *pb.UsageRequest) (*pb.UsageResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("error parsing uuid in Usage: %v", err)
+		return nil, err
+	}
+
+	if owner, ok := s.owner(ctx, id); ok {
+		resp, err := s.cluster.ForwardUsage(ctx, owner, req)
+		if err == nil {
+			return resp, nil
+		}
+		log.Printf("could not forward to %s, reporting locally: %v", owner, err)
+	}
+
+	usage, err := s.quotas.Usage(ctx, id, time.Now())
+	if err != nil {
+		log.Printf("could not read quotas: %v", err)
+		return nil, err
+	}
+	resp := &pb.UsageResponse{}
+	for _, u := range usage {
+		resp.Usage = append(resp.Usage, usageToPb(u))
+	}
+	return resp, nil
+}
+
+func usageToPb(u quota.Usage) *pb.QuotaUsage {
+	return &pb.QuotaUsage{
+		Period:         string(u.Period),
+		Currency:       u.Currency,
+		MaxCount:       u.MaxCount,
+		MaxAmountMinor: u.MaxAmountMinor,
+		Count:          u.Count,
+		AmountMinor:    u.AmountMinor,
+		PeriodStart:    u.Start.Unix(),
+		PeriodEnd:      u.End.Unix(),
+	}
+}
+
+// millis rounds d up to milliseconds, so a request retried after them is
+// not early
+func millis(d time.Duration) int64 {
+	return int64((d + time.Millisecond - 1) / time.Millisecond)
+}
+
 func getEnvOrFlag[T int | string](env string, flagVal *T, conv func(string) (T, error)) T {
 	if envVal := os.Getenv(env); envVal != "" {
 		v, err := conv(envVal)
@@ -116,6 +349,8 @@ func getEnvOrFlag[T int | string](env string, flagVal *T, conv func(string) (T,
 }
 
 func main() {
+	flag.Parse()
+
 	var (
 		network      string = "tcp4"
 		ipVersion    int    = getEnvOrFlag("IP_VERSION", ipVersionFlag, strconv.Atoi)
@@ -123,6 +358,13 @@ func main() {
 		port         int    = getEnvOrFlag("RATE_LIMITER_SERVICE_PORT", portFlag, strconv.Atoi)
 		merchantHost string = getEnvOrFlag("MERCHANT_SERVICE_HOST", merchantHostFlag, func(v string) (string, error) { return v, nil })
 		merchantPort int    = getEnvOrFlag("MERCHANT_SERVICE_PORT", merchantPortFlag, strconv.Atoi)
+		idleTTL      string = getEnvOrFlag("RATE_LIMITER_IDLE_TTL", idleTTLFlag, func(v string) (string, error) { return v, nil })
+		refresh      string = getEnvOrFlag("RATE_LIMITER_REFRESH_INTERVAL", refreshFlag, func(v string) (string, error) { return v, nil })
+		peers        string = getEnvOrFlag("RATE_LIMITER_PEERS", peersFlag, func(v string) (string, error) { return v, nil })
+		advertise    string = getEnvOrFlag("RATE_LIMITER_ADVERTISE_ADDRESS", advertiseFlag, func(v string) (string, error) { return v, nil })
+		peerTimeout  string = getEnvOrFlag("RATE_LIMITER_PEER_TIMEOUT", peerTimeoutFlag, func(v string) (string, error) { return v, nil })
+		quotaFile    string = getEnvOrFlag("RATE_LIMITER_QUOTA_STATE_FILE", quotaFileFlag, func(v string) (string, error) { return v, nil })
+		quotaSave    string = getEnvOrFlag("RATE_LIMITER_QUOTA_SAVE_INTERVAL", quotaSaveFlag, func(v string) (string, error) { return v, nil })
 	)
 
 	if ipVersion == 6 {
@@ -139,11 +381,91 @@ func main() {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
+	ttl, err := time.ParseDuration(idleTTL)
+	if err != nil {
+		log.Fatalf("invalid idle ttl: %v", err)
+	}
+	refreshInterval, err := time.ParseDuration(refresh)
+	if err != nil {
+		log.Fatalf("invalid refresh interval: %v", err)
+	}
+
+	// quotas follow plan changes as often as limits do
+	quotaTTL := refreshInterval
+	if quotaTTL <= 0 {
+		quotaTTL = math.MaxInt64
+	}
+	srv := newServerWithMemoryLimiter(merchantAddress, quotaTTL)
+	if peers != "" {
+		timeout, err := time.ParseDuration(peerTimeout)
+		if err != nil {
+			log.Fatalf("invalid peer timeout: %v", err)
+		}
+		var peerList []string
+		for _, peer := range strings.Split(peers, ",") {
+			if peer = strings.TrimSpace(peer); peer != "" {
+				peerList = append(peerList, peer)
+			}
+		}
+		srv.cluster, err = cluster.New(advertise, peerList, timeout)
+		if err != nil {
+			log.Fatalf("invalid cluster: %v", err)
+		}
+		defer srv.cluster.Close()
+		log.Printf("sharing limits with %d peers as %s", len(peerList)-1, advertise)
+	}
+	if quotaFile != "" {
+		saveInterval, err := time.ParseDuration(quotaSave)
+		if err != nil || saveInterval <= 0 {
+			log.Fatalf("invalid quota save interval: %s", quotaSave)
+		}
+		if err := srv.quotas.ReadFile(quotaFile); err != nil {
+			log.Fatalf("could not restore quota counters: %v", err)
+		}
+		// payments counted since the last write are lost if the process
+		// dies, a graceful stop writes them all
+		go every(context.Background(), saveInterval, func(time.Time) {
+			if err := srv.quotas.WriteFile(quotaFile); err != nil {
+				log.Printf("could not save quota counters: %v", err)
+			}
+		})
+	}
+	if ttl > 0 {
+		// limiters live between ttl and one and a half ttl without requests
+		go every(context.Background(), ttl/2, func(now time.Time) {
+			if n := srv.limiters.EvictIdle(now, ttl); n > 0 {
+				log.Printf("evicted %d idle limiters", n)
+			}
+			if n := srv.quotas.EvictIdle(now); n > 0 {
+				log.Printf("evicted the quotas of %d idle merchants", n)
+			}
+		})
+	}
+	if refreshInterval > 0 {
+		go every(context.Background(), refreshInterval, func(time.Time) {
+			srv.limiters.Refresh(context.Background())
+		})
+	}
+
 	s := grpc.NewServer()
-	pb.RegisterRateLimiterServiceServer(s, newServerWithMemoryLimiter(merchantAddress))
+	pb.RegisterRateLimiterServiceServer(s, srv)
 	reflection.Register(s)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-stop
+		log.Printf("stopping")
+		s.GracefulStop()
+	}()
+
 	log.Printf("server listening at %v", listener.Addr())
 	if err := s.Serve(listener); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
+	if quotaFile != "" {
+		if err := srv.quotas.WriteFile(quotaFile); err != nil {
+			log.Printf("could not save quota counters: %v", err)
+		}
+	}
 }
@@ -0,0 +1,64 @@
+package quota
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ReadFile restores the counters a Tracker wrote to path with WriteFile,
+// nothing is restored when the file does not exist yet
+func (t *Tracker) ReadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	t.Restore(snap)
+	return nil
+}
+
+// WriteFile replaces path with the counters of every merchant, in a way
+// that a reader either sees the old ones or the new ones, never a partial
+// write
+func (t *Tracker) WriteFile(path string) error {
+	data, err := json.Marshal(t.Snapshot())
+	if err != nil {
+		return err
+	}
+
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
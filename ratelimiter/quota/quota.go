@@ -0,0 +1,444 @@
+// Package quota counts the payments of each merchant per day and month, and
+// refuses the ones that do not fit in the quotas of its plan. Unlike the
+// limiters, whose state is gone a second later, counts last for a whole
+// period, so they are never dropped while it lasts.
+package quota
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrUnknownPeriod must be used when a quota is not per one of Periods
+var ErrUnknownPeriod = errors.New("unknown quota period")
+
+// Period is how long a quota lasts before it starts over, days and months
+// start at midnight UTC
+type Period string
+
+const (
+	// Day starts over every midnight UTC
+	Day Period = "day"
+	// Month starts over every first of the month at midnight UTC
+	Month Period = "month"
+)
+
+// Periods lists every valid period, in the order usage is reported
+var Periods = []Period{Day, Month}
+
+// Bounds returns when the period containing now starts and ends
+func (p Period) Bounds(now time.Time) (time.Time, time.Time, error) {
+	y, m, d := now.UTC().Date()
+	switch p {
+	case Day:
+		start := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1), nil
+	case Month:
+		start := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	return time.Time{}, time.Time{}, ErrUnknownPeriod
+}
+
+// Quota caps the payments of a merchant in each Period, in Currency, or in
+// any currency when it is empty. A max of 0 is no cap, and amounts are only
+// capped for a currency, as amounts of different currencies can not be
+// added up
+type Quota struct {
+	Period         Period
+	Currency       string
+	MaxCount       int64
+	MaxAmountMinor int64
+}
+
+// Payment is what a payment takes from the quotas
+type Payment struct {
+	Currency    string
+	AmountMinor int64
+}
+
+// Usage is how much of a quota the period containing now used
+type Usage struct {
+	Quota
+	Count       int64
+	AmountMinor int64
+	Start       time.Time
+	End         time.Time
+}
+
+// Result is the answer to one payment
+type Result struct {
+	Allowed bool
+	// Exceeded is the usage of the first quota the payment did not fit in,
+	// before it, only set when it is not Allowed
+	Exceeded Usage
+}
+
+// Counter is what a merchant used in the period starting at Start, in
+// Currency, or in any currency when it is empty
+type Counter struct {
+	Period      Period    `json:"period"`
+	Currency    string    `json:"currency,omitempty"`
+	Start       time.Time `json:"start"`
+	Count       int64     `json:"count"`
+	AmountMinor int64     `json:"amount_minor"`
+}
+
+// Snapshot has the counters of every merchant a Tracker counts for
+type Snapshot map[uuid.UUID][]Counter
+
+// Loader reads the quotas of a merchant
+type Loader func(ctx context.Context, id uuid.UUID) ([]Quota, error)
+
+// Collector gathers the counters of a merchant kept elsewhere, handing them
+// over to the Tracker starting to count for it
+type Collector func(ctx context.Context, id uuid.UUID) []Counter
+
+// Tracker counts payments per merchant, it is safe for concurrent use
+type Tracker struct {
+	load    Loader
+	collect Collector
+	// ttl is how long quotas are used before being read again
+	ttl time.Duration
+
+	mu        sync.Mutex
+	merchants map[uuid.UUID]*merchant
+	loading   singleflight.Group
+}
+
+type merchant struct {
+	mu     sync.Mutex
+	quotas []Quota
+	// loadedAt is zero until the quotas are read, for a merchant whose
+	// counters were restored or handed over
+	loadedAt time.Time
+	// counters of every payment are kept, not only the ones quotas are set
+	// for, so a plan changed in the middle of a period finds what it used
+	counters map[key]*counter
+}
+
+type key struct {
+	period Period
+	// currency is empty for the count of every payment
+	currency string
+}
+
+type counter struct {
+	start  time.Time
+	count  int64
+	amount int64
+}
+
+// New is a factory for a Tracker that reads quotas with load, and reads
+// them again once they are older than ttl. The counters collect gathers,
+// when it is not nil, are added the first time quotas of a merchant are read
+func New(load Loader, collect Collector, ttl time.Duration) *Tracker {
+	return &Tracker{
+		load:      load,
+		collect:   collect,
+		ttl:       ttl,
+		merchants: make(map[uuid.UUID]*merchant),
+	}
+}
+
+// Consume counts payment p of merchant id made at now, if it fits in every
+// quota of the merchant. An error is returned when the quotas can not be
+// read
+func (t *Tracker) Consume(ctx context.Context, id uuid.UUID, p Payment, now time.Time) (Result, error) {
+	m, err := t.merchant(ctx, id, now)
+	if err != nil {
+		return Result{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, q := range m.quotas {
+		if q.Currency != "" && q.Currency != p.Currency {
+			continue
+		}
+		u, err := m.usage(q, now)
+		if err != nil {
+			log.Printf("merchant %s has an invalid quota: %v", id, err)
+			continue
+		}
+		overCount := q.MaxCount > 0 && u.Count+1 > q.MaxCount
+		overAmount := q.MaxAmountMinor > 0 && q.Currency != "" && u.AmountMinor+p.AmountMinor > q.MaxAmountMinor
+		if overCount || overAmount {
+			return Result{Exceeded: u}, nil
+		}
+	}
+
+	currencies := []string{""}
+	if p.Currency != "" {
+		currencies = append(currencies, p.Currency)
+	}
+	for _, period := range Periods {
+		for _, currency := range currencies {
+			c := m.counter(key{period, currency}, now)
+			c.count++
+			c.amount += p.AmountMinor
+		}
+	}
+	return Result{Allowed: true}, nil
+}
+
+// Release gives back payment p of merchant id, counted by Consume at
+// consumedAt, when the payment did not happen. Nothing is given back to
+// periods that already ended, nor to a merchant that is not tracked
+func (t *Tracker) Release(id uuid.UUID, p Payment, consumedAt time.Time) {
+	t.mu.Lock()
+	m, ok := t.merchants[id]
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	currencies := []string{""}
+	if p.Currency != "" {
+		currencies = append(currencies, p.Currency)
+	}
+	for _, period := range Periods {
+		start, _, _ := period.Bounds(consumedAt)
+		for _, currency := range currencies {
+			c, ok := m.counters[key{period, currency}]
+			if !ok || !c.start.Equal(start) {
+				continue
+			}
+			if c.count > 0 {
+				c.count--
+			}
+			c.amount -= p.AmountMinor
+			if c.amount < 0 {
+				c.amount = 0
+			}
+		}
+	}
+}
+
+// Usage returns how much of each quota of merchant id the periods
+// containing now used
+func (t *Tracker) Usage(ctx context.Context, id uuid.UUID, now time.Time) ([]Usage, error) {
+	m, err := t.merchant(ctx, id, now)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make([]Usage, 0, len(m.quotas))
+	for _, q := range m.quotas {
+		u, err := m.usage(q, now)
+		if err != nil {
+			log.Printf("merchant %s has an invalid quota: %v", id, err)
+			continue
+		}
+		res = append(res, u)
+	}
+	return res, nil
+}
+
+// Add adds counters to the ones of merchant id, counters of periods older
+// than the ones counted are left out
+func (t *Tracker) Add(id uuid.UUID, counters []Counter) {
+	if len(counters) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	m, ok := t.merchants[id]
+	if !ok {
+		m = &merchant{counters: make(map[key]*counter)}
+		t.merchants[id] = m
+	}
+	t.mu.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.add(counters)
+}
+
+// HandOver stops counting for merchant id, and returns its counters for
+// another Tracker to Add
+func (t *Tracker) HandOver(id uuid.UUID) []Counter {
+	t.mu.Lock()
+	m, ok := t.merchants[id]
+	delete(t.merchants, id)
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshot()
+}
+
+// Snapshot copies the counters of every merchant
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snap := make(Snapshot, len(t.merchants))
+	for id, m := range t.merchants {
+		m.mu.Lock()
+		if counters := m.snapshot(); len(counters) > 0 {
+			snap[id] = counters
+		}
+		m.mu.Unlock()
+	}
+	return snap
+}
+
+// Restore adds the counters in snap, quotas of their merchants are read when
+// they are next used
+func (t *Tracker) Restore(snap Snapshot) {
+	for id, counters := range snap {
+		t.Add(id, counters)
+	}
+}
+
+// EvictIdle drops the merchants whose counters are all from periods that
+// ended before now, and whose quotas are due to be read again, then
+// returns how many were dropped
+func (t *Tracker) EvictIdle(now time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	evicted := 0
+	for id, m := range t.merchants {
+		m.mu.Lock()
+		idle := now.Sub(m.loadedAt) >= t.ttl
+		for k, c := range m.counters {
+			if start, _, err := k.period.Bounds(now); err != nil || !c.start.Before(start) {
+				idle = false
+			}
+		}
+		m.mu.Unlock()
+		if idle {
+			delete(t.merchants, id)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// loaded is what is read for a merchant without any lock held
+type loaded struct {
+	quotas   []Quota
+	counters []Counter
+}
+
+// merchant returns the counters of merchant id, reading its quotas first if
+// it has none yet or they are due. A merchant whose quotas can not be read
+// again keeps the ones it has
+func (t *Tracker) merchant(ctx context.Context, id uuid.UUID, now time.Time) (*merchant, error) {
+	t.mu.Lock()
+	m, ok := t.merchants[id]
+	t.mu.Unlock()
+
+	first := true
+	if ok {
+		m.mu.Lock()
+		first = m.loadedAt.IsZero()
+		due := now.Sub(m.loadedAt) >= t.ttl
+		m.mu.Unlock()
+		if !first && !due {
+			return m, nil
+		}
+	}
+
+	// quotas are read without any lock held, once however many payments
+	// are waiting for them
+	v, err, _ := t.loading.Do(id.String(), func() (interface{}, error) {
+		quotas, err := t.load(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		l := loaded{quotas: quotas}
+		if first && t.collect != nil {
+			l.counters = t.collect(ctx, id)
+		}
+		return l, nil
+	})
+	if err != nil {
+		if !first {
+			log.Printf("could not read quotas of merchant %s again: %v", id, err)
+			return m, nil
+		}
+		return nil, err
+	}
+	l := v.(loaded)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if m, ok = t.merchants[id]; !ok {
+		m = &merchant{counters: make(map[key]*counter)}
+		t.merchants[id] = m
+	}
+	m.mu.Lock()
+	m.add(l.counters)
+	m.quotas, m.loadedAt = l.quotas, now
+	m.mu.Unlock()
+	return m, nil
+}
+
+// add adds counters to the ones of m, m.mu must be held
+func (m *merchant) add(counters []Counter) {
+	for _, c := range counters {
+		k := key{c.Period, c.Currency}
+		mc, ok := m.counters[k]
+		switch {
+		case !ok || mc.start.Before(c.Start):
+			m.counters[k] = &counter{start: c.Start, count: c.Count, amount: c.AmountMinor}
+		case mc.start.Equal(c.Start):
+			mc.count += c.Count
+			mc.amount += c.AmountMinor
+		}
+	}
+}
+
+// snapshot copies the counters of m, m.mu must be held
+func (m *merchant) snapshot() []Counter {
+	counters := make([]Counter, 0, len(m.counters))
+	for k, c := range m.counters {
+		counters = append(counters, Counter{Period: k.period, Currency: k.currency, Start: c.start, Count: c.count, AmountMinor: c.amount})
+	}
+	return counters
+}
+
+// usage returns how much of q the period containing now used, m.mu must be
+// held
+func (m *merchant) usage(q Quota, now time.Time) (Usage, error) {
+	start, end, err := q.Period.Bounds(now)
+	if err != nil {
+		return Usage{}, err
+	}
+	u := Usage{Quota: q, Start: start, End: end}
+	if c, ok := m.counters[key{q.Period, q.Currency}]; ok && c.start.Equal(start) {
+		u.Count, u.AmountMinor = c.count, c.amount
+	}
+	return u, nil
+}
+
+// counter returns the counter of k for the period containing now, starting
+// it over when its period ended, m.mu must be held
+func (m *merchant) counter(k key, now time.Time) *counter {
+	start, _, _ := k.period.Bounds(now)
+	c, ok := m.counters[k]
+	if !ok || !c.start.Equal(start) {
+		c = &counter{start: start}
+		m.counters[k] = c
+	}
+	return c
+}
@@ -0,0 +1,414 @@
+package quota_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/quota"
+)
+
+var start = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+// fakeMerchants stands in for the merchant service
+type fakeMerchants struct {
+	mu     sync.Mutex
+	quotas map[uuid.UUID][]quota.Quota
+	reads  int
+}
+
+func (f *fakeMerchants) set(id uuid.UUID, quotas []quota.Quota) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.quotas == nil {
+		f.quotas = make(map[uuid.UUID][]quota.Quota)
+	}
+	f.quotas[id] = quotas
+}
+
+func (f *fakeMerchants) remove(id uuid.UUID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.quotas, id)
+}
+
+func (f *fakeMerchants) load(_ context.Context, id uuid.UUID) ([]quota.Quota, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	q, ok := f.quotas[id]
+	if !ok {
+		return nil, errors.New("unknown merchant")
+	}
+	return q, nil
+}
+
+func TestPeriod_Bounds(t *testing.T) {
+	type testCase struct {
+		testName      string
+		period        quota.Period
+		now           time.Time
+		expectedStart time.Time
+		expectedEnd   time.Time
+		expectedErr   error
+	}
+
+	testCases := []testCase{
+		{
+			testName:      "day",
+			period:        quota.Day,
+			now:           start,
+			expectedStart: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// days start at midnight UTC, whatever the zone of now
+			testName:      "day_other_zone",
+			period:        quota.Day,
+			now:           time.Date(2026, 10, 18, 22, 0, 0, 0, time.FixedZone("UTC-3", -3*60*60)),
+			expectedStart: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			testName:      "month",
+			period:        quota.Month,
+			now:           start,
+			expectedStart: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			testName:      "december",
+			period:        quota.Month,
+			now:           time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC),
+			expectedStart: time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			testName:    "unknown",
+			period:      "week",
+			now:         start,
+			expectedErr: quota.ErrUnknownPeriod,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			s, e, err := tc.period.Bounds(tc.now)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected %v, got %v", tc.expectedErr, err)
+			}
+			if !s.Equal(tc.expectedStart) || !e.Equal(tc.expectedEnd) {
+				t.Errorf("expected [%v, %v), got [%v, %v)", tc.expectedStart, tc.expectedEnd, s, e)
+			}
+		})
+	}
+}
+
+func TestTracker_Consume(t *testing.T) {
+	quotas := []quota.Quota{
+		{Period: quota.Day, MaxCount: 4},
+		{Period: quota.Day, Currency: "USD", MaxAmountMinor: 1000},
+		{Period: quota.Month, Currency: "EUR", MaxCount: 2},
+	}
+
+	type payment struct {
+		payment quota.Payment
+		at      time.Time
+		// exceeded is the quota refusing it, nil if it is allowed
+		exceeded *quota.Quota
+	}
+
+	testCases := []struct {
+		testName string
+		payments []payment
+	}{
+		{
+			testName: "count_in_any_currency",
+			payments: []payment{
+				{payment: quota.Payment{Currency: "USD", AmountMinor: 100}, at: start},
+				{payment: quota.Payment{Currency: "EUR", AmountMinor: 100}, at: start},
+				{payment: quota.Payment{Currency: "BRL", AmountMinor: 100}, at: start},
+				{payment: quota.Payment{Currency: "GBP", AmountMinor: 100}, at: start},
+				{payment: quota.Payment{Currency: "GBP", AmountMinor: 100}, at: start, exceeded: &quotas[0]},
+				// the next day starts over
+				{payment: quota.Payment{Currency: "GBP", AmountMinor: 100}, at: start.Add(12 * time.Hour)},
+			},
+		},
+		{
+			testName: "amount_per_currency",
+			payments: []payment{
+				{payment: quota.Payment{Currency: "USD", AmountMinor: 600}, at: start},
+				{payment: quota.Payment{Currency: "USD", AmountMinor: 500}, at: start, exceeded: &quotas[1]},
+				// a refused payment takes nothing, so a smaller one fits
+				{payment: quota.Payment{Currency: "USD", AmountMinor: 400}, at: start},
+				// the amount of other currencies is not capped
+				{payment: quota.Payment{Currency: "BRL", AmountMinor: 100000}, at: start},
+			},
+		},
+		{
+			testName: "month_carries_over_days",
+			payments: []payment{
+				{payment: quota.Payment{Currency: "EUR", AmountMinor: 100}, at: start},
+				{payment: quota.Payment{Currency: "EUR", AmountMinor: 100}, at: start.AddDate(0, 0, 1)},
+				{payment: quota.Payment{Currency: "EUR", AmountMinor: 100}, at: start.AddDate(0, 0, 2), exceeded: &quotas[2]},
+				{payment: quota.Payment{Currency: "EUR", AmountMinor: 100}, at: start.AddDate(0, 1, 0)},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			f := &fakeMerchants{}
+			id := uuid.New()
+			f.set(id, quotas)
+			tr := quota.New(f.load, nil, time.Hour)
+
+			for i, p := range tc.payments {
+				res, err := tr.Consume(context.Background(), id, p.payment, p.at)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Allowed != (p.exceeded == nil) {
+					t.Fatalf("payment %d: expected allowed=%t, got %t", i, p.exceeded == nil, res.Allowed)
+				}
+				if p.exceeded != nil && res.Exceeded.Quota != *p.exceeded {
+					t.Errorf("payment %d: expected %+v exceeded, got %+v", i, *p.exceeded, res.Exceeded.Quota)
+				}
+			}
+		})
+	}
+}
+
+func TestTracker_Usage(t *testing.T) {
+	f := &fakeMerchants{}
+	id, unknown := uuid.New(), uuid.New()
+	f.set(id, []quota.Quota{
+		{Period: quota.Day, MaxCount: 10},
+		{Period: quota.Month, Currency: "USD", MaxCount: 100, MaxAmountMinor: 100000},
+	})
+	tr := quota.New(f.load, nil, time.Hour)
+
+	for _, p := range []quota.Payment{{Currency: "USD", AmountMinor: 1000}, {Currency: "USD", AmountMinor: 250}, {Currency: "EUR", AmountMinor: 5000}} {
+		if _, err := tr.Consume(context.Background(), id, p, start.AddDate(0, 0, -1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []quota.Payment{{Currency: "USD", AmountMinor: 300}, {Currency: "EUR", AmountMinor: 700}} {
+		if _, err := tr.Consume(context.Background(), id, p, start); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage, err := tr.Usage(context.Background(), id, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 2 {
+		t.Fatalf("expected the usage of 2 quotas, got %d", len(usage))
+	}
+	// the day only has today's payments, the month both days' in USD
+	if u := usage[0]; u.Count != 2 || !u.Start.Equal(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 2 payments today, got %+v", u)
+	}
+	if u := usage[1]; u.Count != 3 || u.AmountMinor != 1550 || !u.End.Equal(time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 3 USD payments of 1550 this month, got %+v", u)
+	}
+
+	if _, err := tr.Usage(context.Background(), unknown, start); err == nil {
+		t.Error("expected an error for a merchant whose quotas can not be read")
+	}
+}
+
+func TestTracker_Release(t *testing.T) {
+	f := &fakeMerchants{}
+	id := uuid.New()
+	f.set(id, []quota.Quota{
+		{Period: quota.Day, MaxCount: 2},
+		{Period: quota.Month, Currency: "USD", MaxAmountMinor: 1000},
+	})
+	tr := quota.New(f.load, nil, time.Hour)
+
+	consume := func(p quota.Payment, at time.Time) bool {
+		t.Helper()
+		res, err := tr.Consume(context.Background(), id, p, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Allowed
+	}
+
+	yesterday := start.AddDate(0, 0, -1)
+	consume(quota.Payment{Currency: "USD", AmountMinor: 400}, yesterday)
+	consume(quota.Payment{Currency: "USD", AmountMinor: 400}, start)
+	consume(quota.Payment{Currency: "USD", AmountMinor: 100}, start)
+	if consume(quota.Payment{Currency: "USD", AmountMinor: 100}, start) {
+		t.Fatal("expected the third payment of the day to be refused")
+	}
+
+	// a payment that did not happen fits again, in the day and the month
+	tr.Release(id, quota.Payment{Currency: "USD", AmountMinor: 400}, start)
+	if !consume(quota.Payment{Currency: "USD", AmountMinor: 500}, start) {
+		t.Error("expected the released payment to leave room for another one")
+	}
+
+	// yesterday's payment is only given back to the month
+	tr.Release(id, quota.Payment{Currency: "USD", AmountMinor: 400}, yesterday)
+	usage, err := tr.Usage(context.Background(), id, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage[0].Count != 2 || usage[1].AmountMinor != 600 {
+		t.Errorf("expected 2 payments today and 600 this month, got %+v", usage)
+	}
+
+	// unknown merchants and ended periods are left alone
+	tr.Release(uuid.New(), quota.Payment{Currency: "USD", AmountMinor: 1}, start)
+	tr.Release(id, quota.Payment{Currency: "USD", AmountMinor: 1}, start.AddDate(0, -1, 0))
+	if usage, _ := tr.Usage(context.Background(), id, start); usage[1].AmountMinor != 600 {
+		t.Errorf("expected 600 this month, got %+v", usage[1])
+	}
+}
+
+func TestTracker_Reload(t *testing.T) {
+	f := &fakeMerchants{}
+	id := uuid.New()
+	f.set(id, []quota.Quota{{Period: quota.Day, MaxCount: 2}})
+	tr := quota.New(f.load, nil, time.Minute)
+
+	consume := func(at time.Time) bool {
+		t.Helper()
+		res, err := tr.Consume(context.Background(), id, quota.Payment{Currency: "USD", AmountMinor: 1}, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Allowed
+	}
+
+	consume(start)
+	consume(start)
+	if consume(start) {
+		t.Fatal("expected the third payment to be refused")
+	}
+	if f.reads != 1 {
+		t.Errorf("expected the quotas to be read once, got %d", f.reads)
+	}
+
+	// a bigger quota counts the payments made before it
+	f.set(id, []quota.Quota{{Period: quota.Day, MaxCount: 3}})
+	if !consume(start.Add(time.Minute)) {
+		t.Error("expected the third payment to fit in the new quota")
+	}
+	if consume(start.Add(time.Minute)) {
+		t.Error("expected the fourth payment to be refused")
+	}
+
+	// quotas that can not be read again are kept
+	f.remove(id)
+	if consume(start.Add(2 * time.Minute)) {
+		t.Error("expected the quotas in use to be kept")
+	}
+}
+
+func TestTracker_EvictIdle(t *testing.T) {
+	f := &fakeMerchants{}
+	id := uuid.New()
+	f.set(id, []quota.Quota{{Period: quota.Month, MaxCount: 1}})
+	tr := quota.New(f.load, nil, time.Minute)
+
+	if _, err := tr.Consume(context.Background(), id, quota.Payment{Currency: "USD", AmountMinor: 1}, start); err != nil {
+		t.Fatal(err)
+	}
+	// the month is not over, its count is kept
+	if n := tr.EvictIdle(start.AddDate(0, 0, 7)); n != 0 {
+		t.Errorf("expected nothing evicted, got %d", n)
+	}
+	res, err := tr.Consume(context.Background(), id, quota.Payment{Currency: "USD", AmountMinor: 1}, start.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed {
+		t.Error("expected the payment of the first week to still count")
+	}
+
+	if n := tr.EvictIdle(start.AddDate(0, 1, 0)); n != 1 {
+		t.Errorf("expected 1 evicted once the month is over, got %d", n)
+	}
+}
+
+func TestTracker_HandOver(t *testing.T) {
+	f := &fakeMerchants{}
+	id := uuid.New()
+	f.set(id, []quota.Quota{{Period: quota.Month, Currency: "USD", MaxCount: 3}})
+
+	// the merchant paid twice on another tracker, once in an older month
+	other := quota.New(f.load, nil, time.Hour)
+	for _, at := range []time.Time{start.AddDate(0, -1, 0), start, start} {
+		if _, err := other.Consume(context.Background(), id, quota.Payment{Currency: "USD", AmountMinor: 1}, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collected := 0
+	tr := quota.New(f.load, func(_ context.Context, id uuid.UUID) []quota.Counter {
+		collected++
+		return other.HandOver(id)
+	}, time.Hour)
+
+	res, err := tr.Consume(context.Background(), id, quota.Payment{Currency: "USD", AmountMinor: 1}, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed {
+		t.Fatal("expected the third payment of the month to fit")
+	}
+	if res, _ := tr.Consume(context.Background(), id, quota.Payment{Currency: "USD", AmountMinor: 1}, start); res.Allowed {
+		t.Error("expected the payments handed over to count")
+	}
+	if collected != 1 {
+		t.Errorf("expected the counters to be collected once, got %d", collected)
+	}
+	if counters := other.HandOver(id); len(counters) != 0 {
+		t.Errorf("expected the other tracker to stop counting, got %+v", counters)
+	}
+}
+
+func TestTracker_File(t *testing.T) {
+	f := &fakeMerchants{}
+	id := uuid.New()
+	f.set(id, []quota.Quota{{Period: quota.Day, MaxCount: 2}})
+	path := filepath.Join(t.TempDir(), "ratelimiter", "quotas.json")
+
+	tr := quota.New(f.load, nil, time.Hour)
+	if err := tr.ReadFile(path); err != nil {
+		t.Fatalf("expected a missing file to restore nothing, got %v", err)
+	}
+	if _, err := tr.Consume(context.Background(), id, quota.Payment{Currency: "USD", AmountMinor: 1}, start); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// a restart keeps the payments of the day
+	restarted := quota.New(f.load, nil, time.Hour)
+	if err := restarted.ReadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	usage, err := restarted.Usage(context.Background(), id, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 1 || usage[0].Count != 1 {
+		t.Errorf("expected 1 payment today, got %+v", usage)
+	}
+	if res, _ := restarted.Consume(context.Background(), id, quota.Payment{Currency: "USD", AmountMinor: 1}, start); !res.Allowed {
+		t.Error("expected the second payment to fit")
+	}
+	if res, _ := restarted.Consume(context.Background(), id, quota.Payment{Currency: "USD", AmountMinor: 1}, start); res.Allowed {
+		t.Error("expected the third payment to be refused")
+	}
+}
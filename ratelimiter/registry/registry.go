@@ -0,0 +1,205 @@
+// Package registry keeps the limiter of each merchant. Lookups only take the
+// read lock of one of many shards, and the limit of an unknown merchant is
+// loaded outside of any lock, once however many requests are waiting for it.
+package registry
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/limiter"
+	"golang.org/x/sync/singleflight"
+)
+
+// shardCount is a power of two, so a shard is picked by the low bits of an id
+const shardCount = 64
+
+// Loader reads the limit of a merchant
+type Loader func(ctx context.Context, id uuid.UUID) (limiter.Config, error)
+
+// Registry keeps a limiter per merchant, it is safe for concurrent use
+type Registry struct {
+	load Loader
+	// fallback replaces algorithms and bursts limiter.New refuses
+	fallback limiter.Config
+	shards   [shardCount]shard
+	loading  singleflight.Group
+}
+
+type shard struct {
+	sync.RWMutex
+	clients map[uuid.UUID]*client
+}
+
+type client struct {
+	state atomic.Pointer[state]
+	// lastSeen is in unix nanoseconds, so requests do not need a lock to
+	// update it
+	lastSeen atomic.Int64
+}
+
+// state is replaced as a whole when the limit changes
+type state struct {
+	limiter limiter.Limiter
+	// config is what limiter was built with
+	config limiter.Config
+}
+
+// New is a factory for a Registry that reads limits with load. Merchants
+// whose algorithm or burst limiter.New refuses get the ones of fallback
+func New(load Loader, fallback limiter.Config) *Registry {
+	r := &Registry{load: load, fallback: fallback}
+	for i := range r.shards {
+		r.shards[i].clients = make(map[uuid.UUID]*client)
+	}
+	return r
+}
+
+func (r *Registry) shard(id uuid.UUID) *shard {
+	return &r.shards[binary.BigEndian.Uint64(id[8:])%shardCount]
+}
+
+// Allow counts a request of merchant id made at now, if it is allowed. The
+// limit of a merchant without a limiter is loaded first, and an error is
+// returned when it can not be
+func (r *Registry) Allow(ctx context.Context, id uuid.UUID, now time.Time) (limiter.Decision, error) {
+	s := r.shard(id)
+	s.RLock()
+	c, ok := s.clients[id]
+	s.RUnlock()
+
+	if !ok {
+		var err error
+		c, err = r.add(ctx, id, now)
+		if err != nil {
+			return limiter.Decision{}, err
+		}
+	}
+
+	c.lastSeen.Store(now.UnixNano())
+	return c.state.Load().limiter.Allow(now), nil
+}
+
+// add loads the limit of a merchant and gives it a limiter, concurrent calls
+// for the same merchant share one load
+func (r *Registry) add(ctx context.Context, id uuid.UUID, now time.Time) (*client, error) {
+	v, err, _ := r.loading.Do(id.String(), func() (interface{}, error) {
+		config, err := r.load(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		l, config := r.newLimiter(id, config, now)
+		c := &client{}
+		c.state.Store(&state{limiter: l, config: config})
+
+		s := r.shard(id)
+		s.Lock()
+		defer s.Unlock()
+		// a load that finished right before this one already added it
+		if current, ok := s.clients[id]; ok {
+			return current, nil
+		}
+		s.clients[id] = c
+		return c, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*client), nil
+}
+
+// newLimiter builds the limiter of a merchant, falling back to r.fallback
+// for an algorithm this service does not know yet, and returns the config it
+// was built with
+func (r *Registry) newLimiter(id uuid.UUID, c limiter.Config, now time.Time) (limiter.Limiter, limiter.Config) {
+	l, err := limiter.New(c, now)
+	if err != nil {
+		log.Printf("merchant %s falls back to %s: %v", id, r.fallback.Algorithm, err)
+		c.Algorithm, c.Burst = r.fallback.Algorithm, r.fallback.Burst
+		l, _ = limiter.New(c, now)
+	}
+	return l, c
+}
+
+// Len is how many merchants have a limiter
+func (r *Registry) Len() int {
+	n := 0
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.RLock()
+		n += len(s.clients)
+		s.RUnlock()
+	}
+	return n
+}
+
+// EvictIdle drops the limiters of merchants without requests since ttl
+// before now, and returns how many were dropped. A merchant that comes back
+// gets a new limiter, its limit is read again
+func (r *Registry) EvictIdle(now time.Time, ttl time.Duration) int {
+	evicted := 0
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.Lock()
+		for id, c := range s.clients {
+			if now.Sub(time.Unix(0, c.lastSeen.Load())) >= ttl {
+				delete(s.clients, id)
+				evicted++
+			}
+		}
+		s.Unlock()
+	}
+	return evicted
+}
+
+// Refresh reads the limit of every merchant with a limiter again, and
+// changes the limiters whose limit changed without forgetting the requests
+// they counted, then returns how many changed. Merchants whose limit can not
+// be read keep the one they have
+func (r *Registry) Refresh(ctx context.Context) int {
+	changed := 0
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.RLock()
+		clients := make(map[uuid.UUID]*client, len(s.clients))
+		for id, c := range s.clients {
+			clients[id] = c
+		}
+		s.RUnlock()
+
+		// limits are loaded without any lock held, so requests are not
+		// kept waiting for them
+		for id, c := range clients {
+			config, err := r.load(ctx, id)
+			if err != nil {
+				log.Printf("could not refresh limit of merchant %s: %v", id, err)
+				continue
+			}
+			if r.reconfigure(id, c, config, time.Now()) {
+				changed++
+			}
+		}
+	}
+	return changed
+}
+
+// reconfigure changes the limit of c to config, if it differs
+func (r *Registry) reconfigure(id uuid.UUID, c *client, config limiter.Config, now time.Time) bool {
+	current := c.state.Load()
+	if current.config == config {
+		return false
+	}
+	l, err := limiter.Reconfigure(current.limiter, current.config, config, now)
+	if err != nil {
+		log.Printf("merchant %s keeps its limit: %v", id, err)
+		return false
+	}
+	log.Printf("merchant %s limit changed from %+v to %+v", id, current.config, config)
+	c.state.Store(&state{limiter: l, config: config})
+	return true
+}
@@ -0,0 +1,142 @@
+package registry_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/limiter"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/registry"
+)
+
+// allower is what both the registry and the baseline offer
+type allower interface {
+	Allow(ctx context.Context, id uuid.UUID, now time.Time) (limiter.Decision, error)
+}
+
+// mutexRegistry is how limiters were kept before the registry: one mutex
+// held for every request, including while an unknown merchant is loaded
+type mutexRegistry struct {
+	mu       sync.Mutex
+	load     registry.Loader
+	limiters map[uuid.UUID]limiter.Limiter
+}
+
+func newMutexRegistry(load registry.Loader) *mutexRegistry {
+	return &mutexRegistry{load: load, limiters: make(map[uuid.UUID]limiter.Limiter)}
+}
+
+func (m *mutexRegistry) Allow(ctx context.Context, id uuid.UUID, now time.Time) (limiter.Decision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, ok := m.limiters[id]
+	if !ok {
+		c, err := m.load(ctx, id)
+		if err != nil {
+			return limiter.Decision{}, err
+		}
+		l, err = limiter.New(c, now)
+		if err != nil {
+			return limiter.Decision{}, err
+		}
+		m.limiters[id] = l
+	}
+	return l.Allow(now), nil
+}
+
+// benchLoad gives every merchant a limit high enough to not deny requests
+func benchLoad(delay time.Duration) registry.Loader {
+	return func(context.Context, uuid.UUID) (limiter.Config, error) {
+		time.Sleep(delay)
+		return limiter.Config{Algorithm: limiter.TokenBucket, QPS: 1e9, Burst: 1e9}, nil
+	}
+}
+
+func merchantIDs(n int) []uuid.UUID {
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	return ids
+}
+
+// runAllow spreads requests over ids from many goroutines
+func runAllow(b *testing.B, a allower, ids []uuid.UUID) {
+	var next atomic.Uint64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.Background()
+		i := next.Add(uint64(len(ids) / 7))
+		for pb.Next() {
+			i++
+			if _, err := a.Allow(ctx, ids[i%uint64(len(ids))], time.Now()); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// BenchmarkAllow measures requests of merchants whose limiters are already
+// loaded
+func BenchmarkAllow(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		ids := merchantIDs(n)
+		warm := func(a allower) {
+			for _, id := range ids {
+				a.Allow(context.Background(), id, time.Now())
+			}
+		}
+
+		b.Run(fmt.Sprintf("registry/merchants=%d", n), func(b *testing.B) {
+			r := registry.New(benchLoad(0), fallback)
+			warm(r)
+			runAllow(b, r, ids)
+		})
+		b.Run(fmt.Sprintf("mutex/merchants=%d", n), func(b *testing.B) {
+			m := newMutexRegistry(benchLoad(0))
+			warm(m)
+			runAllow(b, m, ids)
+		})
+	}
+}
+
+// BenchmarkAllowWhileLoading measures requests of loaded merchants while
+// new merchants keep arriving, each taking a millisecond to load
+func BenchmarkAllowWhileLoading(b *testing.B) {
+	const n = 1000
+
+	run := func(b *testing.B, a allower) {
+		ids := merchantIDs(n)
+		for _, id := range ids {
+			a.Allow(context.Background(), id, time.Now())
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				a.Allow(ctx, uuid.New(), time.Now())
+			}
+		}()
+
+		runAllow(b, a, ids)
+		b.StopTimer()
+		cancel()
+		wg.Wait()
+	}
+
+	b.Run("registry", func(b *testing.B) {
+		run(b, registry.New(benchLoad(time.Millisecond), fallback))
+	})
+	b.Run("mutex", func(b *testing.B) {
+		run(b, newMutexRegistry(benchLoad(time.Millisecond)))
+	})
+}
@@ -0,0 +1,279 @@
+package registry_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/limiter"
+	"github.com/thiagolcmelo/payment-gateway/ratelimiter/registry"
+)
+
+var fallback = limiter.Config{Algorithm: limiter.TokenBucket, Burst: 10}
+
+// fakeMerchants stands in for the merchant service, and counts how many
+// times each limit was read
+type fakeMerchants struct {
+	mu      sync.Mutex
+	configs map[uuid.UUID]limiter.Config
+	reads   map[uuid.UUID]int
+	// delay is how long each read takes
+	delay time.Duration
+}
+
+func newFakeMerchants() *fakeMerchants {
+	return &fakeMerchants{
+		configs: make(map[uuid.UUID]limiter.Config),
+		reads:   make(map[uuid.UUID]int),
+	}
+}
+
+func (f *fakeMerchants) set(id uuid.UUID, c limiter.Config) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.configs[id] = c
+}
+
+func (f *fakeMerchants) remove(id uuid.UUID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.configs, id)
+}
+
+func (f *fakeMerchants) readCount(id uuid.UUID) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reads[id]
+}
+
+func (f *fakeMerchants) load(_ context.Context, id uuid.UUID) (limiter.Config, error) {
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads[id]++
+	c, ok := f.configs[id]
+	if !ok {
+		return limiter.Config{}, errors.New("unknown merchant")
+	}
+	return c, nil
+}
+
+// allowed counts how many of n requests in a row are allowed
+func allowed(t *testing.T, r *registry.Registry, id uuid.UUID, n int) int {
+	t.Helper()
+
+	res := 0
+	for i := 0; i < n; i++ {
+		d, err := r.Allow(context.Background(), id, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Allowed {
+			res++
+		}
+	}
+	return res
+}
+
+func TestRegistry_Allow(t *testing.T) {
+	f := newFakeMerchants()
+	r := registry.New(f.load, fallback)
+	known, unknownAlgorithm, unknown := uuid.New(), uuid.New(), uuid.New()
+	f.set(known, limiter.Config{Algorithm: limiter.SlidingLog, QPS: 5})
+	f.set(unknownAlgorithm, limiter.Config{Algorithm: "leaky_bucket", QPS: 5})
+
+	if got := allowed(t, r, known, 10); got != 5 {
+		t.Errorf("expected 5 allowed, got %d", got)
+	}
+	// the fallback keeps the qps of the merchant, with its own burst
+	if got := allowed(t, r, unknownAlgorithm, 20); got != fallback.Burst {
+		t.Errorf("expected %d allowed, got %d", fallback.Burst, got)
+	}
+	if _, err := r.Allow(context.Background(), unknown, time.Now()); err == nil {
+		t.Error("expected an error for a merchant whose limit can not be read")
+	}
+	if r.Len() != 2 {
+		t.Errorf("expected 2 limiters, got %d", r.Len())
+	}
+	if f.readCount(known) != 1 {
+		t.Errorf("expected the limit to be read once, got %d", f.readCount(known))
+	}
+}
+
+func TestRegistry_SingleFlight(t *testing.T) {
+	f := newFakeMerchants()
+	f.delay = 50 * time.Millisecond
+	r := registry.New(f.load, fallback)
+	id := uuid.New()
+	f.set(id, limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 100})
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		count int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d, err := r.Allow(context.Background(), id, time.Now())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if d.Allowed {
+				mu.Lock()
+				count++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if f.readCount(id) != 1 {
+		t.Errorf("expected the limit to be read once, got %d", f.readCount(id))
+	}
+	if count != 50 {
+		t.Errorf("expected every request to be counted by the same limiter, got %d allowed", count)
+	}
+}
+
+func TestRegistry_SlowLoadDoesNotBlock(t *testing.T) {
+	f := newFakeMerchants()
+	r := registry.New(f.load, fallback)
+	known, slow := uuid.New(), uuid.New()
+	f.set(known, limiter.Config{Algorithm: limiter.TokenBucket, QPS: 1000, Burst: 1000})
+	f.set(slow, limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 10})
+	allowed(t, r, known, 1)
+
+	f.delay = time.Second
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Allow(context.Background(), slow, time.Now())
+	}()
+
+	// the merchant already known is answered while the other one loads
+	start := time.Now()
+	allowed(t, r, known, 100)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected requests of a known merchant to not wait for a load, took %v", elapsed)
+	}
+	<-done
+}
+
+func TestRegistry_EvictIdle(t *testing.T) {
+	f := newFakeMerchants()
+	r := registry.New(f.load, fallback)
+	idle, busy := uuid.New(), uuid.New()
+	f.set(idle, limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 10})
+	f.set(busy, limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 10})
+
+	now := time.Now()
+	if _, err := r.Allow(context.Background(), idle, now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Allow(context.Background(), busy, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := r.EvictIdle(now, 10*time.Minute); n != 1 {
+		t.Errorf("expected 1 evicted, got %d", n)
+	}
+	if r.Len() != 1 {
+		t.Errorf("expected 1 limiter left, got %d", r.Len())
+	}
+
+	// a merchant that comes back has its limit read again
+	allowed(t, r, idle, 1)
+	if f.readCount(idle) != 2 {
+		t.Errorf("expected 2 reads of the limit, got %d", f.readCount(idle))
+	}
+}
+
+func TestRegistry_Refresh(t *testing.T) {
+	type testCase struct {
+		testName        string
+		from            limiter.Config
+		to              *limiter.Config
+		expectedChanged int
+		expectedAllowed int
+	}
+
+	bucket := limiter.Config{Algorithm: limiter.TokenBucket, QPS: 10, Burst: 10}
+
+	testCases := []testCase{
+		{
+			testName:        "unchanged",
+			from:            bucket,
+			to:              &bucket,
+			expectedChanged: 0,
+			expectedAllowed: 0,
+		},
+		{
+			// the bucket emptied before the change stays empty
+			testName:        "higher_qps",
+			from:            bucket,
+			to:              &limiter.Config{Algorithm: limiter.TokenBucket, QPS: 100, Burst: 100},
+			expectedChanged: 1,
+			expectedAllowed: 0,
+		},
+		{
+			testName:        "no_qps",
+			from:            bucket,
+			to:              &limiter.Config{Algorithm: limiter.TokenBucket, QPS: 0, Burst: 10},
+			expectedChanged: 1,
+			expectedAllowed: 0,
+		},
+		{
+			testName:        "other_algorithm",
+			from:            bucket,
+			to:              &limiter.Config{Algorithm: limiter.SlidingLog, QPS: 5},
+			expectedChanged: 1,
+			expectedAllowed: 5,
+		},
+		{
+			// the limit can not be read, the one in use is kept
+			testName:        "unknown_merchant",
+			from:            bucket,
+			to:              nil,
+			expectedChanged: 0,
+			expectedAllowed: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			f := newFakeMerchants()
+			r := registry.New(f.load, fallback)
+			id := uuid.New()
+			f.set(id, tc.from)
+
+			if got := allowed(t, r, id, 20); got != tc.from.Burst {
+				t.Fatalf("expected %d allowed before the change, got %d", tc.from.Burst, got)
+			}
+
+			if tc.to != nil {
+				f.set(id, *tc.to)
+			} else {
+				f.remove(id)
+			}
+			if n := r.Refresh(context.Background()); n != tc.expectedChanged {
+				t.Errorf("expected %d changed, got %d", tc.expectedChanged, n)
+			}
+
+			// a few tokens may have been refilled since, but never a burst
+			got := allowed(t, r, id, 5)
+			if tc.expectedAllowed == 0 && got > 1 {
+				t.Errorf("expected the requests before the change to count, got %d allowed", got)
+			}
+			if tc.expectedAllowed > 0 && got != tc.expectedAllowed {
+				t.Errorf("expected %d allowed, got %d", tc.expectedAllowed, got)
+			}
+		})
+	}
+}